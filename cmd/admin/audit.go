@@ -0,0 +1,113 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/danielnegri/jurassic-park-go/storage/postgres"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func commandAudit() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "audit",
+		Short:   "Inspect the audit log",
+		Example: fmt.Sprintf("%s audit tail -f --actor muldoon", shortDescription),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+			os.Exit(2)
+		},
+	}
+
+	addDatabaseFlags(cmd.PersistentFlags())
+
+	tail := &cobra.Command{
+		Use:   "tail",
+		Short: "Print the last entries of the audit log",
+		Args:  cobra.NoArgs,
+		Run: runPostgres(func(ctx context.Context, pg *postgres.Postgres) error {
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			defer stop()
+
+			return tailAudit(ctx, pg, os.Stdout)
+		}),
+	}
+
+	flags := tail.Flags()
+	flags.String("entity-id", "", "only print the entries of a cage or dinosaur")
+	flags.String("actor", "", "only print the entries of an actor")
+	flags.IntP("lines", "n", 20, "number of entries to print")
+	flags.BoolP("follow", "f", false, "keep printing entries as they are recorded")
+	flags.Duration("interval", 2*time.Second, "polling interval when following")
+
+	cmd.AddCommand(tail)
+
+	return &cmd
+}
+
+// tailAudit prints the last entries of the audit log, oldest first, and then
+// polls for new ones if following.
+func tailAudit(ctx context.Context, st storage.Storage, w io.Writer) error {
+	params := storage.ListAuditParams{
+		Pagination: storage.NewPagination(viper.GetInt("lines"), 0),
+		EntityID:   model.ID(viper.GetString("entity_id")),
+		Actor:      viper.GetString("actor"),
+	}
+
+	ticker := time.NewTicker(viper.GetDuration("interval"))
+	defer ticker.Stop()
+
+	for {
+		entries, _, err := st.ListAuditEntries(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		for i := len(entries) - 1; i >= 0; i-- {
+			printAuditEntry(w, entries[i])
+		}
+
+		if len(entries) > 0 {
+			params.AfterID = entries[0].ID
+		}
+
+		if !viper.GetBool("follow") {
+			return nil
+		}
+
+		// Entries recorded between two polls are all printed.
+		params.Pagination = nil
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func printAuditEntry(w io.Writer, entry *model.AuditEntry) {
+	_, _ = fmt.Fprintf(w, "%s %d %s %s %s %s\n",
+		entry.CreatedAt.Format(time.RFC3339), entry.ID, entry.Actor, entry.Op, entry.EntityType, entry.EntityID)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/danielnegri/jurassic-park-go/storage/postgres"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func commandIncidents() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "incidents",
+		Short:   "Inspect containment incidents",
+		Example: fmt.Sprintf("%s incidents list --open --severity critical", shortDescription),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+			os.Exit(2)
+		},
+	}
+
+	addDatabaseFlags(cmd.PersistentFlags())
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "Print incidents, most recent first",
+		Args:  cobra.NoArgs,
+		Run: runPostgres(func(ctx context.Context, pg *postgres.Postgres) error {
+			return listIncidents(ctx, pg, os.Stdout)
+		}),
+	}
+
+	flags := list.Flags()
+	flags.String("status", "", "only print incidents with a status")
+	flags.String("severity", "", "only print incidents with a severity")
+	flags.String("kind", "", "only print incidents of a kind")
+	flags.String("cage", "", "only print the incidents of a cage")
+	flags.Bool("open", false, "only print incidents not closed yet")
+	flags.IntP("lines", "n", 20, "number of incidents to print")
+
+	cmd.AddCommand(list)
+
+	return &cmd
+}
+
+func listIncidents(ctx context.Context, st storage.Storage, w io.Writer) error {
+	incidents, total, err := st.ListIncidents(ctx, storage.ListIncidentParams{
+		Pagination: storage.NewPagination(viper.GetInt("lines"), 0),
+		Status:     viper.GetString("status"),
+		Severity:   viper.GetString("severity"),
+		Kind:       viper.GetString("kind"),
+		CageID:     model.ID(viper.GetString("cage")),
+		Open:       viper.GetBool("open"),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, incident := range incidents {
+		printIncident(w, incident)
+	}
+
+	_, _ = fmt.Fprintf(w, "%d of %d incidents\n", len(incidents), total)
+	return nil
+}
+
+func printIncident(w io.Writer, incident *model.Incident) {
+	cages := make([]string, 0, len(incident.CageIDs))
+	for _, id := range incident.CageIDs {
+		cages = append(cages, string(id))
+	}
+
+	_, _ = fmt.Fprintf(w, "%s %d %s %s %s %q %s\n",
+		incident.CreatedAt.Format(time.RFC3339), incident.ID, incident.Severity, incident.Status,
+		incident.Kind, incident.Title, strings.Join(cages, ","))
+}
@@ -19,6 +19,7 @@ import (
 	"os"
 
 	"github.com/danielnegri/jurassic-park-go/pkg/app"
+	"github.com/danielnegri/jurassic-park-go/pkg/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -32,20 +33,33 @@ const (
 var environment = os.Getenv("ENVIRONMENT")
 
 func commandRoot() *cobra.Command {
+	var (
+		logFormat string
+		logLevel  string
+	)
+
 	rootCmd := &cobra.Command{
-		Use:   shortDescription,
-		Short: shortDescription,
-		Long:  longDescription,
+		Use:              shortDescription,
+		Short:            shortDescription,
+		Long:             longDescription,
+		PersistentPreRun: bindFlags,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
 			os.Exit(2)
 		},
 	}
 
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", log.DefaultFormat, "logger format")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.DefaultLevel, "logger level")
+
 	viper.SetEnvPrefix(envPrefix)
 	viper.AutomaticEnv()
 
 	rootCmd.AddCommand(commandServe())
+	rootCmd.AddCommand(commandMigrate())
+	rootCmd.AddCommand(commandAudit())
+	rootCmd.AddCommand(commandPlan())
+	rootCmd.AddCommand(commandIncidents())
 	rootCmd.AddCommand(newVersion(longDescription))
 
 	return rootCmd
@@ -0,0 +1,135 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/pkg/log"
+	"github.com/danielnegri/jurassic-park-go/storage/postgres"
+	"github.com/spf13/cobra"
+)
+
+func commandMigrate() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "migrate",
+		Short:   "Manage the database schema",
+		Example: fmt.Sprintf("%s migrate up", shortDescription),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+			os.Exit(2)
+		},
+	}
+
+	addDatabaseFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "Apply all pending migrations",
+		Args:  cobra.NoArgs,
+		Run: runPostgres(func(ctx context.Context, pg *postgres.Postgres) error {
+			return pg.Migrate(ctx, -1)
+		}),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "down",
+		Short: "Roll back the last applied migration",
+		Args:  cobra.NoArgs,
+		Run: runPostgres(func(ctx context.Context, pg *postgres.Postgres) error {
+			return pg.MigrateDown(ctx)
+		}),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "to N",
+		Short: "Apply or roll back migrations until the schema is at version N",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			version, err := strconv.Atoi(args[0])
+			if err != nil || version < 0 {
+				_, _ = fmt.Fprintf(os.Stderr, "invalid version: %q\n", args[0])
+				os.Exit(2)
+			}
+
+			runPostgres(func(ctx context.Context, pg *postgres.Postgres) error {
+				return pg.Migrate(ctx, version)
+			})(cmd, args)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Print the applied and pending migrations",
+		Args:  cobra.NoArgs,
+		Run: runPostgres(func(ctx context.Context, pg *postgres.Postgres) error {
+			statuses, err := pg.MigrationStatus(ctx)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT")
+			for _, status := range statuses {
+				state, appliedAt := "pending", "-"
+				if status.Applied {
+					state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+				}
+
+				if status.Modified {
+					state = "modified"
+				}
+
+				_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, state, appliedAt)
+			}
+
+			return w.Flush()
+		}),
+	})
+
+	return &cmd
+}
+
+func runPostgres(fn func(ctx context.Context, pg *postgres.Postgres) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		log.SetLogger(newLogger())
+
+		if err := withPostgres(fn); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// withPostgres runs fn against the database configured by the flags.
+func withPostgres(fn func(ctx context.Context, pg *postgres.Postgres) error) error {
+	pgOpts, err := newPostgresOptions()
+	if err != nil {
+		return err
+	}
+
+	pg, err := postgres.Connect(pgOpts, time.Now)
+	if err != nil {
+		return err
+	}
+	defer pg.Close()
+
+	return fn(context.Background(), pg)
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/danielnegri/jurassic-park-go/storage/postgres"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func commandPlan() *cobra.Command {
+	cmd := cobra.Command{
+		Use:     "plan",
+		Short:   "Plan changes to the park",
+		Example: fmt.Sprintf("%s plan consolidate --free 2", shortDescription),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+			os.Exit(2)
+		},
+	}
+
+	addDatabaseFlags(cmd.PersistentFlags())
+
+	consolidate := &cobra.Command{
+		Use:   "consolidate",
+		Short: "Print the fewest transfers that empty cages",
+		Args:  cobra.NoArgs,
+		Run: runPostgres(func(ctx context.Context, pg *postgres.Postgres) error {
+			return consolidate(ctx, pg, os.Stdout)
+		}),
+	}
+
+	flags := consolidate.Flags()
+	flags.StringArray("cage", nil, "cage to empty (repeatable)")
+	flags.Int("free", 0, "number of cages to empty, chosen to move as few dinosaurs as possible")
+	flags.Bool("execute", false, "make the transfers of the plan")
+	flags.String("reason", "", "reason recorded with the transfers")
+
+	cmd.AddCommand(consolidate)
+
+	return &cmd
+}
+
+// consolidate plans the transfers that empty cages and prints them, making
+// them if asked to.
+func consolidate(ctx context.Context, st storage.Storage, w io.Writer) error {
+	cages, _, err := st.ListCages(ctx, storage.ListCageParams{WithDinosaurs: true})
+	if err != nil {
+		return err
+	}
+
+	var targets []model.ID
+	for _, id := range viper.GetStringSlice("cage") {
+		targets = append(targets, model.ID(id))
+	}
+
+	incidents, _, err := st.ListIncidents(ctx, storage.ListIncidentParams{Open: true, Severity: model.SeverityCritical})
+	if err != nil {
+		return err
+	}
+
+	plan, err := park.PlanConsolidation(cages, incidents, targets, viper.GetInt("free"))
+	if err != nil {
+		return err
+	}
+
+	for _, move := range plan.Transfers {
+		_, _ = fmt.Fprintf(w, "%s: %s -> %s\n", move.DinosaurID, move.FromCageID, move.ToCageID)
+	}
+
+	if !viper.GetBool("execute") {
+		_, _ = fmt.Fprintf(w, "%d transfers free %d cages\n", len(plan.Transfers), len(plan.Freed))
+		return nil
+	}
+
+	if _, err := st.TransferDinosaurs(ctx, plan.Transfers, viper.GetString("reason")); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "%d transfers made, %d cages freed\n", len(plan.Transfers), len(plan.Freed))
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events relays the outbox of the park to subscribers in process.
+// Every server instance runs its own relay against the shared storage, so
+// subscribers see the events committed through any instance.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/log"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultInterval = time.Second
+
+	// batchSize caps the number of events read from the outbox at once.
+	batchSize = 100
+
+	// bufferSize is the number of events a subscriber may lag behind before
+	// it is dropped.
+	bufferSize = 64
+)
+
+// Relay polls the outbox and publishes new events to its subscribers.
+type Relay struct {
+	storage  storage.Storage
+	interval time.Duration
+	logger   logrus.FieldLogger
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+	last int64
+}
+
+// NewRelay returns a relay polling st at interval, or DefaultInterval if
+// zero.
+func NewRelay(st storage.Storage, interval time.Duration) *Relay {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Relay{
+		storage:  st,
+		interval: interval,
+		logger:   log.WithField("component", "relay"),
+		subs:     make(map[*Subscription]struct{}),
+	}
+}
+
+// Run publishes the events committed from now on until ctx is done, then
+// closes every subscription.
+func (r *Relay) Run(ctx context.Context) error {
+	defer r.closeAll()
+
+	last, err := r.storage.LastEventID(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.last = last
+	r.mu.Unlock()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.poll(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Errorf("error while polling the outbox: %v", err)
+			}
+		}
+	}
+}
+
+// poll publishes the events committed since the last poll.
+func (r *Relay) poll(ctx context.Context) error {
+	for {
+		r.mu.Lock()
+		last := r.last
+		r.mu.Unlock()
+
+		events, err := r.storage.ListEvents(ctx, storage.ListEventParams{
+			AfterID: last,
+			Limit:   batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			r.publish(event)
+		}
+
+		if len(events) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (r *Relay) publish(event *model.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.last = event.ID
+	for sub := range r.subs {
+		if !sub.matches(event.Type) {
+			continue
+		}
+
+		select {
+		case sub.c <- event:
+		default:
+			// The subscriber fell behind. Dropping it lets it resume from
+			// the outbox rather than silently miss events.
+			r.logger.Warnf("Dropping subscriber lagging behind event %d", event.ID)
+			r.remove(sub)
+		}
+	}
+}
+
+// Subscribe returns a subscription to the events of the given types, or of
+// every type if none.
+func (r *Relay) Subscribe(types ...string) *Subscription {
+	c := make(chan *model.Event, bufferSize)
+	sub := &Subscription{C: c, c: c, types: types, relay: r}
+
+	r.mu.Lock()
+	r.subs[sub] = struct{}{}
+	r.mu.Unlock()
+
+	return sub
+}
+
+// remove closes a subscription. The caller must hold the lock.
+func (r *Relay) remove(sub *Subscription) {
+	if _, ok := r.subs[sub]; ok {
+		delete(r.subs, sub)
+		close(sub.c)
+	}
+}
+
+func (r *Relay) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for sub := range r.subs {
+		r.remove(sub)
+	}
+}
+
+// Subscription delivers events in order of ID on C, which is closed when the
+// subscription ends.
+type Subscription struct {
+	C <-chan *model.Event
+
+	c     chan *model.Event
+	types []string
+	relay *Relay
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.relay.mu.Lock()
+	defer s.relay.mu.Unlock()
+
+	s.relay.remove(s)
+}
+
+func (s *Subscription) matches(eventType string) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+
+	for _, t := range s.types {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
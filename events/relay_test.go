@@ -0,0 +1,99 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, sub *Subscription) *model.Event {
+	t.Helper()
+
+	select {
+	case event := <-sub.C:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+func TestRelay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := memory.New(nil)
+	old := &model.Cage{ID: "cg_1", Capacity: 1}
+	require.NoError(t, st.CreateCage(ctx, old))
+
+	relay := NewRelay(st, 10*time.Millisecond)
+	all := relay.Subscribe()
+	placed := relay.Subscribe(model.EventDinosaurPlaced)
+	closed := relay.Subscribe()
+	closed.Close()
+
+	done := make(chan error)
+	go func() { done <- relay.Run(ctx) }()
+
+	// Give the relay time to skip the events committed before it started.
+	time.Sleep(50 * time.Millisecond)
+
+	cage := &model.Cage{ID: "cg_2", Capacity: 1}
+	require.NoError(t, st.CreateCage(ctx, cage))
+	require.NoError(t, st.CreateDinosaur(ctx, &model.Dinosaur{ID: "dn_1", Name: "Rexy", Species: model.Tyrannosaurus, CageID: cage.ID}))
+
+	event := receive(t, all)
+	assert.Equal(t, model.EventCageCreated, event.Type)
+	assert.Equal(t, cage.ID, event.EntityID)
+
+	event = receive(t, all)
+	assert.Equal(t, model.EventDinosaurPlaced, event.Type)
+
+	event = receive(t, placed)
+	assert.Equal(t, model.EventDinosaurPlaced, event.Type)
+	assert.Equal(t, model.ID("dn_1"), event.EntityID)
+
+	_, ok := <-closed.C
+	assert.False(t, ok)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	_, ok = <-all.C
+	assert.False(t, ok)
+}
+
+func TestRelay_DropsLaggingSubscriber(t *testing.T) {
+	relay := NewRelay(memory.New(nil), 0)
+	sub := relay.Subscribe()
+
+	for i := 1; i <= bufferSize+1; i++ {
+		relay.publish(&model.Event{ID: int64(i), Type: model.EventCageCreated})
+	}
+
+	var n int
+	for range sub.C {
+		n++
+	}
+
+	assert.Equal(t, bufferSize, n)
+}
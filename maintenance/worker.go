@@ -0,0 +1,216 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package maintenance carries out the maintenance windows of cages. At the
+// start of a window the fence of its cage goes into MAINTENANCE and at the
+// end it is energized again. Power changes go through Storage.UpdateCage, so
+// they are checked, audited and published like those made through the API.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/pkg/log"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultInterval = time.Minute
+
+	// Actor is recorded as the actor of the changes made by the worker.
+	Actor = "maintenance"
+)
+
+// errUnchanged tells UpdateCage to leave a cage already in the wanted state.
+var errUnchanged = fmt.Errorf("cage unchanged")
+
+// Worker polls the maintenance windows and switches the power of their
+// cages at the window boundaries. Windows are claimed by a status change
+// before their cage is touched, so several workers can run against the same
+// storage.
+type Worker struct {
+	storage  storage.Storage
+	interval time.Duration
+	logger   logrus.FieldLogger
+	now      func() time.Time
+}
+
+// NewWorker returns a worker polling st at interval, or DefaultInterval if
+// zero, and telling time with now, or time.Now if nil.
+func NewWorker(st storage.Storage, interval time.Duration, now func() time.Time) *Worker {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &Worker{
+		storage:  st,
+		interval: interval,
+		logger:   log.WithField("component", "maintenance"),
+		now:      now,
+	}
+}
+
+// Run carries out the windows until ctx is done.
+func (w *Worker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := w.tick(ctx); err != nil && ctx.Err() == nil {
+				w.logger.Errorf("error while carrying out maintenance windows: %v", err)
+			}
+		}
+	}
+}
+
+// tick ends the windows that are over, then starts those that are due.
+// Windows are ended first so that a cage can go straight from one window
+// into the next.
+func (w *Worker) tick(ctx context.Context) error {
+	ctx = storage.WithActor(ctx, Actor)
+	now := w.now().UTC()
+
+	ending, _, err := w.storage.ListMaintenanceWindows(ctx, storage.ListMaintenanceParams{
+		Status:     model.MaintenanceInProgress,
+		EndsBefore: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, window := range ending {
+		if err := w.end(ctx, window); err != nil {
+			return err
+		}
+	}
+
+	starting, _, err := w.storage.ListMaintenanceWindows(ctx, storage.ListMaintenanceParams{
+		Status:       model.MaintenanceScheduled,
+		StartsBefore: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, window := range starting {
+		if err := w.start(ctx, window, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// start claims a due window and puts its cage into maintenance. The window
+// fails if the cage cannot go into maintenance, for instance because it is
+// still occupied, or if it was over before the worker got to it.
+func (w *Worker) start(ctx context.Context, window *model.MaintenanceWindow, now time.Time) error {
+	if !window.EndsAt.After(now) {
+		return w.finish(ctx, window, model.MaintenanceScheduled, model.MaintenanceFailed, "window was over before it could start")
+	}
+
+	if err := w.claim(ctx, window, model.MaintenanceScheduled, model.MaintenanceInProgress, ""); err != nil {
+		if errors.Is(err, errors.KindPreconditionFailed) {
+			return nil
+		}
+
+		return err
+	}
+
+	if err := w.power(ctx, window, model.PowerMaintenance); err != nil {
+		w.logger.Warnf("Maintenance window %d of cage %s failed: %v", window.ID, window.CageID, err)
+		return w.finish(ctx, window, model.MaintenanceInProgress, model.MaintenanceFailed, err.Error())
+	}
+
+	w.logger.Infof("Maintenance window %d of cage %s started", window.ID, window.CageID)
+	return nil
+}
+
+// end completes a window that is over and energizes its cage again unless
+// it was moved out of maintenance meanwhile.
+func (w *Worker) end(ctx context.Context, window *model.MaintenanceWindow) error {
+	if err := w.power(ctx, window, model.PowerActive); err != nil {
+		w.logger.Warnf("Maintenance window %d of cage %s failed: %v", window.ID, window.CageID, err)
+		return w.finish(ctx, window, model.MaintenanceInProgress, model.MaintenanceFailed, err.Error())
+	}
+
+	w.logger.Infof("Maintenance window %d of cage %s completed", window.ID, window.CageID)
+	return w.finish(ctx, window, model.MaintenanceInProgress, model.MaintenanceCompleted, "")
+}
+
+// power moves the cage of a window into maintenance, or out of it into
+// status. A cage already in the wanted state, or out of maintenance when
+// leaving it, is left alone.
+func (w *Worker) power(ctx context.Context, window *model.MaintenanceWindow, status model.PowerStatus) error {
+	reason := fmt.Sprintf("maintenance window %d", window.ID)
+	if window.Reason != "" {
+		reason += ": " + window.Reason
+	}
+
+	ctx = storage.WithReason(ctx, reason)
+
+	err := w.storage.UpdateCage(ctx, window.CageID, func(old *model.Cage) (*model.Cage, error) {
+		if old.Status == status || status != model.PowerMaintenance && old.Status != model.PowerMaintenance {
+			return nil, errUnchanged
+		}
+
+		old.Status = status
+		return old, nil
+	})
+	if err == errUnchanged {
+		return nil
+	}
+
+	return err
+}
+
+// finish is like claim for the last status change of a window, which other
+// workers cannot race for.
+func (w *Worker) finish(ctx context.Context, window *model.MaintenanceWindow, from, to, msg string) error {
+	if err := w.claim(ctx, window, from, to, msg); err != nil && !errors.Is(err, errors.KindPreconditionFailed) {
+		return err
+	}
+
+	return nil
+}
+
+// claim moves a window from one status to another and fails with
+// errors.KindPreconditionFailed if it is no longer in the first.
+func (w *Worker) claim(ctx context.Context, window *model.MaintenanceWindow, from, to, msg string) error {
+	const op errors.Op = "maintenance.claim"
+
+	return w.storage.UpdateMaintenanceWindow(ctx, window.ID, func(old *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+		if old.Status != from {
+			return nil, errors.E(op, errors.KindPreconditionFailed, fmt.Sprintf(
+				"maintenance window %d is %s", old.ID, old.Status))
+		}
+
+		old.Status = to
+		old.Error = msg
+		return old, nil
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New(nil)
+	start := time.Now().UTC()
+	now := start
+	w := NewWorker(st, 0, func() time.Time { return now })
+
+	empty := &model.Cage{ID: "cg_1", Capacity: 1}
+	filled := &model.Cage{ID: "cg_2", Capacity: 1}
+	require.NoError(t, st.CreateCage(ctx, empty))
+	require.NoError(t, st.CreateCage(ctx, filled))
+
+	fence := &model.MaintenanceWindow{CageID: empty.ID, StartsAt: start.Add(time.Hour), EndsAt: start.Add(2 * time.Hour), Reason: "fence"}
+	late := &model.MaintenanceWindow{CageID: filled.ID, StartsAt: start.Add(30 * time.Hour), EndsAt: start.Add(31 * time.Hour)}
+	require.NoError(t, st.CreateMaintenanceWindow(ctx, fence))
+	require.NoError(t, st.CreateMaintenanceWindow(ctx, late))
+
+	// The cage is filled after the window was accepted, so the window fails.
+	require.NoError(t, st.CreateDinosaur(ctx, &model.Dinosaur{ID: "dn_1", Name: "Rexy", Species: model.Tyrannosaurus, CageID: filled.ID}))
+
+	status := func(id int64) *model.MaintenanceWindow {
+		windows, _, err := st.ListMaintenanceWindows(ctx, storage.ListMaintenanceParams{})
+		require.NoError(t, err)
+		for _, window := range windows {
+			if window.ID == id {
+				return window
+			}
+		}
+
+		t.Fatalf("no maintenance window %d", id)
+		return nil
+	}
+
+	power := func(id model.ID) model.PowerStatus {
+		cage, err := st.GetCage(ctx, id)
+		require.NoError(t, err)
+		return cage.Status
+	}
+
+	require.NoError(t, w.tick(ctx))
+	assert.Equal(t, model.MaintenanceScheduled, status(fence.ID).Status)
+	assert.Equal(t, model.PowerActive, power(empty.ID))
+
+	now = start.Add(90 * time.Minute)
+	require.NoError(t, w.tick(ctx))
+	assert.Equal(t, model.MaintenanceInProgress, status(fence.ID).Status)
+	assert.Equal(t, model.PowerMaintenance, power(empty.ID))
+
+	events, _, err := st.ListPowerEvents(ctx, empty.ID, nil)
+	require.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, Actor, events[0].Actor)
+		assert.Equal(t, fmt.Sprintf("maintenance window %d: fence", fence.ID), events[0].Reason)
+	}
+
+	now = start.Add(3 * time.Hour)
+	require.NoError(t, w.tick(ctx))
+	assert.Equal(t, model.MaintenanceCompleted, status(fence.ID).Status)
+	assert.Equal(t, model.PowerActive, power(empty.ID))
+
+	now = start.Add(30*time.Hour + 30*time.Minute)
+	require.NoError(t, w.tick(ctx))
+	window := status(late.ID)
+	assert.Equal(t, model.MaintenanceFailed, window.Status)
+	assert.Contains(t, window.Error, "holds 1 dinosaurs")
+	assert.Equal(t, model.PowerActive, power(filled.ID))
+
+	// Failed and completed windows are left alone.
+	now = start.Add(48 * time.Hour)
+	require.NoError(t, w.tick(ctx))
+	assert.Equal(t, model.MaintenanceFailed, status(late.ID).Status)
+	assert.Equal(t, model.MaintenanceCompleted, status(fence.ID).Status)
+}
+
+func TestWorker_Elapsed(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New(nil)
+	start := time.Now().UTC()
+	now := start.Add(3 * time.Hour)
+	w := NewWorker(st, 0, func() time.Time { return now })
+
+	require.NoError(t, st.CreateCage(ctx, &model.Cage{ID: "cg_1", Capacity: 1}))
+	window := &model.MaintenanceWindow{CageID: "cg_1", StartsAt: start.Add(time.Hour), EndsAt: start.Add(2 * time.Hour)}
+	require.NoError(t, st.CreateMaintenanceWindow(ctx, window))
+
+	require.NoError(t, w.tick(ctx))
+
+	windows, _, err := st.ListMaintenanceWindows(ctx, storage.ListMaintenanceParams{CageID: "cg_1"})
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.Equal(t, model.MaintenanceFailed, windows[0].Status)
+	assert.NotEmpty(t, windows[0].Error)
+
+	cage, err := st.GetCage(ctx, "cg_1")
+	require.NoError(t, err)
+	assert.Equal(t, model.PowerActive, cage.Status)
+}
@@ -0,0 +1,72 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+ALTER TABLE dinosaurs
+    ADD COLUMN IF NOT EXISTS medical_hold        BOOLEAN DEFAULT false NOT NULL,
+    ADD COLUMN IF NOT EXISTS medical_hold_reason TEXT;
+
+CREATE TABLE IF NOT EXISTS vet_visits
+(
+    id          BIGSERIAL                 NOT NULL PRIMARY KEY,
+    dinosaur_id TEXT                      NOT NULL,
+    vet         TEXT                      NOT NULL,
+    reason      TEXT,
+    notes       TEXT,
+    actor       TEXT                      NOT NULL,
+    visited_at  TIMESTAMPTZ               NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT vet_visits_dinosaur_id_fk FOREIGN KEY (dinosaur_id) REFERENCES dinosaurs (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS vet_visits_dinosaur_id_idx ON vet_visits (dinosaur_id, visited_at);
+
+CREATE TABLE IF NOT EXISTS diagnoses
+(
+    id          BIGSERIAL NOT NULL PRIMARY KEY,
+    visit_id    BIGINT    NOT NULL,
+    dinosaur_id TEXT      NOT NULL,
+    condition   TEXT      NOT NULL,
+    notes       TEXT,
+    CONSTRAINT diagnoses_visit_id_fk FOREIGN KEY (visit_id) REFERENCES vet_visits (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS diagnoses_visit_id_idx ON diagnoses (visit_id);
+
+CREATE TABLE IF NOT EXISTS treatments
+(
+    id          BIGSERIAL NOT NULL PRIMARY KEY,
+    visit_id    BIGINT    NOT NULL,
+    dinosaur_id TEXT      NOT NULL,
+    name        TEXT      NOT NULL,
+    dosage      TEXT,
+    notes       TEXT,
+    CONSTRAINT treatments_visit_id_fk FOREIGN KEY (visit_id) REFERENCES vet_visits (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS treatments_visit_id_idx ON treatments (visit_id);
+
+CREATE TABLE IF NOT EXISTS biometrics
+(
+    id          BIGSERIAL                 NOT NULL PRIMARY KEY,
+    dinosaur_id TEXT                      NOT NULL,
+    metric      TEXT                      NOT NULL,
+    value       DOUBLE PRECISION          NOT NULL,
+    measured_at TIMESTAMPTZ               NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT biometrics_dinosaur_id_fk FOREIGN KEY (dinosaur_id) REFERENCES dinosaurs (id) ON DELETE CASCADE,
+    CONSTRAINT biometrics_metric CHECK (metric IN ('weight', 'length', 'temperature'))
+);
+
+CREATE INDEX IF NOT EXISTS biometrics_dinosaur_id_idx ON biometrics (dinosaur_id, metric, measured_at);
+CREATE INDEX IF NOT EXISTS biometrics_metric_idx ON biometrics (metric, measured_at);
@@ -0,0 +1,32 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+CREATE TABLE IF NOT EXISTS maintenance_windows
+(
+    id         BIGSERIAL                 NOT NULL PRIMARY KEY,
+    cage_id    TEXT                      NOT NULL,
+    starts_at  TIMESTAMPTZ               NOT NULL,
+    ends_at    TIMESTAMPTZ               NOT NULL,
+    reason     TEXT,
+    actor      TEXT                      NOT NULL,
+    status     TEXT                      NOT NULL,
+    error      TEXT,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT maintenance_windows_cage_id_fk FOREIGN KEY (cage_id) REFERENCES cages (id) ON DELETE CASCADE,
+    CONSTRAINT maintenance_windows_ends_at CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS maintenance_windows_cage_id_idx ON maintenance_windows (cage_id, starts_at);
+CREATE INDEX IF NOT EXISTS maintenance_windows_status_idx ON maintenance_windows (status, starts_at);
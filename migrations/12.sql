@@ -0,0 +1,66 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+CREATE TABLE IF NOT EXISTS incidents
+(
+    id              BIGSERIAL                 NOT NULL PRIMARY KEY,
+    kind            TEXT                      NOT NULL,
+    severity        TEXT                      NOT NULL,
+    status          TEXT                      NOT NULL,
+    title           TEXT                      NOT NULL,
+    description     TEXT,
+    actor           TEXT                      NOT NULL,
+    acknowledged_at TIMESTAMPTZ,
+    mitigated_at    TIMESTAMPTZ,
+    closed_at       TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at      TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS incidents_status_idx ON incidents (status, severity);
+
+CREATE TABLE IF NOT EXISTS incident_cages
+(
+    incident_id BIGINT NOT NULL,
+    cage_id     TEXT   NOT NULL,
+    PRIMARY KEY (incident_id, cage_id),
+    CONSTRAINT incident_cages_incident_id_fk FOREIGN KEY (incident_id) REFERENCES incidents (id) ON DELETE CASCADE,
+    CONSTRAINT incident_cages_cage_id_fk FOREIGN KEY (cage_id) REFERENCES cages (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS incident_cages_cage_id_idx ON incident_cages (cage_id);
+
+CREATE TABLE IF NOT EXISTS incident_dinosaurs
+(
+    incident_id BIGINT NOT NULL,
+    dinosaur_id TEXT   NOT NULL,
+    PRIMARY KEY (incident_id, dinosaur_id),
+    CONSTRAINT incident_dinosaurs_incident_id_fk FOREIGN KEY (incident_id) REFERENCES incidents (id) ON DELETE CASCADE,
+    CONSTRAINT incident_dinosaurs_dinosaur_id_fk FOREIGN KEY (dinosaur_id) REFERENCES dinosaurs (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS incident_dinosaurs_dinosaur_id_idx ON incident_dinosaurs (dinosaur_id);
+
+CREATE TABLE IF NOT EXISTS incident_notes
+(
+    id          BIGSERIAL                 NOT NULL PRIMARY KEY,
+    incident_id BIGINT                    NOT NULL,
+    status      TEXT,
+    text        TEXT,
+    actor       TEXT                      NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT incident_notes_incident_id_fk FOREIGN KEY (incident_id) REFERENCES incidents (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS incident_notes_incident_id_idx ON incident_notes (incident_id, id);
@@ -0,0 +1,64 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id            BIGSERIAL                 NOT NULL PRIMARY KEY,
+    url           TEXT                      NOT NULL,
+    secret        TEXT                      NOT NULL,
+    types         TEXT[],
+    active        BOOLEAN     DEFAULT TRUE  NOT NULL,
+    last_event_id BIGINT      DEFAULT 0     NOT NULL,
+    actor         TEXT                      NOT NULL,
+    created_at    TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at    TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id              BIGSERIAL                 NOT NULL PRIMARY KEY,
+    webhook_id      BIGINT                    NOT NULL,
+    event_id        BIGINT                    NOT NULL,
+    event_type      TEXT                      NOT NULL,
+    status          TEXT                      NOT NULL,
+    attempts        INTEGER     DEFAULT 0     NOT NULL,
+    next_attempt_at TIMESTAMPTZ,
+    last_error      TEXT,
+    delivered_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT webhook_deliveries_webhook_id_fk FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE,
+    CONSTRAINT webhook_deliveries_event_id_fk FOREIGN KEY (event_id) REFERENCES outbox (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id);
+
+CREATE TABLE IF NOT EXISTS webhook_attempts
+(
+    id          BIGSERIAL                 NOT NULL PRIMARY KEY,
+    webhook_id  BIGINT                    NOT NULL,
+    delivery_id BIGINT                    NOT NULL,
+    event_id    BIGINT                    NOT NULL,
+    attempt     INTEGER                   NOT NULL,
+    status_code INTEGER,
+    error       TEXT,
+    duration_ms BIGINT      DEFAULT 0     NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT webhook_attempts_webhook_id_fk FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE,
+    CONSTRAINT webhook_attempts_delivery_id_fk FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_attempts_delivery_id_idx ON webhook_attempts (delivery_id, id);
+CREATE INDEX IF NOT EXISTS webhook_attempts_webhook_id_idx ON webhook_attempts (webhook_id, id);
@@ -0,0 +1,29 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+CREATE TABLE IF NOT EXISTS zones
+(
+    id              TEXT                      NOT NULL PRIMARY KEY,
+    name            TEXT                      NOT NULL,
+    forbidden_kinds TEXT[],
+    created_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT zones_name_key UNIQUE (name)
+);
+
+ALTER TABLE cages
+    ADD COLUMN IF NOT EXISTS zone_id TEXT,
+    ADD CONSTRAINT cages_zone_id_fk FOREIGN KEY (zone_id) REFERENCES zones (id);
+
+CREATE INDEX IF NOT EXISTS cages_zone_id_idx ON cages (zone_id);
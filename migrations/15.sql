@@ -0,0 +1,55 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+CREATE TABLE IF NOT EXISTS circuits
+(
+    id         TEXT                      NOT NULL PRIMARY KEY,
+    name       TEXT                      NOT NULL,
+    status     TEXT                      NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT circuits_name_key UNIQUE (name)
+);
+
+ALTER TABLE cages
+    ADD COLUMN IF NOT EXISTS circuit_id TEXT,
+    ADD CONSTRAINT cages_circuit_id_fk FOREIGN KEY (circuit_id) REFERENCES circuits (id);
+
+CREATE INDEX IF NOT EXISTS cages_circuit_id_idx ON cages (circuit_id);
+
+CREATE TABLE IF NOT EXISTS circuit_outages
+(
+    id          BIGSERIAL   NOT NULL PRIMARY KEY,
+    circuit_id  TEXT        NOT NULL,
+    reason      TEXT,
+    actor       TEXT        NOT NULL,
+    started_at  TIMESTAMPTZ NOT NULL,
+    restored_at TIMESTAMPTZ,
+    CONSTRAINT circuit_outages_circuit_id_fk FOREIGN KEY (circuit_id) REFERENCES circuits (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS circuit_outages_circuit_id_idx ON circuit_outages (circuit_id, id);
+
+CREATE TABLE IF NOT EXISTS outage_cages
+(
+    outage_id   BIGINT  NOT NULL,
+    cage_id     TEXT    NOT NULL,
+    status      TEXT    NOT NULL,
+    allocation  INTEGER NOT NULL,
+    incident_id BIGINT,
+    PRIMARY KEY (outage_id, cage_id),
+    CONSTRAINT outage_cages_outage_id_fk FOREIGN KEY (outage_id) REFERENCES circuit_outages (id) ON DELETE CASCADE,
+    CONSTRAINT outage_cages_cage_id_fk FOREIGN KEY (cage_id) REFERENCES cages (id) ON DELETE CASCADE,
+    CONSTRAINT outage_cages_incident_id_fk FOREIGN KEY (incident_id) REFERENCES incidents (id) ON DELETE SET NULL
+);
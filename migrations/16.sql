@@ -0,0 +1,59 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+CREATE TABLE IF NOT EXISTS telemetry_readings
+(
+    id          BIGSERIAL        NOT NULL PRIMARY KEY,
+    cage_id     TEXT             NOT NULL,
+    sensor      TEXT             NOT NULL,
+    value       DOUBLE PRECISION NOT NULL,
+    recorded_at TIMESTAMPTZ      NOT NULL,
+    created_at  TIMESTAMPTZ      NOT NULL,
+    CONSTRAINT telemetry_readings_cage_id_fk FOREIGN KEY (cage_id) REFERENCES cages (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS telemetry_readings_cage_id_idx ON telemetry_readings (cage_id, sensor, recorded_at);
+CREATE INDEX IF NOT EXISTS telemetry_readings_recorded_at_idx ON telemetry_readings (recorded_at);
+
+CREATE TABLE IF NOT EXISTS telemetry_rollups
+(
+    cage_id      TEXT             NOT NULL,
+    sensor       TEXT             NOT NULL,
+    bucket_start TIMESTAMPTZ      NOT NULL,
+    count        INTEGER          NOT NULL,
+    min          DOUBLE PRECISION NOT NULL,
+    max          DOUBLE PRECISION NOT NULL,
+    sum          DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (cage_id, sensor, bucket_start),
+    CONSTRAINT telemetry_rollups_cage_id_fk FOREIGN KEY (cage_id) REFERENCES cages (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS telemetry_rollups_bucket_start_idx ON telemetry_rollups (bucket_start);
+
+CREATE TABLE IF NOT EXISTS fence_alerts
+(
+    id          BIGSERIAL        NOT NULL PRIMARY KEY,
+    cage_id     TEXT             NOT NULL,
+    kind        TEXT             NOT NULL,
+    threshold   DOUBLE PRECISION NOT NULL,
+    voltage     DOUBLE PRECISION NOT NULL,
+    incident_id BIGINT,
+    raised_at   TIMESTAMPTZ      NOT NULL,
+    cleared_at  TIMESTAMPTZ,
+    CONSTRAINT fence_alerts_cage_id_fk FOREIGN KEY (cage_id) REFERENCES cages (id) ON DELETE CASCADE,
+    CONSTRAINT fence_alerts_incident_id_fk FOREIGN KEY (incident_id) REFERENCES incidents (id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS fence_alerts_open_idx ON fence_alerts (cage_id) WHERE cleared_at IS NULL;
+CREATE INDEX IF NOT EXISTS fence_alerts_cage_id_idx ON fence_alerts (cage_id, id);
@@ -0,0 +1,38 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+ALTER TABLE cages
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'ACTIVE'
+        CONSTRAINT cages_status_check CHECK (status IN ('ACTIVE', 'DOWN', 'MAINTENANCE'));
+
+UPDATE cages SET status = CASE WHEN active IS TRUE THEN 'ACTIVE' ELSE 'DOWN' END;
+
+DROP INDEX IF EXISTS cages_active_idx;
+ALTER TABLE cages DROP COLUMN active;
+
+CREATE INDEX IF NOT EXISTS cages_status_idx ON cages (status);
+
+CREATE TABLE IF NOT EXISTS cage_power_events
+(
+    id          BIGSERIAL                 NOT NULL PRIMARY KEY,
+    cage_id     TEXT                      NOT NULL,
+    from_status TEXT,
+    to_status   TEXT                      NOT NULL,
+    actor       TEXT                      NOT NULL,
+    reason      TEXT,
+    created_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT cage_power_events_cage_id_fk FOREIGN KEY (cage_id) REFERENCES cages (id)
+);
+
+CREATE INDEX IF NOT EXISTS cage_power_events_cage_id_idx ON cage_power_events (cage_id, created_at);
@@ -0,0 +1,31 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+CREATE TABLE IF NOT EXISTS transfers
+(
+    id           BIGSERIAL                 NOT NULL PRIMARY KEY,
+    dinosaur_id  TEXT                      NOT NULL,
+    from_cage_id TEXT                      NOT NULL,
+    to_cage_id   TEXT                      NOT NULL,
+    reason       TEXT,
+    actor        TEXT                      NOT NULL,
+    created_at   TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT transfers_dinosaur_id_fk FOREIGN KEY (dinosaur_id) REFERENCES dinosaurs (id) ON DELETE CASCADE,
+    CONSTRAINT transfers_from_cage_id_fk FOREIGN KEY (from_cage_id) REFERENCES cages (id),
+    CONSTRAINT transfers_to_cage_id_fk FOREIGN KEY (to_cage_id) REFERENCES cages (id)
+);
+
+CREATE INDEX IF NOT EXISTS transfers_dinosaur_id_idx ON transfers (dinosaur_id, created_at);
+CREATE INDEX IF NOT EXISTS transfers_from_cage_id_idx ON transfers (from_cage_id, created_at);
+CREATE INDEX IF NOT EXISTS transfers_to_cage_id_idx ON transfers (to_cage_id, created_at);
@@ -0,0 +1,24 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+DROP INDEX IF EXISTS dinosaurs_name_live_idx;
+ALTER TABLE dinosaurs ADD CONSTRAINT dinosaurs_name UNIQUE (name);
+
+ALTER TABLE dinosaurs
+    DROP COLUMN IF EXISTS archived_reason,
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE cages
+    DROP COLUMN IF EXISTS archived_reason,
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,25 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+ALTER TABLE cages
+    ADD COLUMN IF NOT EXISTS deleted_at      TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS archived_reason TEXT;
+
+ALTER TABLE dinosaurs
+    ADD COLUMN IF NOT EXISTS deleted_at      TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS archived_reason TEXT;
+
+-- Names are unique among live dinosaurs only.
+ALTER TABLE dinosaurs DROP CONSTRAINT IF EXISTS dinosaurs_name;
+CREATE UNIQUE INDEX IF NOT EXISTS dinosaurs_name_live_idx ON dinosaurs (name) WHERE deleted_at IS NULL;
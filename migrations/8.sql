@@ -0,0 +1,38 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+CREATE TABLE IF NOT EXISTS species
+(
+    name            TEXT                      NOT NULL PRIMARY KEY,
+    kind            TEXT                      NOT NULL,
+    common_name     TEXT,
+    scientific_name TEXT,
+    max_per_cage    INTEGER     DEFAULT 0     NOT NULL,
+    habitat         TEXT,
+    created_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at      TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT species_kind CHECK (kind IN ('carnivore', 'herbivores')),
+    CONSTRAINT species_max_per_cage CHECK (max_per_cage >= 0)
+);
+
+INSERT INTO species (name, kind, common_name, scientific_name, max_per_cage, habitat)
+VALUES ('ankylosaurus', 'herbivores', 'Ankylosaurus', 'Ankylosaurus magniventris', 4, 'forest'),
+       ('brachiosaurus', 'herbivores', 'Brachiosaurus', 'Brachiosaurus altithorax', 3, 'grassland'),
+       ('megalosaurus', 'carnivore', 'Megalosaurus', 'Megalosaurus bucklandii', 4, 'forest'),
+       ('spinosaurus', 'carnivore', 'Spinosaurus', 'Spinosaurus aegyptiacus', 2, 'wetland'),
+       ('stegosaurus', 'herbivores', 'Stegosaurus', 'Stegosaurus stenops', 6, 'grassland'),
+       ('triceratops', 'herbivores', 'Triceratops', 'Triceratops horridus', 6, 'grassland'),
+       ('tyrannosaurus', 'carnivore', 'T. rex', 'Tyrannosaurus rex', 2, 'forest'),
+       ('velociraptor', 'carnivore', 'Raptor', 'Velociraptor mongoliensis', 6, 'jungle')
+ON CONFLICT (name) DO NOTHING;
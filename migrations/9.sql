@@ -0,0 +1,43 @@
+-- Copyright 2023 The Jurassic Park Authors
+--
+-- Licensed under the AGPL, Version 3.0 (the "License");
+-- you may not use this file except in compliance with the License.
+-- You may obtain a copy of the License at
+--
+--     https://www.gnu.org/licenses/agpl-3.0.en.html
+--
+-- Unless required by applicable law or agreed to in writing, software
+-- distributed under the License is distributed on an "AS IS" BASIS,
+-- WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+-- See the License for the specific language governing permissions and
+-- limitations under the License.
+
+CREATE TABLE IF NOT EXISTS feeding_schedules
+(
+    cage_id        TEXT                      NOT NULL PRIMARY KEY,
+    food           TEXT                      NOT NULL,
+    interval_hours INTEGER                   NOT NULL,
+    ration_kg      DOUBLE PRECISION          NOT NULL,
+    created_at     TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at     TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT feeding_schedules_cage_id_fk FOREIGN KEY (cage_id) REFERENCES cages (id) ON DELETE CASCADE,
+    CONSTRAINT feeding_schedules_interval_hours CHECK (interval_hours > 0),
+    CONSTRAINT feeding_schedules_ration_kg CHECK (ration_kg >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS feedings
+(
+    id          BIGSERIAL                 NOT NULL PRIMARY KEY,
+    cage_id     TEXT                      NOT NULL,
+    dinosaur_id TEXT                      NOT NULL,
+    food        TEXT                      NOT NULL,
+    amount_kg   DOUBLE PRECISION          NOT NULL,
+    actor       TEXT                      NOT NULL,
+    fed_at      TIMESTAMPTZ               NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT now() NOT NULL,
+    CONSTRAINT feedings_cage_id_fk FOREIGN KEY (cage_id) REFERENCES cages (id),
+    CONSTRAINT feedings_dinosaur_id_fk FOREIGN KEY (dinosaur_id) REFERENCES dinosaurs (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS feedings_dinosaur_id_idx ON feedings (dinosaur_id, fed_at);
+CREATE INDEX IF NOT EXISTS feedings_cage_id_idx ON feedings (cage_id, fed_at);
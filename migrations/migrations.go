@@ -0,0 +1,130 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package migrations embeds the versioned SQL migrations of the Postgres
+// schema. Version N is applied by N.sql and rolled back by N.down.sql.
+package migrations
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+const (
+	upSuffix   = ".sql"
+	downSuffix = ".down.sql"
+)
+
+// Migration is a single schema version.
+type Migration struct {
+	Version  int
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// All returns the embedded migrations ordered by version.
+func All() ([]*Migration, error) {
+	return load(files)
+}
+
+// Latest returns the most recent schema version.
+func Latest() (int, error) {
+	all, err := All()
+	if err != nil {
+		return 0, err
+	}
+
+	return all[len(all)-1].Version, nil
+}
+
+func load(fsys fs.FS) ([]*Migration, error) {
+	names, err := fs.Glob(fsys, "*"+upSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, name := range names {
+		down := strings.HasSuffix(name, downSuffix)
+		suffix := upSuffix
+		if down {
+			suffix = downSuffix
+		}
+
+		version, err := strconv.Atoi(strings.TrimSuffix(name, suffix))
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration file name: %q", name)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version}
+			byVersion[version] = m
+		}
+
+		if down {
+			m.Down = string(content)
+		} else {
+			m.Up = string(content)
+			m.Checksum = checksum(content)
+		}
+	}
+
+	all := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		all = append(all, m)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+
+	for i, m := range all {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("missing migration %d", i+1)
+		}
+
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no %d%s", m.Version, m.Version, upSuffix)
+		}
+
+		if m.Down == "" {
+			return nil, fmt.Errorf("migration %d has no %d%s", m.Version, m.Version, downSuffix)
+		}
+	}
+
+	if len(all) == 0 {
+		return nil, fmt.Errorf("no migrations found")
+	}
+
+	return all, nil
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
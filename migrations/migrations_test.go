@@ -0,0 +1,58 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAll(t *testing.T) {
+	all, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, m := range all {
+		assert.Equal(t, i+1, m.Version)
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+		assert.Len(t, m.Checksum, 64)
+	}
+
+	latest, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, len(all), latest)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("SELECT 1;")}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"empty":        {},
+		"missing down": {"1.sql": file},
+		"missing up":   {"1.down.sql": file},
+		"gap":          {"1.sql": file, "1.down.sql": file, "3.sql": file, "3.down.sql": file},
+		"bad name":     {"init.sql": file},
+	} {
+		_, err := load(fsys)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entity types recorded in the audit log.
+const (
+	EntityCage     = "cage"
+	EntityDinosaur = "dinosaur"
+	EntitySpecies  = "species"
+
+	EntityFeedingSchedule   = "feeding_schedule"
+	EntityFeeding           = "feeding"
+	EntityVetVisit          = "vet_visit"
+	EntityBiometric         = "biometric"
+	EntityMaintenanceWindow = "maintenance_window"
+	EntityIncident          = "incident"
+	EntityWebhook           = "webhook"
+	EntityZone              = "zone"
+	EntityCircuit           = "circuit"
+)
+
+// AuditEntry records a change made through storage: who made it, the
+// operation that made it and the state of the entity before and after.
+type AuditEntry struct {
+	tableName struct{} `pg:"audit_log,alias:audit_entry"`
+
+	ID         int64           `json:"id,omitempty" pg:",pk"`
+	Actor      string          `json:"actor,omitempty"`
+	Op         string          `json:"op,omitempty"`
+	EntityType string          `json:"entity_type,omitempty"`
+	EntityID   ID              `json:"entity_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty" pg:"type:jsonb"`
+	After      json.RawMessage `json:"after,omitempty" pg:"type:jsonb"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type AuditEntriesResource struct {
+	Entries []*AuditEntry `json:"entries"`
+}
@@ -24,14 +24,36 @@ const (
 
 type Cage struct {
 	ID         ID          `json:"id,omitempty" pg:",pk"`
-	Capacity   int         `json:"capacity,omitempty"`
+	Capacity   int         `json:"capacity,omitempty" pg:",use_zero"`
 	Allocation int         `json:"allocation,omitempty" pg:"-"`
 	Species    Species     `json:"species,omitempty" pg:"-"`
 	Dinosaurs  []*Dinosaur `json:"dinosaurs,omitempty" pg:"-"`
-	Active     bool        `json:"active,omitempty"`
+	Status     PowerStatus `json:"status,omitempty"`
+	ZoneID     ID          `json:"zone_id,omitempty"`
+	CircuitID  ID          `json:"circuit_id,omitempty"`
+	Version    int         `json:"version,omitempty"`
 
-	CreatedAt *time.Time `json:"created_at,omitempty"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// ForbiddenKinds are the diet kinds forbidden by the zone of the cage.
+	ForbiddenKinds []string `json:"forbidden_kinds,omitempty" pg:"-"`
+
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	ArchivedReason string     `json:"archived_reason,omitempty"`
+}
+
+// Archived reports whether the cage was decommissioned.
+func (c *Cage) Archived() bool {
+	return c.DeletedAt != nil
+}
+
+// MaxOccupancy returns how many dinosaurs the cage can hold.
+func (c *Cage) MaxOccupancy() int {
+	if c.Capacity > MaxCageCapacity {
+		return MaxCageCapacity
+	}
+
+	return c.Capacity
 }
 
 func NewCageID(uuid string) ID {
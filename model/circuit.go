@@ -0,0 +1,89 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+const prefixCircuit = "ct"
+
+// Circuit statuses. A cut circuit holds the fences of its cages DOWN until
+// it is restored.
+const (
+	CircuitLive = "live"
+	CircuitCut  = "cut"
+)
+
+// Circuit is a power circuit shared by the fences of its cages.
+type Circuit struct {
+	ID     ID     `json:"id,omitempty" pg:",pk"`
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status,omitempty"`
+
+	// Cages counts the live cages on the circuit.
+	Cages int `json:"cages" pg:"-"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Cut reports whether the circuit is cut.
+func (c *Circuit) Cut() bool {
+	return c.Status == CircuitCut
+}
+
+func NewCircuitID(uuid string) ID {
+	return NewID(prefixCircuit, uuid)
+}
+
+// CircuitOutage is a cut of a circuit, open until the circuit is restored.
+type CircuitOutage struct {
+	ID        int64  `json:"id,omitempty" pg:",pk"`
+	CircuitID ID     `json:"circuit_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Actor     string `json:"actor,omitempty"`
+
+	Cages []*OutageCage `json:"cages,omitempty" pg:"-"`
+
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	RestoredAt *time.Time `json:"restored_at,omitempty"`
+}
+
+// OutageCage is a cage put DOWN by an outage. Status is the power state the
+// cage goes back to when the circuit is restored, and IncidentID the alarm
+// raised if the cage was occupied.
+type OutageCage struct {
+	OutageID   int64       `json:"-" pg:",pk"`
+	CageID     ID          `json:"cage_id,omitempty" pg:",pk"`
+	Status     PowerStatus `json:"status,omitempty"`
+	Allocation int         `json:"allocation" pg:",use_zero"`
+	IncidentID int64       `json:"incident_id,omitempty"`
+}
+
+// CircuitImpact tells what cutting a circuit would do: the cages going DOWN,
+// the dinosaurs they hold and the alarms raised for the occupied ones.
+type CircuitImpact struct {
+	CircuitID ID          `json:"circuit_id"`
+	Cages     []*Cage     `json:"cages"`
+	Dinosaurs []*Dinosaur `json:"dinosaurs"`
+	Alarms    int         `json:"alarms"`
+}
+
+type CircuitsResource struct {
+	Circuits []*Circuit `json:"circuits"`
+}
+
+type CircuitOutagesResource struct {
+	Outages []*CircuitOutage `json:"outages"`
+}
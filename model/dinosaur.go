@@ -19,13 +19,28 @@ import "time"
 const prefixDinosaur = "din"
 
 type Dinosaur struct {
-	ID      ID      `json:"id,omitempty"`
+	ID      ID      `json:"id,omitempty" pg:",pk"`
 	Name    string  `json:"name,omitempty"`
 	Species Species `json:"species,omitempty"`
 	CageID  ID      `json:"cage_id,omitempty"`
+	Version int     `json:"version,omitempty"`
 
-	CreatedAt *time.Time `json:"created_at,omitempty"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	ArchivedReason string     `json:"archived_reason,omitempty"`
+
+	// MedicalHold keeps the dinosaur in its cage until a vet lifts it.
+	MedicalHold       bool   `json:"medical_hold,omitempty" pg:",use_zero"`
+	MedicalHoldReason string `json:"medical_hold_reason,omitempty"`
+
+	// LastFedAt is the last time the dinosaur was fed, unset if never.
+	LastFedAt *time.Time `json:"last_fed_at,omitempty" pg:"-"`
+}
+
+// Archived reports whether the dinosaur is deceased or left the park.
+func (d *Dinosaur) Archived() bool {
+	return d.DeletedAt != nil
 }
 
 func NewDinosaurID(uuid string) ID {
@@ -0,0 +1,70 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of the domain events published through the outbox.
+const (
+	EventCageCreated         = "cage.created"
+	EventCagePowerChanged    = "cage.power_changed"
+	EventCageArchived        = "cage.archived"
+	EventCageRestored        = "cage.restored"
+	EventDinosaurPlaced      = "dinosaur.placed"
+	EventDinosaurTransferred = "dinosaur.transferred"
+	EventDinosaurRemoved     = "dinosaur.removed"
+	EventSpeciesChanged      = "species.changed"
+	EventIncidentOpened      = "incident.opened"
+	EventIncidentChanged     = "incident.changed"
+	EventCircuitCut          = "circuit.cut"
+	EventCircuitRestored     = "circuit.restored"
+)
+
+var eventTypes = map[string]bool{
+	EventCageCreated:         true,
+	EventCagePowerChanged:    true,
+	EventCageArchived:        true,
+	EventCageRestored:        true,
+	EventDinosaurPlaced:      true,
+	EventDinosaurTransferred: true,
+	EventDinosaurRemoved:     true,
+	EventSpeciesChanged:      true,
+	EventIncidentOpened:      true,
+	EventIncidentChanged:     true,
+	EventCircuitCut:          true,
+	EventCircuitRestored:     true,
+}
+
+// ValidEventType reports whether t is a known event type.
+func ValidEventType(t string) bool {
+	return eventTypes[t]
+}
+
+// Event is a change of the park written to the outbox in the transaction
+// that made it. IDs grow in commit order, so readers can resume after the
+// last event they have seen.
+type Event struct {
+	tableName struct{} `pg:"outbox,alias:event"`
+
+	ID       int64           `json:"id,omitempty" pg:",pk"`
+	Type     string          `json:"type,omitempty"`
+	EntityID ID              `json:"entity_id,omitempty"`
+	Payload  json.RawMessage `json:"payload,omitempty" pg:"type:jsonb"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// Default feeding schedules by diet kind.
+const (
+	CarnivoreFeedingInterval = 24 * time.Hour
+	CarnivoreRationKg        = 45.0
+	HerbivoreFeedingInterval = 8 * time.Hour
+	HerbivoreRationKg        = 120.0
+)
+
+// FeedingSchedule sets how often the occupants of a cage are fed and how
+// much each one is given.
+type FeedingSchedule struct {
+	tableName struct{} `pg:"feeding_schedules,alias:feeding_schedule"`
+
+	CageID        ID      `json:"cage_id,omitempty" pg:",pk"`
+	Food          string  `json:"food,omitempty"`
+	IntervalHours int     `json:"interval_hours,omitempty" pg:",use_zero"`
+	RationKg      float64 `json:"ration_kg,omitempty" pg:",use_zero"`
+
+	// Default is set when the schedule is derived from the diet of the
+	// occupants rather than stored for the cage.
+	Default bool `json:"default,omitempty" pg:"-"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Interval returns the time between two feedings.
+func (s *FeedingSchedule) Interval() time.Duration {
+	return time.Duration(s.IntervalHours) * time.Hour
+}
+
+// DefaultFeedingSchedule returns the schedule of a cage holding dinosaurs of
+// a diet kind.
+func DefaultFeedingSchedule(cageID ID, kind string) *FeedingSchedule {
+	schedule := &FeedingSchedule{
+		CageID:        cageID,
+		Food:          "vegetation",
+		IntervalHours: int(HerbivoreFeedingInterval / time.Hour),
+		RationKg:      HerbivoreRationKg,
+		Default:       true,
+	}
+
+	if kind == KindCarnivore {
+		schedule.Food = "meat"
+		schedule.IntervalHours = int(CarnivoreFeedingInterval / time.Hour)
+		schedule.RationKg = CarnivoreRationKg
+	}
+
+	return schedule
+}
+
+// Feeding records that a keeper fed a dinosaur.
+type Feeding struct {
+	ID         int64   `json:"id,omitempty" pg:",pk"`
+	CageID     ID      `json:"cage_id,omitempty"`
+	DinosaurID ID      `json:"dinosaur_id,omitempty"`
+	Food       string  `json:"food,omitempty"`
+	AmountKg   float64 `json:"amount_kg,omitempty" pg:",use_zero"`
+	Actor      string  `json:"actor,omitempty"`
+
+	FedAt     *time.Time `json:"fed_at,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// OverdueFeeding reports the occupants of a cage that were not fed on
+// schedule.
+type OverdueFeeding struct {
+	CageID    ID               `json:"cage_id"`
+	Schedule  *FeedingSchedule `json:"schedule"`
+	Dinosaurs []ID             `json:"dinosaurs"`
+
+	// LastFedAt is the last feeding of the occupant due the earliest, unset
+	// if it was never fed.
+	LastFedAt *time.Time `json:"last_fed_at,omitempty"`
+	DueAt     time.Time  `json:"due_at"`
+}
+
+type FeedingScheduleResource struct {
+	Schedule *FeedingSchedule `json:"schedule"`
+}
+
+type FeedingsResource struct {
+	Feedings []*Feeding `json:"feedings"`
+}
+
+type OverdueFeedingsResource struct {
+	Overdue []*OverdueFeeding `json:"overdue"`
+}
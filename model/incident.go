@@ -0,0 +1,132 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// Incident kinds.
+const (
+	IncidentBreach       = "breach"
+	IncidentEscape       = "escape"
+	IncidentPowerFailure = "power_failure"
+	IncidentInjury       = "injury"
+	IncidentOther        = "other"
+)
+
+// ValidIncidentKind reports whether k is a known incident kind.
+func ValidIncidentKind(k string) bool {
+	switch k {
+	case IncidentBreach, IncidentEscape, IncidentPowerFailure, IncidentInjury, IncidentOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// Incident severities, from least to most severe.
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// ValidSeverity reports whether s is a known incident severity.
+func ValidSeverity(s string) bool {
+	switch s {
+	case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+		return true
+	default:
+		return false
+	}
+}
+
+// Incident statuses. An incident is open until someone acknowledges it,
+// mitigated once the danger is contained and closed when resolved.
+const (
+	IncidentOpen         = "open"
+	IncidentAcknowledged = "acknowledged"
+	IncidentMitigated    = "mitigated"
+	IncidentClosed       = "closed"
+)
+
+// ValidIncidentStatus reports whether s is a known incident status.
+func ValidIncidentStatus(s string) bool {
+	switch s {
+	case IncidentOpen, IncidentAcknowledged, IncidentMitigated, IncidentClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Incident is a containment problem affecting cages and dinosaurs.
+type Incident struct {
+	ID          int64  `json:"id,omitempty" pg:",pk"`
+	Kind        string `json:"kind,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Actor       string `json:"actor,omitempty"`
+
+	CageIDs     []ID            `json:"cage_ids,omitempty" pg:"-"`
+	DinosaurIDs []ID            `json:"dinosaur_ids,omitempty" pg:"-"`
+	Notes       []*IncidentNote `json:"notes,omitempty" pg:"-"`
+
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	MitigatedAt    *time.Time `json:"mitigated_at,omitempty"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// Holds reports whether the incident puts a transfer hold on its cages,
+// which critical incidents do until they are closed.
+func (i *Incident) Holds() bool {
+	return i.Severity == SeverityCritical && i.Status != IncidentClosed
+}
+
+// IncidentCage links an incident to a cage.
+type IncidentCage struct {
+	IncidentID int64 `pg:",pk"`
+	CageID     ID    `pg:",pk"`
+}
+
+// IncidentDinosaur links an incident to a dinosaur.
+type IncidentDinosaur struct {
+	IncidentID int64 `pg:",pk"`
+	DinosaurID ID    `pg:",pk"`
+}
+
+// IncidentNote is an entry of the timeline of an incident. Status changes
+// are recorded as notes carrying the new status.
+type IncidentNote struct {
+	ID         int64  `json:"id,omitempty" pg:",pk"`
+	IncidentID int64  `json:"incident_id,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Text       string `json:"text,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type IncidentResource struct {
+	Incident *Incident `json:"incident"`
+}
+
+type IncidentsResource struct {
+	Incidents []*Incident `json:"incidents"`
+}
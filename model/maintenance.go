@@ -0,0 +1,70 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// Maintenance window statuses. A window is scheduled until the fence goes
+// into MAINTENANCE at its start, in progress until the fence is energized
+// again at its end, and completed afterwards. It fails if the cage could not
+// go into maintenance, for instance because it was still occupied.
+const (
+	MaintenanceScheduled  = "scheduled"
+	MaintenanceInProgress = "in_progress"
+	MaintenanceCompleted  = "completed"
+	MaintenanceCancelled  = "cancelled"
+	MaintenanceFailed     = "failed"
+)
+
+// ValidMaintenanceStatus reports whether s is a known window status.
+func ValidMaintenanceStatus(s string) bool {
+	switch s {
+	case MaintenanceScheduled, MaintenanceInProgress, MaintenanceCompleted, MaintenanceCancelled, MaintenanceFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// MaintenanceWindow is a planned power-down of a cage for fence work.
+type MaintenanceWindow struct {
+	ID       int64     `json:"id,omitempty" pg:",pk"`
+	CageID   ID        `json:"cage_id,omitempty"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason,omitempty"`
+	Actor    string    `json:"actor,omitempty"`
+	Status   string    `json:"status,omitempty"`
+
+	// Error tells why a failed window could not be carried out.
+	Error string `json:"error,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// MaintenanceWarning flags an upcoming window whose cage is occupied.
+type MaintenanceWarning struct {
+	WindowID   int64     `json:"window_id"`
+	CageID     ID        `json:"cage_id"`
+	StartsAt   time.Time `json:"starts_at"`
+	Allocation int       `json:"allocation"`
+	Message    string    `json:"message"`
+}
+
+type MaintenanceWindowsResource struct {
+	Windows  []*MaintenanceWindow  `json:"windows"`
+	Warnings []*MaintenanceWarning `json:"warnings,omitempty"`
+}
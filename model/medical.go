@@ -0,0 +1,105 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// Biometric metrics and their units.
+const (
+	MetricWeight      = "weight"      // kilograms
+	MetricLength      = "length"      // meters
+	MetricTemperature = "temperature" // degrees Celsius
+)
+
+// ValidMetric reports whether m is a known biometric metric.
+func ValidMetric(m string) bool {
+	switch m {
+	case MetricWeight, MetricLength, MetricTemperature:
+		return true
+	default:
+		return false
+	}
+}
+
+// VetVisit records a veterinary examination of a dinosaur with the
+// diagnoses made and the treatments prescribed.
+type VetVisit struct {
+	ID         int64  `json:"id,omitempty" pg:",pk"`
+	DinosaurID ID     `json:"dinosaur_id,omitempty"`
+	Vet        string `json:"vet,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+
+	Diagnoses  []*Diagnosis `json:"diagnoses,omitempty" pg:"-"`
+	Treatments []*Treatment `json:"treatments,omitempty" pg:"-"`
+
+	VisitedAt *time.Time `json:"visited_at,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type Diagnosis struct {
+	tableName struct{} `pg:"diagnoses,alias:diagnosis"`
+
+	ID         int64  `json:"id,omitempty" pg:",pk"`
+	VisitID    int64  `json:"visit_id,omitempty"`
+	DinosaurID ID     `json:"dinosaur_id,omitempty"`
+	Condition  string `json:"condition,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+type Treatment struct {
+	ID         int64  `json:"id,omitempty" pg:",pk"`
+	VisitID    int64  `json:"visit_id,omitempty"`
+	DinosaurID ID     `json:"dinosaur_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Dosage     string `json:"dosage,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// Biometric is a measurement of a dinosaur in the unit of its metric.
+type Biometric struct {
+	ID         int64   `json:"id,omitempty" pg:",pk"`
+	DinosaurID ID      `json:"dinosaur_id,omitempty"`
+	Metric     string  `json:"metric,omitempty"`
+	Value      float64 `json:"value" pg:",use_zero"`
+
+	MeasuredAt *time.Time `json:"measured_at,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+// GrowthTrend summarizes the weight change of a dinosaur over a window.
+type GrowthTrend struct {
+	DinosaurID ID        `json:"dinosaur_id"`
+	Samples    int       `json:"samples"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	FirstKg    float64   `json:"first_kg"`
+	LastKg     float64   `json:"last_kg"`
+	ChangePct  float64   `json:"change_pct"`
+	Abnormal   bool      `json:"abnormal"`
+}
+
+type VetVisitsResource struct {
+	Visits []*VetVisit `json:"visits"`
+}
+
+type BiometricsResource struct {
+	Biometrics []*Biometric `json:"biometrics"`
+}
+
+type GrowthTrendsResource struct {
+	Trends []*GrowthTrend `json:"trends"`
+}
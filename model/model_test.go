@@ -24,3 +24,35 @@ func TestNewID(t *testing.T) {
 	id := NewID("test", "123")
 	assert.Equal(t, ID("test_123"), id)
 }
+
+func TestCage_MaxOccupancy(t *testing.T) {
+	assert.Equal(t, 3, (&Cage{Capacity: 3}).MaxOccupancy())
+	assert.Equal(t, MaxCageCapacity, (&Cage{Capacity: MaxCageCapacity + 1}).MaxOccupancy())
+}
+
+func TestPowerStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to PowerStatus
+		want     bool
+	}{
+		{from: PowerActive, to: PowerDown, want: true},
+		{from: PowerActive, to: PowerMaintenance, want: true},
+		{from: PowerMaintenance, to: PowerActive, want: true},
+		{from: PowerMaintenance, to: PowerDown, want: true},
+		{from: PowerDown, to: PowerMaintenance, want: true},
+		{from: PowerDown, to: PowerActive, want: false},
+		{from: PowerActive, to: PowerActive, want: false},
+		{from: PowerActive, to: PowerStatus("OFF"), want: false},
+	}
+	for _, tt := range tests {
+		assert.Equalf(t, tt.want, tt.from.CanTransitionTo(tt.to), "%s.CanTransitionTo(%s)", tt.from, tt.to)
+	}
+
+	assert.True(t, PowerMaintenance.Valid())
+	assert.False(t, PowerStatus("").Valid())
+}
+
+func TestValidEventType(t *testing.T) {
+	assert.True(t, ValidEventType(EventDinosaurTransferred))
+	assert.False(t, ValidEventType("dinosaur.escaped"))
+}
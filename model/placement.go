@@ -0,0 +1,36 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// PlacementCandidate is a cage that can take a dinosaur, scored by how well
+// it suits it.
+type PlacementCandidate struct {
+	Cage    *Cage    `json:"cage"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// PlacementExclusion explains why a cage cannot take a dinosaur.
+type PlacementExclusion struct {
+	CageID ID     `json:"cage_id"`
+	Reason string `json:"reason"`
+}
+
+// PlacementResource ranks the cages for a dinosaur of a species, best first.
+type PlacementResource struct {
+	Species    Species               `json:"species"`
+	Candidates []*PlacementCandidate `json:"candidates"`
+	Excluded   []*PlacementExclusion `json:"excluded"`
+}
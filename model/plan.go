@@ -0,0 +1,39 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// PlannedTransfer is a move of a dinosaur proposed by a plan.
+type PlannedTransfer struct {
+	DinosaurID ID `json:"dinosaur_id"`
+	FromCageID ID `json:"from_cage_id"`
+	ToCageID   ID `json:"to_cage_id"`
+}
+
+// ConsolidationPlan lists the transfers that empty the cages in Freed.
+type ConsolidationPlan struct {
+	Freed     []ID               `json:"freed"`
+	Transfers []*PlannedTransfer `json:"transfers"`
+
+	// Minimal is false when the search for the cages to free was cut short
+	// before it could rule out a plan with fewer transfers.
+	Minimal bool `json:"minimal"`
+}
+
+type ConsolidationResource struct {
+	Plan *ConsolidationPlan `json:"plan"`
+
+	// Transfers records the transfers made if the plan was executed.
+	Transfers []*Transfer `json:"transfers,omitempty"`
+}
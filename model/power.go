@@ -0,0 +1,69 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// PowerStatus is the power state of a cage fence.
+type PowerStatus string
+
+const (
+	PowerActive      PowerStatus = "ACTIVE"
+	PowerDown        PowerStatus = "DOWN"
+	PowerMaintenance PowerStatus = "MAINTENANCE"
+)
+
+// transitions lists the states reachable from each power state. A cage that
+// is DOWN has to go through MAINTENANCE before its fence is energized again.
+var transitions = map[PowerStatus][]PowerStatus{
+	PowerActive:      {PowerMaintenance, PowerDown},
+	PowerMaintenance: {PowerActive, PowerDown},
+	PowerDown:        {PowerMaintenance},
+}
+
+// Valid reports whether s is a known power state.
+func (s PowerStatus) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a cage can go from s to the given state.
+func (s PowerStatus) CanTransitionTo(to PowerStatus) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PowerEvent records a change in the power state of a cage.
+type PowerEvent struct {
+	tableName struct{} `pg:"cage_power_events,alias:power_event"`
+
+	ID     int64       `json:"id,omitempty" pg:",pk"`
+	CageID ID          `json:"cage_id,omitempty"`
+	From   PowerStatus `json:"from,omitempty" pg:"from_status"`
+	To     PowerStatus `json:"to,omitempty" pg:"to_status"`
+	Actor  string      `json:"actor,omitempty"`
+	Reason string      `json:"reason,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type PowerEventsResource struct {
+	Events []*PowerEvent `json:"events"`
+}
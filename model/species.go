@@ -14,6 +14,12 @@
 
 package model
 
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
 type Species string
 
 const (
@@ -36,21 +42,105 @@ const (
 	Triceratops   Species = "triceratops"
 )
 
-var kinds = map[Species]string{
-	Tyrannosaurus: KindCarnivore,
-	Velociraptor:  KindCarnivore,
-	Spinosaurus:   KindCarnivore,
-	Megalosaurus:  KindCarnivore,
-	Brachiosaurus: KindHerbivores,
-	Stegosaurus:   KindHerbivores,
-	Ankylosaurus:  KindHerbivores,
-	Triceratops:   KindHerbivores,
+// SpeciesEntry describes a species of the catalog.
+type SpeciesEntry struct {
+	tableName struct{} `pg:"species,alias:species_entry"`
+
+	Name           Species `json:"name,omitempty" pg:",pk"`
+	Kind           string  `json:"kind,omitempty"`
+	CommonName     string  `json:"common_name,omitempty"`
+	ScientificName string  `json:"scientific_name,omitempty"`
+
+	// MaxPerCage caps the number of dinosaurs of the species sharing a cage.
+	// Zero leaves it to the capacity of the cage.
+	MaxPerCage int `json:"max_per_cage" pg:",use_zero"`
+
+	// Habitat is the environment a cage must provide for the species.
+	Habitat string `json:"habitat,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type SpeciesResource struct {
+	Species []*SpeciesEntry `json:"species"`
+}
+
+// ValidKind reports whether kind is a known diet kind.
+func ValidKind(kind string) bool {
+	return kind == KindCarnivore || kind == KindHerbivores
+}
+
+// DefaultSpecies returns the species the park opened with. They seed new
+// catalogs.
+func DefaultSpecies() []*SpeciesEntry {
+	return []*SpeciesEntry{
+		{Name: Ankylosaurus, Kind: KindHerbivores, CommonName: "Ankylosaurus", ScientificName: "Ankylosaurus magniventris", MaxPerCage: 4, Habitat: "forest"},
+		{Name: Brachiosaurus, Kind: KindHerbivores, CommonName: "Brachiosaurus", ScientificName: "Brachiosaurus altithorax", MaxPerCage: 3, Habitat: "grassland"},
+		{Name: Megalosaurus, Kind: KindCarnivore, CommonName: "Megalosaurus", ScientificName: "Megalosaurus bucklandii", MaxPerCage: 4, Habitat: "forest"},
+		{Name: Spinosaurus, Kind: KindCarnivore, CommonName: "Spinosaurus", ScientificName: "Spinosaurus aegyptiacus", MaxPerCage: 2, Habitat: "wetland"},
+		{Name: Stegosaurus, Kind: KindHerbivores, CommonName: "Stegosaurus", ScientificName: "Stegosaurus stenops", MaxPerCage: 6, Habitat: "grassland"},
+		{Name: Triceratops, Kind: KindHerbivores, CommonName: "Triceratops", ScientificName: "Triceratops horridus", MaxPerCage: 6, Habitat: "grassland"},
+		{Name: Tyrannosaurus, Kind: KindCarnivore, CommonName: "T. rex", ScientificName: "Tyrannosaurus rex", MaxPerCage: 2, Habitat: "forest"},
+		{Name: Velociraptor, Kind: KindCarnivore, CommonName: "Raptor", ScientificName: "Velociraptor mongoliensis", MaxPerCage: 6, Habitat: "jungle"},
+	}
+}
+
+// registry caches the species catalog for the containment rules, which run
+// without access to storage. It starts with DefaultSpecies.
+var registry = struct {
+	sync.RWMutex
+	species map[Species]SpeciesEntry
+}{}
+
+func init() {
+	LoadSpecies(DefaultSpecies())
+}
+
+// LoadSpecies replaces the cached catalog.
+func LoadSpecies(entries []*SpeciesEntry) {
+	species := make(map[Species]SpeciesEntry, len(entries))
+	for _, entry := range entries {
+		species[entry.Name] = *entry
+	}
+
+	registry.Lock()
+	registry.species = species
+	registry.Unlock()
+}
+
+// LookupSpecies returns the cached catalog entry of a species.
+func LookupSpecies(s Species) (*SpeciesEntry, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	entry, ok := registry.species[s]
+	if !ok {
+		return nil, false
+	}
+
+	return &entry, true
 }
 
 func SpeciesKind(s Species) string {
-	if kind, exists := kinds[s]; exists {
-		return kind
+	if entry, exists := LookupSpecies(s); exists {
+		return entry.Kind
 	}
 
 	return KindUnknown
 }
+
+// SpeciesOfKind returns the species of a diet kind sorted by name.
+func SpeciesOfKind(kind string) []Species {
+	registry.RLock()
+	var species []Species
+	for s, entry := range registry.species {
+		if entry.Kind == kind {
+			species = append(species, s)
+		}
+	}
+	registry.RUnlock()
+
+	sort.Slice(species, func(i, j int) bool { return species[i] < species[j] })
+	return species
+}
@@ -45,3 +45,27 @@ func TestSpeciesKind(t *testing.T) {
 		})
 	}
 }
+
+func TestSpeciesOfKind(t *testing.T) {
+	assert.Equal(t, []Species{Megalosaurus, Spinosaurus, Tyrannosaurus, Velociraptor}, SpeciesOfKind(KindCarnivore))
+	assert.Equal(t, []Species{Ankylosaurus, Brachiosaurus, Stegosaurus, Triceratops}, SpeciesOfKind(KindHerbivores))
+	assert.Empty(t, SpeciesOfKind(KindUnknown))
+}
+
+func TestLoadSpecies(t *testing.T) {
+	t.Cleanup(func() { LoadSpecies(DefaultSpecies()) })
+
+	LoadSpecies(append(DefaultSpecies(), &SpeciesEntry{Name: "dilophosaurus", Kind: KindCarnivore}))
+	assert.Equal(t, KindCarnivore, SpeciesKind("dilophosaurus"))
+	assert.Contains(t, SpeciesOfKind(KindCarnivore), Species("dilophosaurus"))
+
+	entry, ok := LookupSpecies(Tyrannosaurus)
+	assert.True(t, ok)
+	assert.Equal(t, "Tyrannosaurus rex", entry.ScientificName)
+
+	LoadSpecies(nil)
+	assert.Equal(t, KindUnknown, SpeciesKind(Tyrannosaurus))
+
+	_, ok = LookupSpecies(Tyrannosaurus)
+	assert.False(t, ok)
+}
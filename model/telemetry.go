@@ -0,0 +1,107 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// Fence sensors and the unit of their readings.
+const (
+	SensorVoltage = "voltage" // volts
+	SensorDoor    = "door"    // 1 if open, 0 if closed
+)
+
+// ValidSensor reports whether s is a known fence sensor.
+func ValidSensor(s string) bool {
+	switch s {
+	case SensorVoltage, SensorDoor:
+		return true
+	default:
+		return false
+	}
+}
+
+// Reading is a sample pushed by a fence sensor of a cage.
+type Reading struct {
+	tableName struct{} `pg:"telemetry_readings,alias:reading"`
+
+	ID     int64   `json:"id,omitempty" pg:",pk"`
+	CageID ID      `json:"cage_id,omitempty"`
+	Sensor string  `json:"sensor,omitempty"`
+	Value  float64 `json:"value" pg:",use_zero"`
+
+	RecordedAt *time.Time `json:"recorded_at,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+// ReadingRollup aggregates the readings of a sensor of a cage recorded
+// within a bucket once they are past their retention.
+type ReadingRollup struct {
+	tableName struct{} `pg:"telemetry_rollups,alias:rollup"`
+
+	CageID      ID        `pg:",pk"`
+	Sensor      string    `pg:",pk"`
+	BucketStart time.Time `pg:",pk"`
+	Count       int       `pg:",use_zero"`
+	Min         float64   `pg:",use_zero"`
+	Max         float64   `pg:",use_zero"`
+	Sum         float64   `pg:",use_zero"`
+}
+
+// ReadingBucket summarizes the readings of a sensor within a time bucket.
+type ReadingBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+	Min   float64   `json:"min"`
+	Avg   float64   `json:"avg"`
+	Max   float64   `json:"max"`
+
+	// Sum is kept to merge buckets before Avg is computed.
+	Sum float64 `json:"-"`
+}
+
+// FenceAlert is raised when the fence voltage of an occupied cage drops
+// below the threshold of the diet kind of its occupants, and cleared once
+// a reading is back above it. IncidentID is the incident opened for it.
+type FenceAlert struct {
+	ID         int64   `json:"id,omitempty" pg:",pk"`
+	CageID     ID      `json:"cage_id,omitempty"`
+	Kind       string  `json:"kind,omitempty"`
+	Threshold  float64 `json:"threshold" pg:",use_zero"`
+	Voltage    float64 `json:"voltage" pg:",use_zero"`
+	IncidentID int64   `json:"incident_id,omitempty"`
+
+	RaisedAt  *time.Time `json:"raised_at,omitempty"`
+	ClearedAt *time.Time `json:"cleared_at,omitempty"`
+}
+
+// Open reports whether the alert is not cleared yet.
+func (a *FenceAlert) Open() bool {
+	return a.ClearedAt == nil
+}
+
+// TelemetryResource is the result of ingesting a batch of readings with the
+// alerts it raised or cleared.
+type TelemetryResource struct {
+	Accepted int           `json:"accepted"`
+	Alerts   []*FenceAlert `json:"alerts"`
+}
+
+type ReadingBucketsResource struct {
+	Buckets []*ReadingBucket `json:"buckets"`
+}
+
+type FenceAlertsResource struct {
+	Alerts []*FenceAlert `json:"alerts"`
+}
@@ -0,0 +1,33 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// Transfer records a dinosaur moving from one cage to another.
+type Transfer struct {
+	ID         int64  `json:"id,omitempty" pg:",pk"`
+	DinosaurID ID     `json:"dinosaur_id,omitempty"`
+	FromCageID ID     `json:"from_cage_id,omitempty"`
+	ToCageID   ID     `json:"to_cage_id,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type TransfersResource struct {
+	Transfers []*Transfer `json:"transfers"`
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// Webhook delivery statuses. A delivery is pending until the subscriber
+// acknowledges it, or dead once every attempt failed. Dead deliveries can be
+// replayed.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// ValidDeliveryStatus reports whether s is a known delivery status.
+func ValidDeliveryStatus(s string) bool {
+	switch s {
+	case DeliveryPending, DeliveryDelivered, DeliveryDead:
+		return true
+	default:
+		return false
+	}
+}
+
+// Webhook subscribes a URL to the events of the outbox, or only to those of
+// the listed types. Payloads are signed with the secret of the webhook.
+type Webhook struct {
+	ID     int64    `json:"id,omitempty" pg:",pk"`
+	URL    string   `json:"url,omitempty"`
+	Types  []string `json:"types,omitempty" pg:",array"`
+	Active bool     `json:"active" pg:",use_zero"`
+	Actor  string   `json:"actor,omitempty"`
+
+	// Secret is only returned when a webhook is created.
+	Secret string `json:"secret,omitempty"`
+
+	// LastEventID is the last event of the outbox considered for delivery.
+	LastEventID int64 `json:"-" pg:",use_zero"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Wants reports whether a webhook subscribes to events of a type. Webhooks
+// without types subscribe to every event.
+func (w *Webhook) Wants(eventType string) bool {
+	if len(w.Types) == 0 {
+		return true
+	}
+
+	for _, t := range w.Types {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery is an event to deliver to a webhook.
+type WebhookDelivery struct {
+	ID            int64      `json:"id,omitempty" pg:",pk"`
+	WebhookID     int64      `json:"webhook_id,omitempty"`
+	EventID       int64      `json:"event_id,omitempty"`
+	EventType     string     `json:"event_type,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	Attempts      int        `json:"attempts" pg:",use_zero"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+
+	// Webhook and Event are loaded when a delivery is claimed for sending.
+	Webhook *Webhook `json:"-" pg:"-"`
+	Event   *Event   `json:"-" pg:"-"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// WebhookAttempt records one attempt to send a delivery.
+type WebhookAttempt struct {
+	ID         int64  `json:"id,omitempty" pg:",pk"`
+	WebhookID  int64  `json:"webhook_id,omitempty"`
+	DeliveryID int64  `json:"delivery_id,omitempty"`
+	EventID    int64  `json:"event_id,omitempty"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms" pg:",use_zero"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type WebhooksResource struct {
+	Webhooks []*Webhook `json:"webhooks"`
+}
+
+type WebhookDeliveriesResource struct {
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+}
+
+type WebhookAttemptsResource struct {
+	Attempts []*WebhookAttempt `json:"attempts"`
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+const prefixZone = "zn"
+
+// Zone groups cages, for instance a sector of the park. A zone may forbid
+// diet kinds, which then cannot move into its empty cages.
+type Zone struct {
+	ID             ID       `json:"id,omitempty" pg:",pk"`
+	Name           string   `json:"name,omitempty"`
+	ForbiddenKinds []string `json:"forbidden_kinds,omitempty" pg:",array"`
+
+	// Cages, Capacity and Allocation aggregate the live cages of the zone,
+	// and Power counts them by power status.
+	Cages      int                 `json:"cages" pg:"-"`
+	Capacity   int                 `json:"capacity" pg:"-"`
+	Allocation int                 `json:"allocation" pg:"-"`
+	Power      map[PowerStatus]int `json:"power,omitempty" pg:"-"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Forbids reports whether the zone forbids a diet kind.
+func (z *Zone) Forbids(kind string) bool {
+	for _, k := range z.ForbiddenKinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+func NewZoneID(uuid string) ID {
+	return NewID(prefixZone, uuid)
+}
+
+type ZonesResource struct {
+	Zones []*Zone `json:"zones"`
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// CheckCircuit verifies that a circuit is well formed.
+func CheckCircuit(circuit *model.Circuit) error {
+	const op errors.Op = "park.CheckCircuit"
+
+	if strings.TrimSpace(circuit.Name) == "" {
+		return errors.E(op, errors.KindBadRequest, "circuit name is required")
+	}
+
+	return nil
+}
+
+// CheckCircuitLive verifies that a circuit is not cut before a cage joins
+// it, leaves it or changes power state on it. The fences of a cut circuit
+// stay DOWN until it is restored.
+func CheckCircuitLive(circuit *model.Circuit) error {
+	const op errors.Op = "park.CheckCircuitLive"
+
+	if circuit.Cut() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("circuit %s is cut", circuit.ID))
+	}
+
+	return nil
+}
+
+// CheckCircuitCut verifies that a circuit can be cut.
+func CheckCircuitCut(circuit *model.Circuit) error {
+	const op errors.Op = "park.CheckCircuitCut"
+
+	if circuit.Cut() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("circuit %s is already cut", circuit.ID))
+	}
+
+	return nil
+}
+
+// CheckCircuitRestore verifies that a circuit can be restored.
+func CheckCircuitRestore(circuit *model.Circuit) error {
+	const op errors.Op = "park.CheckCircuitRestore"
+
+	if !circuit.Cut() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("circuit %s is not cut", circuit.ID))
+	}
+
+	return nil
+}
+
+// CheckCircuitDelete verifies that a circuit feeding cages, archived or
+// not, can be deleted. Cut circuits have to be restored first.
+func CheckCircuitDelete(circuit *model.Circuit, cages int) error {
+	const op errors.Op = "park.CheckCircuitDelete"
+
+	if circuit.Cut() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"circuit %s is cut and cannot be deleted", circuit.ID))
+	}
+
+	if cages > 0 {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"circuit %s feeds %d cages and cannot be deleted", circuit.ID, cages))
+	}
+
+	return nil
+}
+
+// CircuitReason returns the reason recorded for the power changes of a cut
+// or restored circuit unless one is given.
+func CircuitReason(circuit *model.Circuit, reason string) string {
+	if reason != "" {
+		return reason
+	}
+
+	if circuit.Cut() {
+		return fmt.Sprintf("circuit %s was cut", circuit.ID)
+	}
+
+	return fmt.Sprintf("circuit %s was restored", circuit.ID)
+}
+
+// CircuitImpact returns what cutting a circuit would do to its cages, which
+// must carry their occupants. Archived cages and cages already DOWN are
+// left out.
+func CircuitImpact(circuit *model.Circuit, cages []*model.Cage) *model.CircuitImpact {
+	impact := &model.CircuitImpact{
+		CircuitID: circuit.ID,
+		Cages:     []*model.Cage{},
+		Dinosaurs: []*model.Dinosaur{},
+	}
+
+	for _, cage := range cages {
+		if cage.CircuitID != circuit.ID || cage.Archived() || cage.Status == model.PowerDown {
+			continue
+		}
+
+		c := *cage
+		c.Dinosaurs = nil
+		impact.Cages = append(impact.Cages, &c)
+		impact.Dinosaurs = append(impact.Dinosaurs, cage.Dinosaurs...)
+		if cage.Allocation > 0 {
+			impact.Alarms++
+		}
+	}
+
+	return impact
+}
+
+// OutageAlarm returns the incident raised for an occupied cage put DOWN by
+// the cut of its circuit.
+func OutageAlarm(circuit *model.Circuit, cage *model.Cage, reason string) *model.Incident {
+	incident := &model.Incident{
+		Kind:        model.IncidentPowerFailure,
+		Title:       fmt.Sprintf("Cage %s is DOWN after circuit %s was cut", cage.ID, circuit.ID),
+		Description: reason,
+		CageIDs:     []model.ID{cage.ID},
+	}
+
+	incident.Severity, incident.DinosaurIDs = alarmSeverity(cage.Dinosaurs)
+	return incident
+}
+
+// alarmSeverity returns the severity of a power alarm on a cage holding
+// dinosaurs, and their IDs. Carnivores behind a failing fence make it
+// critical, which puts a transfer hold on the cage.
+func alarmSeverity(dinosaurs []*model.Dinosaur) (string, []model.ID) {
+	severity := model.SeverityHigh
+	ids := make([]model.ID, 0, len(dinosaurs))
+	for _, dinosaur := range dinosaurs {
+		ids = append(ids, dinosaur.ID)
+		if model.SpeciesKind(dinosaur.Species) == model.KindCarnivore {
+			severity = model.SeverityCritical
+		}
+	}
+
+	return severity, ids
+}
+
+// SummarizeCircuit counts the live cages of a circuit.
+func SummarizeCircuit(circuit *model.Circuit, cages []*model.Cage) {
+	circuit.Cages = 0
+	for _, cage := range cages {
+		if cage.CircuitID == circuit.ID && !cage.Archived() {
+			circuit.Cages++
+		}
+	}
+}
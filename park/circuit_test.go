@@ -0,0 +1,101 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCircuit(t *testing.T) {
+	live := &model.Circuit{ID: "ct_1", Name: "Grid A", Status: model.CircuitLive}
+	cut := &model.Circuit{ID: "ct_2", Name: "Grid B", Status: model.CircuitCut}
+
+	assert.NoError(t, CheckCircuit(live))
+	assert.True(t, errors.Is(CheckCircuit(&model.Circuit{Name: " "}), errors.KindBadRequest))
+
+	assert.NoError(t, CheckCircuitLive(live))
+	assert.NoError(t, CheckCircuitLive(&model.Circuit{}))
+	assert.True(t, errors.IsUnprocessableErr(CheckCircuitLive(cut)))
+
+	assert.NoError(t, CheckCircuitCut(live))
+	assert.True(t, errors.IsUnprocessableErr(CheckCircuitCut(cut)))
+
+	assert.NoError(t, CheckCircuitRestore(cut))
+	assert.True(t, errors.IsUnprocessableErr(CheckCircuitRestore(live)))
+
+	assert.NoError(t, CheckCircuitDelete(live, 0))
+	assert.True(t, errors.IsUnprocessableErr(CheckCircuitDelete(live, 1)))
+	assert.True(t, errors.IsUnprocessableErr(CheckCircuitDelete(cut, 0)))
+
+	assert.Equal(t, "circuit ct_2 was cut", CircuitReason(cut, ""))
+	assert.Equal(t, "circuit ct_1 was restored", CircuitReason(live, ""))
+	assert.Equal(t, "storm", CircuitReason(cut, "storm"))
+}
+
+func TestCircuitImpact(t *testing.T) {
+	now := time.Now()
+	circuit := &model.Circuit{ID: "ct_1", Status: model.CircuitLive}
+	rex := &model.Dinosaur{ID: "din_1", Species: model.Tyrannosaurus}
+	cera := &model.Dinosaur{ID: "din_2", Species: model.Triceratops}
+
+	impact := CircuitImpact(circuit, []*model.Cage{
+		{ID: "cg_1", CircuitID: "ct_1", Status: model.PowerActive, Allocation: 1, Dinosaurs: []*model.Dinosaur{rex}},
+		{ID: "cg_2", CircuitID: "ct_1", Status: model.PowerActive, Allocation: 1, Dinosaurs: []*model.Dinosaur{cera}},
+		{ID: "cg_3", CircuitID: "ct_1", Status: model.PowerMaintenance},
+		{ID: "cg_4", CircuitID: "ct_1", Status: model.PowerDown},
+		{ID: "cg_5", CircuitID: "ct_1", Status: model.PowerActive, DeletedAt: &now},
+		{ID: "cg_6", CircuitID: "ct_2", Status: model.PowerActive},
+	})
+
+	assert.Equal(t, model.ID("ct_1"), impact.CircuitID)
+	if assert.Len(t, impact.Cages, 3) {
+		assert.Equal(t, model.ID("cg_1"), impact.Cages[0].ID)
+		assert.Nil(t, impact.Cages[0].Dinosaurs)
+		assert.Equal(t, model.ID("cg_3"), impact.Cages[2].ID)
+	}
+
+	assert.Equal(t, []*model.Dinosaur{rex, cera}, impact.Dinosaurs)
+	assert.Equal(t, 2, impact.Alarms)
+
+	SummarizeCircuit(circuit, []*model.Cage{
+		{ID: "cg_1", CircuitID: "ct_1"},
+		{ID: "cg_2", CircuitID: "ct_1", DeletedAt: &now},
+		{ID: "cg_3", CircuitID: "ct_2"},
+	})
+	assert.Equal(t, 1, circuit.Cages)
+}
+
+func TestOutageAlarm(t *testing.T) {
+	circuit := &model.Circuit{ID: "ct_1", Status: model.CircuitCut}
+	herbivores := &model.Cage{ID: "cg_1", Dinosaurs: []*model.Dinosaur{{ID: "din_1", Species: model.Triceratops}}}
+	carnivores := &model.Cage{ID: "cg_2", Dinosaurs: []*model.Dinosaur{{ID: "din_2", Species: model.Velociraptor}}}
+
+	incident := OutageAlarm(circuit, herbivores, "storm")
+	assert.NoError(t, CheckIncident(incident))
+	assert.Equal(t, model.IncidentPowerFailure, incident.Kind)
+	assert.Equal(t, model.SeverityHigh, incident.Severity)
+	assert.Equal(t, "storm", incident.Description)
+	assert.Equal(t, []model.ID{"cg_1"}, incident.CageIDs)
+	assert.Equal(t, []model.ID{"din_1"}, incident.DinosaurIDs)
+
+	incident = OutageAlarm(circuit, carnivores, "storm")
+	assert.Equal(t, model.SeverityCritical, incident.Severity)
+	assert.True(t, incident.Holds())
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// DefaultFeedingSchedule derives the feeding schedule of a cage from the diet
+// kind of its occupants. Cages are never shared across kinds, so the first
+// occupant decides. An empty cage has no schedule.
+func DefaultFeedingSchedule(cage *model.Cage, occupants []*model.Dinosaur) *model.FeedingSchedule {
+	if len(occupants) == 0 {
+		return nil
+	}
+
+	return model.DefaultFeedingSchedule(cage.ID, model.SpeciesKind(occupants[0].Species))
+}
+
+// CheckFeedingSchedule verifies that a schedule can be kept.
+func CheckFeedingSchedule(schedule *model.FeedingSchedule) error {
+	const op errors.Op = "park.CheckFeedingSchedule"
+
+	if schedule.IntervalHours <= 0 {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid feeding interval: %d hours", schedule.IntervalHours))
+	}
+
+	if schedule.RationKg < 0 {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid ration: %g kg", schedule.RationKg))
+	}
+
+	return nil
+}
+
+// CheckFeeding verifies a feeding reported by a keeper at now.
+func CheckFeeding(feeding *model.Feeding, now time.Time) error {
+	const op errors.Op = "park.CheckFeeding"
+
+	if feeding.AmountKg < 0 {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid amount: %g kg", feeding.AmountKg))
+	}
+
+	if feeding.FedAt != nil && feeding.FedAt.After(now) {
+		return errors.E(op, errors.KindBadRequest, "feeding time is in the future")
+	}
+
+	return nil
+}
+
+// CheckOverdue returns the occupants of a cage not fed on schedule at now,
+// or nil if none is. Occupants must carry their last feeding time; those
+// never fed are due one interval after they were created.
+func CheckOverdue(cage *model.Cage, occupants []*model.Dinosaur, schedule *model.FeedingSchedule, now time.Time) *model.OverdueFeeding {
+	var overdue *model.OverdueFeeding
+	for _, d := range occupants {
+		since := d.LastFedAt
+		if since == nil {
+			since = d.CreatedAt
+		}
+
+		if since == nil {
+			continue
+		}
+
+		due := since.Add(schedule.Interval())
+		if !due.Before(now) {
+			continue
+		}
+
+		if overdue == nil {
+			overdue = &model.OverdueFeeding{CageID: cage.ID, Schedule: schedule, DueAt: due, LastFedAt: d.LastFedAt}
+		} else if due.Before(overdue.DueAt) {
+			overdue.DueAt = due
+			overdue.LastFedAt = d.LastFedAt
+		}
+
+		overdue.Dinosaurs = append(overdue.Dinosaurs, d.ID)
+	}
+
+	return overdue
+}
+
+// FeedingTargets returns the occupants of a cage fed by a feeding: those in
+// dinosaurIDs, or all of them if it is empty.
+func FeedingTargets(cage *model.Cage, occupants []*model.Dinosaur, dinosaurIDs []model.ID) ([]*model.Dinosaur, error) {
+	const op errors.Op = "park.FeedingTargets"
+
+	if len(occupants) == 0 {
+		return nil, errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s holds no dinosaurs", cage.ID))
+	}
+
+	if len(dinosaurIDs) == 0 {
+		return occupants, nil
+	}
+
+	byID := make(map[model.ID]*model.Dinosaur, len(occupants))
+	for _, d := range occupants {
+		byID[d.ID] = d
+	}
+
+	targets := make([]*model.Dinosaur, 0, len(dinosaurIDs))
+	for _, id := range dinosaurIDs {
+		d, ok := byID[id]
+		if !ok {
+			return nil, errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is not in cage %s", id, cage.ID))
+		}
+
+		delete(byID, id)
+		targets = append(targets, d)
+	}
+
+	return targets, nil
+}
+
+// FillFeeding defaults the food and amount of a feeding to those of the
+// schedule and its time to now.
+func FillFeeding(feeding *model.Feeding, schedule *model.FeedingSchedule, now time.Time) {
+	if feeding.Food == "" {
+		feeding.Food = schedule.Food
+	}
+
+	if feeding.AmountKg == 0 {
+		feeding.AmountKg = schedule.RationKg
+	}
+
+	fedAt := now
+	if feeding.FedAt != nil {
+		fedAt = feeding.FedAt.UTC()
+	}
+
+	feeding.FedAt = &fedAt
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultFeedingSchedule(t *testing.T) {
+	rex := &model.Dinosaur{ID: "din_1", Species: model.Tyrannosaurus}
+	trike := &model.Dinosaur{ID: "din_2", Species: model.Triceratops}
+
+	assert.Nil(t, DefaultFeedingSchedule(newCage("cg_1", 2, model.PowerActive), nil))
+
+	schedule := DefaultFeedingSchedule(newCage("cg_1", 2, model.PowerActive, rex), []*model.Dinosaur{rex})
+	assert.Equal(t, model.CarnivoreFeedingInterval, schedule.Interval())
+	assert.Equal(t, model.CarnivoreRationKg, schedule.RationKg)
+	assert.True(t, schedule.Default)
+
+	schedule = DefaultFeedingSchedule(newCage("cg_2", 2, model.PowerActive, trike), []*model.Dinosaur{trike})
+	assert.Equal(t, model.HerbivoreFeedingInterval, schedule.Interval())
+	assert.NoError(t, CheckFeedingSchedule(schedule))
+
+	assert.True(t, errors.Is(CheckFeedingSchedule(&model.FeedingSchedule{IntervalHours: 0}), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckFeedingSchedule(&model.FeedingSchedule{IntervalHours: 1, RationKg: -1}), errors.KindBadRequest))
+}
+
+func TestCheckOverdue(t *testing.T) {
+	now := time.Date(2023, 6, 11, 12, 0, 0, 0, time.UTC)
+	fed := now.Add(-30 * time.Hour)
+	created := now.Add(-26 * time.Hour)
+	recent := now.Add(-time.Hour)
+
+	d1 := &model.Dinosaur{ID: "din_1", Species: model.Tyrannosaurus, LastFedAt: &fed}
+	d2 := &model.Dinosaur{ID: "din_2", Species: model.Tyrannosaurus, CreatedAt: &created}
+	d3 := &model.Dinosaur{ID: "din_3", Species: model.Tyrannosaurus, LastFedAt: &recent}
+	cage := newCage("cg_1", 4, model.PowerActive, d1, d2, d3)
+	schedule := DefaultFeedingSchedule(cage, cage.Dinosaurs)
+
+	overdue := CheckOverdue(cage, cage.Dinosaurs, schedule, now)
+	if assert.NotNil(t, overdue) {
+		assert.Equal(t, []model.ID{"din_1", "din_2"}, overdue.Dinosaurs)
+		assert.Equal(t, &fed, overdue.LastFedAt)
+		assert.Equal(t, fed.Add(model.CarnivoreFeedingInterval), overdue.DueAt)
+	}
+
+	assert.Nil(t, CheckOverdue(cage, []*model.Dinosaur{d3}, schedule, now))
+}
+
+func TestFeedingTargets(t *testing.T) {
+	d1 := &model.Dinosaur{ID: "din_1", Species: model.Triceratops}
+	d2 := &model.Dinosaur{ID: "din_2", Species: model.Triceratops}
+	cage := newCage("cg_1", 4, model.PowerActive, d1, d2)
+
+	targets, err := FeedingTargets(cage, cage.Dinosaurs, nil)
+	assert.NoError(t, err)
+	assert.Len(t, targets, 2)
+
+	targets, err = FeedingTargets(cage, cage.Dinosaurs, []model.ID{"din_2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Dinosaur{d2}, targets)
+
+	_, err = FeedingTargets(cage, cage.Dinosaurs, []model.ID{"din_2", "din_2"})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	_, err = FeedingTargets(newCage("cg_2", 4, model.PowerActive), nil, nil)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	future := time.Now().Add(time.Hour)
+	assert.True(t, errors.Is(CheckFeeding(&model.Feeding{FedAt: &future}, time.Now()), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckFeeding(&model.Feeding{AmountKg: -1}, time.Now()), errors.KindBadRequest))
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// incidentErrorKinds maps incident kinds onto the errors of the operations
+// they block. Cages with a breach, an escape or a power failure are locked
+// down, while other incidents make requests unprocessable.
+var incidentErrorKinds = map[string]int{
+	model.IncidentBreach:       errors.KindLocked,
+	model.IncidentEscape:       errors.KindLocked,
+	model.IncidentPowerFailure: errors.KindLocked,
+	model.IncidentInjury:       errors.KindUnprocessable,
+	model.IncidentOther:        errors.KindUnprocessable,
+}
+
+// IncidentErrorKind returns the error kind of operations blocked by an
+// incident of a kind.
+func IncidentErrorKind(kind string) int {
+	if k, ok := incidentErrorKinds[kind]; ok {
+		return k
+	}
+
+	return errors.KindUnprocessable
+}
+
+// incidentTransitions lists the statuses each incident status can move to.
+var incidentTransitions = map[string][]string{
+	model.IncidentOpen:         {model.IncidentAcknowledged, model.IncidentMitigated, model.IncidentClosed},
+	model.IncidentAcknowledged: {model.IncidentMitigated, model.IncidentClosed},
+	model.IncidentMitigated:    {model.IncidentClosed},
+}
+
+// CheckIncident verifies that an incident is well formed.
+func CheckIncident(incident *model.Incident) error {
+	const op errors.Op = "park.CheckIncident"
+
+	if !model.ValidIncidentKind(incident.Kind) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid incident kind: %q", incident.Kind))
+	}
+
+	if !model.ValidSeverity(incident.Severity) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid severity: %q", incident.Severity))
+	}
+
+	if incident.Title == "" {
+		return errors.E(op, errors.KindBadRequest, "incident title is required")
+	}
+
+	for _, ids := range [][]model.ID{incident.CageIDs, incident.DinosaurIDs} {
+		seen := make(map[model.ID]bool, len(ids))
+		for _, id := range ids {
+			if seen[id] {
+				return errors.E(op, errors.KindBadRequest, fmt.Sprintf("%s is linked twice", id))
+			}
+
+			seen[id] = true
+		}
+	}
+
+	return nil
+}
+
+// CheckIncidentNote verifies that a note can be added to an incident.
+func CheckIncidentNote(note *model.IncidentNote) error {
+	const op errors.Op = "park.CheckIncidentNote"
+
+	if note.Text == "" {
+		return errors.E(op, errors.KindBadRequest, "note text is required")
+	}
+
+	return nil
+}
+
+// CheckIncidentTransition verifies that an incident can move to a status.
+// Closed incidents are final.
+func CheckIncidentTransition(incident *model.Incident, to string) error {
+	const op errors.Op = "park.CheckIncidentTransition"
+
+	if !model.ValidIncidentStatus(to) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid incident status: %q", to))
+	}
+
+	for _, status := range incidentTransitions[incident.Status] {
+		if status == to {
+			return nil
+		}
+	}
+
+	return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+		"incident %d cannot go from %s to %s", incident.ID, incident.Status, to))
+}
+
+// StampIncident records that an incident reached its status at now.
+func StampIncident(incident *model.Incident, now time.Time) {
+	switch incident.Status {
+	case model.IncidentAcknowledged:
+		incident.AcknowledgedAt = &now
+	case model.IncidentMitigated:
+		incident.MitigatedAt = &now
+	case model.IncidentClosed:
+		incident.ClosedAt = &now
+	}
+}
+
+// CheckIncidentHolds verifies that none of incidents puts a transfer hold on
+// the given cages. A blocked transfer fails with the error kind of the
+// incident holding the cage.
+func CheckIncidentHolds(cageIDs []model.ID, incidents []*model.Incident) error {
+	const op errors.Op = "park.CheckIncidentHolds"
+
+	for _, incident := range incidents {
+		if !incident.Holds() {
+			continue
+		}
+
+		for _, held := range incident.CageIDs {
+			for _, id := range cageIDs {
+				if id == held {
+					return errors.E(op, IncidentErrorKind(incident.Kind), fmt.Sprintf(
+						"cage %s is on hold for %s incident %d", id, incident.Kind, incident.ID))
+				}
+			}
+		}
+	}
+
+	return nil
+}
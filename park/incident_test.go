@@ -0,0 +1,96 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIncident(t *testing.T) {
+	valid := func() *model.Incident {
+		return &model.Incident{
+			Kind:     model.IncidentBreach,
+			Severity: model.SeverityCritical,
+			Title:    "Fence down in paddock 9",
+			CageIDs:  []model.ID{"cag_1", "cag_2"},
+		}
+	}
+
+	assert.NoError(t, CheckIncident(valid()))
+
+	incident := valid()
+	incident.Kind = "stampede"
+	assert.True(t, errors.Is(CheckIncident(incident), errors.KindBadRequest))
+
+	incident = valid()
+	incident.Severity = "dire"
+	assert.True(t, errors.Is(CheckIncident(incident), errors.KindBadRequest))
+
+	incident = valid()
+	incident.Title = ""
+	assert.True(t, errors.Is(CheckIncident(incident), errors.KindBadRequest))
+
+	incident = valid()
+	incident.CageIDs = []model.ID{"cag_1", "cag_1"}
+	assert.True(t, errors.Is(CheckIncident(incident), errors.KindBadRequest))
+
+	assert.True(t, errors.Is(CheckIncidentNote(&model.IncidentNote{}), errors.KindBadRequest))
+}
+
+func TestCheckIncidentTransition(t *testing.T) {
+	open := &model.Incident{ID: 1, Status: model.IncidentOpen}
+	mitigated := &model.Incident{ID: 1, Status: model.IncidentMitigated}
+	closed := &model.Incident{ID: 1, Status: model.IncidentClosed}
+
+	assert.NoError(t, CheckIncidentTransition(open, model.IncidentAcknowledged))
+	assert.NoError(t, CheckIncidentTransition(open, model.IncidentClosed))
+	assert.NoError(t, CheckIncidentTransition(mitigated, model.IncidentClosed))
+	assert.True(t, errors.IsUnprocessableErr(CheckIncidentTransition(mitigated, model.IncidentAcknowledged)))
+	assert.True(t, errors.IsUnprocessableErr(CheckIncidentTransition(closed, model.IncidentOpen)))
+	assert.True(t, errors.Is(CheckIncidentTransition(open, "resolved"), errors.KindBadRequest))
+}
+
+func TestStampIncident(t *testing.T) {
+	now := time.Now()
+	incident := &model.Incident{Status: model.IncidentMitigated}
+	StampIncident(incident, now)
+	assert.Equal(t, now, *incident.MitigatedAt)
+	assert.Nil(t, incident.ClosedAt)
+}
+
+func TestCheckIncidentHolds(t *testing.T) {
+	incidents := []*model.Incident{
+		{ID: 1, Kind: model.IncidentBreach, Severity: model.SeverityHigh, Status: model.IncidentOpen, CageIDs: []model.ID{"cag_1"}},
+		{ID: 2, Kind: model.IncidentInjury, Severity: model.SeverityCritical, Status: model.IncidentClosed, CageIDs: []model.ID{"cag_1"}},
+		{ID: 3, Kind: model.IncidentBreach, Severity: model.SeverityCritical, Status: model.IncidentMitigated, CageIDs: []model.ID{"cag_2"}},
+		{ID: 4, Kind: model.IncidentInjury, Severity: model.SeverityCritical, Status: model.IncidentAcknowledged, CageIDs: []model.ID{"cag_3"}},
+	}
+
+	assert.NoError(t, CheckIncidentHolds([]model.ID{"cag_1", "cag_4"}, incidents))
+	assert.True(t, errors.IsLockedErr(CheckIncidentHolds([]model.ID{"cag_1", "cag_2"}, incidents)))
+	assert.True(t, errors.IsUnprocessableErr(CheckIncidentHolds([]model.ID{"cag_3"}, incidents)))
+}
+
+func TestIncidentErrorKind(t *testing.T) {
+	assert.Equal(t, errors.KindLocked, IncidentErrorKind(model.IncidentEscape))
+	assert.Equal(t, errors.KindLocked, IncidentErrorKind(model.IncidentPowerFailure))
+	assert.Equal(t, errors.KindUnprocessable, IncidentErrorKind(model.IncidentInjury))
+	assert.Equal(t, errors.KindUnprocessable, IncidentErrorKind("stampede"))
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+const (
+	// MaintenanceLeadTime is the least notice given for the maintenance of
+	// an occupied cage, so that its occupants can be moved out in time.
+	MaintenanceLeadTime = 24 * time.Hour
+
+	// MaintenanceWarningHorizon is how far ahead occupied cages with
+	// upcoming maintenance are warned about.
+	MaintenanceWarningHorizon = 72 * time.Hour
+)
+
+// CheckMaintenanceWindow verifies that a window can be scheduled at now for
+// a cage with its occupancy, given the other windows of the cage. A cage
+// still occupied when the window starts cannot go into maintenance, so a
+// window is rejected unless it leaves MaintenanceLeadTime to evacuate.
+func CheckMaintenanceWindow(window *model.MaintenanceWindow, cage *model.Cage, windows []*model.MaintenanceWindow, now time.Time) error {
+	const op errors.Op = "park.CheckMaintenanceWindow"
+
+	if window.StartsAt.IsZero() || !window.EndsAt.After(window.StartsAt) {
+		return errors.E(op, errors.KindBadRequest, "maintenance window must end after it starts")
+	}
+
+	if !window.EndsAt.After(now) {
+		return errors.E(op, errors.KindBadRequest, "maintenance window ends in the past")
+	}
+
+	if cage.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is archived", cage.ID))
+	}
+
+	for _, other := range windows {
+		if other.Status != model.MaintenanceScheduled && other.Status != model.MaintenanceInProgress {
+			continue
+		}
+
+		if window.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(window.EndsAt) {
+			return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+				"maintenance window overlaps window %d of cage %s", other.ID, cage.ID))
+		}
+	}
+
+	if cage.Allocation > 0 && window.StartsAt.Before(now.Add(MaintenanceLeadTime)) {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"cage %s holds %d dinosaurs and would still be occupied when maintenance starts at %s",
+			cage.ID, cage.Allocation, window.StartsAt.Format(time.RFC3339)))
+	}
+
+	return nil
+}
+
+// MaintenanceWarning returns a warning if a scheduled window starts within
+// MaintenanceWarningHorizon of now while its cage is occupied, or nil.
+func MaintenanceWarning(window *model.MaintenanceWindow, cage *model.Cage, now time.Time) *model.MaintenanceWarning {
+	if window.Status != model.MaintenanceScheduled || cage.Allocation == 0 ||
+		window.StartsAt.After(now.Add(MaintenanceWarningHorizon)) {
+		return nil
+	}
+
+	return &model.MaintenanceWarning{
+		WindowID:   window.ID,
+		CageID:     cage.ID,
+		StartsAt:   window.StartsAt,
+		Allocation: cage.Allocation,
+		Message: fmt.Sprintf("cage %s holds %d dinosaurs to move out before maintenance starts at %s",
+			cage.ID, cage.Allocation, window.StartsAt.Format(time.RFC3339)),
+	}
+}
+
+// maintenanceTransitions lists the statuses each window status can move to.
+var maintenanceTransitions = map[string][]string{
+	model.MaintenanceScheduled:  {model.MaintenanceInProgress, model.MaintenanceCancelled, model.MaintenanceFailed},
+	model.MaintenanceInProgress: {model.MaintenanceCompleted, model.MaintenanceFailed},
+}
+
+// CheckMaintenanceTransition verifies that a window can move between two
+// statuses. Completed, cancelled and failed windows are final.
+func CheckMaintenanceTransition(window *model.MaintenanceWindow, to string) error {
+	const op errors.Op = "park.CheckMaintenanceTransition"
+
+	if !model.ValidMaintenanceStatus(to) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid maintenance status: %q", to))
+	}
+
+	for _, status := range maintenanceTransitions[window.Status] {
+		if status == to {
+			return nil
+		}
+	}
+
+	return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+		"maintenance window %d cannot go from %s to %s", window.ID, window.Status, to))
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMaintenanceWindow(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	window := func(start, end time.Duration) *model.MaintenanceWindow {
+		return &model.MaintenanceWindow{CageID: "cag_1", StartsAt: now.Add(start), EndsAt: now.Add(end)}
+	}
+
+	empty := &model.Cage{ID: "cag_1"}
+	occupied := &model.Cage{ID: "cag_1", Allocation: 2}
+	archived := &model.Cage{ID: "cag_1", DeletedAt: &now}
+	scheduled := []*model.MaintenanceWindow{
+		{ID: 1, CageID: "cag_1", StartsAt: now.Add(48 * time.Hour), EndsAt: now.Add(50 * time.Hour), Status: model.MaintenanceScheduled},
+		{ID: 2, CageID: "cag_1", StartsAt: now.Add(60 * time.Hour), EndsAt: now.Add(62 * time.Hour), Status: model.MaintenanceCancelled},
+	}
+
+	assert.NoError(t, CheckMaintenanceWindow(window(time.Hour, 2*time.Hour), empty, nil, now))
+	assert.NoError(t, CheckMaintenanceWindow(window(-time.Hour, time.Hour), empty, nil, now))
+	assert.NoError(t, CheckMaintenanceWindow(window(25*time.Hour, 26*time.Hour), occupied, nil, now))
+	assert.NoError(t, CheckMaintenanceWindow(window(50*time.Hour, 52*time.Hour), empty, scheduled, now))
+	assert.NoError(t, CheckMaintenanceWindow(window(61*time.Hour, 63*time.Hour), empty, scheduled, now))
+
+	assert.True(t, errors.Is(CheckMaintenanceWindow(window(2*time.Hour, time.Hour), empty, nil, now), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckMaintenanceWindow(window(-2*time.Hour, -time.Hour), empty, nil, now), errors.KindBadRequest))
+	assert.True(t, errors.IsUnprocessableErr(CheckMaintenanceWindow(window(time.Hour, 2*time.Hour), archived, nil, now)))
+	assert.True(t, errors.IsUnprocessableErr(CheckMaintenanceWindow(window(time.Hour, 2*time.Hour), occupied, nil, now)))
+	assert.True(t, errors.IsUnprocessableErr(CheckMaintenanceWindow(window(49*time.Hour, 51*time.Hour), empty, scheduled, now)))
+}
+
+func TestMaintenanceWarning(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	soon := &model.MaintenanceWindow{ID: 1, CageID: "cag_1", StartsAt: now.Add(48 * time.Hour), Status: model.MaintenanceScheduled}
+	later := &model.MaintenanceWindow{ID: 2, CageID: "cag_1", StartsAt: now.Add(96 * time.Hour), Status: model.MaintenanceScheduled}
+	cancelled := &model.MaintenanceWindow{ID: 3, CageID: "cag_1", StartsAt: now.Add(48 * time.Hour), Status: model.MaintenanceCancelled}
+
+	occupied := &model.Cage{ID: "cag_1", Allocation: 2}
+	warning := MaintenanceWarning(soon, occupied, now)
+	if assert.NotNil(t, warning) {
+		assert.Equal(t, int64(1), warning.WindowID)
+		assert.Equal(t, 2, warning.Allocation)
+	}
+
+	assert.Nil(t, MaintenanceWarning(soon, &model.Cage{ID: "cag_1"}, now))
+	assert.Nil(t, MaintenanceWarning(later, occupied, now))
+	assert.Nil(t, MaintenanceWarning(cancelled, occupied, now))
+}
+
+func TestCheckMaintenanceTransition(t *testing.T) {
+	scheduled := &model.MaintenanceWindow{ID: 1, Status: model.MaintenanceScheduled}
+	completed := &model.MaintenanceWindow{ID: 1, Status: model.MaintenanceCompleted}
+
+	assert.NoError(t, CheckMaintenanceTransition(scheduled, model.MaintenanceInProgress))
+	assert.NoError(t, CheckMaintenanceTransition(scheduled, model.MaintenanceCancelled))
+	assert.True(t, errors.IsUnprocessableErr(CheckMaintenanceTransition(scheduled, model.MaintenanceCompleted)))
+	assert.True(t, errors.IsUnprocessableErr(CheckMaintenanceTransition(completed, model.MaintenanceInProgress)))
+	assert.True(t, errors.Is(CheckMaintenanceTransition(scheduled, "paused"), errors.KindBadRequest))
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// Defaults of the growth-trend query: a weight change of more than
+// DefaultGrowthThreshold percent within DefaultGrowthWindow is abnormal.
+const (
+	DefaultGrowthWindow    = 30 * 24 * time.Hour
+	DefaultGrowthThreshold = 10.0
+)
+
+// CheckTransfer verifies that a dinosaur may leave its cage.
+func CheckTransfer(dinosaur *model.Dinosaur) error {
+	const op errors.Op = "park.CheckTransfer"
+
+	if dinosaur.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is archived", dinosaur.ID))
+	}
+
+	if dinosaur.MedicalHold {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is on medical hold", dinosaur.ID))
+	}
+
+	return nil
+}
+
+// CheckVetVisit verifies the records of a visit reported at now.
+func CheckVetVisit(visit *model.VetVisit, now time.Time) error {
+	const op errors.Op = "park.CheckVetVisit"
+
+	if visit.Vet == "" {
+		return errors.E(op, errors.KindBadRequest, "vet is required")
+	}
+
+	if visit.VisitedAt != nil && visit.VisitedAt.After(now) {
+		return errors.E(op, errors.KindBadRequest, "visit time is in the future")
+	}
+
+	for _, diagnosis := range visit.Diagnoses {
+		if diagnosis.Condition == "" {
+			return errors.E(op, errors.KindBadRequest, "diagnosis condition is required")
+		}
+	}
+
+	for _, treatment := range visit.Treatments {
+		if treatment.Name == "" {
+			return errors.E(op, errors.KindBadRequest, "treatment name is required")
+		}
+	}
+
+	return nil
+}
+
+// CheckBiometric verifies a measurement reported at now.
+func CheckBiometric(biometric *model.Biometric, now time.Time) error {
+	const op errors.Op = "park.CheckBiometric"
+
+	if !model.ValidMetric(biometric.Metric) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid metric: %q", biometric.Metric))
+	}
+
+	if math.IsNaN(biometric.Value) || math.IsInf(biometric.Value, 0) ||
+		biometric.Metric != model.MetricTemperature && biometric.Value <= 0 {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid %s: %g", biometric.Metric, biometric.Value))
+	}
+
+	if biometric.MeasuredAt != nil && biometric.MeasuredAt.After(now) {
+		return errors.E(op, errors.KindBadRequest, "measurement time is in the future")
+	}
+
+	return nil
+}
+
+// GrowthTrends computes the weight change of each dinosaur from its weight
+// samples. A change of more than threshold percent, up or down, is abnormal.
+// Dinosaurs with fewer than two samples have no trend.
+func GrowthTrends(samples []*model.Biometric, threshold float64) []*model.GrowthTrend {
+	byDinosaur := make(map[model.ID][]*model.Biometric)
+	for _, sample := range samples {
+		if sample.Metric == model.MetricWeight {
+			byDinosaur[sample.DinosaurID] = append(byDinosaur[sample.DinosaurID], sample)
+		}
+	}
+
+	trends := []*model.GrowthTrend{}
+	for id, weights := range byDinosaur {
+		if len(weights) < 2 {
+			continue
+		}
+
+		sort.SliceStable(weights, func(i, j int) bool { return weights[i].MeasuredAt.Before(*weights[j].MeasuredAt) })
+		first, last := weights[0], weights[len(weights)-1]
+		change := (last.Value - first.Value) / first.Value * 100
+
+		trends = append(trends, &model.GrowthTrend{
+			DinosaurID: id,
+			Samples:    len(weights),
+			From:       *first.MeasuredAt,
+			To:         *last.MeasuredAt,
+			FirstKg:    first.Value,
+			LastKg:     last.Value,
+			ChangePct:  math.Round(change*100) / 100,
+			Abnormal:   math.Abs(change) > threshold,
+		})
+	}
+
+	sort.Slice(trends, func(i, j int) bool { return trends[i].DinosaurID < trends[j].DinosaurID })
+	return trends
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTransfer(t *testing.T) {
+	now := time.Now()
+	assert.NoError(t, CheckTransfer(&model.Dinosaur{ID: "din_1"}))
+	assert.True(t, errors.IsUnprocessableErr(CheckTransfer(&model.Dinosaur{ID: "din_1", MedicalHold: true})))
+	assert.True(t, errors.IsUnprocessableErr(CheckTransfer(&model.Dinosaur{ID: "din_1", DeletedAt: &now})))
+}
+
+func TestCheckVetVisit(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	assert.NoError(t, CheckVetVisit(&model.VetVisit{Vet: "harding", Diagnoses: []*model.Diagnosis{{Condition: "sick"}}}, now))
+	assert.True(t, errors.Is(CheckVetVisit(&model.VetVisit{}, now), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckVetVisit(&model.VetVisit{Vet: "harding", VisitedAt: &later}, now), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckVetVisit(&model.VetVisit{Vet: "harding", Diagnoses: []*model.Diagnosis{{}}}, now), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckVetVisit(&model.VetVisit{Vet: "harding", Treatments: []*model.Treatment{{}}}, now), errors.KindBadRequest))
+}
+
+func TestCheckBiometric(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	assert.NoError(t, CheckBiometric(&model.Biometric{Metric: model.MetricWeight, Value: 7000}, now))
+	assert.NoError(t, CheckBiometric(&model.Biometric{Metric: model.MetricTemperature, Value: -1}, now))
+	assert.True(t, errors.Is(CheckBiometric(&model.Biometric{Metric: "height", Value: 1}, now), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckBiometric(&model.Biometric{Metric: model.MetricLength, Value: 0}, now), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckBiometric(&model.Biometric{Metric: model.MetricTemperature, Value: math.NaN()}, now), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckBiometric(&model.Biometric{Metric: model.MetricWeight, Value: 1, MeasuredAt: &later}, now), errors.KindBadRequest))
+}
+
+func TestGrowthTrends(t *testing.T) {
+	at := func(days int) *time.Time {
+		t := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, days)
+		return &t
+	}
+
+	samples := []*model.Biometric{
+		{DinosaurID: "din_1", Metric: model.MetricWeight, Value: 1100, MeasuredAt: at(10)},
+		{DinosaurID: "din_1", Metric: model.MetricWeight, Value: 1000, MeasuredAt: at(0)},
+		{DinosaurID: "din_2", Metric: model.MetricWeight, Value: 500, MeasuredAt: at(0)},
+		{DinosaurID: "din_2", Metric: model.MetricWeight, Value: 520, MeasuredAt: at(5)},
+		{DinosaurID: "din_2", Metric: model.MetricLength, Value: 3, MeasuredAt: at(6)},
+		{DinosaurID: "din_3", Metric: model.MetricWeight, Value: 800, MeasuredAt: at(0)},
+	}
+
+	trends := GrowthTrends(samples, DefaultGrowthThreshold)
+	if assert.Len(t, trends, 2) {
+		assert.Equal(t, model.ID("din_1"), trends[0].DinosaurID)
+		assert.Equal(t, 10.0, trends[0].ChangePct)
+		assert.False(t, trends[0].Abnormal)
+		assert.Equal(t, 1000.0, trends[0].FirstKg)
+		assert.Equal(t, *at(10), trends[0].To)
+
+		assert.Equal(t, 4.0, trends[1].ChangePct)
+		assert.Equal(t, 2, trends[1].Samples)
+	}
+
+	trends = GrowthTrends(samples, 5)
+	assert.True(t, trends[0].Abnormal)
+	assert.False(t, trends[1].Abnormal)
+}
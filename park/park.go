@@ -0,0 +1,161 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package park implements the containment rules of Jurassic Park. The rules
+// are pure functions over the model so that storage backends can evaluate
+// them inside the transaction that performs the write.
+package park
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// speciesName matches the names of species, which appear in URLs and filters.
+var speciesName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// CheckPlacement verifies that dinosaur can be placed in cage next to its
+// current occupants. The dinosaur itself is ignored if it is listed among the
+// occupants. The first occupant of a cage must be of a diet kind its zone
+// allows. Violations are reported as errors.KindUnprocessable.
+func CheckPlacement(cage *model.Cage, occupants []*model.Dinosaur, dinosaur *model.Dinosaur) error {
+	const op errors.Op = "park.CheckPlacement"
+
+	if cage.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is archived", cage.ID))
+	}
+
+	if cage.Status != model.PowerActive {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is %s", cage.ID, cage.Status))
+	}
+
+	allocation, same := 0, 0
+	for _, occupant := range occupants {
+		if occupant.ID == dinosaur.ID {
+			continue
+		}
+
+		allocation++
+		if !CanCohabit(dinosaur.Species, occupant.Species) {
+			return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+				"%s cannot share cage %s with %s", dinosaur.Species, cage.ID, occupant.Species))
+		}
+
+		if occupant.Species == dinosaur.Species {
+			same++
+		}
+	}
+
+	if kind := model.SpeciesKind(dinosaur.Species); allocation == 0 && forbids(cage.ForbiddenKinds, kind) {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"the zone of cage %s forbids %s dinosaurs", cage.ID, kind))
+	}
+
+	if allocation >= cage.MaxOccupancy() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"cage %s is full (%d/%d)", cage.ID, allocation, cage.MaxOccupancy()))
+	}
+
+	if entry, ok := model.LookupSpecies(dinosaur.Species); ok && entry.MaxPerCage > 0 && same >= entry.MaxPerCage {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"cage %s already holds %d %s, the most allowed", cage.ID, same, dinosaur.Species))
+	}
+
+	return nil
+}
+
+// CheckSpeciesEntry verifies that a catalog entry is well formed.
+func CheckSpeciesEntry(entry *model.SpeciesEntry) error {
+	const op errors.Op = "park.CheckSpeciesEntry"
+
+	if !speciesName.MatchString(string(entry.Name)) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid species name: %q", entry.Name))
+	}
+
+	if !model.ValidKind(entry.Kind) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid kind: %q", entry.Kind))
+	}
+
+	if entry.MaxPerCage < 0 {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid max per cage: %d", entry.MaxPerCage))
+	}
+
+	return nil
+}
+
+// CheckArchive verifies that a cage holding allocation dinosaurs can be
+// archived. Dinosaurs must be transferred out first.
+func CheckArchive(cage *model.Cage, allocation int) error {
+	const op errors.Op = "park.CheckArchive"
+
+	if allocation > 0 {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"cage %s holds %d dinosaurs and cannot be archived", cage.ID, allocation))
+	}
+
+	return nil
+}
+
+// CheckCapacity verifies that the capacity of cage is valid and can hold
+// the dinosaurs it already has.
+func CheckCapacity(cage *model.Cage, allocation int) error {
+	const op errors.Op = "park.CheckCapacity"
+
+	if cage.Capacity < 0 {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid capacity: %d", cage.Capacity))
+	}
+
+	if cage.MaxOccupancy() < allocation {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"capacity of cage %s cannot drop below its %d dinosaurs", cage.ID, allocation))
+	}
+
+	return nil
+}
+
+// CanCohabit reports whether two species can share a cage. Carnivores only
+// live with their own species while herbivores of any species get along.
+func CanCohabit(a, b model.Species) bool {
+	if a == b {
+		return true
+	}
+
+	return model.SpeciesKind(a) == model.KindHerbivores &&
+		model.SpeciesKind(b) == model.KindHerbivores
+}
+
+// CheckPowerTransition verifies that a cage holding allocation dinosaurs can
+// move between two power states. Only empty cages can leave the ACTIVE state.
+func CheckPowerTransition(cageID model.ID, from, to model.PowerStatus, allocation int) error {
+	const op errors.Op = "park.CheckPowerTransition"
+
+	if !to.Valid() {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid power status: %q", to))
+	}
+
+	if !from.CanTransitionTo(to) {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"cage %s cannot go from %s to %s", cageID, from, to))
+	}
+
+	if to != model.PowerActive && allocation > 0 {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"cage %s holds %d dinosaurs and cannot go %s", cageID, allocation, to))
+	}
+
+	return nil
+}
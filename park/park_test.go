@@ -0,0 +1,135 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanCohabit(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b model.Species
+		want bool
+	}{
+		{name: "same carnivore", a: model.Tyrannosaurus, b: model.Tyrannosaurus, want: true},
+		{name: "different carnivores", a: model.Tyrannosaurus, b: model.Velociraptor, want: false},
+		{name: "carnivore and herbivore", a: model.Velociraptor, b: model.Triceratops, want: false},
+		{name: "herbivore and carnivore", a: model.Triceratops, b: model.Velociraptor, want: false},
+		{name: "same herbivore", a: model.Stegosaurus, b: model.Stegosaurus, want: true},
+		{name: "different herbivores", a: model.Stegosaurus, b: model.Brachiosaurus, want: true},
+		{name: "unknown", a: model.Species("segnosaurus"), b: model.Brachiosaurus, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equalf(t, tt.want, CanCohabit(tt.a, tt.b), "CanCohabit(%v, %v)", tt.a, tt.b)
+		})
+	}
+}
+
+func TestCheckPlacement(t *testing.T) {
+	cage := &model.Cage{ID: "cg_1", Capacity: model.MaxCageCapacity, Status: model.PowerActive}
+	rex := &model.Dinosaur{ID: "din_1", Species: model.Tyrannosaurus}
+	trike := &model.Dinosaur{ID: "din_2", Species: model.Triceratops}
+	stego := &model.Dinosaur{ID: "din_3", Species: model.Stegosaurus}
+
+	assert.NoError(t, CheckPlacement(cage, nil, rex))
+	assert.NoError(t, CheckPlacement(cage, []*model.Dinosaur{rex}, rex))
+	assert.NoError(t, CheckPlacement(cage, []*model.Dinosaur{trike}, stego))
+
+	err := CheckPlacement(cage, []*model.Dinosaur{trike, stego}, rex)
+	assert.True(t, errors.Is(err, errors.KindUnprocessable))
+
+	err = CheckPlacement(cage, []*model.Dinosaur{rex}, trike)
+	assert.True(t, errors.IsUnprocessableErr(err))
+}
+
+func TestCheckPlacementCapacity(t *testing.T) {
+	cage := &model.Cage{ID: "cg_1", Capacity: 2, Status: model.PowerActive}
+	d1 := &model.Dinosaur{ID: "din_1", Species: model.Triceratops}
+	d2 := &model.Dinosaur{ID: "din_2", Species: model.Triceratops}
+	d3 := &model.Dinosaur{ID: "din_3", Species: model.Triceratops}
+
+	assert.NoError(t, CheckPlacement(cage, []*model.Dinosaur{d1}, d2))
+	assert.NoError(t, CheckPlacement(cage, []*model.Dinosaur{d1, d2}, d2))
+
+	err := CheckPlacement(cage, []*model.Dinosaur{d1, d2}, d3)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = CheckPlacement(&model.Cage{ID: "cg_2", Status: model.PowerActive}, nil, d1)
+	assert.True(t, errors.IsUnprocessableErr(err))
+}
+
+func TestCheckCapacity(t *testing.T) {
+	assert.NoError(t, CheckCapacity(&model.Cage{Capacity: 2}, 2))
+	assert.NoError(t, CheckCapacity(&model.Cage{Capacity: model.MaxCageCapacity * 2}, model.MaxCageCapacity))
+	assert.True(t, errors.Is(CheckCapacity(&model.Cage{Capacity: -1}, 0), errors.KindBadRequest))
+	assert.True(t, errors.IsUnprocessableErr(CheckCapacity(&model.Cage{Capacity: 1}, 2)))
+}
+
+func TestCheckPlacementPower(t *testing.T) {
+	d := &model.Dinosaur{ID: "din_1", Species: model.Triceratops}
+	for _, status := range []model.PowerStatus{model.PowerDown, model.PowerMaintenance} {
+		cage := &model.Cage{ID: "cg_1", Capacity: 1, Status: status}
+		assert.True(t, errors.IsUnprocessableErr(CheckPlacement(cage, nil, d)))
+	}
+}
+
+func TestCheckPowerTransition(t *testing.T) {
+	const id = model.ID("cg_1")
+
+	assert.NoError(t, CheckPowerTransition(id, model.PowerActive, model.PowerDown, 0))
+	assert.NoError(t, CheckPowerTransition(id, model.PowerDown, model.PowerMaintenance, 0))
+	assert.NoError(t, CheckPowerTransition(id, model.PowerMaintenance, model.PowerActive, 1))
+	assert.True(t, errors.Is(CheckPowerTransition(id, model.PowerActive, "OFF", 0), errors.KindBadRequest))
+	assert.True(t, errors.IsUnprocessableErr(CheckPowerTransition(id, model.PowerDown, model.PowerActive, 0)))
+	assert.True(t, errors.IsUnprocessableErr(CheckPowerTransition(id, model.PowerActive, model.PowerDown, 1)))
+	assert.True(t, errors.IsUnprocessableErr(CheckPowerTransition(id, model.PowerActive, model.PowerMaintenance, 1)))
+}
+
+func TestCheckArchive(t *testing.T) {
+	cage := &model.Cage{ID: "cg_1", Capacity: 2, Status: model.PowerActive}
+	assert.NoError(t, CheckArchive(cage, 0))
+	assert.True(t, errors.IsUnprocessableErr(CheckArchive(cage, 1)))
+
+	now := time.Now()
+	cage.DeletedAt = &now
+	d := &model.Dinosaur{ID: "din_1", Species: model.Triceratops}
+	assert.True(t, errors.IsUnprocessableErr(CheckPlacement(cage, nil, d)))
+}
+
+func TestCheckPlacementMaxPerCage(t *testing.T) {
+	cage := &model.Cage{ID: "cg_1", Capacity: model.MaxCageCapacity, Status: model.PowerActive}
+	d1 := &model.Dinosaur{ID: "din_1", Species: model.Tyrannosaurus}
+	d2 := &model.Dinosaur{ID: "din_2", Species: model.Tyrannosaurus}
+	d3 := &model.Dinosaur{ID: "din_3", Species: model.Tyrannosaurus}
+
+	assert.NoError(t, CheckPlacement(cage, []*model.Dinosaur{d1}, d2))
+
+	err := CheckPlacement(cage, []*model.Dinosaur{d1, d2}, d3)
+	assert.True(t, errors.IsUnprocessableErr(err))
+}
+
+func TestCheckSpeciesEntry(t *testing.T) {
+	assert.NoError(t, CheckSpeciesEntry(&model.SpeciesEntry{Name: "dilophosaurus", Kind: model.KindCarnivore}))
+	assert.True(t, errors.Is(CheckSpeciesEntry(&model.SpeciesEntry{Name: "Dilo phosaurus", Kind: model.KindCarnivore}), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckSpeciesEntry(&model.SpeciesEntry{Name: "dilophosaurus", Kind: model.KindUnknown}), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckSpeciesEntry(&model.SpeciesEntry{Name: "dilophosaurus", Kind: model.KindCarnivore, MaxPerCage: -1}), errors.KindBadRequest))
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+)
+
+// Placement scores. Cages designated for the species come first, then cages
+// shared by herbivores. Empty cages are the only ones a carnivore group can
+// start in, so herbivores take them last.
+const (
+	scoreDesignated        = 100
+	scoreShared            = 50
+	scoreEmpty             = 25
+	scoreEmptyForHerbivore = 0
+)
+
+// SuggestPlacements ranks cages for dinosaur, which may be a new arrival
+// without an ID, and explains why the others cannot take it. Cages must carry
+// their occupants. Among equal scores, fuller cages come first so that free
+// slots stay together.
+func SuggestPlacements(dinosaur *model.Dinosaur, cages []*model.Cage) ([]*model.PlacementCandidate, []*model.PlacementExclusion) {
+	var (
+		candidates []*model.PlacementCandidate
+		excluded   []*model.PlacementExclusion
+	)
+
+	herbivore := model.SpeciesKind(dinosaur.Species) == model.KindHerbivores
+	for _, cage := range cages {
+		if dinosaur.ID != "" && dinosaur.CageID == cage.ID {
+			excluded = append(excluded, &model.PlacementExclusion{
+				CageID: cage.ID,
+				Reason: fmt.Sprintf("cage %s already holds %s", cage.ID, dinosaur.ID),
+			})
+			continue
+		}
+
+		if err := CheckPlacement(cage, cage.Dinosaurs, dinosaur); err != nil {
+			excluded = append(excluded, &model.PlacementExclusion{CageID: cage.ID, Reason: err.Error()})
+			continue
+		}
+
+		candidate := &model.PlacementCandidate{Cage: cage}
+		switch {
+		case cage.Allocation > 0 && cage.Species == dinosaur.Species:
+			candidate.Score = scoreDesignated
+			candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("designated for %s", dinosaur.Species))
+		case cage.Allocation > 0:
+			candidate.Score = scoreShared
+			candidate.Reasons = append(candidate.Reasons, "shared with other herbivores")
+		case herbivore:
+			candidate.Score = scoreEmptyForHerbivore
+			candidate.Reasons = append(candidate.Reasons, "empty, better kept for a carnivore group")
+		default:
+			candidate.Score = scoreEmpty
+			candidate.Reasons = append(candidate.Reasons, "empty")
+		}
+
+		free := cage.MaxOccupancy() - cage.Allocation - 1
+		candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("%d slots left after placement", free))
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+
+		if freeA, freeB := a.Cage.MaxOccupancy()-a.Cage.Allocation, b.Cage.MaxOccupancy()-b.Cage.Allocation; freeA != freeB {
+			return freeA < freeB
+		}
+
+		return a.Cage.ID < b.Cage.ID
+	})
+
+	return candidates, excluded
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCage(id model.ID, capacity int, status model.PowerStatus, occupants ...*model.Dinosaur) *model.Cage {
+	cage := &model.Cage{ID: id, Capacity: capacity, Status: status, Dinosaurs: occupants, Allocation: len(occupants)}
+	for i, d := range occupants {
+		if i == 0 {
+			cage.Species = d.Species
+		} else if d.Species != cage.Species {
+			cage.Species = ""
+		}
+	}
+
+	return cage
+}
+
+func TestSuggestPlacements(t *testing.T) {
+	rex := &model.Dinosaur{ID: "din_1", Species: model.Tyrannosaurus, CageID: "cg_rex"}
+	trike := &model.Dinosaur{ID: "din_2", Species: model.Triceratops, CageID: "cg_trike"}
+	stego := &model.Dinosaur{ID: "din_3", Species: model.Stegosaurus, CageID: "cg_mixed"}
+	ankylo := &model.Dinosaur{ID: "din_4", Species: model.Ankylosaurus, CageID: "cg_mixed"}
+
+	cages := []*model.Cage{
+		newCage("cg_empty", 4, model.PowerActive),
+		newCage("cg_down", 4, model.PowerDown),
+		newCage("cg_rex", 4, model.PowerActive, rex),
+		newCage("cg_mixed", 4, model.PowerActive, stego, ankylo),
+		newCage("cg_trike", 4, model.PowerActive, trike),
+		newCage("cg_full", 1, model.PowerActive, &model.Dinosaur{ID: "din_5", Species: model.Triceratops}),
+	}
+
+	candidates, excluded := SuggestPlacements(&model.Dinosaur{Species: model.Triceratops}, cages)
+
+	var ids []model.ID
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.Cage.ID)
+	}
+
+	assert.Equal(t, []model.ID{"cg_trike", "cg_mixed", "cg_empty"}, ids)
+	assert.Equal(t, scoreEmptyForHerbivore, candidates[2].Score)
+	assert.Len(t, excluded, 3)
+
+	candidates, excluded = SuggestPlacements(&model.Dinosaur{Species: model.Tyrannosaurus}, cages)
+	ids = nil
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.Cage.ID)
+	}
+
+	assert.Equal(t, []model.ID{"cg_rex", "cg_empty"}, ids)
+	assert.Len(t, excluded, 4)
+
+	_, excluded = SuggestPlacements(rex, cages)
+	assert.Equal(t, model.ID("cg_down"), excluded[0].CageID)
+	assert.Contains(t, excluded[0].Reason, "DOWN")
+	assert.Equal(t, model.ID("cg_rex"), excluded[1].CageID)
+	assert.Contains(t, excluded[1].Reason, "already holds")
+}
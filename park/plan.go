@@ -0,0 +1,288 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// maxPlanSteps bounds the number of sets of cages and placements tried while
+// searching for a plan, so that a request cannot keep the server busy. It is a variable so
+// that tests can exhaust it.
+var maxPlanSteps = 100_000
+
+// errPlanTooLarge stops a search that ran out of steps. PlanConsolidation
+// reports it with the budget in force.
+var errPlanTooLarge = fmt.Errorf("no plan found within the step budget")
+
+// PlanConsolidation computes the fewest transfers that empty cages without
+// breaking the containment rules. Either targets names the cages to empty,
+// or free asks for that many occupied cages, chosen to move as few dinosaurs
+// as possible. Cages must carry their occupants and are the only ones that
+// can receive dinosaurs, unless one of incidents holds them. It fails with
+// errors.KindUnprocessable when no plan exists. If the search runs out of
+// steps after finding a plan, the plan is returned but not marked Minimal.
+func PlanConsolidation(cages []*model.Cage, incidents []*model.Incident, targets []model.ID, free int) (*model.ConsolidationPlan, error) {
+	const op errors.Op = "park.PlanConsolidation"
+
+	if (len(targets) == 0) == (free == 0) {
+		return nil, errors.E(op, errors.KindBadRequest, "either target cages or a number of cages to free is required")
+	}
+
+	byID := make(map[model.ID]*model.Cage, len(cages))
+	for _, cage := range cages {
+		byID[cage.ID] = cage
+	}
+
+	for _, id := range targets {
+		if _, ok := byID[id]; !ok {
+			return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("cage %s does not exist", id))
+		}
+	}
+
+	if err := CheckIncidentHolds(targets, incidents); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	// Cages on hold can neither be emptied nor receive dinosaurs.
+	var open []*model.Cage
+	for _, cage := range cages {
+		if CheckIncidentHolds([]model.ID{cage.ID}, incidents) == nil {
+			open = append(open, cage)
+		}
+	}
+	cages = open
+
+	steps := 0
+	if len(targets) > 0 {
+
+		transfers, err := evacuate(cages, targets, &steps)
+		if err != nil {
+			return nil, errors.E(op, errors.KindUnprocessable, planError(err))
+		}
+
+		return &model.ConsolidationPlan{Freed: targets, Transfers: transfers, Minimal: true}, nil
+	}
+
+	if free < 0 {
+		return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid number of cages to free: %d", free))
+	}
+
+	var occupied []*model.Cage
+	for _, cage := range cages {
+		if cage.Allocation > 0 {
+			occupied = append(occupied, cage)
+		}
+	}
+
+	if free > len(occupied) {
+		return nil, errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"cannot free %d cages, %d hold dinosaurs", free, len(occupied)))
+	}
+
+	// Cheaper cages first, so that the bound below prunes early.
+	sort.SliceStable(occupied, func(i, j int) bool { return occupied[i].Allocation < occupied[j].Allocation })
+
+	var (
+		best   *model.ConsolidationPlan
+		bound  = -1
+		chosen []model.ID
+		search func(start, cost int) error
+	)
+
+	search = func(start, cost int) error {
+		if steps++; steps > maxPlanSteps {
+			return errPlanTooLarge
+		}
+
+		if len(chosen) == free {
+			transfers, err := evacuate(cages, chosen, &steps)
+			if err == errPlanTooLarge {
+				return err
+			}
+
+			if err == nil {
+				best = &model.ConsolidationPlan{Freed: append([]model.ID(nil), chosen...), Transfers: transfers}
+				bound = cost
+			}
+
+			return nil
+		}
+
+		for i := start; i < len(occupied); i++ {
+			// The remaining cages cost at least as much as this one.
+			if bound >= 0 && cost+occupied[i].Allocation*(free-len(chosen)) >= bound {
+				break
+			}
+
+			chosen = append(chosen, occupied[i].ID)
+			err := search(i+1, cost+occupied[i].Allocation)
+			chosen = chosen[:len(chosen)-1]
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err := search(0, 0)
+	if err != nil && best == nil {
+		return nil, errors.E(op, errors.KindUnprocessable, planError(err))
+	}
+
+	if best == nil {
+		return nil, errors.E(op, errors.KindUnprocessable, fmt.Sprintf("no set of %d cages can be freed", free))
+	}
+
+	best.Minimal = err == nil
+	return best, nil
+}
+
+// planError adds the step budget to errPlanTooLarge.
+func planError(err error) error {
+	if err == errPlanTooLarge {
+		return fmt.Errorf("%w of %d steps", err, maxPlanSteps)
+	}
+
+	return err
+}
+
+// evacuate finds destinations for the occupants of the cages in targets
+// among the other cages by backtracking over the ranked placements.
+func evacuate(cages []*model.Cage, targets []model.ID, steps *int) ([]*model.PlannedTransfer, error) {
+	evacuated := make(map[model.ID]bool, len(targets))
+	for _, id := range targets {
+		evacuated[id] = true
+	}
+
+	var (
+		destinations []*model.Cage
+		movers       []*model.Dinosaur
+	)
+
+	for _, cage := range cages {
+		if evacuated[cage.ID] {
+			for _, d := range cage.Dinosaurs {
+				if err := CheckTransfer(d); err != nil {
+					return nil, err
+				}
+			}
+
+			movers = append(movers, cage.Dinosaurs...)
+			continue
+		}
+
+		c := *cage
+		c.Dinosaurs = append([]*model.Dinosaur(nil), cage.Dinosaurs...)
+		destinations = append(destinations, &c)
+	}
+
+	sortMovers(movers)
+
+	transfers := make([]*model.PlannedTransfer, len(movers))
+	var place func(i int) error
+	place = func(i int) error {
+		if i == len(movers) {
+			return nil
+		}
+
+		d := movers[i]
+		candidates, _ := SuggestPlacements(d, destinations)
+		for _, candidate := range candidates {
+			if *steps++; *steps > maxPlanSteps {
+				return errPlanTooLarge
+			}
+
+			cage := candidate.Cage
+			occupy(cage, d)
+			transfers[i] = &model.PlannedTransfer{DinosaurID: d.ID, FromCageID: d.CageID, ToCageID: cage.ID}
+
+			err := place(i + 1)
+			if err == nil || err == errPlanTooLarge {
+				return err
+			}
+
+			vacate(cage)
+		}
+
+		return fmt.Errorf("no cage can take %s (%s)", d.ID, d.Species)
+	}
+
+	if err := place(0); err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+// sortMovers orders dinosaurs from the most to the least constrained:
+// carnivores, which need a cage of their own species, then larger groups of a
+// species first.
+func sortMovers(dinosaurs []*model.Dinosaur) {
+	groups := make(map[model.Species]int)
+	for _, d := range dinosaurs {
+		groups[d.Species]++
+	}
+
+	sort.SliceStable(dinosaurs, func(i, j int) bool {
+		a, b := dinosaurs[i], dinosaurs[j]
+		if ca, cb := model.SpeciesKind(a.Species) == model.KindCarnivore, model.SpeciesKind(b.Species) == model.KindCarnivore; ca != cb {
+			return ca
+		}
+
+		if groups[a.Species] != groups[b.Species] {
+			return groups[a.Species] > groups[b.Species]
+		}
+
+		if a.Species != b.Species {
+			return a.Species < b.Species
+		}
+
+		return a.ID < b.ID
+	})
+}
+
+// occupy adds dinosaur to the occupants of cage.
+func occupy(cage *model.Cage, dinosaur *model.Dinosaur) {
+	if cage.Allocation == 0 {
+		cage.Species = dinosaur.Species
+	} else if cage.Species != dinosaur.Species {
+		cage.Species = ""
+	}
+
+	cage.Dinosaurs = append(cage.Dinosaurs, dinosaur)
+	cage.Allocation++
+}
+
+// vacate undoes the last occupy of cage.
+func vacate(cage *model.Cage) {
+	cage.Dinosaurs = cage.Dinosaurs[:len(cage.Dinosaurs)-1]
+	cage.Allocation--
+
+	cage.Species = ""
+	for i, d := range cage.Dinosaurs {
+		if i == 0 {
+			cage.Species = d.Species
+		} else if d.Species != cage.Species {
+			cage.Species = ""
+			break
+		}
+	}
+}
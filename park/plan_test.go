@@ -0,0 +1,209 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanConsolidation(t *testing.T) {
+	trike1 := &model.Dinosaur{ID: "din_1", Species: model.Triceratops, CageID: "cg_herd"}
+	trike2 := &model.Dinosaur{ID: "din_2", Species: model.Triceratops, CageID: "cg_herd"}
+	trike3 := &model.Dinosaur{ID: "din_3", Species: model.Triceratops, CageID: "cg_stray"}
+	rex := &model.Dinosaur{ID: "din_4", Species: model.Tyrannosaurus, CageID: "cg_rex"}
+
+	cages := []*model.Cage{
+		newCage("cg_rex", 4, model.PowerActive, rex),
+		newCage("cg_herd", 3, model.PowerActive, trike1, trike2),
+		newCage("cg_stray", 4, model.PowerActive, trike3),
+		newCage("cg_down", 4, model.PowerDown),
+	}
+
+	plan, err := PlanConsolidation(cages, nil, []model.ID{"cg_stray"}, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []model.ID{"cg_stray"}, plan.Freed)
+		assert.Equal(t, []*model.PlannedTransfer{{DinosaurID: "din_3", FromCageID: "cg_stray", ToCageID: "cg_herd"}}, plan.Transfers)
+	}
+
+	// The T-rex is as cheap to move but has nowhere to go.
+	plan, err = PlanConsolidation(cages, nil, nil, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []model.ID{"cg_stray"}, plan.Freed)
+		assert.Len(t, plan.Transfers, 1)
+	}
+
+	// The herd does not fit in the stray's cage with room for one more.
+	cages[2].Capacity = 2
+	_, err = PlanConsolidation(cages, nil, []model.ID{"cg_herd"}, 0)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	cages[2].Capacity = 4
+	plan, err = PlanConsolidation(cages, nil, []model.ID{"cg_herd"}, 0)
+	if assert.NoError(t, err) {
+		assert.Len(t, plan.Transfers, 2)
+		for _, move := range plan.Transfers {
+			assert.Equal(t, model.ID("cg_stray"), move.ToCageID)
+		}
+	}
+
+	_, err = PlanConsolidation(cages, nil, []model.ID{"cg_rex"}, 0)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	_, err = PlanConsolidation(cages, nil, nil, 3)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	_, err = PlanConsolidation(cages, nil, []model.ID{"cg_foo"}, 0)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	_, err = PlanConsolidation(cages, nil, nil, 0)
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	_, err = PlanConsolidation(cages, nil, []model.ID{"cg_stray"}, 1)
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	// Planning leaves the cages untouched.
+	assert.Len(t, cages[1].Dinosaurs, 2)
+	assert.Len(t, cages[2].Dinosaurs, 1)
+}
+
+func TestPlanConsolidationMedicalHold(t *testing.T) {
+	trike1 := &model.Dinosaur{ID: "din_1", Species: model.Triceratops, CageID: "cg_herd"}
+	trike2 := &model.Dinosaur{ID: "din_2", Species: model.Triceratops, CageID: "cg_sick", MedicalHold: true}
+	trike3 := &model.Dinosaur{ID: "din_3", Species: model.Triceratops, CageID: "cg_stray"}
+
+	cages := []*model.Cage{
+		newCage("cg_herd", 4, model.PowerActive, trike1),
+		newCage("cg_sick", 4, model.PowerActive, trike2),
+		newCage("cg_stray", 4, model.PowerActive, trike3),
+	}
+
+	_, err := PlanConsolidation(cages, nil, []model.ID{"cg_sick"}, 0)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	plan, err := PlanConsolidation(cages, nil, nil, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []model.ID{"cg_herd", "cg_stray"}, plan.Freed)
+	}
+}
+
+func TestPlanConsolidationBudget(t *testing.T) {
+	defer func(steps int) { maxPlanSteps = steps }(maxPlanSteps)
+
+	cages := []*model.Cage{
+		newCage("cg_stego", 4, model.PowerActive,
+			&model.Dinosaur{ID: "din_1", Species: model.Stegosaurus, CageID: "cg_stego"},
+			&model.Dinosaur{ID: "din_2", Species: model.Stegosaurus, CageID: "cg_stego"}),
+		newCage("cg_rex1", 3, model.PowerActive,
+			&model.Dinosaur{ID: "din_3", Species: model.Tyrannosaurus, CageID: "cg_rex1"}),
+		newCage("cg_herd", 4, model.PowerActive,
+			&model.Dinosaur{ID: "din_4", Species: model.Triceratops, CageID: "cg_herd"},
+			&model.Dinosaur{ID: "din_5", Species: model.Triceratops, CageID: "cg_herd"},
+			&model.Dinosaur{ID: "din_6", Species: model.Triceratops, CageID: "cg_herd"}),
+		newCage("cg_rex2", 3, model.PowerActive,
+			&model.Dinosaur{ID: "din_7", Species: model.Tyrannosaurus, CageID: "cg_rex2"}),
+		newCage("cg_full", 3, model.PowerActive,
+			&model.Dinosaur{ID: "din_8", Species: model.Stegosaurus, CageID: "cg_full"},
+			&model.Dinosaur{ID: "din_9", Species: model.Stegosaurus, CageID: "cg_full"},
+			&model.Dinosaur{ID: "din_10", Species: model.Stegosaurus, CageID: "cg_full"}),
+	}
+
+	plan, err := PlanConsolidation(cages, nil, nil, 2)
+	if assert.NoError(t, err) {
+		assert.True(t, plan.Minimal)
+	}
+
+	// Running out of steps after a plan was found returns it, but cannot
+	// vouch for it being the smallest.
+	maxPlanSteps = 16
+	partial, err := PlanConsolidation(cages, nil, nil, 2)
+	if assert.NoError(t, err) {
+		assert.False(t, partial.Minimal)
+		assert.Equal(t, plan.Freed, partial.Freed)
+	}
+
+	maxPlanSteps = 1
+	_, err = PlanConsolidation(cages, nil, nil, 2)
+	if assert.True(t, errors.IsUnprocessableErr(err)) {
+		assert.Equal(t, "no plan found within the step budget of 1 steps", err.Error())
+	}
+
+	plan, err = PlanConsolidation(cages, nil, []model.ID{"cg_rex1"}, 0)
+	if assert.NoError(t, err) {
+		assert.True(t, plan.Minimal)
+	}
+}
+
+func TestPlanConsolidationImpossible(t *testing.T) {
+	// No T-rex can move, and no set of cages to free is ruled out before
+	// trying every set, unless the search runs out of steps.
+	var cages []*model.Cage
+	for i := 0; i < 30; i++ {
+		id := model.ID(fmt.Sprintf("cg_%d", i))
+		cages = append(cages, newCage(id, 1, model.PowerActive,
+			&model.Dinosaur{ID: model.ID(fmt.Sprintf("din_%d", i)), Species: model.Tyrannosaurus, CageID: id}))
+	}
+
+	start := time.Now()
+	_, err := PlanConsolidation(cages, nil, nil, 15)
+	assert.True(t, errors.IsUnprocessableErr(err))
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestPlanConsolidationIncidentHolds(t *testing.T) {
+	trike1 := &model.Dinosaur{ID: "din_1", Species: model.Triceratops, CageID: "cg_herd"}
+	trike2 := &model.Dinosaur{ID: "din_2", Species: model.Triceratops, CageID: "cg_herd"}
+	trike3 := &model.Dinosaur{ID: "din_3", Species: model.Triceratops, CageID: "cg_stray"}
+	stego := &model.Dinosaur{ID: "din_4", Species: model.Stegosaurus, CageID: "cg_stego"}
+
+	cages := []*model.Cage{
+		newCage("cg_herd", 4, model.PowerActive, trike1, trike2),
+		newCage("cg_stray", 4, model.PowerActive, trike3),
+		newCage("cg_stego", 4, model.PowerActive, stego),
+	}
+
+	incidents := []*model.Incident{
+		{ID: 1, Kind: model.IncidentBreach, Severity: model.SeverityCritical, Status: model.IncidentOpen, CageIDs: []model.ID{"cg_herd"}},
+		{ID: 2, Kind: model.IncidentBreach, Severity: model.SeverityLow, Status: model.IncidentOpen, CageIDs: []model.ID{"cg_stego"}},
+	}
+
+	// The herd cannot take the stray while on hold.
+	plan, err := PlanConsolidation(cages, incidents, []model.ID{"cg_stray"}, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []*model.PlannedTransfer{{DinosaurID: "din_3", FromCageID: "cg_stray", ToCageID: "cg_stego"}}, plan.Transfers)
+	}
+
+	_, err = PlanConsolidation(cages, incidents, []model.ID{"cg_herd"}, 0)
+	assert.True(t, errors.Is(err, IncidentErrorKind(model.IncidentBreach)))
+
+	plan, err = PlanConsolidation(cages, incidents, nil, 1)
+	if assert.NoError(t, err) {
+		assert.NotContains(t, plan.Freed, model.ID("cg_herd"))
+		assert.True(t, plan.Minimal)
+		for _, move := range plan.Transfers {
+			assert.NotEqual(t, model.ID("cg_herd"), move.ToCageID)
+		}
+	}
+
+	plan, err = PlanConsolidation(cages, nil, []model.ID{"cg_stray"}, 0)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.ID("cg_herd"), plan.Transfers[0].ToCageID)
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+const (
+	// MaxReadings is the most readings accepted in a batch.
+	MaxReadings = 10000
+
+	// SensorClockSkew is how far ahead of the park clock the clock of a
+	// sensor may run.
+	SensorClockSkew = time.Minute
+
+	// RollupWidth is the bucket readings are rolled up into once past their
+	// retention.
+	RollupWidth = 5 * time.Minute
+
+	// MaxBuckets bounds the buckets of a telemetry query.
+	MaxBuckets = 10000
+)
+
+// fenceThresholds are the lowest fence voltages holding each diet kind.
+var fenceThresholds = map[string]float64{
+	model.KindCarnivore:  9000,
+	model.KindHerbivores: 3000,
+}
+
+// CheckReadings verifies a batch of readings pushed at now.
+func CheckReadings(readings []*model.Reading, now time.Time) error {
+	const op errors.Op = "park.CheckReadings"
+
+	if len(readings) == 0 {
+		return errors.E(op, errors.KindBadRequest, "no readings")
+	}
+
+	if len(readings) > MaxReadings {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("more than %d readings", MaxReadings))
+	}
+
+	for _, reading := range readings {
+		if err := checkReading(reading, now); err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	return nil
+}
+
+func checkReading(reading *model.Reading, now time.Time) error {
+	const op errors.Op = "park.checkReading"
+
+	if reading.CageID == "" {
+		return errors.E(op, errors.KindBadRequest, "reading cage is required")
+	}
+
+	if !model.ValidSensor(reading.Sensor) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid sensor: %q", reading.Sensor))
+	}
+
+	if math.IsNaN(reading.Value) || math.IsInf(reading.Value, 0) || reading.Value < 0 ||
+		reading.Sensor == model.SensorDoor && reading.Value != 0 && reading.Value != 1 {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid %s reading: %g", reading.Sensor, reading.Value))
+	}
+
+	if reading.RecordedAt != nil && reading.RecordedAt.After(now.Add(SensorClockSkew)) {
+		return errors.E(op, errors.KindBadRequest, "reading time is in the future")
+	}
+
+	return nil
+}
+
+// CheckTelemetryQuery verifies that readings can be summarized in buckets
+// of a width between from and to.
+func CheckTelemetryQuery(sensor string, from, to time.Time, width time.Duration) error {
+	const op errors.Op = "park.CheckTelemetryQuery"
+
+	if !model.ValidSensor(sensor) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid sensor: %q", sensor))
+	}
+
+	if !to.After(from) {
+		return errors.E(op, errors.KindBadRequest, "telemetry query must end after it starts")
+	}
+
+	if width < time.Second || width%time.Second != 0 {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid bucket: %s", width))
+	}
+
+	if to.Sub(from)/width > MaxBuckets {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("telemetry query spans more than %d buckets", MaxBuckets))
+	}
+
+	return nil
+}
+
+// BucketStart returns the start of the bucket of a width holding t. Buckets
+// are aligned on the Unix epoch, as they are in the database.
+func BucketStart(t time.Time, width time.Duration) time.Time {
+	seconds := int64(width / time.Second)
+	unix := t.Unix()
+	start := unix - unix%seconds
+	if unix%seconds < 0 {
+		start -= seconds
+	}
+
+	return time.Unix(start, 0).UTC()
+}
+
+// Rollup aggregates readings into RollupWidth buckets per cage and sensor.
+func Rollup(readings []*model.Reading) []*model.ReadingRollup {
+	type key struct {
+		cageID model.ID
+		sensor string
+		start  time.Time
+	}
+
+	byKey := make(map[key]*model.ReadingRollup)
+	var rollups []*model.ReadingRollup
+	for _, reading := range readings {
+		k := key{reading.CageID, reading.Sensor, BucketStart(*reading.RecordedAt, RollupWidth)}
+		rollup, ok := byKey[k]
+		if !ok {
+			rollup = &model.ReadingRollup{CageID: k.cageID, Sensor: k.sensor, BucketStart: k.start}
+			byKey[k] = rollup
+			rollups = append(rollups, rollup)
+		}
+
+		MergeRollup(rollup, &model.ReadingRollup{Count: 1, Min: reading.Value, Max: reading.Value, Sum: reading.Value})
+	}
+
+	return rollups
+}
+
+// MergeRollup adds the readings aggregated by from to into.
+func MergeRollup(into, from *model.ReadingRollup) {
+	if into.Count == 0 || from.Min < into.Min {
+		into.Min = from.Min
+	}
+
+	if into.Count == 0 || from.Max > into.Max {
+		into.Max = from.Max
+	}
+
+	into.Count += from.Count
+	into.Sum += from.Sum
+}
+
+// Bucketize summarizes readings and rollups in buckets of a width, earliest
+// first. Empty buckets are left out.
+func Bucketize(readings []*model.Reading, rollups []*model.ReadingRollup, width time.Duration) []*model.ReadingBucket {
+	byStart := make(map[time.Time]*model.ReadingRollup)
+	add := func(t time.Time, r *model.ReadingRollup) {
+		start := BucketStart(t, width)
+		if byStart[start] == nil {
+			byStart[start] = &model.ReadingRollup{}
+		}
+
+		MergeRollup(byStart[start], r)
+	}
+
+	for _, reading := range readings {
+		add(*reading.RecordedAt, &model.ReadingRollup{Count: 1, Min: reading.Value, Max: reading.Value, Sum: reading.Value})
+	}
+
+	for _, rollup := range rollups {
+		add(rollup.BucketStart, rollup)
+	}
+
+	buckets := make([]*model.ReadingBucket, 0, len(byStart))
+	for start, r := range byStart {
+		buckets = append(buckets, &model.ReadingBucket{
+			Start: start,
+			Count: r.Count,
+			Min:   r.Min,
+			Avg:   r.Sum / float64(r.Count),
+			Max:   r.Max,
+			Sum:   r.Sum,
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	return buckets
+}
+
+// FenceThreshold returns the diet kind held by a cage, which must carry its
+// occupants, and the lowest voltage its fence must carry to hold them. The
+// fences of empty cages and of cages that are not ACTIVE are not watched.
+func FenceThreshold(cage *model.Cage) (string, float64, bool) {
+	if len(cage.Dinosaurs) == 0 || cage.Status != model.PowerActive {
+		return "", 0, false
+	}
+
+	kind := model.SpeciesKind(cage.Dinosaurs[0].Species)
+	threshold, ok := fenceThresholds[kind]
+	return kind, threshold, ok
+}
+
+// LatestVoltages returns the latest voltage reading of each cage among
+// readings.
+func LatestVoltages(readings []*model.Reading) map[model.ID]*model.Reading {
+	latest := make(map[model.ID]*model.Reading)
+	for _, reading := range readings {
+		if reading.Sensor != model.SensorVoltage {
+			continue
+		}
+
+		if l, ok := latest[reading.CageID]; !ok || !reading.RecordedAt.Before(*l.RecordedAt) {
+			latest[reading.CageID] = reading
+		}
+	}
+
+	return latest
+}
+
+// FenceAlarm returns the incident opened for an alert on the fence of a
+// cage, which must carry its occupants.
+func FenceAlarm(cage *model.Cage, alert *model.FenceAlert) *model.Incident {
+	incident := &model.Incident{
+		Kind:  model.IncidentPowerFailure,
+		Title: fmt.Sprintf("Fence voltage of cage %s dropped to %g V", cage.ID, alert.Voltage),
+		Description: fmt.Sprintf("The fence must carry %g V to hold %s dinosaurs.",
+			alert.Threshold, alert.Kind),
+		CageIDs: []model.ID{cage.ID},
+	}
+
+	incident.Severity, incident.DinosaurIDs = alarmSeverity(cage.Dinosaurs)
+	return incident
+}
+
+// FenceRecovery returns the note added to the incident of an alert cleared
+// by a voltage reading.
+func FenceRecovery(alert *model.FenceAlert, voltage float64) string {
+	return fmt.Sprintf("fence voltage of cage %s is back to %g V", alert.CageID, voltage)
+}
+
+// FenceUnwatched returns the note added to the incident of an alert cleared
+// because the fence of its cage, which must carry its occupants, is no
+// longer watched.
+func FenceUnwatched(cage *model.Cage) string {
+	if len(cage.Dinosaurs) == 0 {
+		return fmt.Sprintf("cage %s is empty and its fence is no longer watched", cage.ID)
+	}
+
+	return fmt.Sprintf("cage %s is %s and its fence is no longer watched", cage.ID, cage.Status)
+}
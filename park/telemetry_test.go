@@ -0,0 +1,147 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReadings(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	valid := []*model.Reading{
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: 9500},
+		{CageID: "cg_1", Sensor: model.SensorDoor, Value: 1, RecordedAt: at(-time.Hour)},
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: 0, RecordedAt: at(30 * time.Second)},
+	}
+	assert.NoError(t, CheckReadings(valid, now))
+
+	for _, reading := range []*model.Reading{
+		{Sensor: model.SensorVoltage, Value: 1},
+		{CageID: "cg_1", Sensor: "temperature", Value: 1},
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: -1},
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: math.NaN()},
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: math.Inf(1)},
+		{CageID: "cg_1", Sensor: model.SensorDoor, Value: 0.5},
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: 1, RecordedAt: at(time.Hour)},
+	} {
+		err := CheckReadings([]*model.Reading{reading}, now)
+		assert.True(t, errors.Is(err, errors.KindBadRequest), "%+v: %v", reading, err)
+	}
+
+	assert.True(t, errors.Is(CheckReadings(nil, now), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckReadings(make([]*model.Reading, MaxReadings+1), now), errors.KindBadRequest))
+}
+
+func TestCheckTelemetryQuery(t *testing.T) {
+	from := time.Now()
+
+	assert.NoError(t, CheckTelemetryQuery(model.SensorVoltage, from, from.Add(time.Hour), time.Minute))
+	assert.True(t, errors.Is(CheckTelemetryQuery("temperature", from, from.Add(time.Hour), time.Minute), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckTelemetryQuery(model.SensorVoltage, from, from, time.Minute), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckTelemetryQuery(model.SensorVoltage, from, from.Add(time.Hour), 0), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckTelemetryQuery(model.SensorVoltage, from, from.Add(time.Hour), 1500*time.Millisecond), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckTelemetryQuery(model.SensorVoltage, from, from.Add(30*24*time.Hour), time.Second), errors.KindBadRequest))
+}
+
+func TestBucketize(t *testing.T) {
+	start := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := start.Add(d)
+		return &t
+	}
+
+	assert.Equal(t, start, BucketStart(start.Add(4*time.Minute+59*time.Second), RollupWidth))
+	assert.Equal(t, start.Add(5*time.Minute), BucketStart(start.Add(5*time.Minute), RollupWidth))
+	assert.Equal(t, time.Unix(-60, 0).UTC(), BucketStart(time.Unix(-1, 0), time.Minute))
+
+	readings := []*model.Reading{
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: 9000, RecordedAt: at(time.Minute)},
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: 8000, RecordedAt: at(2 * time.Minute)},
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: 10000, RecordedAt: at(7 * time.Minute)},
+		{CageID: "cg_1", Sensor: model.SensorDoor, Value: 1, RecordedAt: at(time.Minute)},
+	}
+
+	rollups := Rollup(readings)
+	if assert.Len(t, rollups, 3) {
+		assert.Equal(t, &model.ReadingRollup{CageID: "cg_1", Sensor: model.SensorVoltage, BucketStart: start, Count: 2, Min: 8000, Max: 9000, Sum: 17000}, rollups[0])
+		assert.Equal(t, start.Add(5*time.Minute), rollups[1].BucketStart)
+		assert.Equal(t, model.SensorDoor, rollups[2].Sensor)
+	}
+
+	// The rollup of earlier readings lands in the first bucket.
+	buckets := Bucketize(readings[1:3], rollups[:1], 10*time.Minute)
+	if assert.Len(t, buckets, 1) {
+		assert.Equal(t, start, buckets[0].Start)
+		assert.Equal(t, 4, buckets[0].Count)
+		assert.Equal(t, 8000.0, buckets[0].Min)
+		assert.Equal(t, 8750.0, buckets[0].Avg)
+		assert.Equal(t, 10000.0, buckets[0].Max)
+	}
+
+	buckets = Bucketize(readings[:3], nil, time.Minute)
+	if assert.Len(t, buckets, 3) {
+		assert.Equal(t, start.Add(time.Minute), buckets[0].Start)
+		assert.Equal(t, start.Add(7*time.Minute), buckets[2].Start)
+	}
+}
+
+func TestFenceThreshold(t *testing.T) {
+	rex := &model.Dinosaur{ID: "din_1", Species: model.Tyrannosaurus}
+	cera := &model.Dinosaur{ID: "din_2", Species: model.Triceratops}
+
+	_, _, watched := FenceThreshold(&model.Cage{ID: "cg_1", Status: model.PowerActive})
+	assert.False(t, watched)
+
+	_, _, watched = FenceThreshold(&model.Cage{ID: "cg_1", Status: model.PowerMaintenance, Dinosaurs: []*model.Dinosaur{rex}})
+	assert.False(t, watched)
+
+	carnivores := &model.Cage{ID: "cg_1", Status: model.PowerActive, Dinosaurs: []*model.Dinosaur{rex}}
+	kind, carnivoreThreshold, watched := FenceThreshold(carnivores)
+	assert.True(t, watched)
+	assert.Equal(t, model.KindCarnivore, kind)
+
+	herbivores := &model.Cage{ID: "cg_2", Status: model.PowerActive, Dinosaurs: []*model.Dinosaur{cera}}
+	kind, herbivoreThreshold, watched := FenceThreshold(herbivores)
+	assert.True(t, watched)
+	assert.Equal(t, model.KindHerbivores, kind)
+	assert.Less(t, herbivoreThreshold, carnivoreThreshold)
+
+	incident := FenceAlarm(carnivores, &model.FenceAlert{CageID: "cg_1", Kind: model.KindCarnivore, Threshold: carnivoreThreshold, Voltage: 4000})
+	assert.Equal(t, model.SeverityCritical, incident.Severity)
+	assert.Equal(t, []model.ID{"cg_1"}, incident.CageIDs)
+	assert.Equal(t, []model.ID{"din_1"}, incident.DinosaurIDs)
+	assert.Equal(t, model.SeverityHigh, FenceAlarm(herbivores, &model.FenceAlert{CageID: "cg_2"}).Severity)
+
+	assert.Equal(t, "cage cg_1 is MAINTENANCE and its fence is no longer watched",
+		FenceUnwatched(&model.Cage{ID: "cg_1", Status: model.PowerMaintenance, Dinosaurs: []*model.Dinosaur{rex}}))
+	assert.Equal(t, "cage cg_2 is empty and its fence is no longer watched", FenceUnwatched(&model.Cage{ID: "cg_2", Status: model.PowerActive}))
+
+	latest := LatestVoltages([]*model.Reading{
+		{CageID: "cg_1", Sensor: model.SensorVoltage, Value: 1, RecordedAt: &time.Time{}},
+		{CageID: "cg_1", Sensor: model.SensorDoor, Value: 1, RecordedAt: &time.Time{}},
+	})
+	assert.Len(t, latest, 1)
+}
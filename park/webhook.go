@@ -0,0 +1,83 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// CheckWebhook verifies that a webhook has an absolute HTTP URL and
+// subscribes to known event types only.
+func CheckWebhook(webhook *model.Webhook) error {
+	const op errors.Op = "park.CheckWebhook"
+
+	u, err := url.Parse(webhook.URL)
+	if err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid webhook url: %q", webhook.URL))
+	}
+
+	if webhook.Secret == "" {
+		return errors.E(op, errors.KindBadRequest, "webhook secret is required")
+	}
+
+	seen := make(map[string]bool, len(webhook.Types))
+	for _, t := range webhook.Types {
+		if !model.ValidEventType(t) {
+			return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid event type: %q", t))
+		}
+
+		if seen[t] {
+			return errors.E(op, errors.KindBadRequest, fmt.Sprintf("event type %s is listed twice", t))
+		}
+
+		seen[t] = true
+	}
+
+	return nil
+}
+
+// CheckDeliveryReplay verifies that a delivery can be replayed. Only dead
+// deliveries are, since pending ones are still retried and delivered ones
+// reached their subscriber.
+func CheckDeliveryReplay(delivery *model.WebhookDelivery) error {
+	const op errors.Op = "park.CheckDeliveryReplay"
+
+	if delivery.Status != model.DeliveryDead {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"delivery %d is %s and cannot be replayed", delivery.ID, delivery.Status))
+	}
+
+	return nil
+}
+
+// CheckDeliveryClaim verifies that the attempt of a dispatcher which claimed
+// a delivery until claimed can still be recorded. Once the lease runs out,
+// another dispatcher may claim the delivery again, or even deliver it, and
+// the attempt of the first one no longer counts.
+func CheckDeliveryClaim(delivery *model.WebhookDelivery, claimed time.Time) error {
+	const op errors.Op = "park.CheckDeliveryClaim"
+
+	if delivery.Status != model.DeliveryPending || delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.Equal(claimed) {
+		return errors.E(op, errors.KindPreconditionFailed, fmt.Sprintf(
+			"delivery %d is no longer claimed by this attempt", delivery.ID))
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckWebhook(t *testing.T) {
+	webhook := func(url string, types ...string) *model.Webhook {
+		return &model.Webhook{URL: url, Secret: "s3cr3t", Types: types}
+	}
+
+	assert.NoError(t, CheckWebhook(webhook("https://pager.example.com/hooks")))
+	assert.NoError(t, CheckWebhook(webhook("http://localhost:8080", model.EventCagePowerChanged, model.EventIncidentOpened)))
+
+	assert.True(t, errors.Is(CheckWebhook(webhook("")), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckWebhook(webhook("ftp://example.com")), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckWebhook(webhook("/hooks")), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckWebhook(webhook("https://example.com", "cage.exploded")), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckWebhook(webhook("https://example.com", model.EventCageCreated, model.EventCageCreated)), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckWebhook(&model.Webhook{URL: "https://example.com"}), errors.KindBadRequest))
+}
+
+func TestCheckDeliveryReplay(t *testing.T) {
+	assert.NoError(t, CheckDeliveryReplay(&model.WebhookDelivery{ID: 1, Status: model.DeliveryDead}))
+	assert.True(t, errors.IsUnprocessableErr(CheckDeliveryReplay(&model.WebhookDelivery{ID: 1, Status: model.DeliveryPending})))
+	assert.True(t, errors.IsUnprocessableErr(CheckDeliveryReplay(&model.WebhookDelivery{ID: 1, Status: model.DeliveryDelivered})))
+}
+
+func TestCheckDeliveryClaim(t *testing.T) {
+	claimed := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	later := claimed.Add(time.Minute)
+
+	assert.NoError(t, CheckDeliveryClaim(&model.WebhookDelivery{ID: 1, Status: model.DeliveryPending, NextAttemptAt: &claimed}, claimed))
+	assert.True(t, errors.IsPreconditionFailedErr(CheckDeliveryClaim(&model.WebhookDelivery{ID: 1, Status: model.DeliveryPending, NextAttemptAt: &later}, claimed)))
+	assert.True(t, errors.IsPreconditionFailedErr(CheckDeliveryClaim(&model.WebhookDelivery{ID: 1, Status: model.DeliveryDelivered}, claimed)))
+	assert.True(t, errors.IsPreconditionFailedErr(CheckDeliveryClaim(&model.WebhookDelivery{ID: 1, Status: model.DeliveryDead}, claimed)))
+}
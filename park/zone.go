@@ -0,0 +1,106 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// CheckZone verifies that a zone is well formed.
+func CheckZone(zone *model.Zone) error {
+	const op errors.Op = "park.CheckZone"
+
+	if strings.TrimSpace(zone.Name) == "" {
+		return errors.E(op, errors.KindBadRequest, "zone name is required")
+	}
+
+	seen := make(map[string]bool, len(zone.ForbiddenKinds))
+	for _, kind := range zone.ForbiddenKinds {
+		if !model.ValidKind(kind) {
+			return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid kind: %q", kind))
+		}
+
+		if seen[kind] {
+			return errors.E(op, errors.KindBadRequest, fmt.Sprintf("kind %s is forbidden twice", kind))
+		}
+
+		seen[kind] = true
+	}
+
+	return nil
+}
+
+// CheckZoneCages verifies that the occupants of cages are allowed in a zone,
+// either when the cages join it or when the zone forbids more kinds. Cages
+// must carry their occupants.
+func CheckZoneCages(zone *model.Zone, cages []*model.Cage) error {
+	const op errors.Op = "park.CheckZoneCages"
+
+	for _, cage := range cages {
+		for _, dinosaur := range cage.Dinosaurs {
+			if kind := model.SpeciesKind(dinosaur.Species); zone.Forbids(kind) {
+				return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+					"zone %s forbids %s dinosaurs and cage %s holds %s", zone.ID, kind, cage.ID, dinosaur.ID))
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckZoneDelete verifies that a zone holding cages, archived or not, can be
+// deleted. Cages must be moved out first.
+func CheckZoneDelete(zone *model.Zone, cages int) error {
+	const op errors.Op = "park.CheckZoneDelete"
+
+	if cages > 0 {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"zone %s holds %d cages and cannot be deleted", zone.ID, cages))
+	}
+
+	return nil
+}
+
+// forbids reports whether kind is among the forbidden kinds.
+func forbids(forbidden []string, kind string) bool {
+	for _, k := range forbidden {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SummarizeZone sets the aggregates of a zone from its cages, which must
+// carry their allocation. Archived cages are left out.
+func SummarizeZone(zone *model.Zone, cages []*model.Cage) {
+	zone.Cages, zone.Capacity, zone.Allocation = 0, 0, 0
+	zone.Power = make(map[model.PowerStatus]int)
+	for _, cage := range cages {
+		if cage.ZoneID != zone.ID || cage.Archived() {
+			continue
+		}
+
+		zone.Cages++
+		zone.Capacity += cage.MaxOccupancy()
+		zone.Allocation += cage.Allocation
+		zone.Power[cage.Status]++
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package park
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckZone(t *testing.T) {
+	assert.NoError(t, CheckZone(&model.Zone{Name: "North Sector"}))
+	assert.NoError(t, CheckZone(&model.Zone{Name: "Petting Zoo", ForbiddenKinds: []string{model.KindCarnivore}}))
+
+	assert.True(t, errors.Is(CheckZone(&model.Zone{Name: " "}), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckZone(&model.Zone{Name: "Petting Zoo", ForbiddenKinds: []string{"omnivore"}}), errors.KindBadRequest))
+	assert.True(t, errors.Is(CheckZone(&model.Zone{Name: "Petting Zoo", ForbiddenKinds: []string{model.KindCarnivore, model.KindCarnivore}}), errors.KindBadRequest))
+}
+
+func TestCheckPlacementZone(t *testing.T) {
+	cage := &model.Cage{ID: "cg_1", Capacity: 4, Status: model.PowerActive, ZoneID: "zn_1", ForbiddenKinds: []string{model.KindCarnivore}}
+	rex := &model.Dinosaur{ID: "din_1", Species: model.Tyrannosaurus}
+	stego := &model.Dinosaur{ID: "din_2", Species: model.Stegosaurus}
+
+	assert.NoError(t, CheckPlacement(cage, nil, stego))
+	assert.True(t, errors.IsUnprocessableErr(CheckPlacement(cage, nil, rex)))
+
+	// The rule applies to the first occupant only.
+	assert.NoError(t, CheckPlacement(cage, []*model.Dinosaur{rex}, &model.Dinosaur{ID: "din_3", Species: model.Tyrannosaurus}))
+	assert.NoError(t, CheckPlacement(&model.Cage{ID: "cg_2", Capacity: 4, Status: model.PowerActive}, nil, rex))
+}
+
+func TestCheckZoneCages(t *testing.T) {
+	zoo := &model.Zone{ID: "zn_1", Name: "Petting Zoo", ForbiddenKinds: []string{model.KindCarnivore}}
+	herbivores := &model.Cage{ID: "cg_1", Dinosaurs: []*model.Dinosaur{{ID: "din_1", Species: model.Triceratops}}}
+	carnivores := &model.Cage{ID: "cg_2", Dinosaurs: []*model.Dinosaur{{ID: "din_2", Species: model.Velociraptor}}}
+
+	assert.NoError(t, CheckZoneCages(zoo, nil))
+	assert.NoError(t, CheckZoneCages(zoo, []*model.Cage{herbivores, {ID: "cg_3"}}))
+	assert.NoError(t, CheckZoneCages(&model.Zone{ID: "zn_2"}, []*model.Cage{carnivores}))
+	assert.True(t, errors.IsUnprocessableErr(CheckZoneCages(zoo, []*model.Cage{herbivores, carnivores})))
+}
+
+func TestCheckZoneDelete(t *testing.T) {
+	zone := &model.Zone{ID: "zn_1"}
+	assert.NoError(t, CheckZoneDelete(zone, 0))
+	assert.True(t, errors.IsUnprocessableErr(CheckZoneDelete(zone, 2)))
+}
+
+func TestSummarizeZone(t *testing.T) {
+	now := time.Now()
+	zone := &model.Zone{ID: "zn_1"}
+	SummarizeZone(zone, []*model.Cage{
+		{ID: "cg_1", ZoneID: "zn_1", Capacity: 4, Allocation: 2, Status: model.PowerActive},
+		{ID: "cg_2", ZoneID: "zn_1", Capacity: 20, Allocation: 1, Status: model.PowerActive},
+		{ID: "cg_3", ZoneID: "zn_1", Capacity: 2, Status: model.PowerDown},
+		{ID: "cg_4", ZoneID: "zn_1", Capacity: 2, Status: model.PowerDown, DeletedAt: &now},
+		{ID: "cg_5", ZoneID: "zn_2", Capacity: 2, Allocation: 1, Status: model.PowerActive},
+	})
+
+	assert.Equal(t, 3, zone.Cages)
+	assert.Equal(t, 4+model.MaxCageCapacity+2, zone.Capacity)
+	assert.Equal(t, 3, zone.Allocation)
+	assert.Equal(t, map[model.PowerStatus]int{model.PowerActive: 2, model.PowerDown: 1}, zone.Power)
+}
@@ -25,9 +25,28 @@ const (
 	KindRateLimit      = http.StatusTooManyRequests
 	KindNotImplemented = http.StatusNotImplemented
 	KindRedirect       = http.StatusMovedPermanently
+	KindUnprocessable  = http.StatusUnprocessableEntity
+	KindLocked         = http.StatusLocked
+
+	KindPreconditionFailed = http.StatusPreconditionFailed
 )
 
 // IsNotFoundErr helper function for KindNotFound.
 func IsNotFoundErr(err error) bool {
 	return Kind(err) == KindNotFound
 }
+
+// IsUnprocessableErr helper function for KindUnprocessable.
+func IsUnprocessableErr(err error) bool {
+	return Kind(err) == KindUnprocessable
+}
+
+// IsPreconditionFailedErr helper function for KindPreconditionFailed.
+func IsPreconditionFailedErr(err error) bool {
+	return Kind(err) == KindPreconditionFailed
+}
+
+// IsLockedErr helper function for KindLocked.
+func IsLockedErr(err error) bool {
+	return Kind(err) == KindLocked
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *service) handleListAudit(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	since, err := queryTime(c, "since")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	until, err := queryTime(c, "until")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	entries, total, err := s.storage.ListAuditEntries(c.Request.Context(), storage.ListAuditParams{
+		Pagination: p,
+		EntityID:   model.ID(c.Query("entity_id")),
+		Actor:      c.Query("actor"),
+		Since:      since,
+		Until:      until,
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.AuditEntriesResource{Entries: entries})
+}
+
+// queryTime reads an optional RFC 3339 time query parameter.
+func queryTime(c *gin.Context, key string) (time.Time, error) {
+	const op errors.Op = "server.queryTime"
+
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid %s", key))
+	}
+
+	return t, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAudit(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	c1, c2 := createCage(t, h, 1), createCage(t, h, 1)
+	d := createDinosaur(t, h, "Rexy", model.Tyrannosaurus, c1.ID)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs/"+string(d.ID)+"/transfer", body{"cage_id": c2.ID}, ActorHeader, "muldoon")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/audit?entity_id="+string(d.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(TotalCountHeader))
+
+	var res model.AuditEntriesResource
+	decode(t, rec, &res)
+	require.Len(t, res.Entries, 2)
+	assert.Equal(t, "memory.TransferDinosaur", res.Entries[0].Op)
+	assert.Equal(t, "muldoon", res.Entries[0].Actor)
+	assert.NotEmpty(t, res.Entries[0].Before)
+	assert.NotEmpty(t, res.Entries[0].After)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/audit?actor=muldoon", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/audit?since=2100-01-01T00:00:00Z", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/audit?until=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
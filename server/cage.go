@@ -0,0 +1,295 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type createCageRequest struct {
+	Capacity  int               `json:"capacity"`
+	Status    model.PowerStatus `json:"status"`
+	ZoneID    model.ID          `json:"zone_id"`
+	CircuitID model.ID          `json:"circuit_id"`
+}
+
+type cageZoneRequest struct {
+	ZoneID model.ID `json:"zone_id"`
+}
+
+type cageCircuitRequest struct {
+	CircuitID model.ID `json:"circuit_id"`
+}
+
+type capacityRequest struct {
+	Capacity *int `json:"capacity" binding:"required"`
+}
+
+type powerRequest struct {
+	Status model.PowerStatus `json:"status" binding:"required"`
+	Reason string            `json:"reason"`
+}
+
+func (s *service) handleCreateCage(c *gin.Context) {
+	const op errors.Op = "server.handleCreateCage"
+
+	var req createCageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	id, err := s.nextID(model.NewCageID)
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	cage := &model.Cage{
+		ID:        id,
+		Capacity:  req.Capacity,
+		Status:    req.Status,
+		ZoneID:    req.ZoneID,
+		CircuitID: req.CircuitID,
+	}
+
+	if err := s.storage.CreateCage(c.Request.Context(), cage); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	setETag(c, cage.Version)
+	c.JSON(http.StatusCreated, &model.CagesResource{Cages: []*model.Cage{cage}})
+}
+
+func (s *service) handleGetCage(c *gin.Context) {
+	cage, err := s.storage.GetCage(c.Request.Context(), model.ID(c.Param("id")))
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	setETag(c, cage.Version)
+	if ifNoneMatch(c, cage.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.CagesResource{Cages: []*model.Cage{cage}})
+}
+
+func (s *service) handleListCages(c *gin.Context) {
+	s.listCages(c, storage.ListCageParams{
+		ZoneID:    model.ID(c.Query("zone_id")),
+		CircuitID: model.ID(c.Query("circuit_id")),
+	})
+}
+
+// listCages responds with the cages matching the query parameters within
+// the zone and circuit of params.
+func (s *service) listCages(c *gin.Context, params storage.ListCageParams) {
+	const op errors.Op = "server.listCages"
+
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	available, err := queryBool(c, "available")
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	withDinosaurs, err := queryBool(c, "dinosaurs")
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	includeArchived, err := queryBool(c, "include_archived")
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	params.Pagination = p
+	params.Status = model.PowerStatus(c.Query("status"))
+	params.Species = model.Species(c.Query("species"))
+	params.Kind = c.Query("kind")
+	params.Available = available
+	params.OrderBy = c.Query("order")
+	params.WithDinosaurs = withDinosaurs
+	params.IncludeArchived = includeArchived
+	cages, total, err := s.storage.ListCages(c.Request.Context(), params)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.CagesResource{Cages: cages})
+}
+
+func (s *service) handleUpdateCapacity(c *gin.Context) {
+	const op errors.Op = "server.handleUpdateCapacity"
+
+	var req capacityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	s.updateCage(c, func(old *model.Cage) (*model.Cage, error) {
+		old.Capacity = *req.Capacity
+		return old, nil
+	})
+}
+
+// handleUpdateCageZone moves a cage into a zone, or out of any zone when
+// zone_id is empty. Occupants must not be of a kind the zone forbids.
+func (s *service) handleUpdateCageZone(c *gin.Context) {
+	const op errors.Op = "server.handleUpdateCageZone"
+
+	var req cageZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	s.updateCage(c, func(old *model.Cage) (*model.Cage, error) {
+		old.ZoneID = req.ZoneID
+		return old, nil
+	})
+}
+
+// handleUpdateCageCircuit moves a cage onto a circuit, or off the grid when
+// circuit_id is empty. Cages cannot join or leave a cut circuit.
+func (s *service) handleUpdateCageCircuit(c *gin.Context) {
+	const op errors.Op = "server.handleUpdateCageCircuit"
+
+	var req cageCircuitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	s.updateCage(c, func(old *model.Cage) (*model.Cage, error) {
+		old.CircuitID = req.CircuitID
+		return old, nil
+	})
+}
+
+func (s *service) handleUpdatePower(c *gin.Context) {
+	const op errors.Op = "server.handleUpdatePower"
+
+	var req powerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	c.Request = c.Request.WithContext(storage.WithReason(c.Request.Context(), req.Reason))
+	s.updateCage(c, func(old *model.Cage) (*model.Cage, error) {
+		old.Status = req.Status
+		return old, nil
+	})
+}
+
+// updateCage applies updater to the cage identified in the path and responds
+// with the updated cage. The update is rejected with 412 Precondition Failed
+// unless the cage matches the If-Match header.
+func (s *service) updateCage(c *gin.Context, updater storage.CageUpdater) {
+	ctx := c.Request.Context()
+	id := model.ID(c.Param("id"))
+
+	versions, err := ifMatch(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	err = s.storage.UpdateCage(ctx, id, func(old *model.Cage) (*model.Cage, error) {
+		old.Version = matchVersion(old.Version, versions)
+		return updater(old)
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	cage, err := s.storage.GetCage(ctx, id)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	setETag(c, cage.Version)
+	c.JSON(http.StatusOK, &model.CagesResource{Cages: []*model.Cage{cage}})
+}
+
+// handleArchiveCage decommissions an empty cage. The optional "reason" query
+// parameter records why.
+func (s *service) handleArchiveCage(c *gin.Context) {
+	if err := s.storage.ArchiveCage(c.Request.Context(), model.ID(c.Param("id")), c.Query("reason")); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *service) handleRestoreCage(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := model.ID(c.Param("id"))
+
+	if err := s.storage.RestoreCage(ctx, id); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	cage, err := s.storage.GetCage(ctx, id)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	setETag(c, cage.Version)
+	c.JSON(http.StatusOK, &model.CagesResource{Cages: []*model.Cage{cage}})
+}
+
+func (s *service) handleListPowerEvents(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	events, total, err := s.storage.ListPowerEvents(c.Request.Context(), model.ID(c.Param("id")), p)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.PowerEventsResource{Events: events})
+}
@@ -0,0 +1,232 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createCage(t *testing.T, h http.Handler, capacity int) *model.Cage {
+	t.Helper()
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/cages", body{"capacity": capacity})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res model.CagesResource
+	decode(t, rec, &res)
+	require.Len(t, res.Cages, 1)
+
+	return res.Cages[0]
+}
+
+func TestCreateCage(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+
+	cage := createCage(t, h, 4)
+	assert.True(t, strings.HasPrefix(string(cage.ID), "cg_"))
+	assert.Equal(t, 4, cage.Capacity)
+	assert.Equal(t, model.PowerActive, cage.Status)
+	assert.NotEqual(t, cage.ID, createCage(t, h, 4).ID)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/cages", "capacity")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetCage(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 2)
+
+	rec := doRequest(t, h, http.MethodGet, Prefix+"/cages/"+string(cage.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res model.CagesResource
+	decode(t, rec, &res)
+	assert.Equal(t, cage.ID, res.Cages[0].ID)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages/foo", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	var e HTTPErrorResponse
+	decode(t, rec, &e)
+	assert.Equal(t, http.StatusNotFound, e.Code)
+}
+
+func TestListCages(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	small := createCage(t, h, 2)
+	large := createCage(t, h, 3)
+	down := createCage(t, h, 5)
+	createDinosaur(t, h, "Cera", model.Triceratops, large.ID)
+
+	rec := doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(down.ID)+"/power", body{"status": model.PowerDown})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages?status=ACTIVE&available=true&order=-capacity&per_page=1&page=1", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(TotalCountHeader))
+
+	var res model.CagesResource
+	decode(t, rec, &res)
+	require.Len(t, res.Cages, 1)
+	assert.Equal(t, small.ID, res.Cages[0].ID)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages?species=triceratops&kind=herbivores&dinosaurs=1", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	decode(t, rec, &res)
+	require.Len(t, res.Cages, 1)
+	assert.Equal(t, large.ID, res.Cages[0].ID)
+	assert.Equal(t, 1, res.Cages[0].Allocation)
+	assert.Len(t, res.Cages[0].Dinosaurs, 1)
+
+	for _, query := range []string{"available=maybe", "per_page=0", "page=-1", "order=name", "status=ON", "kind=omnivore"} {
+		rec = doRequest(t, h, http.MethodGet, Prefix+"/cages?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestUpdateCapacity(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 2)
+
+	rec := doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(cage.ID)+"/capacity", body{"capacity": 5})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res model.CagesResource
+	decode(t, rec, &res)
+	assert.Equal(t, 5, res.Cages[0].Capacity)
+
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(cage.ID)+"/capacity", body{})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(cage.ID)+"/capacity", body{"capacity": -1})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/foo/capacity", body{"capacity": 1})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestUpdatePower(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 2)
+	path := Prefix + "/cages/" + string(cage.ID) + "/power"
+
+	rec := doRequest(t, h, http.MethodPut, path, body{"status": model.PowerDown, "reason": "fence repair"}, ActorHeader, "muldoon")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res model.CagesResource
+	decode(t, rec, &res)
+	assert.Equal(t, model.PowerDown, res.Cages[0].Status)
+
+	rec = doRequest(t, h, http.MethodPut, path, body{"status": model.PowerActive})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, path, body{"status": "OFF"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(TotalCountHeader))
+
+	var events model.PowerEventsResource
+	decode(t, rec, &events)
+	assert.Equal(t, model.PowerDown, events.Events[0].To)
+	assert.Equal(t, "muldoon", events.Events[0].Actor)
+	assert.Equal(t, "fence repair", events.Events[0].Reason)
+}
+
+func TestCageETag(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 2)
+	path := Prefix + "/cages/" + string(cage.ID)
+
+	rec := doRequest(t, h, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get(ETagHeader))
+
+	rec = doRequest(t, h, http.MethodGet, path, nil, IfNoneMatchHeader, `"1"`)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPut, path+"/capacity", body{"capacity": 5}, IfMatchHeader, `"1"`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, `"2"`, rec.Header().Get(ETagHeader))
+
+	// The second operator still holds version 1.
+	rec = doRequest(t, h, http.MethodPut, path+"/capacity", body{"capacity": 3}, IfMatchHeader, `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, path+"/capacity", body{"capacity": 3}, IfMatchHeader, `"1", "2"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, path+"/power", body{"status": model.PowerDown}, IfMatchHeader, "3")
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, path+"/power", body{"status": model.PowerDown}, IfMatchHeader, "*")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path, nil, IfNoneMatchHeader, `"1"`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res model.CagesResource
+	decode(t, rec, &res)
+	assert.Equal(t, 4, res.Cages[0].Version)
+}
+
+func TestArchiveCage(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 1)
+	path := Prefix + "/cages/" + string(cage.ID)
+	d := createDinosaur(t, h, "Blue", model.Velociraptor, cage.ID)
+
+	rec := doRequest(t, h, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodDelete, Prefix+"/dinosaurs/"+string(d.ID), nil)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRequest(t, h, http.MethodDelete, path+"?reason=decommissioned", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages", nil)
+	assert.Equal(t, "0", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages?include_archived=1", nil)
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	// An archived dinosaur can only come back to a cage in service.
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs/"+string(d.ID)+"/restore", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, path+"/capacity", body{"capacity": 2})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/restore", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res model.CagesResource
+	decode(t, rec, &res)
+	assert.False(t, res.Cages[0].Archived())
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs/"+string(d.ID)+"/restore", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,166 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type circuitRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type outageRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (s *service) handleCreateCircuit(c *gin.Context) {
+	const op errors.Op = "server.handleCreateCircuit"
+
+	var req circuitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	id, err := s.nextID(model.NewCircuitID)
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	circuit := &model.Circuit{ID: id, Name: req.Name}
+	if err := s.storage.CreateCircuit(c.Request.Context(), circuit); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &model.CircuitsResource{Circuits: []*model.Circuit{circuit}})
+}
+
+func (s *service) handleListCircuits(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	circuits, total, err := s.storage.ListCircuits(c.Request.Context(), storage.ListCircuitParams{Pagination: p})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.CircuitsResource{Circuits: circuits})
+}
+
+func (s *service) handleGetCircuit(c *gin.Context) {
+	circuit, err := s.storage.GetCircuit(c.Request.Context(), model.ID(c.Param("id")))
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.CircuitsResource{Circuits: []*model.Circuit{circuit}})
+}
+
+func (s *service) handleDeleteCircuit(c *gin.Context) {
+	if err := s.storage.DeleteCircuit(c.Request.Context(), model.ID(c.Param("id"))); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *service) handleListCircuitCages(c *gin.Context) {
+	id := model.ID(c.Param("id"))
+	if _, err := s.storage.GetCircuit(c.Request.Context(), id); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.listCages(c, storage.ListCageParams{CircuitID: id})
+}
+
+// handleGetCircuitImpact tells what cutting a circuit would do: the cages
+// going DOWN and the dinosaurs behind their fences. Nothing is changed.
+func (s *service) handleGetCircuitImpact(c *gin.Context) {
+	impact, err := s.storage.GetCircuitImpact(c.Request.Context(), model.ID(c.Param("id")))
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, impact)
+}
+
+func (s *service) handleListCircuitOutages(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	outages, total, err := s.storage.ListCircuitOutages(c.Request.Context(), model.ID(c.Param("id")), p)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.CircuitOutagesResource{Outages: outages})
+}
+
+// handleCutCircuit cuts a circuit, putting its cages DOWN and opening an
+// incident for each occupied one.
+func (s *service) handleCutCircuit(c *gin.Context) {
+	s.outage(c, s.storage.CutCircuit)
+}
+
+// handleRestoreCircuit restores a cut circuit and the power states of the
+// cages its outage put DOWN.
+func (s *service) handleRestoreCircuit(c *gin.Context) {
+	s.outage(c, s.storage.RestoreCircuit)
+}
+
+// outage applies fn to the circuit identified in the path with the reason
+// of the request, and responds with the outage.
+func (s *service) outage(c *gin.Context, fn func(ctx context.Context, id model.ID) (*model.CircuitOutage, error)) {
+	const op errors.Op = "server.outage"
+
+	var req outageRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	ctx := storage.WithReason(c.Request.Context(), req.Reason)
+	outage, err := fn(ctx, model.ID(c.Param("id")))
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.CircuitOutagesResource{Outages: []*model.CircuitOutage{outage}})
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuits(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/circuits", body{"name": "Grid A"})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res model.CircuitsResource
+	decode(t, rec, &res)
+	require.Len(t, res.Circuits, 1)
+	circuit := res.Circuits[0]
+	assert.Equal(t, model.CircuitLive, circuit.Status)
+
+	path := Prefix + "/circuits/" + string(circuit.ID)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/circuits", body{"name": "Grid A"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/circuits", body{})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/cages", body{"capacity": 2, "circuit_id": circuit.ID})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var cages model.CagesResource
+	decode(t, rec, &cages)
+	require.Len(t, cages.Cages, 1)
+	paddock := cages.Cages[0]
+	assert.Equal(t, circuit.ID, paddock.CircuitID)
+	blue := createDinosaur(t, h, "Blue", model.Velociraptor, paddock.ID)
+
+	spare := createCage(t, h, 2)
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(spare.ID)+"/circuit", body{"circuit_id": circuit.ID})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(spare.ID)+"/circuit", body{"circuit_id": "ct_foo"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	decode(t, rec, &res)
+	require.Len(t, res.Circuits, 1)
+	assert.Equal(t, 2, res.Circuits[0].Cages)
+
+	rec = doRequest(t, h, http.MethodGet, path+"/cages", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "2", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages?circuit_id="+string(circuit.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "2", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/circuits", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	// What if the circuit fails?
+	rec = doRequest(t, h, http.MethodGet, path+"/impact", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var impact model.CircuitImpact
+	decode(t, rec, &impact)
+	assert.Len(t, impact.Cages, 2)
+	if assert.Len(t, impact.Dinosaurs, 1) {
+		assert.Equal(t, blue.ID, impact.Dinosaurs[0].ID)
+	}
+	assert.Equal(t, 1, impact.Alarms)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/circuits/ct_foo/impact", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/cut", body{"reason": "storm"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var outages model.CircuitOutagesResource
+	decode(t, rec, &outages)
+	require.Len(t, outages.Outages, 1)
+	assert.Equal(t, "storm", outages.Outages[0].Reason)
+	assert.Len(t, outages.Outages[0].Cages, 2)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages/"+string(paddock.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	decode(t, rec, &cages)
+	assert.Equal(t, model.PowerDown, cages.Cages[0].Status)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/incidents?cage_id="+string(paddock.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(spare.ID)+"/power", body{"status": model.PowerMaintenance})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/cut", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/restore", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	decode(t, rec, &outages)
+	require.Len(t, outages.Outages, 1)
+	assert.NotNil(t, outages.Outages[0].RestoredAt)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages/"+string(paddock.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	decode(t, rec, &cages)
+	assert.Equal(t, model.PowerActive, cages.Cages[0].Status)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/restore", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path+"/outages", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	for _, cage := range []*model.Cage{paddock, spare} {
+		rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(cage.ID)+"/circuit", body{"circuit_id": ""})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type createDinosaurRequest struct {
+	Name    string        `json:"name" binding:"required"`
+	Species model.Species `json:"species" binding:"required"`
+	CageID  model.ID      `json:"cage_id" binding:"required"`
+}
+
+func (s *service) handleCreateDinosaur(c *gin.Context) {
+	const op errors.Op = "server.handleCreateDinosaur"
+
+	var req createDinosaurRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	id, err := s.nextID(model.NewDinosaurID)
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	dinosaur := &model.Dinosaur{
+		ID:      id,
+		Name:    req.Name,
+		Species: req.Species,
+		CageID:  req.CageID,
+	}
+
+	if err := s.storage.CreateDinosaur(c.Request.Context(), dinosaur); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	setETag(c, dinosaur.Version)
+	c.JSON(http.StatusCreated, &model.DinosaursResource{Dinosaurs: []*model.Dinosaur{dinosaur}})
+}
+
+func (s *service) handleGetDinosaur(c *gin.Context) {
+	dinosaur, err := s.storage.GetDinosaur(c.Request.Context(), model.ID(c.Param("id")))
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	setETag(c, dinosaur.Version)
+	if ifNoneMatch(c, dinosaur.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.DinosaursResource{Dinosaurs: []*model.Dinosaur{dinosaur}})
+}
+
+func (s *service) handleListDinosaurs(c *gin.Context) {
+	s.listDinosaurs(c, storage.ListDinosaurParams{
+		Species: model.Species(c.Query("species")),
+		CageID:  model.ID(c.Query("cage_id")),
+		Name:    c.Query("name"),
+	})
+}
+
+func (s *service) handleListCageDinosaurs(c *gin.Context) {
+	id := model.ID(c.Param("id"))
+	if _, err := s.storage.GetCage(c.Request.Context(), id); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.listDinosaurs(c, storage.ListDinosaurParams{
+		Species: model.Species(c.Query("species")),
+		CageID:  id,
+	})
+}
+
+func (s *service) listDinosaurs(c *gin.Context, params storage.ListDinosaurParams) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	includeArchived, err := queryBool(c, "include_archived")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	params.Pagination = p
+	params.IncludeArchived = includeArchived
+	dinosaurs, total, err := s.storage.ListDinosaurs(c.Request.Context(), params)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.DinosaursResource{Dinosaurs: dinosaurs})
+}
+
+// handleArchiveDinosaur archives a dinosaur, keeping its history. The
+// optional "reason" query parameter records why it left the park.
+func (s *service) handleArchiveDinosaur(c *gin.Context) {
+	if err := s.storage.ArchiveDinosaur(c.Request.Context(), model.ID(c.Param("id")), c.Query("reason")); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *service) handleRestoreDinosaur(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := model.ID(c.Param("id"))
+
+	if err := s.storage.RestoreDinosaur(ctx, id); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	dinosaur, err := s.storage.GetDinosaur(ctx, id)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	setETag(c, dinosaur.Version)
+	c.JSON(http.StatusOK, &model.DinosaursResource{Dinosaurs: []*model.Dinosaur{dinosaur}})
+}
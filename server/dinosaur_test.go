@@ -0,0 +1,169 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createDinosaur(t *testing.T, h http.Handler, name string, species model.Species, cageID model.ID) *model.Dinosaur {
+	t.Helper()
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs", body{
+		"name":    name,
+		"species": species,
+		"cage_id": cageID,
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res model.DinosaursResource
+	decode(t, rec, &res)
+	require.Len(t, res.Dinosaurs, 1)
+
+	return res.Dinosaurs[0]
+}
+
+func TestCreateDinosaur(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 2)
+
+	d := createDinosaur(t, h, "Blue", model.Velociraptor, cage.ID)
+	assert.True(t, strings.HasPrefix(string(d.ID), "din_"))
+	assert.Equal(t, "Blue", d.Name)
+	assert.Equal(t, cage.ID, d.CageID)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs", body{"name": "Charlie", "species": model.Velociraptor})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs", body{
+		"name":    "Cera",
+		"species": model.Triceratops,
+		"cage_id": cage.ID,
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestGetDinosaur(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	d := createDinosaur(t, h, "Rexy", model.Tyrannosaurus, createCage(t, h, 1).ID)
+
+	rec := doRequest(t, h, http.MethodGet, Prefix+"/dinosaurs/"+string(d.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res model.DinosaursResource
+	decode(t, rec, &res)
+	assert.Equal(t, d.ID, res.Dinosaurs[0].ID)
+	assert.Equal(t, `"1"`, rec.Header().Get(ETagHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/dinosaurs/"+string(d.ID), nil, IfNoneMatchHeader, `W/"1"`)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/dinosaurs/foo", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestListDinosaurs(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	c1, c2 := createCage(t, h, 3), createCage(t, h, 3)
+	createDinosaur(t, h, "Cera", model.Triceratops, c1.ID)
+	createDinosaur(t, h, "Spike", model.Stegosaurus, c1.ID)
+	createDinosaur(t, h, "Littlefoot", model.Brachiosaurus, c2.ID)
+
+	rec := doRequest(t, h, http.MethodGet, Prefix+"/dinosaurs?species=triceratops", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	var res model.DinosaursResource
+	decode(t, rec, &res)
+	assert.Equal(t, "Cera", res.Dinosaurs[0].Name)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages/"+string(c1.ID)+"/dinosaurs", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages/foo/dinosaurs", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestArchiveDinosaur(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 1)
+	d := createDinosaur(t, h, "Rexy", model.Tyrannosaurus, cage.ID)
+	path := Prefix + "/dinosaurs/" + string(d.ID)
+
+	rec := doRequest(t, h, http.MethodDelete, path+"?reason=deceased", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res model.DinosaursResource
+	decode(t, rec, &res)
+	assert.NotNil(t, res.Dinosaurs[0].DeletedAt)
+	assert.Equal(t, "deceased", res.Dinosaurs[0].ArchivedReason)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/dinosaurs", nil)
+	assert.Equal(t, "0", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/dinosaurs?include_archived=true", nil)
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/restore", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var restored model.DinosaursResource
+	decode(t, rec, &restored)
+	assert.Nil(t, restored.Dinosaurs[0].DeletedAt)
+	assert.Empty(t, restored.Dinosaurs[0].ArchivedReason)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/restore", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodDelete, Prefix+"/dinosaurs/foo", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTransferDinosaur(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	c1, c2 := createCage(t, h, 1), createCage(t, h, 1)
+	d := createDinosaur(t, h, "Rexy", model.Tyrannosaurus, c1.ID)
+	path := Prefix + "/dinosaurs/" + string(d.ID) + "/transfer"
+
+	rec := doRequest(t, h, http.MethodPost, path, body{"cage_id": c2.ID, "reason": "tour"}, ActorHeader, "muldoon")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res model.TransfersResource
+	decode(t, rec, &res)
+	assert.Equal(t, c1.ID, res.Transfers[0].FromCageID)
+	assert.Equal(t, c2.ID, res.Transfers[0].ToCageID)
+	assert.Equal(t, "muldoon", res.Transfers[0].Actor)
+
+	createDinosaur(t, h, "Blue", model.Velociraptor, c1.ID)
+	rec = doRequest(t, h, http.MethodPost, path, body{"cage_id": c1.ID})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, path, body{})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// Entity tags are the quoted version of a cage or dinosaur.
+const (
+	ETagHeader        = "ETag"
+	IfMatchHeader     = "If-Match"
+	IfNoneMatchHeader = "If-None-Match"
+)
+
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+func setETag(c *gin.Context, version int) {
+	c.Header(ETagHeader, etag(version))
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches version, in
+// which case a GET request is answered with 304 Not Modified.
+func ifNoneMatch(c *gin.Context, version int) bool {
+	header := c.GetHeader(IfNoneMatchHeader)
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch returns the versions listed by the If-Match header, or nil if the
+// request accepts any version.
+func ifMatch(c *gin.Context) ([]int, error) {
+	const op errors.Op = "server.ifMatch"
+
+	header := strings.TrimSpace(c.GetHeader(IfMatchHeader))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	var versions []int
+	for _, tag := range strings.Split(header, ",") {
+		unquoted, err := strconv.Unquote(strings.TrimSpace(tag))
+		if err != nil {
+			return nil, errors.E(op, errors.KindPreconditionFailed, fmt.Sprintf("invalid entity tag: %s", tag))
+		}
+
+		version, err := strconv.Atoi(unquoted)
+		if err != nil {
+			return nil, errors.E(op, errors.KindPreconditionFailed, fmt.Sprintf("invalid entity tag: %s", tag))
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+// matchVersion returns current if versions accepts it, and otherwise the
+// first version requested. Updaters keep the returned version so that
+// storage rejects the write when the requested version is stale.
+func matchVersion(current int, versions []int) int {
+	if len(versions) == 0 {
+		return current
+	}
+
+	for _, version := range versions {
+		if version == current {
+			return current
+		}
+	}
+
+	return versions[0]
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// LastEventIDHeader carries the ID of the last event received by a
+	// client reconnecting to the event stream.
+	LastEventIDHeader = "Last-Event-ID"
+
+	// heartbeatInterval keeps idle streams open through proxies.
+	heartbeatInterval = 15 * time.Second
+
+	// retryDelay is how long clients wait before reconnecting, for instance
+	// once the server's write timeout ends a stream.
+	retryDelay = time.Second
+)
+
+// handleEvents streams the events of the park as Server-Sent Events. Clients
+// resuming with a Last-Event-ID first receive the events they missed from the
+// outbox, then live events from the relay.
+func (s *service) handleEvents(c *gin.Context) {
+	const op errors.Op = "server.handleEvents"
+
+	types, err := queryEventTypes(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	last, resume, err := lastEventID(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	// Subscribe before reading the outbox so that no event falls in between.
+	sub := s.relay.Subscribe(types...)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", retryDelay.Milliseconds()); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	for resume {
+		events, err := s.storage.ListEvents(ctx, storage.ListEventParams{
+			AfterID: last,
+			Types:   types,
+			Limit:   storage.PaginationLimit,
+		})
+		if err != nil {
+			s.logger.Errorf("%s: error while reading the outbox: %v", op, err)
+			return
+		}
+
+		for _, event := range events {
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+			last = event.ID
+		}
+
+		resume = len(events) == storage.PaginationLimit
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+
+			// Already sent from the outbox.
+			if event.ID <= last {
+				continue
+			}
+
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+			last = event.ID
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, event *model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+		return err
+	}
+
+	c.Writer.Flush()
+	return nil
+}
+
+// queryEventTypes reads the "type" query parameter, which may be repeated or
+// hold a comma-separated list.
+func queryEventTypes(c *gin.Context) ([]string, error) {
+	const op errors.Op = "server.queryEventTypes"
+
+	var types []string
+	for _, value := range c.QueryArray("type") {
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t == "" {
+				continue
+			}
+
+			if !model.ValidEventType(t) {
+				return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid event type: %q", t))
+			}
+
+			types = append(types, t)
+		}
+	}
+
+	return types, nil
+}
+
+// lastEventID reads the LastEventIDHeader, or the "last_event_id" query
+// parameter for clients that cannot set headers, and reports whether either
+// was given.
+func lastEventID(c *gin.Context) (int64, bool, error) {
+	const op errors.Op = "server.lastEventID"
+
+	value := c.GetHeader(LastEventIDHeader)
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+
+	if value == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, errors.E(op, errors.KindBadRequest, "invalid last event ID")
+	}
+
+	return id, true, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvent reads the next event of a Server-Sent Events stream, skipping
+// comments and fields other than data.
+func readEvent(t *testing.T, scanner *bufio.Scanner) *model.Event {
+	t.Helper()
+
+	var event model.Event
+	for scanner.Scan() {
+		line := scanner.Text()
+		if data := strings.TrimPrefix(line, "data: "); data != line {
+			require.NoError(t, json.Unmarshal([]byte(data), &event))
+		}
+
+		if line == "" && event.ID != 0 {
+			return &event
+		}
+	}
+
+	t.Fatalf("stream ended: %v", scanner.Err())
+	return nil
+}
+
+func TestEvents(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	cage := createCage(t, h, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+Prefix+"/events?type=cage.created,dinosaur.placed", nil)
+	require.NoError(t, err)
+	req.Header.Set(LastEventIDHeader, "0")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	scanner := bufio.NewScanner(res.Body)
+	event := readEvent(t, scanner)
+	assert.Equal(t, model.EventCageCreated, event.Type)
+	assert.Equal(t, cage.ID, event.EntityID)
+
+	rec := doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(cage.ID)+"/power", body{"status": model.PowerMaintenance})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(cage.ID)+"/power", body{"status": model.PowerActive})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	d := createDinosaur(t, h, "Rexy", model.Tyrannosaurus, cage.ID)
+
+	event = readEvent(t, scanner)
+	assert.Equal(t, model.EventDinosaurPlaced, event.Type)
+	assert.Equal(t, d.ID, event.EntityID)
+
+	var placed model.Dinosaur
+	require.NoError(t, json.Unmarshal(event.Payload, &placed))
+	assert.Equal(t, "Rexy", placed.Name)
+}
+
+func TestEventsInvalid(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+
+	rec := doRequest(t, h, http.MethodGet, Prefix+"/events?type=dinosaur.escaped", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/events", nil, LastEventIDHeader, "last")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
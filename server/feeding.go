@@ -0,0 +1,133 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type feedingScheduleRequest struct {
+	Food          string  `json:"food" binding:"required"`
+	IntervalHours int     `json:"interval_hours" binding:"required"`
+	RationKg      float64 `json:"ration_kg"`
+}
+
+type feedingRequest struct {
+	DinosaurIDs []model.ID `json:"dinosaur_ids"`
+	Food        string     `json:"food"`
+	AmountKg    float64    `json:"amount_kg"`
+	FedAt       *time.Time `json:"fed_at"`
+}
+
+func (s *service) handleGetFeedingSchedule(c *gin.Context) {
+	schedule, err := s.storage.GetFeedingSchedule(c.Request.Context(), model.ID(c.Param("id")))
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.FeedingScheduleResource{Schedule: schedule})
+}
+
+func (s *service) handleSetFeedingSchedule(c *gin.Context) {
+	const op errors.Op = "server.handleSetFeedingSchedule"
+
+	var req feedingScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	schedule := &model.FeedingSchedule{
+		CageID:        model.ID(c.Param("id")),
+		Food:          req.Food,
+		IntervalHours: req.IntervalHours,
+		RationKg:      req.RationKg,
+	}
+
+	if err := s.storage.SetFeedingSchedule(c.Request.Context(), schedule); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.FeedingScheduleResource{Schedule: schedule})
+}
+
+// handleRecordFeeding records that a keeper fed the dinosaurs of a cage,
+// all of them unless dinosaur_ids lists some.
+func (s *service) handleRecordFeeding(c *gin.Context) {
+	const op errors.Op = "server.handleRecordFeeding"
+
+	var req feedingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	feeding := &model.Feeding{
+		CageID:   model.ID(c.Param("id")),
+		Food:     req.Food,
+		AmountKg: req.AmountKg,
+		FedAt:    req.FedAt,
+	}
+
+	feedings, err := s.storage.RecordFeeding(c.Request.Context(), feeding, req.DinosaurIDs)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &model.FeedingsResource{Feedings: feedings})
+}
+
+func (s *service) handleListFeedings(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	feedings, total, err := s.storage.ListFeedings(c.Request.Context(), storage.ListFeedingParams{
+		Pagination: p,
+		CageID:     model.ID(c.Query("cage_id")),
+		DinosaurID: model.ID(c.Query("dinosaur_id")),
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.FeedingsResource{Feedings: feedings})
+}
+
+// handleListOverdueFeedings lists the cages whose occupants were not fed on
+// schedule, or only the one given by the "cage_id" query parameter.
+func (s *service) handleListOverdueFeedings(c *gin.Context) {
+	overdue, err := s.storage.ListOverdueFeedings(c.Request.Context(), model.ID(c.Query("cage_id")))
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.OverdueFeedingsResource{Overdue: overdue})
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedings(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 4)
+	rexy := createDinosaur(t, h, "Rexy", model.Tyrannosaurus, cage.ID)
+	path := Prefix + "/cages/" + string(cage.ID)
+
+	rec := doRequest(t, h, http.MethodGet, path+"/feeding-schedule", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var schedule model.FeedingScheduleResource
+	decode(t, rec, &schedule)
+	assert.True(t, schedule.Schedule.Default)
+	assert.Equal(t, "meat", schedule.Schedule.Food)
+
+	rec = doRequest(t, h, http.MethodPut, path+"/feeding-schedule", body{"food": "goats", "interval_hours": 12, "ration_kg": 60})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPut, path+"/feeding-schedule", body{"food": "goats"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	fedAt := time.Now().UTC().Add(-13 * time.Hour).Truncate(time.Second)
+	rec = doRequest(t, h, http.MethodPost, path+"/feedings", body{"fed_at": fedAt})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var feedings model.FeedingsResource
+	decode(t, rec, &feedings)
+	require.Len(t, feedings.Feedings, 1)
+	assert.Equal(t, "goats", feedings.Feedings[0].Food)
+	assert.Equal(t, 60.0, feedings.Feedings[0].AmountKg)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/feedings/overdue", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var overdue model.OverdueFeedingsResource
+	decode(t, rec, &overdue)
+	require.Len(t, overdue.Overdue, 1)
+	assert.Equal(t, []model.ID{rexy.ID}, overdue.Overdue[0].Dinosaurs)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/feedings", body{"dinosaur_ids": []model.ID{rexy.ID}})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/feedings/overdue?cage_id="+string(cage.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	overdue = model.OverdueFeedingsResource{}
+	decode(t, rec, &overdue)
+	assert.Empty(t, overdue.Overdue)
+
+	rec = doRequest(t, h, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var cages model.CagesResource
+	decode(t, rec, &cages)
+	require.Len(t, cages.Cages[0].Dinosaurs, 1)
+	assert.NotNil(t, cages.Cages[0].Dinosaurs[0].LastFedAt)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/feedings?dinosaur_id="+string(rexy.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodPost, path+"/feedings", body{"dinosaur_ids": []string{"foo"}})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages/foo/feeding-schedule", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
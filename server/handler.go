@@ -19,12 +19,14 @@ import (
 	"net/http"
 	"regexp"
 	"runtime"
-	"strings"
+	"strconv"
 	"time"
 
+	"github.com/danielnegri/jurassic-park-go/model"
 	"github.com/danielnegri/jurassic-park-go/pkg/app"
 	"github.com/danielnegri/jurassic-park-go/pkg/errors"
 	"github.com/danielnegri/jurassic-park-go/pkg/version"
+	"github.com/danielnegri/jurassic-park-go/storage"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -56,6 +58,102 @@ func (s *service) newHandler() http.Handler {
 	router.GET("/health", s.handleHealth)
 	router.GET("/ping", s.handlePing)
 
+	api := router.Group(Prefix)
+	api.Use(ActorMiddleware())
+
+	cages := api.Group("/cages")
+	cages.POST("", s.handleCreateCage)
+	cages.GET("", s.handleListCages)
+	cages.GET("/:id", s.handleGetCage)
+	cages.DELETE("/:id", s.handleArchiveCage)
+	cages.POST("/:id/restore", s.handleRestoreCage)
+	cages.PUT("/:id/capacity", s.handleUpdateCapacity)
+	cages.PUT("/:id/zone", s.handleUpdateCageZone)
+	cages.PUT("/:id/circuit", s.handleUpdateCageCircuit)
+	cages.GET("/:id/dinosaurs", s.handleListCageDinosaurs)
+	cages.GET("/:id/power", s.handleListPowerEvents)
+	cages.PUT("/:id/power", s.handleUpdatePower)
+	cages.GET("/:id/feeding-schedule", s.handleGetFeedingSchedule)
+	cages.PUT("/:id/feeding-schedule", s.handleSetFeedingSchedule)
+	cages.POST("/:id/feedings", s.handleRecordFeeding)
+	cages.POST("/:id/maintenance", s.handleCreateMaintenanceWindow)
+	cages.GET("/:id/maintenance", s.handleListCageMaintenance)
+	cages.DELETE("/:id/maintenance/:window_id", s.handleCancelMaintenanceWindow)
+
+	zones := api.Group("/zones")
+	zones.POST("", s.handleCreateZone)
+	zones.GET("", s.handleListZones)
+	zones.GET("/:id", s.handleGetZone)
+	zones.PUT("/:id", s.handleUpdateZone)
+	zones.DELETE("/:id", s.handleDeleteZone)
+	zones.GET("/:id/cages", s.handleListZoneCages)
+
+	circuits := api.Group("/circuits")
+	circuits.POST("", s.handleCreateCircuit)
+	circuits.GET("", s.handleListCircuits)
+	circuits.GET("/:id", s.handleGetCircuit)
+	circuits.DELETE("/:id", s.handleDeleteCircuit)
+	circuits.GET("/:id/cages", s.handleListCircuitCages)
+	circuits.GET("/:id/impact", s.handleGetCircuitImpact)
+	circuits.GET("/:id/outages", s.handleListCircuitOutages)
+	circuits.POST("/:id/cut", s.handleCutCircuit)
+	circuits.POST("/:id/restore", s.handleRestoreCircuit)
+
+	dinosaurs := api.Group("/dinosaurs")
+	dinosaurs.POST("", s.handleCreateDinosaur)
+	dinosaurs.GET("", s.handleListDinosaurs)
+	dinosaurs.GET("/:id", s.handleGetDinosaur)
+	dinosaurs.DELETE("/:id", s.handleArchiveDinosaur)
+	dinosaurs.POST("/:id/restore", s.handleRestoreDinosaur)
+	dinosaurs.POST("/:id/transfer", s.handleTransferDinosaur)
+	dinosaurs.PUT("/:id/medical-hold", s.handleSetMedicalHold)
+	dinosaurs.POST("/:id/visits", s.handleCreateVetVisit)
+	dinosaurs.GET("/:id/visits", s.handleListVetVisits)
+	dinosaurs.POST("/:id/biometrics", s.handleRecordBiometrics)
+	dinosaurs.GET("/:id/biometrics", s.handleListBiometrics)
+
+	species := api.Group("/species")
+	species.GET("", s.handleListSpecies)
+	species.POST("", s.handleCreateSpecies)
+	species.GET("/:name", s.handleGetSpecies)
+	species.PUT("/:name", s.handleUpdateSpecies)
+	species.DELETE("/:name", s.handleDeleteSpecies)
+
+	incidents := api.Group("/incidents")
+	incidents.POST("", s.handleCreateIncident)
+	incidents.GET("", s.handleListIncidents)
+	incidents.GET("/:id", s.handleGetIncident)
+	incidents.POST("/:id/acknowledge", s.handleIncidentStatus(model.IncidentAcknowledged))
+	incidents.POST("/:id/mitigate", s.handleIncidentStatus(model.IncidentMitigated))
+	incidents.POST("/:id/close", s.handleIncidentStatus(model.IncidentClosed))
+	incidents.PUT("/:id/severity", s.handleUpdateIncidentSeverity)
+	incidents.POST("/:id/notes", s.handleAddIncidentNote)
+
+	webhooks := api.Group("/webhooks")
+	webhooks.POST("", s.handleCreateWebhook)
+	webhooks.GET("", s.handleListWebhooks)
+	webhooks.GET("/:id", s.handleGetWebhook)
+	webhooks.PUT("/:id", s.handleUpdateWebhook)
+	webhooks.DELETE("/:id", s.handleDeleteWebhook)
+	webhooks.GET("/:id/deliveries", s.handleListWebhookDeliveries)
+	webhooks.GET("/:id/attempts", s.handleListWebhookAttempts)
+	webhooks.POST("/:id/deliveries/:delivery_id/replay", s.handleReplayWebhookDelivery)
+
+	telemetry := api.Group("/telemetry")
+	telemetry.POST("", s.handleRecordTelemetry)
+	telemetry.GET("", s.handleQueryTelemetry)
+	telemetry.GET("/alerts", s.handleListFenceAlerts)
+
+	api.POST("/placements/suggest", s.handleSuggestPlacements)
+	api.POST("/plans/consolidate", s.handleConsolidate)
+	api.GET("/transfers", s.handleListTransfers)
+	api.GET("/feedings", s.handleListFeedings)
+	api.GET("/feedings/overdue", s.handleListOverdueFeedings)
+	api.GET("/biometrics/growth", s.handleListGrowthTrends)
+	api.GET("/maintenance", s.handleListMaintenance)
+	api.GET("/audit", s.handleListAudit)
+	api.GET("/events", s.handleEvents)
+
 	return router
 }
 
@@ -80,6 +178,62 @@ func (s *service) handlePing(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+const (
+	// TotalCountHeader carries the number of items matching a list request
+	// regardless of pagination.
+	TotalCountHeader = "X-Total-Count"
+
+	defaultPerPage = 20
+)
+
+// pagination reads the "per_page" and "page" query parameters. Pages are
+// zero-based.
+func pagination(c *gin.Context) (*storage.Pagination, error) {
+	const op errors.Op = "server.pagination"
+
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultPerPage)))
+	if err != nil || perPage <= 0 {
+		return nil, errors.E(op, errors.KindBadRequest, "invalid per_page")
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "0"))
+	if err != nil || page < 0 {
+		return nil, errors.E(op, errors.KindBadRequest, "invalid page")
+	}
+
+	return storage.NewPagination(perPage, page), nil
+}
+
+// queryBool reads an optional boolean query parameter.
+func queryBool(c *gin.Context, key string) (bool, error) {
+	const op errors.Op = "server.queryBool"
+
+	value, ok := c.GetQuery(key)
+	if !ok || value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid %s", key))
+	}
+
+	return b, nil
+}
+
+// nextID generates a new ID with the server's generator. newID is one of the
+// model constructors, such as model.NewCageID.
+func (s *service) nextID(newID func(uuid string) model.ID) (model.ID, error) {
+	const op errors.Op = "server.nextID"
+
+	uuid, err := s.guid.NextID()
+	if err != nil {
+		return "", errors.E(op, errors.KindUnexpected, err)
+	}
+
+	return newID(uuid), nil
+}
+
 type HTTPErrorResponse struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -101,12 +255,9 @@ var newLine = regexp.MustCompile(`\r?\n?\t`)
 func (s *service) abortWithError(ctx *gin.Context, err error) {
 	code := errors.KindUnexpected
 	msg := newLine.ReplaceAllString(err.Error(), " ")
-	e, ok := err.(*errors.Error)
-	if ok {
-		code = e.Kind
-		if index := strings.Index(msg, ":"); len(msg) > index+1 {
-			msg = strings.TrimSpace(msg[index+1:])
-		}
+	var e errors.Error
+	if errors.AsErr(err, &e) {
+		code = errors.Kind(e)
 	}
 
 	ctx.AbortWithStatusJSON(code, &HTTPErrorResponse{
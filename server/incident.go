@@ -0,0 +1,216 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type incidentRequest struct {
+	Kind        string     `json:"kind" binding:"required"`
+	Severity    string     `json:"severity" binding:"required"`
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	CageIDs     []model.ID `json:"cage_ids"`
+	DinosaurIDs []model.ID `json:"dinosaur_ids"`
+	Note        string     `json:"note"`
+}
+
+type incidentStatusRequest struct {
+	Note string `json:"note"`
+}
+
+type incidentSeverityRequest struct {
+	Severity string `json:"severity" binding:"required"`
+}
+
+type incidentNoteRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// handleCreateIncident opens an incident. A critical incident puts a
+// transfer hold on its cages until it is closed.
+func (s *service) handleCreateIncident(c *gin.Context) {
+	const op errors.Op = "server.handleCreateIncident"
+
+	var req incidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	incident := &model.Incident{
+		Kind:        req.Kind,
+		Severity:    req.Severity,
+		Title:       req.Title,
+		Description: req.Description,
+		CageIDs:     req.CageIDs,
+		DinosaurIDs: req.DinosaurIDs,
+	}
+
+	ctx := storage.WithReason(c.Request.Context(), req.Note)
+	if err := s.storage.CreateIncident(ctx, incident); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.respondIncident(c, http.StatusCreated, incident.ID)
+}
+
+func (s *service) handleListIncidents(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	open, err := queryBool(c, "open")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	incidents, total, err := s.storage.ListIncidents(c.Request.Context(), storage.ListIncidentParams{
+		Pagination: p,
+		Status:     c.Query("status"),
+		Severity:   c.Query("severity"),
+		Kind:       c.Query("kind"),
+		CageID:     model.ID(c.Query("cage_id")),
+		DinosaurID: model.ID(c.Query("dinosaur_id")),
+		Open:       open,
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.IncidentsResource{Incidents: incidents})
+}
+
+func (s *service) handleGetIncident(c *gin.Context) {
+	id, err := incidentID(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.respondIncident(c, http.StatusOK, id)
+}
+
+// handleIncidentStatus returns a handler moving an incident to a status,
+// with an optional note added to its timeline.
+func (s *service) handleIncidentStatus(status string) gin.HandlerFunc {
+	const op errors.Op = "server.handleIncidentStatus"
+
+	return func(c *gin.Context) {
+		var req incidentStatusRequest
+		if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+			s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+			return
+		}
+
+		ctx := storage.WithReason(c.Request.Context(), req.Note)
+		c.Request = c.Request.WithContext(ctx)
+		s.updateIncident(c, func(old *model.Incident) (*model.Incident, error) {
+			old.Status = status
+			return old, nil
+		})
+	}
+}
+
+func (s *service) handleUpdateIncidentSeverity(c *gin.Context) {
+	const op errors.Op = "server.handleUpdateIncidentSeverity"
+
+	var req incidentSeverityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	s.updateIncident(c, func(old *model.Incident) (*model.Incident, error) {
+		old.Severity = req.Severity
+		return old, nil
+	})
+}
+
+func (s *service) handleAddIncidentNote(c *gin.Context) {
+	const op errors.Op = "server.handleAddIncidentNote"
+
+	id, err := incidentID(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	var req incidentNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	if err := s.storage.AddIncidentNote(c.Request.Context(), &model.IncidentNote{IncidentID: id, Text: req.Text}); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.respondIncident(c, http.StatusCreated, id)
+}
+
+// updateIncident applies updater to the incident identified in the path and
+// responds with the updated incident.
+func (s *service) updateIncident(c *gin.Context, updater storage.IncidentUpdater) {
+	id, err := incidentID(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	if err := s.storage.UpdateIncident(c.Request.Context(), id, updater); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.respondIncident(c, http.StatusOK, id)
+}
+
+// respondIncident responds with an incident and its timeline.
+func (s *service) respondIncident(c *gin.Context, code int, id int64) {
+	incident, err := s.storage.GetIncident(c.Request.Context(), id)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(code, &model.IncidentResource{Incident: incident})
+}
+
+// incidentID reads the ID of an incident from the path.
+func incidentID(c *gin.Context) (int64, error) {
+	const op errors.Op = "server.incidentID"
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, errors.E(op, errors.KindBadRequest, "invalid incident id")
+	}
+
+	return id, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncidents(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	c1, c2 := createCage(t, h, 2), createCage(t, h, 2)
+	blue := createDinosaur(t, h, "Blue", model.Velociraptor, c1.ID)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/incidents", body{
+		"kind":         model.IncidentEscape,
+		"severity":     model.SeverityCritical,
+		"title":        "Raptor loose near the visitor center",
+		"cage_ids":     []model.ID{c1.ID},
+		"dinosaur_ids": []model.ID{blue.ID},
+		"note":         "Gate found open",
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res model.IncidentResource
+	decode(t, rec, &res)
+	incident := res.Incident
+	assert.Equal(t, model.IncidentOpen, incident.Status)
+	require.Len(t, incident.Notes, 1)
+	assert.Equal(t, "Gate found open", incident.Notes[0].Text)
+	path := fmt.Sprintf("%s/incidents/%d", Prefix, incident.ID)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs/"+string(blue.ID)+"/transfer", body{"cage_id": c2.ID})
+	assert.Equal(t, http.StatusLocked, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "on hold")
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/incidents", body{"kind": "stampede", "severity": model.SeverityLow, "title": "foo"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/incidents", body{"kind": model.IncidentOther, "severity": model.SeverityLow, "title": "foo", "cage_ids": []string{"foo"}})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/acknowledge", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPut, path+"/severity", body{"severity": model.SeverityHigh})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPut, path+"/severity", body{"severity": "dire"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs/"+string(blue.ID)+"/transfer", body{"cage_id": c2.ID})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPost, path+"/notes", body{"text": "Blue recaptured"})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPost, path+"/notes", body{})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/close", body{"note": "Gate lock replaced"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	res = model.IncidentResource{}
+	decode(t, rec, &res)
+	assert.Equal(t, model.IncidentClosed, res.Incident.Status)
+	assert.Equal(t, model.SeverityHigh, res.Incident.Severity)
+	require.Len(t, res.Incident.Notes, 4)
+	assert.Equal(t, "Gate lock replaced", res.Incident.Notes[3].Text)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/mitigate", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/incidents?open=true", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "0", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/incidents?cage_id="+string(c1.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/incidents/foo", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/incidents/999", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,176 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type maintenanceRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+// handleCreateMaintenanceWindow schedules maintenance of a cage. The
+// response warns if the cage is occupied and the window is coming up.
+func (s *service) handleCreateMaintenanceWindow(c *gin.Context) {
+	const op errors.Op = "server.handleCreateMaintenanceWindow"
+
+	var req maintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	window := &model.MaintenanceWindow{
+		CageID:   model.ID(c.Param("id")),
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	}
+
+	ctx := c.Request.Context()
+	if err := s.storage.CreateMaintenanceWindow(ctx, window); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	windows := []*model.MaintenanceWindow{window}
+	warnings, err := s.maintenanceWarnings(ctx, windows)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &model.MaintenanceWindowsResource{Windows: windows, Warnings: warnings})
+}
+
+func (s *service) handleListCageMaintenance(c *gin.Context) {
+	if _, err := s.storage.GetCage(c.Request.Context(), model.ID(c.Param("id"))); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.listMaintenance(c, model.ID(c.Param("id")))
+}
+
+// handleListMaintenance lists the maintenance windows of every cage, or of
+// the one given by the "cage_id" query parameter.
+func (s *service) handleListMaintenance(c *gin.Context) {
+	s.listMaintenance(c, model.ID(c.Query("cage_id")))
+}
+
+func (s *service) listMaintenance(c *gin.Context, cageID model.ID) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	windows, total, err := s.storage.ListMaintenanceWindows(ctx, storage.ListMaintenanceParams{
+		Pagination: p,
+		CageID:     cageID,
+		Status:     c.Query("status"),
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	warnings, err := s.maintenanceWarnings(ctx, windows)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.MaintenanceWindowsResource{Windows: windows, Warnings: warnings})
+}
+
+// handleCancelMaintenanceWindow cancels a window that has not started yet.
+func (s *service) handleCancelMaintenanceWindow(c *gin.Context) {
+	const op errors.Op = "server.handleCancelMaintenanceWindow"
+
+	id, err := strconv.ParseInt(c.Param("window_id"), 10, 64)
+	if err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, "invalid window id"))
+		return
+	}
+
+	cageID := model.ID(c.Param("id"))
+	var window *model.MaintenanceWindow
+	err = s.storage.UpdateMaintenanceWindow(c.Request.Context(), id, func(old *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+		if old.CageID != cageID {
+			return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf(
+				"cage %s has no maintenance window %d", cageID, id))
+		}
+
+		if err := park.CheckMaintenanceTransition(old, model.MaintenanceCancelled); err != nil {
+			return nil, errors.E(op, err)
+		}
+
+		old.Status = model.MaintenanceCancelled
+		window = old
+		return old, nil
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.MaintenanceWindowsResource{Windows: []*model.MaintenanceWindow{window}})
+}
+
+// maintenanceWarnings returns the warnings of the upcoming windows whose
+// cages are occupied.
+func (s *service) maintenanceWarnings(ctx context.Context, windows []*model.MaintenanceWindow) ([]*model.MaintenanceWarning, error) {
+	now := s.now().UTC()
+	cages := make(map[model.ID]*model.Cage)
+
+	var warnings []*model.MaintenanceWarning
+	for _, window := range windows {
+		if window.Status != model.MaintenanceScheduled {
+			continue
+		}
+
+		cage, ok := cages[window.CageID]
+		if !ok {
+			var err error
+			if cage, err = s.storage.GetCage(ctx, window.CageID); err != nil {
+				return nil, err
+			}
+
+			cages[window.CageID] = cage
+		}
+
+		if warning := park.MaintenanceWarning(window, cage, now); warning != nil {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	return warnings, nil
+}
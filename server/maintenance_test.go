@@ -0,0 +1,104 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindows(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	empty, occupied := createCage(t, h, 2), createCage(t, h, 2)
+	createDinosaur(t, h, "Cera", model.Triceratops, occupied.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	window := func(start, end time.Duration) body {
+		return body{"starts_at": now.Add(start), "ends_at": now.Add(end), "reason": "fence"}
+	}
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/cages/"+string(occupied.ID)+"/maintenance", window(time.Hour, 2*time.Hour))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "would still be occupied")
+	assert.Contains(t, rec.Body.String(), now.Add(time.Hour).Format(time.RFC3339))
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/cages/"+string(occupied.ID)+"/maintenance", window(48*time.Hour, 50*time.Hour))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res model.MaintenanceWindowsResource
+	decode(t, rec, &res)
+	require.Len(t, res.Windows, 1)
+	assert.Equal(t, model.MaintenanceScheduled, res.Windows[0].Status)
+	require.Len(t, res.Warnings, 1)
+	assert.Equal(t, occupied.ID, res.Warnings[0].CageID)
+	assert.Equal(t, 1, res.Warnings[0].Allocation)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/cages/"+string(empty.ID)+"/maintenance", window(time.Hour, 2*time.Hour))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	res = model.MaintenanceWindowsResource{}
+	decode(t, rec, &res)
+	require.Len(t, res.Windows, 1)
+	assert.Empty(t, res.Warnings)
+	id := res.Windows[0].ID
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/cages/"+string(empty.ID)+"/maintenance", body{"starts_at": now})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/maintenance", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "2", rec.Header().Get(TotalCountHeader))
+
+	res = model.MaintenanceWindowsResource{}
+	decode(t, rec, &res)
+	require.Len(t, res.Windows, 2)
+	assert.Equal(t, id, res.Windows[0].ID)
+	assert.Len(t, res.Warnings, 1)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages/"+string(empty.ID)+"/maintenance", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages/foo/maintenance", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(t, h, http.MethodDelete, fmt.Sprintf("%s/cages/%s/maintenance/%d", Prefix, occupied.ID, id), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(t, h, http.MethodDelete, fmt.Sprintf("%s/cages/%s/maintenance/%d", Prefix, empty.ID, id), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	res = model.MaintenanceWindowsResource{}
+	decode(t, rec, &res)
+	require.Len(t, res.Windows, 1)
+	assert.Equal(t, model.MaintenanceCancelled, res.Windows[0].Status)
+
+	rec = doRequest(t, h, http.MethodDelete, fmt.Sprintf("%s/cages/%s/maintenance/%d", Prefix, empty.ID, id), nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/maintenance?status=cancelled", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/maintenance?status=paused", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
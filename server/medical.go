@@ -0,0 +1,265 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type medicalHoldRequest struct {
+	Hold   *bool  `json:"hold" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type vetVisitRequest struct {
+	Vet        string             `json:"vet" binding:"required"`
+	Reason     string             `json:"reason"`
+	Notes      string             `json:"notes"`
+	VisitedAt  *time.Time         `json:"visited_at"`
+	Diagnoses  []*model.Diagnosis `json:"diagnoses"`
+	Treatments []*model.Treatment `json:"treatments"`
+}
+
+type biometricsRequest struct {
+	Samples []*model.Biometric `json:"samples" binding:"required"`
+}
+
+// handleSetMedicalHold puts a dinosaur on medical hold, which blocks its
+// transfers, or lifts the hold. The update is rejected with 412 Precondition
+// Failed unless the dinosaur matches the If-Match header.
+func (s *service) handleSetMedicalHold(c *gin.Context) {
+	const op errors.Op = "server.handleSetMedicalHold"
+
+	var req medicalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	versions, err := ifMatch(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	id := model.ID(c.Param("id"))
+	err = s.storage.UpdateDinosaur(ctx, id, func(old *model.Dinosaur) (*model.Dinosaur, error) {
+		old.Version = matchVersion(old.Version, versions)
+		old.MedicalHold = *req.Hold
+		old.MedicalHoldReason = ""
+		if old.MedicalHold {
+			old.MedicalHoldReason = req.Reason
+		}
+
+		return old, nil
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	dinosaur, err := s.storage.GetDinosaur(ctx, id)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	setETag(c, dinosaur.Version)
+	c.JSON(http.StatusOK, &model.DinosaursResource{Dinosaurs: []*model.Dinosaur{dinosaur}})
+}
+
+func (s *service) handleCreateVetVisit(c *gin.Context) {
+	const op errors.Op = "server.handleCreateVetVisit"
+
+	var req vetVisitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	visit := &model.VetVisit{
+		DinosaurID: model.ID(c.Param("id")),
+		Vet:        req.Vet,
+		Reason:     req.Reason,
+		Notes:      req.Notes,
+		VisitedAt:  req.VisitedAt,
+		Diagnoses:  req.Diagnoses,
+		Treatments: req.Treatments,
+	}
+
+	if err := s.storage.CreateVetVisit(c.Request.Context(), visit); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &model.VetVisitsResource{Visits: []*model.VetVisit{visit}})
+}
+
+func (s *service) handleListVetVisits(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	visits, total, err := s.storage.ListVetVisits(c.Request.Context(), storage.ListVetVisitParams{
+		Pagination: p,
+		DinosaurID: model.ID(c.Param("id")),
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.VetVisitsResource{Visits: visits})
+}
+
+func (s *service) handleRecordBiometrics(c *gin.Context) {
+	const op errors.Op = "server.handleRecordBiometrics"
+
+	var req biometricsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	for _, sample := range req.Samples {
+		sample.ID = 0
+		sample.DinosaurID = model.ID(c.Param("id"))
+	}
+
+	if err := s.storage.RecordBiometrics(c.Request.Context(), req.Samples); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &model.BiometricsResource{Biometrics: req.Samples})
+}
+
+// handleListBiometrics returns the measurements of a dinosaur, optionally of
+// one "metric" between the "since" and "until" times.
+func (s *service) handleListBiometrics(c *gin.Context) {
+	const op errors.Op = "server.handleListBiometrics"
+
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	metric := c.Query("metric")
+	if metric != "" && !model.ValidMetric(metric) {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid metric: %q", metric)))
+		return
+	}
+
+	since, err := queryTime(c, "since")
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	until, err := queryTime(c, "until")
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	biometrics, total, err := s.storage.ListBiometrics(c.Request.Context(), storage.ListBiometricParams{
+		Pagination: p,
+		DinosaurID: model.ID(c.Param("id")),
+		Metric:     metric,
+		Since:      since,
+		Until:      until,
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.BiometricsResource{Biometrics: biometrics})
+}
+
+// handleListGrowthTrends reports the weight change of dinosaurs over the
+// "window" duration. Changes above "threshold" percent are flagged abnormal,
+// and "abnormal=true" lists only those. "dinosaur_id" restricts the query to
+// one dinosaur.
+func (s *service) handleListGrowthTrends(c *gin.Context) {
+	const op errors.Op = "server.handleListGrowthTrends"
+
+	window := park.DefaultGrowthWindow
+	if value := c.Query("window"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			s.abortWithError(c, errors.E(op, errors.KindBadRequest, "invalid window"))
+			return
+		}
+
+		window = d
+	}
+
+	threshold := park.DefaultGrowthThreshold
+	if value := c.Query("threshold"); value != "" {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < 0 {
+			s.abortWithError(c, errors.E(op, errors.KindBadRequest, "invalid threshold"))
+			return
+		}
+
+		threshold = f
+	}
+
+	abnormal, err := queryBool(c, "abnormal")
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	samples, _, err := s.storage.ListBiometrics(c.Request.Context(), storage.ListBiometricParams{
+		DinosaurID: model.ID(c.Query("dinosaur_id")),
+		Metric:     model.MetricWeight,
+		Since:      s.now().Add(-window),
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	trends := park.GrowthTrends(samples, threshold)
+	if abnormal {
+		flagged := []*model.GrowthTrend{}
+		for _, trend := range trends {
+			if trend.Abnormal {
+				flagged = append(flagged, trend)
+			}
+		}
+
+		trends = flagged
+	}
+
+	c.JSON(http.StatusOK, &model.GrowthTrendsResource{Trends: trends})
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMedicalHold(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	c1, c2 := createCage(t, h, 2), createCage(t, h, 2)
+	d := createDinosaur(t, h, "Stego", model.Stegosaurus, c1.ID)
+	path := Prefix + "/dinosaurs/" + string(d.ID)
+
+	rec := doRequest(t, h, http.MethodPut, path+"/medical-hold", body{"hold": true, "reason": "fever"}, IfMatchHeader, etag(0))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, path+"/medical-hold", body{"hold": true, "reason": "fever"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res model.DinosaursResource
+	decode(t, rec, &res)
+	assert.True(t, res.Dinosaurs[0].MedicalHold)
+	assert.Equal(t, "fever", res.Dinosaurs[0].MedicalHoldReason)
+
+	rec = doRequest(t, h, http.MethodPost, path+"/transfer", body{"cage_id": c2.ID})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, path+"/medical-hold", body{"hold": false})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPost, path+"/transfer", body{"cage_id": c2.ID})
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPut, path+"/medical-hold", body{})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestVetVisits(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 2)
+	d := createDinosaur(t, h, "Stego", model.Stegosaurus, cage.ID)
+	path := Prefix + "/dinosaurs/" + string(d.ID) + "/visits"
+
+	rec := doRequest(t, h, http.MethodPost, path, body{
+		"vet":        "harding",
+		"reason":     "lethargy",
+		"diagnoses":  []body{{"condition": "west indian lilac poisoning"}},
+		"treatments": []body{{"name": "activated charcoal"}},
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	var res model.VetVisitsResource
+	decode(t, rec, &res)
+	require.Len(t, res.Visits, 1)
+	assert.Len(t, res.Visits[0].Diagnoses, 1)
+	assert.Len(t, res.Visits[0].Treatments, 1)
+
+	rec = doRequest(t, h, http.MethodPost, path, body{"reason": "checkup"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs/foo/visits", body{"vet": "harding"})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestBiometrics(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 2)
+	stego := createDinosaur(t, h, "Stego", model.Stegosaurus, cage.ID)
+	trike := createDinosaur(t, h, "Trike", model.Triceratops, cage.ID)
+
+	now := time.Now().UTC()
+	record := func(d *model.Dinosaur, weights ...float64) {
+		var samples []body
+		for i, weight := range weights {
+			samples = append(samples, body{
+				"metric":      model.MetricWeight,
+				"value":       weight,
+				"measured_at": now.Add(time.Duration(i-len(weights)) * 24 * time.Hour),
+			})
+		}
+
+		rec := doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs/"+string(d.ID)+"/biometrics", body{"samples": samples})
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	record(stego, 3000, 3050, 3100)
+	record(trike, 6000, 5000)
+
+	rec := doRequest(t, h, http.MethodGet, Prefix+"/dinosaurs/"+string(stego.ID)+"/biometrics?metric=weight", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/biometrics/growth?abnormal=true", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res model.GrowthTrendsResource
+	decode(t, rec, &res)
+	require.Len(t, res.Trends, 1)
+	assert.Equal(t, trike.ID, res.Trends[0].DinosaurID)
+	assert.InDelta(t, -16.67, res.Trends[0].ChangePct, 0.001)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/biometrics/growth?window=60h&dinosaur_id="+string(stego.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	res = model.GrowthTrendsResource{}
+	decode(t, rec, &res)
+	require.Len(t, res.Trends, 1)
+	assert.Equal(t, stego.ID, res.Trends[0].DinosaurID)
+	assert.Equal(t, 2, res.Trends[0].Samples)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/biometrics/growth?window=-1h", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs/"+string(stego.ID)+"/biometrics", body{"samples": []body{{"metric": "height", "value": 1}}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Messages holding a colon come back whole.
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/dinosaurs/"+string(stego.ID)+"/biometrics?metric=x", nil)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var e HTTPErrorResponse
+	decode(t, rec, &e)
+	assert.Equal(t, `invalid metric: "x"`, e.Message)
+}
@@ -17,6 +17,7 @@ package server
 import (
 	"time"
 
+	"github.com/danielnegri/jurassic-park-go/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -63,3 +64,19 @@ func LoggerMiddleware(logger logrus.FieldLogger, now func() time.Time, timeForma
 		}
 	}
 }
+
+// ActorHeader identifies who is making a request.
+const ActorHeader = "X-Actor"
+
+// ActorMiddleware returns a gin.HandlerFunc (middleware) that attaches the
+// ActorHeader of a request to its context, so that storage can record who made
+// each change.
+func ActorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actor := c.GetHeader(ActorHeader); actor != "" {
+			c.Request = c.Request.WithContext(storage.WithActor(c.Request.Context(), actor))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type suggestRequest struct {
+	Species    model.Species `json:"species"`
+	DinosaurID model.ID      `json:"dinosaur_id"`
+}
+
+// handleSuggestPlacements ranks the cages that can take a new dinosaur of a
+// species or an existing dinosaur. Cages on hold for a critical incident are
+// excluded, and a dinosaur that may not leave its cage gets no suggestions.
+func (s *service) handleSuggestPlacements(c *gin.Context) {
+	const op errors.Op = "server.handleSuggestPlacements"
+
+	var req suggestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	if (req.Species == "") == (req.DinosaurID == "") {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, "either species or dinosaur_id is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	dinosaur := &model.Dinosaur{Species: req.Species}
+	if req.DinosaurID != "" {
+		var err error
+		if dinosaur, err = s.storage.GetDinosaur(ctx, req.DinosaurID); err != nil {
+			s.abortWithError(c, err)
+			return
+		}
+
+		if err := park.CheckTransfer(dinosaur); err != nil {
+			s.abortWithError(c, errors.E(op, err))
+			return
+		}
+	}
+
+	if _, ok := model.LookupSpecies(dinosaur.Species); !ok {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, fmt.Sprintf("unknown species: %q", dinosaur.Species)))
+		return
+	}
+
+	cages, _, err := s.storage.ListCages(ctx, storage.ListCageParams{WithDinosaurs: true})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	incidents, _, err := s.storage.ListIncidents(ctx, storage.ListIncidentParams{Open: true, Severity: model.SeverityCritical})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	if dinosaur.ID != "" {
+		if err := park.CheckIncidentHolds([]model.ID{dinosaur.CageID}, incidents); err != nil {
+			s.abortWithError(c, errors.E(op, err))
+			return
+		}
+	}
+
+	var (
+		open []*model.Cage
+		held []*model.PlacementExclusion
+	)
+
+	for _, cage := range cages {
+		if err := park.CheckIncidentHolds([]model.ID{cage.ID}, incidents); err != nil {
+			held = append(held, &model.PlacementExclusion{CageID: cage.ID, Reason: err.Error()})
+			continue
+		}
+
+		open = append(open, cage)
+	}
+
+	candidates, excluded := park.SuggestPlacements(dinosaur, open)
+	excluded = append(excluded, held...)
+	for _, candidate := range candidates {
+		candidate.Cage.Dinosaurs = nil
+	}
+
+	c.JSON(http.StatusOK, &model.PlacementResource{
+		Species:    dinosaur.Species,
+		Candidates: candidates,
+		Excluded:   excluded,
+	})
+}
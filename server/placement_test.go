@@ -0,0 +1,100 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestPlacements(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	c1, c2, c3 := createCage(t, h, 2), createCage(t, h, 2), createCage(t, h, 2)
+	rex := createDinosaur(t, h, "Rexy", model.Tyrannosaurus, c1.ID)
+	createDinosaur(t, h, "Cera", model.Triceratops, c2.ID)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/placements/suggest", body{"species": model.Stegosaurus})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res model.PlacementResource
+	decode(t, rec, &res)
+	require.Len(t, res.Candidates, 2)
+	assert.Equal(t, c2.ID, res.Candidates[0].Cage.ID)
+	assert.Equal(t, c3.ID, res.Candidates[1].Cage.ID)
+	require.Len(t, res.Excluded, 1)
+	assert.Equal(t, c1.ID, res.Excluded[0].CageID)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/placements/suggest", body{"dinosaur_id": rex.ID})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	res = model.PlacementResource{}
+	decode(t, rec, &res)
+	assert.Equal(t, model.Tyrannosaurus, res.Species)
+	require.Len(t, res.Candidates, 1)
+	assert.Equal(t, c3.ID, res.Candidates[0].Cage.ID)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/placements/suggest", body{"species": "segnosaurus"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/placements/suggest", body{})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/placements/suggest", body{"dinosaur_id": "foo"})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestSuggestPlacementsHolds(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	c1, c2, c3 := createCage(t, h, 2), createCage(t, h, 2), createCage(t, h, 2)
+	cera := createDinosaur(t, h, "Cera", model.Triceratops, c1.ID)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/incidents", body{
+		"kind":     model.IncidentBreach,
+		"severity": model.SeverityCritical,
+		"title":    "Fence down on the east side",
+		"cage_ids": []model.ID{c2.ID},
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// Cages on hold are excluded rather than suggested.
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/placements/suggest", body{"dinosaur_id": cera.ID})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res model.PlacementResource
+	decode(t, rec, &res)
+	require.Len(t, res.Candidates, 1)
+	assert.Equal(t, c3.ID, res.Candidates[0].Cage.ID)
+	require.Len(t, res.Excluded, 2)
+	assert.Equal(t, c2.ID, res.Excluded[1].CageID)
+	assert.Contains(t, res.Excluded[1].Reason, "on hold")
+
+	// A dinosaur on medical hold cannot be moved anywhere.
+	path := Prefix + "/dinosaurs/" + string(cera.ID)
+	rec = doRequest(t, h, http.MethodPut, path+"/medical-hold", body{"hold": true, "reason": "limping"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/placements/suggest", body{"dinosaur_id": cera.ID})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "medical hold")
+
+	// New arrivals are not held back by a hold on a dinosaur.
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/placements/suggest", body{"species": model.Triceratops})
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
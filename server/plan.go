@@ -0,0 +1,74 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type consolidateRequest struct {
+	CageIDs []model.ID `json:"cage_ids"`
+	Free    int        `json:"free"`
+	Execute bool       `json:"execute"`
+	Reason  string     `json:"reason"`
+}
+
+// handleConsolidate plans the transfers that empty the requested cages, or
+// the given number of cages, and makes them if asked to. Cages on hold for a
+// critical incident are left out of the plan.
+func (s *service) handleConsolidate(c *gin.Context) {
+	const op errors.Op = "server.handleConsolidate"
+
+	var req consolidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	cages, _, err := s.storage.ListCages(ctx, storage.ListCageParams{WithDinosaurs: true})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	incidents, _, err := s.storage.ListIncidents(ctx, storage.ListIncidentParams{Open: true, Severity: model.SeverityCritical})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	plan, err := park.PlanConsolidation(cages, incidents, req.CageIDs, req.Free)
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	resource := &model.ConsolidationResource{Plan: plan}
+	if req.Execute {
+		if resource.Transfers, err = s.storage.TransferDinosaurs(ctx, plan.Transfers, req.Reason); err != nil {
+			s.abortWithError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, resource)
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolidate(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+	c1, c2, c3 := createCage(t, h, 4), createCage(t, h, 4), createCage(t, h, 4)
+	createDinosaur(t, h, "Cera", model.Triceratops, c1.ID)
+	createDinosaur(t, h, "Horns", model.Triceratops, c1.ID)
+	stray := createDinosaur(t, h, "Stray", model.Triceratops, c2.ID)
+	createDinosaur(t, h, "Rexy", model.Tyrannosaurus, c3.ID)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/plans/consolidate", body{"free": 1})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res model.ConsolidationResource
+	decode(t, rec, &res)
+	assert.Equal(t, []model.ID{c2.ID}, res.Plan.Freed)
+	assert.True(t, res.Plan.Minimal)
+	require.Len(t, res.Plan.Transfers, 1)
+	assert.Equal(t, stray.ID, res.Plan.Transfers[0].DinosaurID)
+	assert.Equal(t, c1.ID, res.Plan.Transfers[0].ToCageID)
+	assert.Empty(t, res.Transfers)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/plans/consolidate", body{"cage_ids": []model.ID{c2.ID}, "execute": true, "reason": "cleaning"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	res = model.ConsolidationResource{}
+	decode(t, rec, &res)
+	require.Len(t, res.Transfers, 1)
+	assert.Equal(t, "cleaning", res.Transfers[0].Reason)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages/"+string(c2.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var cages model.CagesResource
+	decode(t, rec, &cages)
+	assert.Zero(t, cages.Cages[0].Allocation)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/plans/consolidate", body{"free": 3})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/plans/consolidate", body{})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
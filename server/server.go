@@ -19,6 +19,8 @@ import (
 	"time"
 
 	gosundheit "github.com/AppsFlyer/go-sundheit"
+	"github.com/danielnegri/jurassic-park-go/events"
+	"github.com/danielnegri/jurassic-park-go/maintenance"
 	"github.com/danielnegri/jurassic-park-go/pkg/app"
 	"github.com/danielnegri/jurassic-park-go/pkg/errors"
 	"github.com/danielnegri/jurassic-park-go/pkg/guid"
@@ -26,6 +28,8 @@ import (
 	"github.com/danielnegri/jurassic-park-go/pkg/net"
 	"github.com/danielnegri/jurassic-park-go/pkg/version"
 	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/danielnegri/jurassic-park-go/telemetry"
+	"github.com/danielnegri/jurassic-park-go/webhooks"
 	"github.com/sirupsen/logrus"
 )
 
@@ -42,6 +46,22 @@ type Config struct {
 
 	Storage storage.Storage
 
+	// EventsInterval is how often the outbox is polled for events. Defaults
+	// to events.DefaultInterval.
+	EventsInterval time.Duration
+
+	// MaintenanceInterval is how often maintenance windows are checked for
+	// cages to power down or up. Defaults to maintenance.DefaultInterval.
+	MaintenanceInterval time.Duration
+
+	// WebhooksInterval is how often the outbox is polled for events to
+	// deliver to webhooks. Defaults to webhooks.DefaultInterval.
+	WebhooksInterval time.Duration
+
+	// TelemetryInterval is how often fence readings past their retention
+	// are rolled up. Defaults to telemetry.DefaultInterval.
+	TelemetryInterval time.Duration
+
 	// If specified, the server will use this function for determining time.
 	Now func() time.Time
 }
@@ -53,6 +73,13 @@ type service struct {
 	logger  logrus.FieldLogger
 	server  net.Server
 	storage storage.Storage
+	relay   *events.Relay
+	worker  *maintenance.Worker
+	hooks   *webhooks.Dispatcher
+	sampler *telemetry.Downsampler
+
+	// stop ends the background work started by Run.
+	stop context.CancelFunc
 
 	now func() time.Time
 }
@@ -76,6 +103,11 @@ func New(cfg Config) *service {
 		health:  healthChecker,
 		logger:  log.WithField("component", "server"),
 		storage: cfg.Storage,
+		relay:   events.NewRelay(cfg.Storage, cfg.EventsInterval),
+		worker:  maintenance.NewWorker(cfg.Storage, cfg.MaintenanceInterval, cfg.Now),
+		hooks:   webhooks.NewDispatcher(cfg.Storage, cfg.WebhooksInterval, cfg.Now),
+		sampler: telemetry.NewDownsampler(cfg.Storage, cfg.TelemetryInterval, cfg.Now),
+		stop:    func() {},
 		now:     cfg.Now,
 	}
 
@@ -98,6 +130,29 @@ func (s *service) Run() error {
 		return errors.E(op, errors.KindUnexpected, "invalid storage configuration")
 	}
 
+	ctx, s.stop = context.WithCancel(ctx)
+	go func() {
+		if err := s.relay.Run(ctx); err != nil {
+			s.logger.Errorf("error while relaying events: %v", err)
+		}
+	}()
+	go func() {
+		if err := s.worker.Run(ctx); err != nil {
+			s.logger.Errorf("error while carrying out maintenance windows: %v", err)
+		}
+	}()
+	go func() {
+		if err := s.hooks.Run(ctx); err != nil {
+			s.logger.Errorf("error while delivering webhooks: %v", err)
+		}
+	}()
+	go func() {
+		if err := s.sampler.Run(ctx); err != nil {
+			s.logger.Errorf("error while downsampling telemetry: %v", err)
+		}
+	}()
+	go s.watchSpecies(ctx)
+
 	// Start Server
 	if err := s.server.Run(); err != nil {
 		return errors.E(op, "failed to start server", err)
@@ -108,6 +163,7 @@ func (s *service) Run() error {
 
 func (s *service) Shutdown() {
 	s.logger.Infof("%s: Stopping HTTP Server", app.Description)
+	s.stop()
 	s.storage.Close()
 
 }
@@ -0,0 +1,90 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+	"github.com/danielnegri/jurassic-park-go/events"
+	"github.com/danielnegri/jurassic-park-go/pkg/app"
+	"github.com/danielnegri/jurassic-park-go/pkg/guid"
+	"github.com/danielnegri/jurassic-park-go/pkg/log"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func newTestService(t *testing.T, st storage.Storage) http.Handler {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	svc := &service{
+		cfg: Config{ReleaseMode: true},
+		guid: guid.New(guid.Settings{
+			StartTime: app.StartDate(),
+			MachineID: func() (uint16, error) { return 1, nil },
+		}),
+		health:  gosundheit.New(),
+		logger:  log.WithField("component", "server"),
+		storage: st,
+		relay:   events.NewRelay(st, 10*time.Millisecond),
+		stop:    cancel,
+		now:     time.Now,
+	}
+
+	go svc.relay.Run(ctx)
+
+	return svc.newHandler()
+}
+
+func doRequest(t *testing.T, h http.Handler, method, path string, body interface{}, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+}
+
+// body is a shorthand for JSON request bodies.
+type body map[string]interface{}
@@ -0,0 +1,155 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type speciesRequest struct {
+	Name           model.Species `json:"name"`
+	Kind           string        `json:"kind" binding:"required"`
+	CommonName     string        `json:"common_name"`
+	ScientificName string        `json:"scientific_name"`
+	MaxPerCage     int           `json:"max_per_cage"`
+	Habitat        string        `json:"habitat"`
+}
+
+func (s *service) handleListSpecies(c *gin.Context) {
+	entries, err := s.storage.ListSpecies(c.Request.Context())
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.SpeciesResource{Species: entries})
+}
+
+func (s *service) handleGetSpecies(c *gin.Context) {
+	entry, err := s.storage.GetSpecies(c.Request.Context(), model.Species(c.Param("name")))
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.SpeciesResource{Species: []*model.SpeciesEntry{entry}})
+}
+
+func (s *service) handleCreateSpecies(c *gin.Context) {
+	const op errors.Op = "server.handleCreateSpecies"
+
+	var req speciesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	entry := &model.SpeciesEntry{
+		Name:           req.Name,
+		Kind:           req.Kind,
+		CommonName:     req.CommonName,
+		ScientificName: req.ScientificName,
+		MaxPerCage:     req.MaxPerCage,
+		Habitat:        req.Habitat,
+	}
+
+	if err := s.storage.CreateSpecies(c.Request.Context(), entry); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.reloadSpecies(c.Request.Context())
+	c.JSON(http.StatusCreated, &model.SpeciesResource{Species: []*model.SpeciesEntry{entry}})
+}
+
+func (s *service) handleUpdateSpecies(c *gin.Context) {
+	const op errors.Op = "server.handleUpdateSpecies"
+
+	var req speciesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	name := model.Species(c.Param("name"))
+	var entry *model.SpeciesEntry
+	err := s.storage.UpdateSpecies(c.Request.Context(), name, func(old *model.SpeciesEntry) (*model.SpeciesEntry, error) {
+		old.Kind = req.Kind
+		old.CommonName = req.CommonName
+		old.ScientificName = req.ScientificName
+		old.MaxPerCage = req.MaxPerCage
+		old.Habitat = req.Habitat
+		entry = old
+		return old, nil
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.reloadSpecies(c.Request.Context())
+	c.JSON(http.StatusOK, &model.SpeciesResource{Species: []*model.SpeciesEntry{entry}})
+}
+
+func (s *service) handleDeleteSpecies(c *gin.Context) {
+	if err := s.storage.DeleteSpecies(c.Request.Context(), model.Species(c.Param("name"))); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.reloadSpecies(c.Request.Context())
+	c.Status(http.StatusNoContent)
+}
+
+// reloadSpecies refreshes the species registry from storage. Failures are
+// logged since watchSpecies retries on the next change.
+func (s *service) reloadSpecies(ctx context.Context) {
+	entries, err := s.storage.ListSpecies(ctx)
+	if err != nil {
+		s.logger.Errorf("error while loading species: %v", err)
+		return
+	}
+
+	model.LoadSpecies(entries)
+}
+
+// watchSpecies keeps the species registry in sync with the catalog, which
+// other instances may change, until ctx is done.
+func (s *service) watchSpecies(ctx context.Context) {
+	for ctx.Err() == nil {
+		sub := s.relay.Subscribe(model.EventSpeciesChanged)
+
+		// Reload after subscribing so that no change falls in between.
+		s.reloadSpecies(ctx)
+
+		for open := true; open; {
+			select {
+			case <-ctx.Done():
+				open = false
+			case _, open = <-sub.C:
+				if open {
+					s.reloadSpecies(ctx)
+				}
+			}
+		}
+
+		sub.Close()
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/events"
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/log"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecies(t *testing.T) {
+	t.Cleanup(func() { model.LoadSpecies(model.DefaultSpecies()) })
+
+	h := newTestService(t, memory.New(nil))
+	cage := createCage(t, h, 2)
+	createDinosaur(t, h, "Cera", model.Triceratops, cage.ID)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs", body{"name": "Gallie", "species": "gallimimus", "cage_id": cage.ID})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/species", body{"name": "gallimimus", "kind": model.KindHerbivores, "max_per_cage": 6})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// The registry knows the new species is a herbivore at once.
+	createDinosaur(t, h, "Gallie", "gallimimus", cage.ID)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/species/gallimimus", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res model.SpeciesResource
+	decode(t, rec, &res)
+	require.Len(t, res.Species, 1)
+	assert.Equal(t, model.KindHerbivores, res.Species[0].Kind)
+	assert.Equal(t, 6, res.Species[0].MaxPerCage)
+
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/species/gallimimus", body{"kind": model.KindCarnivore})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/species/gallimimus", body{"kind": model.KindHerbivores, "habitat": "grassland"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodDelete, Prefix+"/species/gallimimus", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/species", body{"name": "Bad Name", "kind": model.KindHerbivores})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/species", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	decode(t, rec, &res)
+	assert.Len(t, res.Species, len(model.DefaultSpecies())+1)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/species/segnosaurus", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestWatchSpecies(t *testing.T) {
+	t.Cleanup(func() { model.LoadSpecies(model.DefaultSpecies()) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := memory.New(nil)
+	svc := &service{
+		logger:  log.WithField("component", "server"),
+		storage: st,
+		relay:   events.NewRelay(st, 10*time.Millisecond),
+	}
+
+	go svc.relay.Run(ctx)
+	go svc.watchSpecies(ctx)
+
+	// Give the relay time to start after the events already committed.
+	time.Sleep(50 * time.Millisecond)
+
+	// Another instance changes the catalog.
+	require.NoError(t, st.CreateSpecies(ctx, &model.SpeciesEntry{Name: "dilophosaurus", Kind: model.KindCarnivore}))
+
+	assert.Eventually(t, func() bool {
+		return model.SpeciesKind("dilophosaurus") == model.KindCarnivore
+	}, time.Second, 10*time.Millisecond)
+}
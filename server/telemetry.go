@@ -0,0 +1,179 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// ContentTypeNDJSON is the content type of batches of readings sent one per
+// line.
+const ContentTypeNDJSON = "application/x-ndjson"
+
+const (
+	defaultTelemetryWindow = time.Hour
+	defaultTelemetryBucket = time.Minute
+)
+
+type telemetryRequest struct {
+	Readings []*model.Reading `json:"readings" binding:"required"`
+}
+
+// handleRecordTelemetry ingests a batch of fence readings sent as a JSON
+// object or as NDJSON, and responds with the alerts it raised or cleared.
+func (s *service) handleRecordTelemetry(c *gin.Context) {
+	readings, err := bindReadings(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	for _, reading := range readings {
+		reading.ID = 0
+	}
+
+	alerts, err := s.storage.RecordTelemetry(c.Request.Context(), readings)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &model.TelemetryResource{Accepted: len(readings), Alerts: alerts})
+}
+
+// bindReadings reads the readings of a telemetry request. NDJSON bodies
+// are read no further than park.MaxReadings readings.
+func bindReadings(c *gin.Context) ([]*model.Reading, error) {
+	const op errors.Op = "server.bindReadings"
+
+	if c.ContentType() != ContentTypeNDJSON {
+		var req telemetryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, errors.E(op, errors.KindBadRequest, err)
+		}
+
+		return req.Readings, nil
+	}
+
+	var readings []*model.Reading
+	dec := json.NewDecoder(c.Request.Body)
+	for {
+		var reading model.Reading
+		if err := dec.Decode(&reading); err == io.EOF {
+			return readings, nil
+		} else if err != nil {
+			return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid reading on line %d", len(readings)+1))
+		}
+
+		if len(readings) == park.MaxReadings {
+			return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("more than %d readings", park.MaxReadings))
+		}
+
+		readings = append(readings, &reading)
+	}
+}
+
+// handleQueryTelemetry summarizes the readings of a "sensor" of a cage,
+// voltage by default, in buckets of "bucket" between the "from" and "to"
+// times. Buckets default to a minute and the query to the last hour.
+func (s *service) handleQueryTelemetry(c *gin.Context) {
+	const op errors.Op = "server.handleQueryTelemetry"
+
+	cageID := model.ID(c.Query("cage_id"))
+	if cageID == "" {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, "cage_id is required"))
+		return
+	}
+
+	from, err := queryTime(c, "from")
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	to, err := queryTime(c, "to")
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	if to.IsZero() {
+		to = s.now().UTC()
+	}
+
+	if from.IsZero() {
+		from = to.Add(-defaultTelemetryWindow)
+	}
+
+	bucket := defaultTelemetryBucket
+	if value := c.Query("bucket"); value != "" {
+		if bucket, err = time.ParseDuration(value); err != nil {
+			s.abortWithError(c, errors.E(op, errors.KindBadRequest, "invalid bucket"))
+			return
+		}
+	}
+
+	buckets, err := s.storage.QueryTelemetry(c.Request.Context(), storage.TelemetryQuery{
+		CageID: cageID,
+		Sensor: c.DefaultQuery("sensor", model.SensorVoltage),
+		From:   from,
+		To:     to,
+		Bucket: bucket,
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.ReadingBucketsResource{Buckets: buckets})
+}
+
+func (s *service) handleListFenceAlerts(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	open, err := queryBool(c, "open")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	alerts, total, err := s.storage.ListFenceAlerts(c.Request.Context(), storage.ListFenceAlertParams{
+		Pagination: p,
+		CageID:     model.ID(c.Query("cage_id")),
+		Open:       open,
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.FenceAlertsResource{Alerts: alerts})
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetry(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+
+	paddock := createCage(t, h, 2)
+	createDinosaur(t, h, "Rexy", model.Tyrannosaurus, paddock.ID)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/telemetry", body{"readings": []body{
+		{"cage_id": paddock.ID, "sensor": model.SensorVoltage, "value": 9500},
+		{"cage_id": paddock.ID, "sensor": model.SensorDoor, "value": 0},
+	}})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res model.TelemetryResource
+	decode(t, rec, &res)
+	assert.Equal(t, 2, res.Accepted)
+	assert.Empty(t, res.Alerts)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/telemetry", body{"readings": []body{
+		{"cage_id": paddock.ID, "sensor": "temperature", "value": 20},
+	}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/telemetry", body{"readings": []body{
+		{"cage_id": "cg_foo", "sensor": model.SensorVoltage, "value": 9500},
+	}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ndjson := func(lines ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, Prefix+"/telemetry", strings.NewReader(strings.Join(lines, "\n")))
+		req.Header.Set("Content-Type", ContentTypeNDJSON)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec = ndjson(
+		fmt.Sprintf(`{"cage_id": %q, "sensor": "door", "value": 1}`, paddock.ID),
+		fmt.Sprintf(`{"cage_id": %q, "sensor": "voltage", "value": 4200}`, paddock.ID),
+	)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	decode(t, rec, &res)
+	assert.Equal(t, 2, res.Accepted)
+	require.Len(t, res.Alerts, 1)
+	alert := res.Alerts[0]
+	assert.Equal(t, paddock.ID, alert.CageID)
+	assert.Equal(t, model.KindCarnivore, alert.Kind)
+	assert.Equal(t, 4200.0, alert.Voltage)
+	assert.NotZero(t, alert.IncidentID)
+
+	rec = ndjson(fmt.Sprintf(`{"cage_id": %q, "sensor": "voltage", "value": 4200}`, paddock.ID), "{")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, fmt.Sprintf("%s/incidents/%d", Prefix, alert.IncidentID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var incident model.IncidentResource
+	decode(t, rec, &incident)
+	assert.Equal(t, model.SeverityCritical, incident.Incident.Severity)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/telemetry/alerts?open=true", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	var alerts model.FenceAlertsResource
+	decode(t, rec, &alerts)
+	require.Len(t, alerts.Alerts, 1)
+	assert.Equal(t, alert.ID, alerts.Alerts[0].ID)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/telemetry?cage_id="+string(paddock.ID)+"&bucket=1h", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var buckets model.ReadingBucketsResource
+	decode(t, rec, &buckets)
+	require.NotEmpty(t, buckets.Buckets)
+	count, low, high := 0, buckets.Buckets[0].Min, buckets.Buckets[0].Max
+	for _, bucket := range buckets.Buckets {
+		count += bucket.Count
+		low = math.Min(low, bucket.Min)
+		high = math.Max(high, bucket.Max)
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 4200.0, low)
+	assert.Equal(t, 9500.0, high)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/telemetry?cage_id="+string(paddock.ID)+"&sensor=door", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	for _, query := range []string{
+		"",
+		"?cage_id=" + string(paddock.ID) + "&bucket=fortnight",
+		"?cage_id=" + string(paddock.ID) + "&bucket=1ms",
+		"?cage_id=" + string(paddock.ID) + "&from=yesterday",
+		"?cage_id=" + string(paddock.ID) + "&sensor=temperature",
+	} {
+		rec = doRequest(t, h, http.MethodGet, Prefix+"/telemetry"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/telemetry?cage_id=cg_foo", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
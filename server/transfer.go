@@ -0,0 +1,69 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type transferRequest struct {
+	CageID model.ID `json:"cage_id" binding:"required"`
+	Reason string   `json:"reason"`
+}
+
+func (s *service) handleTransferDinosaur(c *gin.Context) {
+	const op errors.Op = "server.handleTransferDinosaur"
+
+	var req transferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	transfer, err := s.storage.TransferDinosaur(c.Request.Context(), model.ID(c.Param("id")), req.CageID, req.Reason)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &model.TransfersResource{Transfers: []*model.Transfer{transfer}})
+}
+
+func (s *service) handleListTransfers(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	transfers, total, err := s.storage.ListTransfers(c.Request.Context(), storage.ListTransferParams{
+		Pagination: p,
+		DinosaurID: model.ID(c.Query("dinosaur_id")),
+		CageID:     model.ID(c.Query("cage_id")),
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.TransfersResource{Transfers: transfers})
+}
@@ -0,0 +1,292 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// secretSize is the number of random bytes of a generated webhook secret.
+const secretSize = 32
+
+type webhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret"`
+	Types  []string `json:"types"`
+	Active *bool    `json:"active"`
+}
+
+// handleCreateWebhook subscribes a URL to the events published from now on.
+// The secret signing the deliveries is generated unless given, and returned
+// only in this response.
+func (s *service) handleCreateWebhook(c *gin.Context) {
+	const op errors.Op = "server.handleCreateWebhook"
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	webhook := &model.Webhook{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Types:  req.Types,
+		Active: req.Active == nil || *req.Active,
+	}
+
+	if webhook.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			s.abortWithError(c, errors.E(op, errors.KindUnexpected, err))
+			return
+		}
+
+		webhook.Secret = secret
+	}
+
+	if err := s.storage.CreateWebhook(c.Request.Context(), webhook); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+func (s *service) handleListWebhooks(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	webhooks, total, err := s.storage.ListWebhooks(c.Request.Context(), storage.ListWebhookParams{Pagination: p})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.WebhooksResource{Webhooks: webhooks})
+}
+
+func (s *service) handleGetWebhook(c *gin.Context) {
+	id, err := webhookID(c, "id")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.respondWebhook(c, id)
+}
+
+// handleUpdateWebhook replaces the URL and event types of a webhook. The
+// secret and activity are kept unless given.
+func (s *service) handleUpdateWebhook(c *gin.Context) {
+	const op errors.Op = "server.handleUpdateWebhook"
+
+	id, err := webhookID(c, "id")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	err = s.storage.UpdateWebhook(c.Request.Context(), id, func(old *model.Webhook) (*model.Webhook, error) {
+		old.URL = req.URL
+		old.Types = req.Types
+		if req.Secret != "" {
+			old.Secret = req.Secret
+		}
+
+		if req.Active != nil {
+			old.Active = *req.Active
+		}
+
+		return old, nil
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.respondWebhook(c, id)
+}
+
+func (s *service) handleDeleteWebhook(c *gin.Context) {
+	id, err := webhookID(c, "id")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	if err := s.storage.DeleteWebhook(c.Request.Context(), id); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *service) handleListWebhookDeliveries(c *gin.Context) {
+	id, err := webhookID(c, "id")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	if _, err := s.storage.GetWebhook(c.Request.Context(), id); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	deliveries, total, err := s.storage.ListWebhookDeliveries(c.Request.Context(), storage.ListWebhookDeliveryParams{
+		Pagination: p,
+		WebhookID:  id,
+		Status:     c.Query("status"),
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.WebhookDeliveriesResource{Deliveries: deliveries})
+}
+
+// handleListWebhookAttempts lists the attempts to send the deliveries of a
+// webhook, or of one of them given by "delivery_id", most recent first.
+func (s *service) handleListWebhookAttempts(c *gin.Context) {
+	const op errors.Op = "server.handleListWebhookAttempts"
+
+	id, err := webhookID(c, "id")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	var deliveryID int64
+	if value := c.Query("delivery_id"); value != "" {
+		if deliveryID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			s.abortWithError(c, errors.E(op, errors.KindBadRequest, "invalid delivery_id"))
+			return
+		}
+	}
+
+	if _, err := s.storage.GetWebhook(c.Request.Context(), id); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	attempts, total, err := s.storage.ListWebhookAttempts(c.Request.Context(), storage.ListWebhookAttemptParams{
+		Pagination: p,
+		WebhookID:  id,
+		DeliveryID: deliveryID,
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.WebhookAttemptsResource{Attempts: attempts})
+}
+
+// handleReplayWebhookDelivery sends a dead delivery again.
+func (s *service) handleReplayWebhookDelivery(c *gin.Context) {
+	id, err := webhookID(c, "id")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	deliveryID, err := webhookID(c, "delivery_id")
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	delivery, err := s.storage.ReplayWebhookDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// respondWebhook responds with a webhook, without its secret.
+func (s *service) respondWebhook(c *gin.Context, id int64) {
+	webhook, err := s.storage.GetWebhook(c.Request.Context(), id)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	webhook.Secret = ""
+	c.JSON(http.StatusOK, webhook)
+}
+
+// webhookID reads the ID of a webhook, or of one of its deliveries, from the
+// path parameter key.
+func webhookID(c *gin.Context, key string) (int64, error) {
+	const op errors.Op = "server.webhookID"
+
+	id, err := strconv.ParseInt(c.Param(key), 10, 64)
+	if err != nil {
+		return 0, errors.E(op, errors.KindBadRequest, "invalid "+key)
+	}
+
+	return id, nil
+}
+
+// newSecret returns a random webhook secret.
+func newSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New(nil)
+	h := newTestService(t, st)
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/webhooks", body{"url": "https://pager.example.com", "types": []string{model.EventCageCreated}})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var webhook model.Webhook
+	decode(t, rec, &webhook)
+	assert.NotZero(t, webhook.ID)
+	assert.True(t, webhook.Active)
+	assert.Len(t, webhook.Secret, 2*secretSize)
+
+	path := fmt.Sprintf("%s/webhooks/%d", Prefix, webhook.ID)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/webhooks", body{"url": "https://pager.example.com", "types": []string{"cage.exploded"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/webhooks", body{"types": []string{model.EventCageCreated}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var got model.Webhook
+	decode(t, rec, &got)
+	assert.Equal(t, "https://pager.example.com", got.URL)
+	assert.Empty(t, got.Secret)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/webhooks", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+	assert.NotContains(t, rec.Body.String(), webhook.Secret)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/webhooks/foo", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/webhooks/-1", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// A delivery that ran out of attempts can be replayed.
+	createCage(t, h, 2)
+	_, err := st.EnqueueWebhookDeliveries(ctx, 100)
+	require.NoError(t, err)
+
+	claimed, err := st.ClaimWebhookDeliveries(ctx, time.Now(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.NoError(t, st.RecordWebhookAttempt(ctx, &model.WebhookAttempt{DeliveryID: claimed[0].ID, Attempt: 1, StatusCode: 410}, *claimed[0].NextAttemptAt, model.DeliveryDead, time.Time{}))
+
+	rec = doRequest(t, h, http.MethodGet, path+"/deliveries?status=dead", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, path+"/deliveries?status=lost", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, fmt.Sprintf("%s/attempts?delivery_id=%d", path, claimed[0].ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var attempts model.WebhookAttemptsResource
+	decode(t, rec, &attempts)
+	if assert.Len(t, attempts.Attempts, 1) {
+		assert.Equal(t, 410, attempts.Attempts[0].StatusCode)
+	}
+
+	replay := fmt.Sprintf("%s/deliveries/%d/replay", path, claimed[0].ID)
+	rec = doRequest(t, h, http.MethodPost, replay, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var delivery model.WebhookDelivery
+	decode(t, rec, &delivery)
+	assert.Equal(t, model.DeliveryPending, delivery.Status)
+	assert.Zero(t, delivery.Attempts)
+
+	rec = doRequest(t, h, http.MethodPost, replay, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, path, body{"url": "https://tickets.example.com", "active": false})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	got = model.Webhook{}
+	decode(t, rec, &got)
+	assert.Equal(t, "https://tickets.example.com", got.URL)
+	assert.False(t, got.Active)
+	assert.Empty(t, got.Types)
+
+	stored, err := st.GetWebhook(ctx, webhook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.Secret, stored.Secret)
+
+	rec = doRequest(t, h, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path+"/deliveries", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
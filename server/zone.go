@@ -0,0 +1,135 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type zoneRequest struct {
+	Name           string   `json:"name" binding:"required"`
+	ForbiddenKinds []string `json:"forbidden_kinds"`
+}
+
+func (s *service) handleCreateZone(c *gin.Context) {
+	const op errors.Op = "server.handleCreateZone"
+
+	var req zoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	id, err := s.nextID(model.NewZoneID)
+	if err != nil {
+		s.abortWithError(c, errors.E(op, err))
+		return
+	}
+
+	zone := &model.Zone{
+		ID:             id,
+		Name:           req.Name,
+		ForbiddenKinds: req.ForbiddenKinds,
+	}
+
+	if err := s.storage.CreateZone(c.Request.Context(), zone); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.respondZone(c, http.StatusCreated, id)
+}
+
+func (s *service) handleListZones(c *gin.Context) {
+	p, err := pagination(c)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	zones, total, err := s.storage.ListZones(c.Request.Context(), storage.ListZoneParams{Pagination: p})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Header(TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, &model.ZonesResource{Zones: zones})
+}
+
+func (s *service) handleGetZone(c *gin.Context) {
+	s.respondZone(c, http.StatusOK, model.ID(c.Param("id")))
+}
+
+// handleUpdateZone renames a zone and replaces the diet kinds it forbids,
+// which must not be held by any of its cages.
+func (s *service) handleUpdateZone(c *gin.Context) {
+	const op errors.Op = "server.handleUpdateZone"
+
+	var req zoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.abortWithError(c, errors.E(op, errors.KindBadRequest, err))
+		return
+	}
+
+	id := model.ID(c.Param("id"))
+	err := s.storage.UpdateZone(c.Request.Context(), id, func(old *model.Zone) (*model.Zone, error) {
+		old.Name = req.Name
+		old.ForbiddenKinds = req.ForbiddenKinds
+		return old, nil
+	})
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.respondZone(c, http.StatusOK, id)
+}
+
+func (s *service) handleDeleteZone(c *gin.Context) {
+	if err := s.storage.DeleteZone(c.Request.Context(), model.ID(c.Param("id"))); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *service) handleListZoneCages(c *gin.Context) {
+	id := model.ID(c.Param("id"))
+	if _, err := s.storage.GetZone(c.Request.Context(), id); err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	s.listCages(c, storage.ListCageParams{ZoneID: id})
+}
+
+// respondZone responds with a zone and its aggregates.
+func (s *service) respondZone(c *gin.Context, code int, id model.ID) {
+	zone, err := s.storage.GetZone(c.Request.Context(), id)
+	if err != nil {
+		s.abortWithError(c, err)
+		return
+	}
+
+	c.JSON(code, &model.ZonesResource{Zones: []*model.Zone{zone}})
+}
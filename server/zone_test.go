@@ -0,0 +1,115 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZones(t *testing.T) {
+	h := newTestService(t, memory.New(nil))
+
+	rec := doRequest(t, h, http.MethodPost, Prefix+"/zones", body{"name": "Petting Zoo", "forbidden_kinds": []string{model.KindCarnivore}})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res model.ZonesResource
+	decode(t, rec, &res)
+	require.Len(t, res.Zones, 1)
+	zone := res.Zones[0]
+	assert.Equal(t, []string{model.KindCarnivore}, zone.ForbiddenKinds)
+
+	path := Prefix + "/zones/" + string(zone.ID)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/zones", body{"name": "Petting Zoo"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/zones", body{"name": "North Sector", "forbidden_kinds": []string{"omnivore"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/cages", body{"capacity": 4, "zone_id": zone.ID})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var cages model.CagesResource
+	decode(t, rec, &cages)
+	require.Len(t, cages.Cages, 1)
+	paddock := cages.Cages[0]
+	assert.Equal(t, zone.ID, paddock.ZoneID)
+
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/cages", body{"capacity": 4, "zone_id": "zn_foo"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// The zone forbids carnivores as first occupants.
+	rec = doRequest(t, h, http.MethodPost, Prefix+"/dinosaurs", body{"name": "Rexy", "species": model.Tyrannosaurus, "cage_id": paddock.ID})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	createDinosaur(t, h, "Cera", model.Triceratops, paddock.ID)
+
+	pen := createCage(t, h, 2)
+	createDinosaur(t, h, "Blue", model.Velociraptor, pen.ID)
+
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(pen.ID)+"/zone", body{"zone_id": zone.ID})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	decode(t, rec, &res)
+	require.Len(t, res.Zones, 1)
+	assert.Equal(t, 1, res.Zones[0].Cages)
+	assert.Equal(t, 4, res.Zones[0].Capacity)
+	assert.Equal(t, 1, res.Zones[0].Allocation)
+	assert.Equal(t, map[model.PowerStatus]int{model.PowerActive: 1}, res.Zones[0].Power)
+
+	rec = doRequest(t, h, http.MethodGet, path+"/cages", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/cages?zone_id="+string(zone.ID), nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/zones", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+
+	rec = doRequest(t, h, http.MethodGet, Prefix+"/zones/zn_foo/cages", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, path, body{"name": "Petting Zoo", "forbidden_kinds": []string{model.KindHerbivores}})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(t, h, http.MethodPut, Prefix+"/cages/"+string(paddock.ID)+"/zone", body{"zone_id": ""})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(t, h, http.MethodPut, path, body{"name": "Herbivore Meadow", "forbidden_kinds": []string{model.KindHerbivores}})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	decode(t, rec, &res)
+	assert.Equal(t, "Herbivore Meadow", res.Zones[0].Name)
+	assert.Zero(t, res.Zones[0].Cages)
+
+	rec = doRequest(t, h, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRequest(t, h, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
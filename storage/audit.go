@@ -0,0 +1,87 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// Snapshot returns the JSON state of an entity recorded in the audit log and
+// the outbox. Fields computed from other tables, such as the occupancy of a
+// cage or the last feeding of a dinosaur, are left out, and so are secrets. A
+// nil entity, such as the state before a creation, has no snapshot.
+func Snapshot(entity interface{}) json.RawMessage {
+	if v := reflect.ValueOf(entity); !v.IsValid() || v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+
+	switch e := entity.(type) {
+	case *model.Cage:
+		c := *e
+		c.Allocation = 0
+		c.Species = ""
+		c.Dinosaurs = nil
+		c.ForbiddenKinds = nil
+		entity = &c
+	case *model.Dinosaur:
+		d := *e
+		d.LastFedAt = nil
+		entity = &d
+	case *model.Zone:
+		z := *e
+		z.Cages, z.Capacity, z.Allocation, z.Power = 0, 0, 0, nil
+		entity = &z
+	case *model.Circuit:
+		c := *e
+		c.Cages = 0
+		entity = &c
+	case *model.Webhook:
+		w := *e
+		w.Secret = ""
+		entity = &w
+	}
+
+	b, err := json.Marshal(entity)
+	if err != nil {
+		return nil
+	}
+
+	return b
+}
+
+// NewAuditEntry records that the operation op changed an entity on behalf of
+// the actor carried by ctx.
+func NewAuditEntry(ctx context.Context, op errors.Op, entityType string, entityID model.ID, before, after json.RawMessage) *model.AuditEntry {
+	return &model.AuditEntry{
+		Actor:      Actor(ctx),
+		Op:         op.String(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     before,
+		After:      after,
+	}
+}
+
+// SerialID returns the entity ID of records with serial IDs, such as
+// maintenance windows.
+func SerialID(id int64) model.ID {
+	return model.ID(strconv.FormatInt(id, 10))
+}
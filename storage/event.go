@@ -0,0 +1,48 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+)
+
+// NewEvent returns an outbox event about an entity carrying the snapshot of
+// payload.
+func NewEvent(eventType string, entityID model.ID, payload interface{}) *model.Event {
+	return &model.Event{
+		Type:     eventType,
+		EntityID: entityID,
+		Payload:  Snapshot(payload),
+	}
+}
+
+// NewSpeciesChange returns the audit entry and the event recording a change
+// of the species catalog. Either before or after is nil when the species is
+// created or deleted.
+func NewSpeciesChange(ctx context.Context, op errors.Op, name model.Species, before, after *model.SpeciesEntry) (*model.AuditEntry, *model.Event) {
+	var b, a, payload interface{}
+	if before != nil {
+		b, payload = before, before
+	}
+	if after != nil {
+		a, payload = after, after
+	}
+
+	entry := NewAuditEntry(ctx, op, model.EntitySpecies, model.ID(name), Snapshot(b), Snapshot(a))
+	return entry, NewEvent(model.EventSpeciesChanged, model.ID(name), payload)
+}
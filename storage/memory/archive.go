@@ -0,0 +1,148 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) ArchiveCage(ctx context.Context, id model.ID, reason string) error {
+	const op errors.Op = "memory.ArchiveCage"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cage, err := m.getCage(id, op)
+	if err != nil {
+		return err
+	}
+
+	if cage.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is already archived", id))
+	}
+
+	if err := park.CheckArchive(cage, cage.Allocation); err != nil {
+		return errors.E(op, err)
+	}
+
+	before := storage.Snapshot(cage)
+	now := m.timestamp()
+	cage.DeletedAt = now
+	cage.ArchivedReason = reason
+	cage.UpdatedAt = now
+	cage.Version++
+	m.cages[id] = cloneCage(cage)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityCage, id, before, storage.Snapshot(cage)))
+	m.publish(storage.NewEvent(model.EventCageArchived, id, cage))
+
+	return nil
+}
+
+func (m *Memory) RestoreCage(ctx context.Context, id model.ID) error {
+	const op errors.Op = "memory.RestoreCage"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cage, err := m.getCage(id, op)
+	if err != nil {
+		return err
+	}
+
+	if !cage.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is not archived", id))
+	}
+
+	before := storage.Snapshot(cage)
+	cage.DeletedAt = nil
+	cage.ArchivedReason = ""
+	cage.UpdatedAt = m.timestamp()
+	cage.Version++
+	m.cages[id] = cloneCage(cage)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityCage, id, before, storage.Snapshot(cage)))
+	m.publish(storage.NewEvent(model.EventCageRestored, id, cage))
+
+	return nil
+}
+
+func (m *Memory) ArchiveDinosaur(ctx context.Context, id model.ID, reason string) error {
+	const op errors.Op = "memory.ArchiveDinosaur"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dinosaur, err := m.getDinosaur(id, op)
+	if err != nil {
+		return err
+	}
+
+	if dinosaur.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is already archived", id))
+	}
+
+	before := storage.Snapshot(dinosaur)
+	now := m.timestamp()
+	dinosaur.DeletedAt = now
+	dinosaur.ArchivedReason = reason
+	dinosaur.UpdatedAt = now
+	dinosaur.Version++
+	m.dinosaurs[id] = dinosaur
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityDinosaur, id, before, storage.Snapshot(dinosaur)))
+	m.publish(storage.NewEvent(model.EventDinosaurRemoved, id, dinosaur))
+
+	return nil
+}
+
+func (m *Memory) RestoreDinosaur(ctx context.Context, id model.ID) error {
+	const op errors.Op = "memory.RestoreDinosaur"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dinosaur, err := m.getDinosaur(id, op)
+	if err != nil {
+		return err
+	}
+
+	if !dinosaur.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is not archived", id))
+	}
+
+	before := storage.Snapshot(dinosaur)
+	if err := m.checkName(dinosaur, op); err != nil {
+		return err
+	}
+
+	// The cage may have changed while the dinosaur was away.
+	if err := m.place(dinosaur, op); err != nil {
+		return err
+	}
+
+	dinosaur.DeletedAt = nil
+	dinosaur.ArchivedReason = ""
+	dinosaur.UpdatedAt = m.timestamp()
+	dinosaur.Version++
+	m.dinosaurs[id] = dinosaur
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityDinosaur, id, before, storage.Snapshot(dinosaur)))
+	m.publish(storage.NewEvent(model.EventDinosaurPlaced, id, dinosaur))
+
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_ArchiveDinosaur(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := &model.Cage{ID: model.NewCageID(newID()), Capacity: 1}
+	if err := m.CreateCage(ctx, cage); err != nil {
+		t.Fatal(err)
+	}
+
+	d1 := newTestDinosaur(cage.ID, model.Tyrannosaurus)
+	if err := m.CreateDinosaur(ctx, d1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.ArchiveDinosaur(ctx, d1.ID, "deceased"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.GetDinosaur(ctx, d1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, got.Archived())
+	assert.Equal(t, "deceased", got.ArchivedReason)
+
+	_, total, err := m.ListDinosaurs(ctx, storage.ListDinosaurParams{CageID: cage.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Zero(t, total)
+
+	_, total, err = m.ListDinosaurs(ctx, storage.ListDinosaurParams{CageID: cage.ID, IncludeArchived: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, total)
+
+	// The name and the slot of an archived dinosaur are free again.
+	d2 := newTestDinosaur(cage.ID, model.Tyrannosaurus)
+	d2.Name = d1.Name
+	if err := m.CreateDinosaur(ctx, d2); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.TransferDinosaur(ctx, d1.ID, newTestCage(t, m).ID, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.RestoreDinosaur(ctx, d1.ID)
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+
+	if err := m.ArchiveDinosaur(ctx, d2.ID, "relocated"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, m.RestoreDinosaur(ctx, d1.ID))
+
+	err = m.RestoreDinosaur(ctx, d1.ID)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.RestoreDinosaur(ctx, d2.ID)
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+}
+
+func TestMemory_ArchiveCage(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := newTestCage(t, m)
+
+	d := newTestDinosaur(cage.ID, model.Triceratops)
+	if err := m.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	err := m.ArchiveCage(ctx, cage.ID, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	if err := m.ArchiveDinosaur(ctx, d.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.ArchiveCage(ctx, cage.ID, "decommissioned"); err != nil {
+		t.Fatal(err)
+	}
+
+	cages, _, err := m.ListCages(ctx, storage.ListCageParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, cages)
+
+	cages, _, err = m.ListCages(ctx, storage.ListCageParams{IncludeArchived: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, cages, 1)
+	assert.Equal(t, "decommissioned", cages[0].ArchivedReason)
+
+	err = m.CreateDinosaur(ctx, newTestDinosaur(cage.ID, model.Triceratops))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.RestoreDinosaur(ctx, d.ID)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	if err := m.RestoreCage(ctx, cage.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, m.RestoreDinosaur(ctx, d.ID))
+
+	got, err := m.GetCage(ctx, cage.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, got.Allocation)
+	assert.Equal(t, 3, got.Version)
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+// audit appends an entry to the audit log. The caller must hold the write
+// lock.
+func (m *Memory) audit(entry *model.AuditEntry) {
+	entry.ID = m.nextSeq()
+	entry.CreatedAt = m.timestamp()
+	m.auditLog = append(m.auditLog, entry)
+}
+
+func (m *Memory) ListAuditEntries(ctx context.Context, params storage.ListAuditParams) ([]*model.AuditEntry, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []*model.AuditEntry
+	for i := len(m.auditLog) - 1; i >= 0; i-- {
+		entry := m.auditLog[i]
+		if params.EntityID != "" && entry.EntityID != params.EntityID {
+			continue
+		}
+
+		if params.Actor != "" && entry.Actor != params.Actor {
+			continue
+		}
+
+		if !params.Since.IsZero() && entry.CreatedAt.Before(params.Since) {
+			continue
+		}
+
+		if !params.Until.IsZero() && !entry.CreatedAt.Before(params.Until) {
+			continue
+		}
+
+		if entry.ID <= params.AfterID {
+			continue
+		}
+
+		e := *entry
+		entries = append(entries, &e)
+	}
+
+	return page(entries, params.Pagination), len(entries), nil
+}
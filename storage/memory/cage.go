@@ -0,0 +1,403 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+var cageOrders = map[string]func(a, b *model.Cage) bool{
+	storage.CageOrderID:         func(a, b *model.Cage) bool { return a.ID < b.ID },
+	storage.CageOrderCapacity:   func(a, b *model.Cage) bool { return a.Capacity < b.Capacity },
+	storage.CageOrderAllocation: func(a, b *model.Cage) bool { return a.Allocation < b.Allocation },
+	storage.CageOrderCreatedAt:  func(a, b *model.Cage) bool { return a.CreatedAt.Before(*b.CreatedAt) },
+	storage.CageOrderUpdatedAt:  func(a, b *model.Cage) bool { return a.UpdatedAt.Before(*b.UpdatedAt) },
+}
+
+func (m *Memory) CreateCage(ctx context.Context, cage *model.Cage) error {
+	const op errors.Op = "memory.CreateCage"
+
+	if cage.Status == "" {
+		cage.Status = model.PowerActive
+	}
+
+	if !cage.Status.Valid() {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid power status: %q", cage.Status))
+	}
+
+	if err := park.CheckCapacity(cage, 0); err != nil {
+		return errors.E(op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.cages[cage.ID]; exists {
+		return errors.E(op, errors.KindAlreadyExists, fmt.Sprintf("cage %s already exists", cage.ID))
+	}
+
+	if _, err := m.cageZone(cage.ZoneID, op); err != nil {
+		return err
+	}
+
+	circuit, err := m.cageCircuit(cage.CircuitID, op)
+	if err != nil {
+		return err
+	}
+
+	if err := park.CheckCircuitLive(circuit); err != nil {
+		return errors.E(op, err)
+	}
+
+	now := m.timestamp()
+	cage.CreatedAt = now
+	cage.UpdatedAt = now
+	cage.Version = 1
+
+	m.cages[cage.ID] = cloneCage(cage)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityCage, cage.ID, nil, storage.Snapshot(cage)))
+	m.publish(storage.NewEvent(model.EventCageCreated, cage.ID, cage))
+	m.recordPower(ctx, cage.ID, "", cage.Status)
+
+	return nil
+}
+
+func (m *Memory) UpdateCage(ctx context.Context, id model.ID, updater storage.CageUpdater) error {
+	const op errors.Op = "memory.UpdateCage"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, err := m.getCage(id, op)
+	if err != nil {
+		return err
+	}
+
+	if old.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is archived", id))
+	}
+
+	before := storage.Snapshot(old)
+	allocation, status, version, zoneID, circuitID := old.Allocation, old.Status, old.Version, old.ZoneID, old.CircuitID
+	cage, err := updater(old)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.CheckVersion(id, version, cage.Version); err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := park.CheckCapacity(cage, allocation); err != nil {
+		return errors.E(op, err)
+	}
+
+	if cage.Status != status {
+		if err := park.CheckPowerTransition(id, status, cage.Status, allocation); err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	if cage.ZoneID != zoneID {
+		zone, err := m.cageZone(cage.ZoneID, op)
+		if err != nil {
+			return err
+		}
+
+		if err := park.CheckZoneCages(zone, []*model.Cage{cage}); err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	// The fence of a cage on a cut circuit stays DOWN until it is restored.
+	circuitIDs := []model.ID{circuitID, cage.CircuitID}
+	if cage.CircuitID == circuitID {
+		circuitIDs = circuitIDs[:1]
+	}
+
+	if cage.Status != status || cage.CircuitID != circuitID {
+		for _, cid := range circuitIDs {
+			circuit, err := m.cageCircuit(cid, op)
+			if err != nil {
+				return err
+			}
+
+			if err := park.CheckCircuitLive(circuit); err != nil {
+				return errors.E(op, err)
+			}
+		}
+	}
+
+	cage.ID = id
+	cage.UpdatedAt = m.timestamp()
+	cage.Version++
+	m.cages[id] = cloneCage(cage)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityCage, id, before, storage.Snapshot(cage)))
+
+	if cage.Status != status {
+		m.recordPower(ctx, id, status, cage.Status)
+	}
+
+	return nil
+}
+
+// recordPower appends a power transition of a cage to its history and
+// publishes it unless the cage is new.
+func (m *Memory) recordPower(ctx context.Context, id model.ID, from, to model.PowerStatus) {
+	event := &model.PowerEvent{
+		ID:        m.nextSeq(),
+		CageID:    id,
+		From:      from,
+		To:        to,
+		Actor:     storage.Actor(ctx),
+		Reason:    storage.Reason(ctx),
+		CreatedAt: m.timestamp(),
+	}
+
+	m.powerEvents = append(m.powerEvents, event)
+
+	if from != "" {
+		m.publish(storage.NewEvent(model.EventCagePowerChanged, id, event))
+	}
+}
+
+func (m *Memory) ListPowerEvents(ctx context.Context, cageID model.ID, pagination *storage.Pagination) ([]*model.PowerEvent, int, error) {
+	const op errors.Op = "memory.ListPowerEvents"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, err := m.getCage(cageID, op); err != nil {
+		return nil, 0, err
+	}
+
+	var events []*model.PowerEvent
+	for i := len(m.powerEvents) - 1; i >= 0; i-- {
+		if event := m.powerEvents[i]; event.CageID == cageID {
+			e := *event
+			events = append(events, &e)
+		}
+	}
+
+	return page(events, pagination), len(events), nil
+}
+
+func (m *Memory) GetCage(ctx context.Context, id model.ID) (*model.Cage, error) {
+	const op errors.Op = "memory.GetCage"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cage, err := m.getCage(id, op)
+	if err != nil {
+		return nil, err
+	}
+
+	m.fillLastFed(cage.Dinosaurs)
+	return cage, nil
+}
+
+// getCage returns a copy of a cage with its occupancy and dinosaurs.
+func (m *Memory) getCage(id model.ID, op errors.Op) (*model.Cage, error) {
+	cage, ok := m.cages[id]
+	if !ok {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("cage %s does not exist", id))
+	}
+
+	return m.withOccupancy(cage, true), nil
+}
+
+// withOccupancy returns a copy of cage with Allocation and Species computed
+// and, if withDinosaurs is set, its dinosaurs.
+func (m *Memory) withOccupancy(cage *model.Cage, withDinosaurs bool) *model.Cage {
+	c := cloneCage(cage)
+
+	occupants := m.occupants(cage.ID)
+	c.Allocation = len(occupants)
+	for i, d := range occupants {
+		if i == 0 {
+			c.Species = d.Species
+		} else if d.Species != c.Species {
+			c.Species = ""
+			break
+		}
+	}
+
+	if withDinosaurs {
+		c.Dinosaurs = occupants
+	}
+
+	if zone, ok := m.zones[cage.ZoneID]; ok {
+		c.ForbiddenKinds = append([]string(nil), zone.ForbiddenKinds...)
+	}
+
+	return c
+}
+
+// cageZone returns the zone a cage joins, or an empty zone for cages out of
+// any zone. Unknown zones are reported as errors.KindBadRequest.
+func (m *Memory) cageZone(id model.ID, op errors.Op) (*model.Zone, error) {
+	if id == "" {
+		return &model.Zone{}, nil
+	}
+
+	zone, ok := m.zones[id]
+	if !ok {
+		return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("zone %s does not exist", id))
+	}
+
+	return zone, nil
+}
+
+// cageCircuit returns the circuit a cage joins, or an empty circuit for
+// cages off the grid. Unknown circuits are reported as errors.KindBadRequest.
+func (m *Memory) cageCircuit(id model.ID, op errors.Op) (*model.Circuit, error) {
+	if id == "" {
+		return &model.Circuit{}, nil
+	}
+
+	circuit, ok := m.circuits[id]
+	if !ok {
+		return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("circuit %s does not exist", id))
+	}
+
+	return circuit, nil
+}
+
+// occupants returns copies of the live dinosaurs held by a cage ordered as
+// the Postgres backend does.
+func (m *Memory) occupants(id model.ID) []*model.Dinosaur {
+	var dinosaurs []*model.Dinosaur
+	for _, d := range m.dinosaurs {
+		if d.CageID == id && !d.Archived() {
+			dinosaurs = append(dinosaurs, cloneDinosaur(d))
+		}
+	}
+
+	sortDinosaurs(dinosaurs)
+	return dinosaurs
+}
+
+func (m *Memory) ListCages(ctx context.Context, params storage.ListCageParams) ([]*model.Cage, int, error) {
+	const op errors.Op = "memory.ListCages"
+
+	if params.Status != "" && !params.Status.Valid() {
+		return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid status: %q", params.Status))
+	}
+
+	if params.Kind != "" && !model.ValidKind(params.Kind) {
+		return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid kind: %q", params.Kind))
+	}
+
+	field, desc := storage.SplitOrder(params.OrderBy)
+	if field == "" {
+		field = storage.CageOrderCreatedAt
+	}
+
+	less, ok := cageOrders[field]
+	if !ok {
+		return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid order: %q", params.OrderBy))
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var cages []*model.Cage
+	for _, cage := range m.cages {
+		c := m.withOccupancy(cage, true)
+
+		if c.Archived() && !params.IncludeArchived {
+			continue
+		}
+
+		if params.Status != "" && c.Status != params.Status {
+			continue
+		}
+
+		if params.Species != "" && c.Species != params.Species {
+			continue
+		}
+
+		if params.Kind != "" && !m.holdsKind(c.Dinosaurs, params.Kind) {
+			continue
+		}
+
+		if params.Available && c.Allocation >= c.MaxOccupancy() {
+			continue
+		}
+
+		if params.ZoneID != "" && c.ZoneID != params.ZoneID {
+			continue
+		}
+
+		if params.CircuitID != "" && c.CircuitID != params.CircuitID {
+			continue
+		}
+
+		if !params.WithDinosaurs {
+			c.Dinosaurs = nil
+		}
+
+		cages = append(cages, c)
+	}
+
+	sort.Slice(cages, func(i, j int) bool {
+		a, b := cages[i], cages[j]
+		if desc {
+			a, b = b, a
+		}
+
+		if less(a, b) {
+			return true
+		}
+
+		if less(b, a) {
+			return false
+		}
+
+		return a.ID < b.ID
+	})
+
+	return page(cages, params.Pagination), len(cages), nil
+}
+
+// holdsKind reports whether any of dinosaurs is of a diet kind according to
+// the catalog.
+func (m *Memory) holdsKind(dinosaurs []*model.Dinosaur, kind string) bool {
+	for _, d := range dinosaurs {
+		if entry, ok := m.species[d.Species]; ok && entry.Kind == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cloneCage copies the persisted fields of a cage.
+func cloneCage(cage *model.Cage) *model.Cage {
+	c := *cage
+	c.Allocation = 0
+	c.Species = ""
+	c.Dinosaurs = nil
+	c.ForbiddenKinds = nil
+	return &c
+}
@@ -0,0 +1,354 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_CreateCage(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+
+	c1 := &model.Cage{ID: model.NewCageID(newID()), Capacity: 5}
+	if err := m.CreateCage(ctx, c1); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, model.PowerActive, c1.Status)
+	assert.NotNil(t, c1.CreatedAt)
+	assert.NotNil(t, c1.UpdatedAt)
+
+	c2, err := m.GetCage(ctx, c1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, c1.Capacity, c2.Capacity)
+	assert.Equal(t, c1.CreatedAt, c2.CreatedAt)
+
+	err = m.CreateCage(ctx, &model.Cage{ID: c1.ID})
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+
+	err = m.CreateCage(ctx, &model.Cage{ID: model.NewCageID(newID()), Status: "ON"})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	_, err = m.GetCage(ctx, "foo")
+	assert.True(t, errors.IsNotFoundErr(err))
+}
+
+func TestMemory_UpdateCage(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := newTestCage(t, m)
+
+	err := m.UpdateCage(ctx, cage.ID, func(old *model.Cage) (*model.Cage, error) {
+		old.Capacity = 3
+		old.Status = model.PowerMaintenance
+		return old, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.GetCage(ctx, cage.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, got.Capacity)
+	assert.Equal(t, model.PowerMaintenance, got.Status)
+	assert.True(t, got.UpdatedAt.After(*got.CreatedAt))
+
+	// A failed updater leaves the cage untouched, even if it mutated it.
+	err = m.UpdateCage(ctx, cage.ID, func(old *model.Cage) (*model.Cage, error) {
+		old.Capacity = 1
+		return nil, errors.E(errors.Op("test"), errors.KindBadRequest)
+	})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	got, err = m.GetCage(ctx, cage.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, got.Capacity)
+
+	err = m.UpdateCage(ctx, "foo", func(old *model.Cage) (*model.Cage, error) { return old, nil })
+	assert.True(t, errors.IsNotFoundErr(err))
+}
+
+func TestMemory_ListCages(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+
+	newCage := func(capacity int, status model.PowerStatus) *model.Cage {
+		cage := &model.Cage{ID: model.NewCageID(newID()), Capacity: capacity, Status: status}
+		if err := m.CreateCage(ctx, cage); err != nil {
+			t.Fatal(err)
+		}
+		return cage
+	}
+
+	addDinosaur := func(cage *model.Cage, species model.Species) {
+		if err := m.CreateDinosaur(ctx, newTestDinosaur(cage.ID, species)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	full := newCage(1, model.PowerActive)
+	addDinosaur(full, model.Tyrannosaurus)
+
+	mixed := newCage(5, model.PowerActive)
+	addDinosaur(mixed, model.Triceratops)
+	addDinosaur(mixed, model.Stegosaurus)
+
+	empty := newCage(3, model.PowerDown)
+
+	ids := func(cages []*model.Cage) []model.ID {
+		var ids []model.ID
+		for _, cage := range cages {
+			ids = append(ids, cage.ID)
+		}
+		return ids
+	}
+
+	cages, total, err := m.ListCages(ctx, storage.ListCageParams{Species: model.Tyrannosaurus, WithDinosaurs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []model.ID{full.ID}, ids(cages))
+	assert.Equal(t, 1, cages[0].Allocation)
+	assert.Equal(t, model.Tyrannosaurus, cages[0].Species)
+	assert.Len(t, cages[0].Dinosaurs, 1)
+
+	cages, _, err = m.ListCages(ctx, storage.ListCageParams{Kind: model.KindHerbivores, Available: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []model.ID{mixed.ID}, ids(cages))
+	assert.Equal(t, 2, cages[0].Allocation)
+	assert.Empty(t, cages[0].Species)
+	assert.Empty(t, cages[0].Dinosaurs)
+
+	cages, _, err = m.ListCages(ctx, storage.ListCageParams{Status: model.PowerDown, Available: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []model.ID{empty.ID}, ids(cages))
+
+	cages, total, err = m.ListCages(ctx, storage.ListCageParams{
+		OrderBy:    "-" + storage.CageOrderCreatedAt,
+		Pagination: storage.NewPagination(2, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []model.ID{empty.ID, mixed.ID}, ids(cages))
+
+	cages, _, err = m.ListCages(ctx, storage.ListCageParams{OrderBy: storage.CageOrderAllocation})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []model.ID{empty.ID, full.ID, mixed.ID}, ids(cages))
+
+	// Ties are broken by ID in the direction of the order.
+	other := newCage(1, model.PowerActive)
+	addDinosaur(other, model.Velociraptor)
+
+	tied := []model.ID{full.ID, other.ID}
+	if tied[0] < tied[1] {
+		tied[0], tied[1] = tied[1], tied[0]
+	}
+
+	cages, _, err = m.ListCages(ctx, storage.ListCageParams{OrderBy: "-" + storage.CageOrderAllocation})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []model.ID{mixed.ID, tied[0], tied[1], empty.ID}, ids(cages))
+
+	_, _, err = m.ListCages(ctx, storage.ListCageParams{OrderBy: "name"})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	_, _, err = m.ListCages(ctx, storage.ListCageParams{Kind: "omnivore"})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+}
+
+func TestMemory_UpdateCageCapacityBelowOccupancy(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := newTestCage(t, m)
+	for i := 0; i < 2; i++ {
+		if err := m.CreateDinosaur(ctx, newTestDinosaur(cage.ID, model.Brachiosaurus)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := m.UpdateCage(ctx, cage.ID, func(old *model.Cage) (*model.Cage, error) {
+		old.Capacity = 1
+		return old, nil
+	})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.UpdateCage(ctx, cage.ID, func(old *model.Cage) (*model.Cage, error) {
+		old.Capacity = 2
+		return old, nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestMemory_ConcurrentPlacements(t *testing.T) {
+	const (
+		capacity   = 3
+		placements = 20
+	)
+
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := &model.Cage{ID: model.NewCageID(newID()), Capacity: capacity}
+	if err := m.CreateCage(ctx, cage); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		placed int32
+		full   int32
+	)
+
+	for i := 0; i < placements; i++ {
+		d := newTestDinosaur(cage.ID, model.Triceratops)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := m.CreateDinosaur(ctx, d)
+			switch {
+			case err == nil:
+				atomic.AddInt32(&placed, 1)
+			case errors.IsUnprocessableErr(err):
+				atomic.AddInt32(&full, 1)
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.EqualValues(t, capacity, placed)
+	assert.EqualValues(t, placements-capacity, full)
+}
+
+func TestMemory_CagePowerTransitions(t *testing.T) {
+	m := newTestMemory()
+	ctx := storage.WithActor(context.Background(), "muldoon")
+	cage := newTestCage(t, m)
+	d := newTestDinosaur(cage.ID, model.Velociraptor)
+	if err := m.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	power := func(status model.PowerStatus) storage.CageUpdater {
+		return func(old *model.Cage) (*model.Cage, error) {
+			old.Status = status
+			return old, nil
+		}
+	}
+
+	err := m.UpdateCage(ctx, cage.ID, power(model.PowerDown))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	if err := m.DeleteDinosaur(ctx, d.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.UpdateCage(storage.WithReason(ctx, "fence repair"), cage.ID, power(model.PowerDown)); err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.CreateDinosaur(ctx, newTestDinosaur(cage.ID, model.Velociraptor))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.UpdateCage(ctx, cage.ID, power(model.PowerActive))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	for _, status := range []model.PowerStatus{model.PowerMaintenance, model.PowerActive} {
+		if err := m.UpdateCage(ctx, cage.ID, power(status)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, total, err := m.ListPowerEvents(ctx, cage.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 4, total)
+	assert.Equal(t, model.PowerMaintenance, events[0].From)
+	assert.Equal(t, model.PowerActive, events[0].To)
+	assert.Equal(t, "muldoon", events[0].Actor)
+	assert.Equal(t, model.PowerDown, events[2].To)
+	assert.Equal(t, "fence repair", events[2].Reason)
+	assert.Empty(t, events[3].From)
+
+	_, _, err = m.ListPowerEvents(ctx, "foo", nil)
+	assert.True(t, errors.IsNotFoundErr(err))
+}
+
+func TestMemory_UpdateCageVersion(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := newTestCage(t, m)
+	assert.Equal(t, 1, cage.Version)
+
+	capacity := func(version, capacity int) storage.CageUpdater {
+		return func(old *model.Cage) (*model.Cage, error) {
+			old.Version = version
+			old.Capacity = capacity
+			return old, nil
+		}
+	}
+
+	assert.NoError(t, m.UpdateCage(ctx, cage.ID, capacity(1, 5)))
+
+	err := m.UpdateCage(ctx, cage.ID, capacity(1, 3))
+	assert.True(t, errors.IsPreconditionFailedErr(err))
+
+	got, err := m.GetCage(ctx, cage.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, got.Version)
+	assert.Equal(t, 5, got.Capacity)
+}
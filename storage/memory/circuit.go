@@ -0,0 +1,334 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) CreateCircuit(ctx context.Context, circuit *model.Circuit) error {
+	const op errors.Op = "memory.CreateCircuit"
+
+	if err := park.CheckCircuit(circuit); err != nil {
+		return errors.E(op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.circuits[circuit.ID]; exists {
+		return errors.E(op, errors.KindAlreadyExists, fmt.Sprintf("circuit %s already exists", circuit.ID))
+	}
+
+	for _, c := range m.circuits {
+		if c.Name == circuit.Name {
+			return errors.E(op, errors.KindAlreadyExists, fmt.Sprintf("circuit %q already exists", circuit.Name))
+		}
+	}
+
+	now := m.timestamp()
+	circuit.Status = model.CircuitLive
+	circuit.Cages = 0
+	circuit.CreatedAt = now
+	circuit.UpdatedAt = now
+
+	c := *circuit
+	m.circuits[circuit.ID] = &c
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityCircuit, circuit.ID, nil, storage.Snapshot(circuit)))
+
+	return nil
+}
+
+func (m *Memory) GetCircuit(ctx context.Context, id model.ID) (*model.Circuit, error) {
+	const op errors.Op = "memory.GetCircuit"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	circuit, err := m.getCircuit(id, op)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.withCircuitCages(circuit), nil
+}
+
+// getCircuit returns the stored circuit with an ID.
+func (m *Memory) getCircuit(id model.ID, op errors.Op) (*model.Circuit, error) {
+	circuit, ok := m.circuits[id]
+	if !ok {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("circuit %s does not exist", id))
+	}
+
+	return circuit, nil
+}
+
+// withCircuitCages returns a copy of circuit with the number of its cages.
+func (m *Memory) withCircuitCages(circuit *model.Circuit) *model.Circuit {
+	var cages []*model.Cage
+	for _, cage := range m.cages {
+		if cage.CircuitID == circuit.ID {
+			cages = append(cages, cage)
+		}
+	}
+
+	c := *circuit
+	park.SummarizeCircuit(&c, cages)
+	return &c
+}
+
+// circuitCages returns copies of the cages of a circuit with their
+// occupants, in order of ID.
+func (m *Memory) circuitCages(id model.ID) []*model.Cage {
+	var cages []*model.Cage
+	for _, cage := range m.cages {
+		if cage.CircuitID == id {
+			cages = append(cages, m.withOccupancy(cage, true))
+		}
+	}
+
+	sort.Slice(cages, func(i, j int) bool { return cages[i].ID < cages[j].ID })
+	return cages
+}
+
+func (m *Memory) ListCircuits(ctx context.Context, params storage.ListCircuitParams) ([]*model.Circuit, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	circuits := make([]*model.Circuit, 0, len(m.circuits))
+	for _, circuit := range m.circuits {
+		circuits = append(circuits, m.withCircuitCages(circuit))
+	}
+
+	sort.Slice(circuits, func(i, j int) bool {
+		if circuits[i].Name != circuits[j].Name {
+			return circuits[i].Name < circuits[j].Name
+		}
+
+		return circuits[i].ID < circuits[j].ID
+	})
+
+	return page(circuits, params.Pagination), len(circuits), nil
+}
+
+func (m *Memory) DeleteCircuit(ctx context.Context, id model.ID) error {
+	const op errors.Op = "memory.DeleteCircuit"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	circuit, err := m.getCircuit(id, op)
+	if err != nil {
+		return err
+	}
+
+	if err := park.CheckCircuitDelete(circuit, len(m.circuitCages(id))); err != nil {
+		return errors.E(op, err)
+	}
+
+	delete(m.circuits, id)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityCircuit, id, storage.Snapshot(circuit), nil))
+
+	return nil
+}
+
+func (m *Memory) CutCircuit(ctx context.Context, id model.ID) (*model.CircuitOutage, error) {
+	const op errors.Op = "memory.CutCircuit"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	circuit, err := m.getCircuit(id, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := park.CheckCircuitCut(circuit); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	cages := m.circuitCages(id)
+	impact := park.CircuitImpact(circuit, cages)
+
+	before := storage.Snapshot(circuit)
+	circuit.Status = model.CircuitCut
+	circuit.UpdatedAt = m.timestamp()
+
+	reason := park.CircuitReason(circuit, storage.Reason(ctx))
+	ctx = storage.WithReason(ctx, reason)
+	outage := &model.CircuitOutage{
+		ID:        m.nextSeq(),
+		CircuitID: id,
+		Reason:    reason,
+		Actor:     storage.Actor(ctx),
+		StartedAt: circuit.UpdatedAt,
+	}
+
+	for _, cage := range cages {
+		if !containsCage(impact.Cages, cage.ID) {
+			continue
+		}
+
+		affected := &model.OutageCage{
+			OutageID:   outage.ID,
+			CageID:     cage.ID,
+			Status:     cage.Status,
+			Allocation: cage.Allocation,
+		}
+
+		m.forcePower(ctx, cage.ID, model.PowerDown, op)
+		if cage.Allocation > 0 {
+			incident := park.OutageAlarm(circuit, cage, reason)
+			m.openIncident(ctx, incident, op)
+			affected.IncidentID = incident.ID
+		}
+
+		outage.Cages = append(outage.Cages, affected)
+	}
+
+	m.outages = append(m.outages, cloneOutage(outage))
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityCircuit, id, before, storage.Snapshot(circuit)))
+	m.publish(storage.NewEvent(model.EventCircuitCut, id, outage))
+
+	return outage, nil
+}
+
+func (m *Memory) RestoreCircuit(ctx context.Context, id model.ID) (*model.CircuitOutage, error) {
+	const op errors.Op = "memory.RestoreCircuit"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	circuit, err := m.getCircuit(id, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := park.CheckCircuitRestore(circuit); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	var outage *model.CircuitOutage
+	for i := len(m.outages) - 1; i >= 0 && outage == nil; i-- {
+		if o := m.outages[i]; o.CircuitID == id && o.RestoredAt == nil {
+			outage = o
+		}
+	}
+
+	if outage == nil {
+		return nil, errors.E(op, errors.KindUnexpected, fmt.Sprintf("circuit %s is cut without an outage", id))
+	}
+
+	before := storage.Snapshot(circuit)
+	circuit.Status = model.CircuitLive
+	circuit.UpdatedAt = m.timestamp()
+	outage.RestoredAt = circuit.UpdatedAt
+
+	reason := park.CircuitReason(circuit, storage.Reason(ctx))
+	ctx = storage.WithReason(ctx, reason)
+	for _, affected := range outage.Cages {
+		if cage, ok := m.cages[affected.CageID]; ok && !cage.Archived() && cage.Status == model.PowerDown {
+			m.forcePower(ctx, affected.CageID, affected.Status, op)
+		}
+
+		if affected.IncidentID != 0 {
+			m.addIncidentNote(ctx, affected.IncidentID, "", reason)
+		}
+	}
+
+	restored := cloneOutage(outage)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityCircuit, id, before, storage.Snapshot(circuit)))
+	m.publish(storage.NewEvent(model.EventCircuitRestored, id, restored))
+
+	return restored, nil
+}
+
+// forcePower moves a cage to a power state regardless of its occupancy and
+// of the transitions allowed to operators, as a circuit does to its fences.
+// The caller must hold the write lock.
+func (m *Memory) forcePower(ctx context.Context, id model.ID, to model.PowerStatus, op errors.Op) {
+	cage := m.cages[id]
+	before := storage.Snapshot(cage)
+	from := cage.Status
+
+	cage.Status = to
+	cage.UpdatedAt = m.timestamp()
+	cage.Version++
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityCage, id, before, storage.Snapshot(cage)))
+	m.recordPower(ctx, id, from, to)
+}
+
+func (m *Memory) GetCircuitImpact(ctx context.Context, id model.ID) (*model.CircuitImpact, error) {
+	const op errors.Op = "memory.GetCircuitImpact"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	circuit, err := m.getCircuit(id, op)
+	if err != nil {
+		return nil, err
+	}
+
+	return park.CircuitImpact(circuit, m.circuitCages(id)), nil
+}
+
+func (m *Memory) ListCircuitOutages(ctx context.Context, circuitID model.ID, pagination *storage.Pagination) ([]*model.CircuitOutage, int, error) {
+	const op errors.Op = "memory.ListCircuitOutages"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, err := m.getCircuit(circuitID, op); err != nil {
+		return nil, 0, err
+	}
+
+	var outages []*model.CircuitOutage
+	for i := len(m.outages) - 1; i >= 0; i-- {
+		if outage := m.outages[i]; outage.CircuitID == circuitID {
+			outages = append(outages, cloneOutage(outage))
+		}
+	}
+
+	return page(outages, pagination), len(outages), nil
+}
+
+// containsCage reports whether a cage with an ID is among cages.
+func containsCage(cages []*model.Cage, id model.ID) bool {
+	for _, cage := range cages {
+		if cage.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cloneOutage(outage *model.CircuitOutage) *model.CircuitOutage {
+	o := *outage
+	o.Cages = make([]*model.OutageCage, 0, len(outage.Cages))
+	for _, affected := range outage.Cages {
+		a := *affected
+		o.Cages = append(o.Cages, &a)
+	}
+
+	return &o
+}
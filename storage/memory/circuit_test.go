@@ -0,0 +1,199 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_Circuits(t *testing.T) {
+	m := newTestMemory()
+	ctx := storage.WithActor(context.Background(), "arnold")
+
+	id := newID()
+	circuit := &model.Circuit{ID: model.NewCircuitID(id), Name: "Grid " + id}
+	if err := m.CreateCircuit(ctx, circuit); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.CircuitLive, circuit.Status)
+
+	err := m.CreateCircuit(ctx, &model.Circuit{ID: model.NewCircuitID(newID()), Name: circuit.Name})
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+
+	err = m.CreateCircuit(ctx, &model.Circuit{ID: model.NewCircuitID(newID())})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	join := func(cageID, circuitID model.ID) error {
+		return m.UpdateCage(ctx, cageID, func(old *model.Cage) (*model.Cage, error) {
+			old.CircuitID = circuitID
+			return old, nil
+		})
+	}
+
+	power := func(cageID model.ID, status model.PowerStatus) error {
+		return m.UpdateCage(ctx, cageID, func(old *model.Cage) (*model.Cage, error) {
+			old.Status = status
+			return old, nil
+		})
+	}
+
+	carnivores, herbivores, empty := newTestCage(t, m), newTestCage(t, m), newTestCage(t, m)
+	rex := newTestDinosaur(carnivores.ID, model.Tyrannosaurus)
+	cera := newTestDinosaur(herbivores.ID, model.Triceratops)
+	for _, d := range []*model.Dinosaur{rex, cera} {
+		if err := m.CreateDinosaur(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := power(empty.ID, model.PowerMaintenance); err != nil {
+		t.Fatal(err)
+	}
+	for _, cage := range []*model.Cage{carnivores, herbivores, empty} {
+		if err := join(cage.ID, circuit.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.True(t, errors.Is(join(carnivores.ID, "ct_foo"), errors.KindBadRequest))
+
+	got, err := m.GetCircuit(ctx, circuit.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, got.Cages)
+	}
+
+	cages, total, err := m.ListCages(ctx, storage.ListCageParams{CircuitID: circuit.ID})
+	if assert.NoError(t, err) && assert.Len(t, cages, 3) {
+		assert.Equal(t, 3, total)
+	}
+
+	// A dry run changes nothing.
+	impact, err := m.GetCircuitImpact(ctx, circuit.ID)
+	if assert.NoError(t, err) {
+		assert.Len(t, impact.Cages, 3)
+		assert.Len(t, impact.Dinosaurs, 2)
+		assert.Equal(t, 2, impact.Alarms)
+	}
+
+	_, err = m.GetCircuitImpact(ctx, "ct_foo")
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	outage, err := m.CutCircuit(storage.WithReason(ctx, "storm"), circuit.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "storm", outage.Reason)
+	assert.Equal(t, "arnold", outage.Actor)
+	assert.Nil(t, outage.RestoredAt)
+	if len(outage.Cages) != 3 {
+		t.Fatalf("outage cages = %d, want 3", len(outage.Cages))
+	}
+
+	statuses := make(map[model.ID]model.PowerStatus)
+	incidents := make(map[model.ID]int64)
+	for _, affected := range outage.Cages {
+		statuses[affected.CageID] = affected.Status
+		incidents[affected.CageID] = affected.IncidentID
+	}
+
+	assert.Equal(t, model.PowerActive, statuses[carnivores.ID])
+	assert.Equal(t, model.PowerMaintenance, statuses[empty.ID])
+	assert.Zero(t, incidents[empty.ID])
+
+	for _, cage := range []*model.Cage{carnivores, herbivores, empty} {
+		c, err := m.GetCage(ctx, cage.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, model.PowerDown, c.Status)
+		}
+	}
+
+	alarm, err := m.GetIncident(ctx, incidents[carnivores.ID])
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.IncidentPowerFailure, alarm.Kind)
+		assert.Equal(t, model.SeverityCritical, alarm.Severity)
+		assert.Equal(t, []model.ID{rex.ID}, alarm.DinosaurIDs)
+	}
+
+	alarm, err = m.GetIncident(ctx, incidents[herbivores.ID])
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.SeverityHigh, alarm.Severity)
+	}
+
+	events, _, err := m.ListPowerEvents(ctx, carnivores.ID, nil)
+	if assert.NoError(t, err) && assert.NotEmpty(t, events) {
+		assert.Equal(t, model.PowerDown, events[0].To)
+		assert.Equal(t, "storm", events[0].Reason)
+	}
+
+	// Fences stay DOWN until the circuit is restored.
+	_, err = m.CutCircuit(ctx, circuit.ID)
+	assert.True(t, errors.IsUnprocessableErr(err))
+	assert.True(t, errors.IsUnprocessableErr(power(empty.ID, model.PowerMaintenance)))
+	assert.True(t, errors.IsUnprocessableErr(join(empty.ID, "")))
+	assert.True(t, errors.IsUnprocessableErr(join(newTestCage(t, m).ID, circuit.ID)))
+	assert.True(t, errors.IsUnprocessableErr(m.CreateCage(ctx, &model.Cage{ID: model.NewCageID(newID()), Capacity: 2, CircuitID: circuit.ID})))
+	assert.True(t, errors.IsUnprocessableErr(m.DeleteCircuit(ctx, circuit.ID)))
+
+	restored, err := m.RestoreCircuit(ctx, circuit.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, outage.ID, restored.ID)
+	assert.NotNil(t, restored.RestoredAt)
+
+	for cageID, status := range statuses {
+		c, err := m.GetCage(ctx, cageID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, status, c.Status)
+		}
+	}
+
+	alarm, err = m.GetIncident(ctx, incidents[carnivores.ID])
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.IncidentOpen, alarm.Status)
+		if assert.Len(t, alarm.Notes, 2) {
+			assert.Equal(t, "circuit "+string(circuit.ID)+" was restored", alarm.Notes[1].Text)
+		}
+	}
+
+	_, err = m.RestoreCircuit(ctx, circuit.ID)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	outages, total, err := m.ListCircuitOutages(ctx, circuit.ID, nil)
+	if assert.NoError(t, err) && assert.Len(t, outages, 1) {
+		assert.Equal(t, 1, total)
+		assert.Len(t, outages[0].Cages, 3)
+		assert.NotNil(t, outages[0].RestoredAt)
+	}
+
+	_, _, err = m.ListCircuitOutages(ctx, "ct_foo", nil)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	assert.True(t, errors.IsUnprocessableErr(m.DeleteCircuit(ctx, circuit.ID)))
+	for _, cage := range []*model.Cage{carnivores, herbivores, empty} {
+		if err := join(cage.ID, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.NoError(t, m.DeleteCircuit(ctx, circuit.ID))
+	assert.True(t, errors.IsNotFoundErr(m.DeleteCircuit(ctx, circuit.ID)))
+}
@@ -0,0 +1,267 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) CreateDinosaur(ctx context.Context, dinosaur *model.Dinosaur) error {
+	const op errors.Op = "memory.CreateDinosaur"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.dinosaurs[dinosaur.ID]; exists {
+		return errors.E(op, errors.KindAlreadyExists, fmt.Sprintf("dinosaur %s already exists", dinosaur.ID))
+	}
+
+	if err := m.checkName(dinosaur, op); err != nil {
+		return err
+	}
+
+	if err := m.place(dinosaur, op); err != nil {
+		return err
+	}
+
+	now := m.timestamp()
+	dinosaur.CreatedAt = now
+	dinosaur.UpdatedAt = now
+	dinosaur.Version = 1
+
+	m.dinosaurs[dinosaur.ID] = cloneDinosaur(dinosaur)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityDinosaur, dinosaur.ID, nil, storage.Snapshot(dinosaur)))
+	m.publish(storage.NewEvent(model.EventDinosaurPlaced, dinosaur.ID, dinosaur))
+
+	return nil
+}
+
+func (m *Memory) UpdateDinosaur(ctx context.Context, id model.ID, updater storage.DinosaurUpdater) error {
+	const op errors.Op = "memory.UpdateDinosaur"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, err := m.getDinosaur(id, op)
+	if err != nil {
+		return err
+	}
+
+	if old.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is archived", id))
+	}
+
+	before := storage.Snapshot(old)
+	cageID, species, version := old.CageID, old.Species, old.Version
+	dinosaur, err := updater(old)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.CheckVersion(id, version, dinosaur.Version); err != nil {
+		return errors.E(op, err)
+	}
+
+	dinosaur.ID = id
+	if dinosaur.CageID != cageID {
+		return errors.E(op, errors.KindBadRequest, "dinosaurs change cages through transfers")
+	}
+
+	if err := m.checkName(dinosaur, op); err != nil {
+		return err
+	}
+
+	if dinosaur.Species != species {
+		if err := m.place(dinosaur, op); err != nil {
+			return err
+		}
+	}
+
+	dinosaur.UpdatedAt = m.timestamp()
+	dinosaur.Version++
+	m.dinosaurs[id] = cloneDinosaur(dinosaur)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityDinosaur, id, before, storage.Snapshot(dinosaur)))
+
+	return nil
+}
+
+// checkName enforces the uniqueness of the names of live dinosaurs.
+func (m *Memory) checkName(dinosaur *model.Dinosaur, op errors.Op) error {
+	for _, d := range m.dinosaurs {
+		if d.ID != dinosaur.ID && d.Name == dinosaur.Name && !d.Archived() {
+			return errors.E(op, errors.KindAlreadyExists, fmt.Sprintf("dinosaur named %q already exists", dinosaur.Name))
+		}
+	}
+
+	return nil
+}
+
+// place verifies that the species of dinosaur is in the catalog and that the
+// dinosaur may join the occupants of its cage.
+func (m *Memory) place(dinosaur *model.Dinosaur, op errors.Op) error {
+	if _, ok := m.species[dinosaur.Species]; !ok {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("unknown species: %q", dinosaur.Species))
+	}
+
+	cage, err := m.getCage(dinosaur.CageID, op)
+	if errors.IsNotFoundErr(err) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("cage %s does not exist", dinosaur.CageID))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := park.CheckPlacement(cage, cage.Dinosaurs, dinosaur); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+func (m *Memory) GetDinosaur(ctx context.Context, id model.ID) (*model.Dinosaur, error) {
+	const op errors.Op = "memory.GetDinosaur"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.getDinosaur(id, op)
+}
+
+func (m *Memory) getDinosaur(id model.ID, op errors.Op) (*model.Dinosaur, error) {
+	dinosaur, ok := m.dinosaurs[id]
+	if !ok {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("dinosaur %s does not exist", id))
+	}
+
+	return cloneDinosaur(dinosaur), nil
+}
+
+func (m *Memory) DeleteDinosaur(ctx context.Context, id model.ID) error {
+	const op errors.Op = "memory.DeleteDinosaur"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dinosaur, err := m.getDinosaur(id, op)
+	if err != nil {
+		return err
+	}
+
+	delete(m.dinosaurs, id)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityDinosaur, id, storage.Snapshot(dinosaur), nil))
+	m.publish(storage.NewEvent(model.EventDinosaurRemoved, id, dinosaur))
+
+	// The records of the dinosaur go with it, as the foreign keys of the
+	// Postgres backend cascade.
+	transfers := m.transfers[:0]
+	for _, transfer := range m.transfers {
+		if transfer.DinosaurID != id {
+			transfers = append(transfers, transfer)
+		}
+	}
+	m.transfers = transfers
+
+	feedings := m.feedings[:0]
+	for _, feeding := range m.feedings {
+		if feeding.DinosaurID != id {
+			feedings = append(feedings, feeding)
+		}
+	}
+	m.feedings = feedings
+
+	visits := m.vetVisits[:0]
+	for _, visit := range m.vetVisits {
+		if visit.DinosaurID != id {
+			visits = append(visits, visit)
+		}
+	}
+	m.vetVisits = visits
+
+	biometrics := m.biometrics[:0]
+	for _, biometric := range m.biometrics {
+		if biometric.DinosaurID != id {
+			biometrics = append(biometrics, biometric)
+		}
+	}
+	m.biometrics = biometrics
+
+	for _, incident := range m.incidents {
+		ids := incident.DinosaurIDs[:0]
+		for _, dinosaurID := range incident.DinosaurIDs {
+			if dinosaurID != id {
+				ids = append(ids, dinosaurID)
+			}
+		}
+		incident.DinosaurIDs = ids
+	}
+
+	return nil
+}
+
+func (m *Memory) ListDinosaurs(ctx context.Context, params storage.ListDinosaurParams) ([]*model.Dinosaur, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	name := strings.ToLower(params.Name)
+
+	var dinosaurs []*model.Dinosaur
+	for _, d := range m.dinosaurs {
+		if d.Archived() && !params.IncludeArchived {
+			continue
+		}
+
+		if params.Species != "" && d.Species != params.Species {
+			continue
+		}
+
+		if params.CageID != "" && d.CageID != params.CageID {
+			continue
+		}
+
+		if name != "" && !strings.Contains(strings.ToLower(d.Name), name) {
+			continue
+		}
+
+		dinosaurs = append(dinosaurs, cloneDinosaur(d))
+	}
+
+	sortDinosaurs(dinosaurs)
+	return page(dinosaurs, params.Pagination), len(dinosaurs), nil
+}
+
+// sortDinosaurs orders dinosaurs by creation time and ID.
+func sortDinosaurs(dinosaurs []*model.Dinosaur) {
+	sort.Slice(dinosaurs, func(i, j int) bool {
+		a, b := dinosaurs[i], dinosaurs[j]
+		if !a.CreatedAt.Equal(*b.CreatedAt) {
+			return a.CreatedAt.Before(*b.CreatedAt)
+		}
+
+		return a.ID < b.ID
+	})
+}
+
+func cloneDinosaur(dinosaur *model.Dinosaur) *model.Dinosaur {
+	d := *dinosaur
+	return &d
+}
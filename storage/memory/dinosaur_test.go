@@ -0,0 +1,241 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_CreateDinosaur(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := newTestCage(t, m)
+
+	d1 := newTestDinosaur(cage.ID, model.Velociraptor)
+	if err := m.CreateDinosaur(ctx, d1); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotNil(t, d1.CreatedAt)
+	assert.NotNil(t, d1.UpdatedAt)
+
+	d2, err := m.GetDinosaur(ctx, d1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, d1, d2)
+
+	d3 := newTestDinosaur(cage.ID, model.Velociraptor)
+	d3.Name = d1.Name
+	err = m.CreateDinosaur(ctx, d3)
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+
+	err = m.CreateDinosaur(ctx, newTestDinosaur("foo", model.Velociraptor))
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	_, err = m.GetDinosaur(ctx, "foo")
+	assert.True(t, errors.IsNotFoundErr(err))
+}
+
+func TestMemory_UpdateDinosaur(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := newTestCage(t, m)
+
+	d := newTestDinosaur(cage.ID, model.Triceratops)
+	if err := m.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	err := m.UpdateDinosaur(ctx, d.ID, func(old *model.Dinosaur) (*model.Dinosaur, error) {
+		old.Name = "Cera"
+		old.Species = model.Stegosaurus
+		return old, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.GetDinosaur(ctx, d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "Cera", got.Name)
+	assert.Equal(t, model.Stegosaurus, got.Species)
+	assert.True(t, got.UpdatedAt.After(*got.CreatedAt))
+
+	err = m.UpdateDinosaur(ctx, "foo", func(old *model.Dinosaur) (*model.Dinosaur, error) { return old, nil })
+	assert.True(t, errors.IsNotFoundErr(err))
+}
+
+func TestMemory_DeleteDinosaur(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := newTestCage(t, m)
+
+	d := newTestDinosaur(cage.ID, model.Triceratops)
+	if err := m.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	other := newTestDinosaur(cage.ID, model.Triceratops)
+	if err := m.CreateDinosaur(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	// The records of the dinosaur are deleted with it.
+	if _, err := m.RecordFeeding(ctx, &model.Feeding{CageID: cage.ID}, []model.ID{d.ID, other.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []model.ID{d.ID, other.ID} {
+		if err := m.CreateVetVisit(ctx, &model.VetVisit{DinosaurID: id, Vet: "harding"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := m.RecordBiometrics(ctx, []*model.Biometric{{DinosaurID: id, Metric: model.MetricWeight, Value: 6000}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	incident := &model.Incident{Kind: model.IncidentInjury, Severity: model.SeverityLow, Title: "Limping", DinosaurIDs: []model.ID{d.ID, other.ID}}
+	if err := m.CreateIncident(ctx, incident); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.DeleteDinosaur(ctx, d.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := m.GetDinosaur(ctx, d.ID)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	err = m.DeleteDinosaur(ctx, d.ID)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	for id, want := range map[model.ID]int{d.ID: 0, other.ID: 1} {
+		_, total, err := m.ListFeedings(ctx, storage.ListFeedingParams{DinosaurID: id})
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, total)
+		}
+
+		_, total, err = m.ListVetVisits(ctx, storage.ListVetVisitParams{DinosaurID: id})
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, total)
+		}
+
+		_, total, err = m.ListBiometrics(ctx, storage.ListBiometricParams{DinosaurID: id})
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, total)
+		}
+
+		_, total, err = m.ListIncidents(ctx, storage.ListIncidentParams{DinosaurID: id})
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, total)
+		}
+	}
+
+	got, err := m.GetIncident(ctx, incident.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []model.ID{other.ID}, got.DinosaurIDs)
+	}
+}
+
+func TestMemory_ListDinosaurs(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := newTestCage(t, m)
+
+	d1 := newTestDinosaur(cage.ID, model.Brachiosaurus)
+	d2 := newTestDinosaur(cage.ID, model.Triceratops)
+	for _, d := range []*model.Dinosaur{d1, d2} {
+		if err := m.CreateDinosaur(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dinosaurs, total, err := m.ListDinosaurs(ctx, storage.ListDinosaurParams{CageID: cage.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, total)
+	assert.Equal(t, d1.ID, dinosaurs[0].ID)
+	assert.Equal(t, d2.ID, dinosaurs[1].ID)
+
+	dinosaurs, total, err = m.ListDinosaurs(ctx, storage.ListDinosaurParams{Species: model.Triceratops})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, total)
+	assert.Equal(t, d2.ID, dinosaurs[0].ID)
+
+	dinosaurs, total, err = m.ListDinosaurs(ctx, storage.ListDinosaurParams{Name: strings.ToUpper(d1.Name)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, total)
+	assert.Equal(t, d1.ID, dinosaurs[0].ID)
+
+	dinosaurs, total, err = m.ListDinosaurs(ctx, storage.ListDinosaurParams{Pagination: storage.NewPagination(1, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, total)
+	assert.Len(t, dinosaurs, 1)
+	assert.Equal(t, d2.ID, dinosaurs[0].ID)
+}
+
+func TestMemory_CreateDinosaurIncompatible(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+
+	herbivores := newTestCage(t, m)
+	for _, species := range []model.Species{model.Triceratops, model.Stegosaurus} {
+		if err := m.CreateDinosaur(ctx, newTestDinosaur(herbivores.ID, species)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := m.CreateDinosaur(ctx, newTestDinosaur(herbivores.ID, model.Velociraptor))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	carnivores := newTestCage(t, m)
+	d := newTestDinosaur(carnivores.ID, model.Velociraptor)
+	if err := m.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.CreateDinosaur(ctx, newTestDinosaur(carnivores.ID, model.Tyrannosaurus))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.UpdateDinosaur(ctx, d.ID, func(old *model.Dinosaur) (*model.Dinosaur, error) {
+		old.CageID = herbivores.ID
+		return old, nil
+	})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+// publish appends an event to the outbox. The caller must hold the write
+// lock.
+func (m *Memory) publish(event *model.Event) {
+	event.ID = m.nextSeq()
+	event.CreatedAt = m.timestamp()
+	m.outbox = append(m.outbox, event)
+}
+
+func (m *Memory) ListEvents(ctx context.Context, params storage.ListEventParams) ([]*model.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var events []*model.Event
+	for _, event := range m.outbox {
+		if event.ID <= params.AfterID || !hasType(params.Types, event.Type) {
+			continue
+		}
+
+		e := *event
+		events = append(events, &e)
+
+		if params.Limit > 0 && len(events) == params.Limit {
+			break
+		}
+	}
+
+	return events, nil
+}
+
+func (m *Memory) LastEventID(ctx context.Context) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.outbox) == 0 {
+		return 0, nil
+	}
+
+	return m.outbox[len(m.outbox)-1].ID, nil
+}
+
+// hasType reports whether an event type is in types, which matches every
+// type if empty.
+func hasType(types []string, t string) bool {
+	if len(types) == 0 {
+		return true
+	}
+
+	for _, want := range types {
+		if want == t {
+			return true
+		}
+	}
+
+	return false
+}
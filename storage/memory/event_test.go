@@ -0,0 +1,93 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_ListEvents(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+
+	start, err := m.LastEventID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c1, c2 := newTestCage(t, m), newTestCage(t, m)
+	d := newTestDinosaur(c1.ID, model.Tyrannosaurus)
+	if err := m.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.TransferDinosaur(ctx, d.ID, c2.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.UpdateCage(ctx, c1.ID, func(old *model.Cage) (*model.Cage, error) {
+		old.Status = model.PowerDown
+		return old, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := m.ListEvents(ctx, storage.ListEventParams{AfterID: start})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+
+	assert.Equal(t, []string{
+		model.EventCageCreated,
+		model.EventCageCreated,
+		model.EventDinosaurPlaced,
+		model.EventDinosaurTransferred,
+		model.EventCagePowerChanged,
+	}, types)
+
+	var transfer model.Transfer
+	assert.NoError(t, json.Unmarshal(events[3].Payload, &transfer))
+	assert.Equal(t, c2.ID, transfer.ToCageID)
+
+	last, err := m.LastEventID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, events[4].ID, last)
+
+	filtered, err := m.ListEvents(ctx, storage.ListEventParams{
+		AfterID: events[0].ID,
+		Types:   []string{model.EventCageCreated, model.EventCagePowerChanged},
+		Limit:   1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, events[1].ID, filtered[0].ID)
+}
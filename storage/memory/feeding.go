@@ -0,0 +1,227 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) GetFeedingSchedule(ctx context.Context, cageID model.ID) (*model.FeedingSchedule, error) {
+	const op errors.Op = "memory.GetFeedingSchedule"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cage, err := m.getCage(cageID, op)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.feedingSchedule(cage, op)
+}
+
+// feedingSchedule returns the stored or derived feeding schedule of a cage
+// with its dinosaurs.
+func (m *Memory) feedingSchedule(cage *model.Cage, op errors.Op) (*model.FeedingSchedule, error) {
+	if schedule, ok := m.feedingSchedules[cage.ID]; ok {
+		s := *schedule
+		return &s, nil
+	}
+
+	schedule := park.DefaultFeedingSchedule(cage, cage.Dinosaurs)
+	if schedule == nil {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("cage %s has no feeding schedule", cage.ID))
+	}
+
+	return schedule, nil
+}
+
+func (m *Memory) SetFeedingSchedule(ctx context.Context, schedule *model.FeedingSchedule) error {
+	const op errors.Op = "memory.SetFeedingSchedule"
+
+	if err := park.CheckFeedingSchedule(schedule); err != nil {
+		return errors.E(op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cage, err := m.getCage(schedule.CageID, op)
+	if err != nil {
+		return err
+	}
+
+	if cage.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is archived", cage.ID))
+	}
+
+	var before *model.FeedingSchedule
+	now := m.timestamp()
+	schedule.Default = false
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	if old, ok := m.feedingSchedules[cage.ID]; ok {
+		before = old
+		schedule.CreatedAt = old.CreatedAt
+	}
+
+	s := *schedule
+	m.feedingSchedules[cage.ID] = &s
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityFeedingSchedule, cage.ID, storage.Snapshot(before), storage.Snapshot(schedule)))
+
+	return nil
+}
+
+func (m *Memory) RecordFeeding(ctx context.Context, feeding *model.Feeding, dinosaurIDs []model.ID) ([]*model.Feeding, error) {
+	const op errors.Op = "memory.RecordFeeding"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.timestamp()
+	if err := park.CheckFeeding(feeding, *now); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	cage, err := m.getCage(feeding.CageID, op)
+	if err != nil {
+		return nil, err
+	}
+
+	fed, err := park.FeedingTargets(cage, cage.Dinosaurs, dinosaurIDs)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	schedule, err := m.feedingSchedule(cage, op)
+	if err != nil {
+		return nil, err
+	}
+
+	park.FillFeeding(feeding, schedule, *now)
+
+	feedings := make([]*model.Feeding, 0, len(fed))
+	for _, d := range fed {
+		f := *feeding
+		f.ID = m.nextSeq()
+		f.DinosaurID = d.ID
+		f.Actor = storage.Actor(ctx)
+		f.CreatedAt = now
+		m.feedings = append(m.feedings, &f)
+		m.audit(storage.NewAuditEntry(ctx, op, model.EntityFeeding, storage.SerialID(f.ID), nil, storage.Snapshot(&f)))
+
+		c := f
+		feedings = append(feedings, &c)
+	}
+
+	return feedings, nil
+}
+
+func (m *Memory) ListFeedings(ctx context.Context, params storage.ListFeedingParams) ([]*model.Feeding, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var feedings []*model.Feeding
+	for _, feeding := range m.feedings {
+		if params.CageID != "" && feeding.CageID != params.CageID {
+			continue
+		}
+
+		if params.DinosaurID != "" && feeding.DinosaurID != params.DinosaurID {
+			continue
+		}
+
+		f := *feeding
+		feedings = append(feedings, &f)
+	}
+
+	sortFeedings(feedings)
+	return page(feedings, params.Pagination), len(feedings), nil
+}
+
+// sortFeedings sorts feedings most recent first as the Postgres backend does.
+func sortFeedings(feedings []*model.Feeding) {
+	sort.SliceStable(feedings, func(i, j int) bool {
+		if !feedings[i].FedAt.Equal(*feedings[j].FedAt) {
+			return feedings[i].FedAt.After(*feedings[j].FedAt)
+		}
+
+		return feedings[i].ID > feedings[j].ID
+	})
+}
+
+func (m *Memory) ListOverdueFeedings(ctx context.Context, cageID model.ID) ([]*model.OverdueFeeding, error) {
+	const op errors.Op = "memory.ListOverdueFeedings"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var cages []*model.Cage
+	if cageID != "" {
+		cage, err := m.getCage(cageID, op)
+		if err != nil {
+			return nil, err
+		}
+
+		cages = append(cages, cage)
+	} else {
+		for _, cage := range m.cages {
+			if !cage.Archived() {
+				cages = append(cages, m.withOccupancy(cage, true))
+			}
+		}
+	}
+
+	sort.Slice(cages, func(i, j int) bool { return cages[i].ID < cages[j].ID })
+
+	now := m.now().UTC()
+	overdue := []*model.OverdueFeeding{}
+	for _, cage := range cages {
+		if len(cage.Dinosaurs) == 0 {
+			continue
+		}
+
+		m.fillLastFed(cage.Dinosaurs)
+		schedule, err := m.feedingSchedule(cage, op)
+		if err != nil {
+			return nil, err
+		}
+
+		if o := park.CheckOverdue(cage, cage.Dinosaurs, schedule, now); o != nil {
+			overdue = append(overdue, o)
+		}
+	}
+
+	return overdue, nil
+}
+
+// fillLastFed sets the last feeding time of dinosaurs.
+func (m *Memory) fillLastFed(dinosaurs []*model.Dinosaur) {
+	for _, d := range dinosaurs {
+		for _, feeding := range m.feedings {
+			if feeding.DinosaurID == d.ID && (d.LastFedAt == nil || feeding.FedAt.After(*d.LastFedAt)) {
+				fedAt := *feeding.FedAt
+				d.LastFedAt = &fedAt
+			}
+		}
+	}
+}
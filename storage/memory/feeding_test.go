@@ -0,0 +1,162 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/app"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_FeedingSchedule(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	c := newTestCage(t, m)
+
+	_, err := m.GetFeedingSchedule(ctx, c.ID)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	if err := m.CreateDinosaur(ctx, newTestDinosaur(c.ID, model.Velociraptor)); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := m.GetFeedingSchedule(ctx, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, schedule.Default)
+	assert.Equal(t, "meat", schedule.Food)
+	assert.Equal(t, model.CarnivoreFeedingInterval, schedule.Interval())
+
+	err = m.SetFeedingSchedule(ctx, &model.FeedingSchedule{CageID: c.ID, Food: "goats", IntervalHours: 12, RationKg: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err = m.GetFeedingSchedule(ctx, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, schedule.Default)
+	assert.Equal(t, "goats", schedule.Food)
+	assert.Equal(t, 12, schedule.IntervalHours)
+
+	entries, _, err := m.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: c.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, model.EntityFeedingSchedule, entries[0].EntityType)
+	assert.Nil(t, entries[0].Before)
+
+	err = m.SetFeedingSchedule(ctx, &model.FeedingSchedule{CageID: c.ID, Food: "goats"})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	err = m.SetFeedingSchedule(ctx, &model.FeedingSchedule{CageID: "foo", Food: "goats", IntervalHours: 12})
+	assert.True(t, errors.IsNotFoundErr(err))
+}
+
+func TestMemory_RecordFeeding(t *testing.T) {
+	m := newTestMemory()
+	ctx := storage.WithActor(context.Background(), "muldoon")
+	c := newTestCage(t, m)
+	d1, d2 := newTestDinosaur(c.ID, model.Velociraptor), newTestDinosaur(c.ID, model.Velociraptor)
+	for _, d := range []*model.Dinosaur{d1, d2} {
+		if err := m.CreateDinosaur(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	overdue, err := m.ListOverdueFeedings(ctx, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, overdue)
+
+	// Two days ago, only the first one was fed.
+	fedAt := app.StartDate().UTC().Add(-48 * time.Hour)
+	feedings, err := m.RecordFeeding(ctx, &model.Feeding{CageID: c.ID, FedAt: &fedAt}, []model.ID{d1.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, feedings, 1)
+	assert.Equal(t, "meat", feedings[0].Food)
+	assert.Equal(t, model.CarnivoreRationKg, feedings[0].AmountKg)
+	assert.Equal(t, "muldoon", feedings[0].Actor)
+
+	overdue, err = m.ListOverdueFeedings(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, overdue, 1) {
+		assert.Equal(t, c.ID, overdue[0].CageID)
+		assert.Equal(t, []model.ID{d1.ID}, overdue[0].Dinosaurs)
+		assert.Equal(t, fedAt, *overdue[0].LastFedAt)
+		assert.Equal(t, fedAt.Add(model.CarnivoreFeedingInterval), overdue[0].DueAt)
+	}
+
+	feedings, err = m.RecordFeeding(ctx, &model.Feeding{CageID: c.ID, Food: "goats", AmountKg: 20}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, feedings, 2)
+
+	overdue, err = m.ListOverdueFeedings(ctx, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, overdue)
+
+	cage, err := m.GetCage(ctx, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range cage.Dinosaurs {
+		assert.Equal(t, feedings[0].FedAt, d.LastFedAt)
+	}
+
+	listed, total, err := m.ListFeedings(ctx, storage.ListFeedingParams{DinosaurID: d1.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "goats", listed[0].Food)
+	assert.Equal(t, fedAt, *listed[1].FedAt)
+
+	_, err = m.RecordFeeding(ctx, &model.Feeding{CageID: c.ID}, []model.ID{"foo"})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	future := app.StartDate().Add(time.Hour)
+	_, err = m.RecordFeeding(ctx, &model.Feeding{CageID: c.ID, FedAt: &future}, nil)
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	_, err = m.RecordFeeding(ctx, &model.Feeding{CageID: newTestCage(t, m).ID}, nil)
+	assert.True(t, errors.IsUnprocessableErr(err))
+}
@@ -0,0 +1,239 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) CreateIncident(ctx context.Context, incident *model.Incident) error {
+	const op errors.Op = "memory.CreateIncident"
+
+	if err := park.CheckIncident(incident); err != nil {
+		return errors.E(op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range incident.CageIDs {
+		if _, err := m.getCage(id, op); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range incident.DinosaurIDs {
+		if _, err := m.getDinosaur(id, op); err != nil {
+			return err
+		}
+	}
+
+	m.openIncident(ctx, incident, op)
+	return nil
+}
+
+// openIncident stores a new incident on behalf of the actor in ctx, with the
+// reason in ctx as the first note of its timeline. The caller must hold the
+// write lock and have checked the incident.
+func (m *Memory) openIncident(ctx context.Context, incident *model.Incident, op errors.Op) {
+	now := m.timestamp()
+	incident.ID = m.nextSeq()
+	incident.Status = model.IncidentOpen
+	incident.Actor = storage.Actor(ctx)
+	incident.Notes = nil
+	incident.AcknowledgedAt = nil
+	incident.MitigatedAt = nil
+	incident.ClosedAt = nil
+	incident.CreatedAt = now
+	incident.UpdatedAt = now
+
+	m.incidents = append(m.incidents, cloneIncident(incident))
+	m.addIncidentNote(ctx, incident.ID, model.IncidentOpen, storage.Reason(ctx))
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityIncident, storage.SerialID(incident.ID), nil, storage.Snapshot(incident)))
+	m.publish(storage.NewEvent(model.EventIncidentOpened, storage.SerialID(incident.ID), incident))
+}
+
+func (m *Memory) GetIncident(ctx context.Context, id int64) (*model.Incident, error) {
+	const op errors.Op = "memory.GetIncident"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, err := m.getIncident(id, op)
+	if err != nil {
+		return nil, err
+	}
+
+	incident := cloneIncident(stored)
+	for _, note := range m.incidentNotes {
+		if note.IncidentID == id {
+			n := *note
+			incident.Notes = append(incident.Notes, &n)
+		}
+	}
+
+	return incident, nil
+}
+
+// getIncident returns the stored incident with an ID.
+func (m *Memory) getIncident(id int64, op errors.Op) (*model.Incident, error) {
+	for _, incident := range m.incidents {
+		if incident.ID == id {
+			return incident, nil
+		}
+	}
+
+	return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("incident %d does not exist", id))
+}
+
+func (m *Memory) ListIncidents(ctx context.Context, params storage.ListIncidentParams) ([]*model.Incident, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var incidents []*model.Incident
+	for i := len(m.incidents) - 1; i >= 0; i-- {
+		incident := m.incidents[i]
+		if params.Status != "" && incident.Status != params.Status {
+			continue
+		}
+
+		if params.Severity != "" && incident.Severity != params.Severity {
+			continue
+		}
+
+		if params.Kind != "" && incident.Kind != params.Kind {
+			continue
+		}
+
+		if params.Open && incident.Status == model.IncidentClosed {
+			continue
+		}
+
+		if params.CageID != "" && !containsID(incident.CageIDs, params.CageID) {
+			continue
+		}
+
+		if params.DinosaurID != "" && !containsID(incident.DinosaurIDs, params.DinosaurID) {
+			continue
+		}
+
+		incidents = append(incidents, cloneIncident(incident))
+	}
+
+	return page(incidents, params.Pagination), len(incidents), nil
+}
+
+func (m *Memory) UpdateIncident(ctx context.Context, id int64, updater storage.IncidentUpdater) error {
+	const op errors.Op = "memory.UpdateIncident"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.getIncident(id, op)
+	if err != nil {
+		return err
+	}
+
+	incident, err := updater(cloneIncident(stored))
+	if err != nil {
+		return err
+	}
+
+	if !model.ValidSeverity(incident.Severity) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid severity: %q", incident.Severity))
+	}
+
+	statusChanged := incident.Status != stored.Status
+	if statusChanged {
+		if err := park.CheckIncidentTransition(stored, incident.Status); err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	before := storage.Snapshot(stored)
+	now := m.timestamp()
+	stored.Severity = incident.Severity
+	stored.UpdatedAt = now
+	if statusChanged {
+		stored.Status = incident.Status
+		park.StampIncident(stored, *now)
+		m.addIncidentNote(ctx, id, stored.Status, storage.Reason(ctx))
+	}
+
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityIncident, storage.SerialID(id), before, storage.Snapshot(stored)))
+	m.publish(storage.NewEvent(model.EventIncidentChanged, storage.SerialID(id), stored))
+
+	return nil
+}
+
+func (m *Memory) AddIncidentNote(ctx context.Context, note *model.IncidentNote) error {
+	const op errors.Op = "memory.AddIncidentNote"
+
+	if err := park.CheckIncidentNote(note); err != nil {
+		return errors.E(op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.getIncident(note.IncidentID, op); err != nil {
+		return err
+	}
+
+	*note = *m.addIncidentNote(ctx, note.IncidentID, "", note.Text)
+	return nil
+}
+
+// addIncidentNote appends a note to the timeline of an incident and returns
+// a copy of it. The caller must hold the write lock.
+func (m *Memory) addIncidentNote(ctx context.Context, incidentID int64, status, text string) *model.IncidentNote {
+	note := &model.IncidentNote{
+		ID:         m.nextSeq(),
+		IncidentID: incidentID,
+		Status:     status,
+		Text:       text,
+		Actor:      storage.Actor(ctx),
+		CreatedAt:  m.timestamp(),
+	}
+
+	m.incidentNotes = append(m.incidentNotes, note)
+
+	n := *note
+	return &n
+}
+
+func containsID(ids []model.ID, id model.ID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cloneIncident(incident *model.Incident) *model.Incident {
+	i := *incident
+	i.CageIDs = append([]model.ID(nil), incident.CageIDs...)
+	i.DinosaurIDs = append([]model.ID(nil), incident.DinosaurIDs...)
+	i.Notes = nil
+	return &i
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_Incidents(t *testing.T) {
+	m := newTestMemory()
+	ctx := storage.WithActor(context.Background(), "muldoon")
+	c1, c2 := newTestCage(t, m), newTestCage(t, m)
+	d := newTestDinosaur(c1.ID, model.Velociraptor)
+	if err := m.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	incident := &model.Incident{
+		Kind:        model.IncidentBreach,
+		Severity:    model.SeverityHigh,
+		Title:       "Raptor testing the fences",
+		CageIDs:     []model.ID{c1.ID},
+		DinosaurIDs: []model.ID{d.ID},
+	}
+	if err := m.CreateIncident(storage.WithReason(ctx, "systematic attacks"), incident); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotZero(t, incident.ID)
+	assert.Equal(t, model.IncidentOpen, incident.Status)
+	assert.Equal(t, "muldoon", incident.Actor)
+
+	err := m.CreateIncident(ctx, &model.Incident{Kind: model.IncidentBreach, Severity: model.SeverityLow, Title: "foo", CageIDs: []model.ID{"cag_foo"}})
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	// Incidents below critical do not hold transfers.
+	_, err = m.TransferDinosaur(ctx, d.ID, c2.ID, "")
+	assert.NoError(t, err)
+
+	err = m.UpdateIncident(ctx, incident.ID, func(old *model.Incident) (*model.Incident, error) {
+		old.Severity = model.SeverityCritical
+		return old, nil
+	})
+	assert.NoError(t, err)
+
+	// The hold covers transfers out of the cage too.
+	other := newTestDinosaur(c1.ID, model.Velociraptor)
+	if err := m.CreateDinosaur(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.TransferDinosaur(ctx, d.ID, c1.ID, "")
+	assert.True(t, errors.IsLockedErr(err))
+
+	_, err = m.TransferDinosaur(ctx, other.ID, c2.ID, "")
+	assert.True(t, errors.IsLockedErr(err))
+
+	for _, status := range []string{model.IncidentAcknowledged, model.IncidentClosed} {
+		err = m.UpdateIncident(storage.WithReason(ctx, status), incident.ID, func(old *model.Incident) (*model.Incident, error) {
+			old.Status = status
+			return old, nil
+		})
+		assert.NoError(t, err)
+	}
+
+	err = m.UpdateIncident(ctx, incident.ID, func(old *model.Incident) (*model.Incident, error) {
+		old.Status = model.IncidentMitigated
+		return old, nil
+	})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	_, err = m.TransferDinosaur(ctx, other.ID, c2.ID, "")
+	assert.NoError(t, err)
+
+	note := &model.IncidentNote{IncidentID: incident.ID, Text: "Fences reinforced"}
+	assert.NoError(t, m.AddIncidentNote(ctx, note))
+	assert.NotZero(t, note.ID)
+	assert.True(t, errors.IsNotFoundErr(m.AddIncidentNote(ctx, &model.IncidentNote{IncidentID: -1, Text: "foo"})))
+
+	got, err := m.GetIncident(ctx, incident.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.IncidentClosed, got.Status)
+		assert.NotNil(t, got.AcknowledgedAt)
+		assert.NotNil(t, got.ClosedAt)
+		assert.Nil(t, got.MitigatedAt)
+		assert.Equal(t, []model.ID{c1.ID}, got.CageIDs)
+		if assert.Len(t, got.Notes, 4) {
+			assert.Equal(t, model.IncidentOpen, got.Notes[0].Status)
+			assert.Equal(t, "systematic attacks", got.Notes[0].Text)
+			assert.Equal(t, model.IncidentAcknowledged, got.Notes[1].Status)
+			assert.Equal(t, model.IncidentClosed, got.Notes[2].Status)
+			assert.Equal(t, "Fences reinforced", got.Notes[3].Text)
+		}
+	}
+
+	_, err = m.GetIncident(ctx, -1)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	incidents, total, err := m.ListIncidents(ctx, storage.ListIncidentParams{DinosaurID: d.ID})
+	if assert.NoError(t, err) && assert.Len(t, incidents, 1) {
+		assert.Equal(t, 1, total)
+		assert.Equal(t, incident.ID, incidents[0].ID)
+	}
+
+	incidents, _, err = m.ListIncidents(ctx, storage.ListIncidentParams{CageID: c1.ID, Open: true})
+	if assert.NoError(t, err) {
+		assert.Empty(t, incidents)
+	}
+
+	events, err := m.ListEvents(ctx, storage.ListEventParams{Types: []string{model.EventIncidentOpened, model.EventIncidentChanged}})
+	if assert.NoError(t, err) {
+		assert.Len(t, events, 4)
+	}
+}
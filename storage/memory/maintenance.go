@@ -0,0 +1,147 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) CreateMaintenanceWindow(ctx context.Context, window *model.MaintenanceWindow) error {
+	const op errors.Op = "memory.CreateMaintenanceWindow"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cage, err := m.getCage(window.CageID, op)
+	if err != nil {
+		return err
+	}
+
+	var windows []*model.MaintenanceWindow
+	for _, w := range m.maintenance {
+		if w.CageID == cage.ID {
+			windows = append(windows, w)
+		}
+	}
+
+	now := m.timestamp()
+	if err := park.CheckMaintenanceWindow(window, cage, windows, *now); err != nil {
+		return errors.E(op, err)
+	}
+
+	window.ID = m.nextSeq()
+	window.StartsAt = window.StartsAt.UTC()
+	window.EndsAt = window.EndsAt.UTC()
+	window.Actor = storage.Actor(ctx)
+	window.Status = model.MaintenanceScheduled
+	window.Error = ""
+	window.CreatedAt = now
+	window.UpdatedAt = now
+
+	w := *window
+	m.maintenance = append(m.maintenance, &w)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityMaintenanceWindow, storage.SerialID(window.ID), nil, storage.Snapshot(window)))
+
+	return nil
+}
+
+func (m *Memory) ListMaintenanceWindows(ctx context.Context, params storage.ListMaintenanceParams) ([]*model.MaintenanceWindow, int, error) {
+	const op errors.Op = "memory.ListMaintenanceWindows"
+
+	if params.Status != "" && !model.ValidMaintenanceStatus(params.Status) {
+		return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid status: %q", params.Status))
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var windows []*model.MaintenanceWindow
+	for _, window := range m.maintenance {
+		if params.CageID != "" && window.CageID != params.CageID {
+			continue
+		}
+
+		if params.Status != "" && window.Status != params.Status {
+			continue
+		}
+
+		if !params.StartsBefore.IsZero() && !window.StartsAt.Before(params.StartsBefore) {
+			continue
+		}
+
+		if !params.EndsBefore.IsZero() && !window.EndsAt.Before(params.EndsBefore) {
+			continue
+		}
+
+		w := *window
+		windows = append(windows, &w)
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		if !windows[i].StartsAt.Equal(windows[j].StartsAt) {
+			return windows[i].StartsAt.Before(windows[j].StartsAt)
+		}
+
+		return windows[i].ID < windows[j].ID
+	})
+
+	return page(windows, params.Pagination), len(windows), nil
+}
+
+func (m *Memory) UpdateMaintenanceWindow(ctx context.Context, id int64, updater storage.MaintenanceUpdater) error {
+	const op errors.Op = "memory.UpdateMaintenanceWindow"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var stored *model.MaintenanceWindow
+	for _, window := range m.maintenance {
+		if window.ID == id {
+			stored = window
+			break
+		}
+	}
+
+	if stored == nil {
+		return errors.E(op, errors.KindNotFound, fmt.Sprintf("maintenance window %d does not exist", id))
+	}
+
+	old := *stored
+	window, err := updater(&old)
+	if err != nil {
+		return err
+	}
+
+	if window.Status != stored.Status {
+		if err := park.CheckMaintenanceTransition(stored, window.Status); err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	before := storage.Snapshot(stored)
+	stored.Status = window.Status
+	stored.Error = window.Error
+	stored.UpdatedAt = m.timestamp()
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityMaintenanceWindow, storage.SerialID(id), before, storage.Snapshot(stored)))
+
+	return nil
+}
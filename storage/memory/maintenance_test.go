@@ -0,0 +1,100 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/app"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_MaintenanceWindows(t *testing.T) {
+	m := newTestMemory()
+	ctx := storage.WithActor(context.Background(), "arnold")
+	empty, occupied := newTestCage(t, m), newTestCage(t, m)
+	if err := m.CreateDinosaur(ctx, newTestDinosaur(occupied.ID, model.Stegosaurus)); err != nil {
+		t.Fatal(err)
+	}
+
+	start := app.StartDate().UTC()
+	soon := &model.MaintenanceWindow{CageID: empty.ID, StartsAt: start.Add(time.Hour), EndsAt: start.Add(2 * time.Hour), Reason: "fence"}
+	later := &model.MaintenanceWindow{CageID: occupied.ID, StartsAt: start.Add(48 * time.Hour), EndsAt: start.Add(50 * time.Hour)}
+	for _, window := range []*model.MaintenanceWindow{soon, later} {
+		if err := m.CreateMaintenanceWindow(ctx, window); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.NotZero(t, soon.ID)
+	assert.Equal(t, model.MaintenanceScheduled, soon.Status)
+	assert.Equal(t, "arnold", soon.Actor)
+
+	err := m.CreateMaintenanceWindow(ctx, &model.MaintenanceWindow{CageID: occupied.ID, StartsAt: start.Add(time.Hour), EndsAt: start.Add(2 * time.Hour)})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.CreateMaintenanceWindow(ctx, &model.MaintenanceWindow{CageID: empty.ID, StartsAt: start.Add(90 * time.Minute), EndsAt: start.Add(3 * time.Hour)})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.CreateMaintenanceWindow(ctx, &model.MaintenanceWindow{CageID: "cag_foo", StartsAt: start.Add(time.Hour), EndsAt: start.Add(2 * time.Hour)})
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	windows, total, err := m.ListMaintenanceWindows(ctx, storage.ListMaintenanceParams{})
+	if assert.NoError(t, err) && assert.Len(t, windows, 2) {
+		assert.Equal(t, 2, total)
+		assert.Equal(t, soon.ID, windows[0].ID)
+		assert.Equal(t, later.ID, windows[1].ID)
+	}
+
+	windows, _, err = m.ListMaintenanceWindows(ctx, storage.ListMaintenanceParams{StartsBefore: start.Add(24 * time.Hour)})
+	if assert.NoError(t, err) && assert.Len(t, windows, 1) {
+		assert.Equal(t, soon.ID, windows[0].ID)
+	}
+
+	_, _, err = m.ListMaintenanceWindows(ctx, storage.ListMaintenanceParams{Status: "paused"})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	err = m.UpdateMaintenanceWindow(ctx, soon.ID, func(old *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+		old.Status = model.MaintenanceCancelled
+		return old, nil
+	})
+	assert.NoError(t, err)
+
+	windows, _, err = m.ListMaintenanceWindows(ctx, storage.ListMaintenanceParams{Status: model.MaintenanceCancelled})
+	if assert.NoError(t, err) && assert.Len(t, windows, 1) {
+		assert.Equal(t, soon.ID, windows[0].ID)
+	}
+
+	err = m.UpdateMaintenanceWindow(ctx, soon.ID, func(old *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+		old.Status = model.MaintenanceInProgress
+		return old, nil
+	})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.UpdateMaintenanceWindow(ctx, -1, func(old *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+		return old, nil
+	})
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	entries, _, err := m.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: storage.SerialID(soon.ID)})
+	if assert.NoError(t, err) {
+		assert.Len(t, entries, 2)
+	}
+}
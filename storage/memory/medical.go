@@ -0,0 +1,197 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) CreateVetVisit(ctx context.Context, visit *model.VetVisit) error {
+	const op errors.Op = "memory.CreateVetVisit"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.timestamp()
+	if err := park.CheckVetVisit(visit, *now); err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := m.checkPatient(visit.DinosaurID, op); err != nil {
+		return err
+	}
+
+	if visit.VisitedAt == nil {
+		visit.VisitedAt = now
+	} else {
+		visitedAt := visit.VisitedAt.UTC()
+		visit.VisitedAt = &visitedAt
+	}
+
+	visit.ID = m.nextSeq()
+	visit.Actor = storage.Actor(ctx)
+	visit.CreatedAt = now
+
+	for _, diagnosis := range visit.Diagnoses {
+		diagnosis.ID = m.nextSeq()
+		diagnosis.VisitID = visit.ID
+		diagnosis.DinosaurID = visit.DinosaurID
+	}
+
+	for _, treatment := range visit.Treatments {
+		treatment.ID = m.nextSeq()
+		treatment.VisitID = visit.ID
+		treatment.DinosaurID = visit.DinosaurID
+	}
+
+	m.vetVisits = append(m.vetVisits, cloneVetVisit(visit))
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityVetVisit, storage.SerialID(visit.ID), nil, storage.Snapshot(visit)))
+	return nil
+}
+
+// checkPatient verifies that medical records can be added for a dinosaur.
+func (m *Memory) checkPatient(id model.ID, op errors.Op) error {
+	dinosaur, err := m.getDinosaur(id, op)
+	if err != nil {
+		return err
+	}
+
+	if dinosaur.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is archived", id))
+	}
+
+	return nil
+}
+
+func (m *Memory) ListVetVisits(ctx context.Context, params storage.ListVetVisitParams) ([]*model.VetVisit, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var visits []*model.VetVisit
+	for _, visit := range m.vetVisits {
+		if params.DinosaurID != "" && visit.DinosaurID != params.DinosaurID {
+			continue
+		}
+
+		visits = append(visits, cloneVetVisit(visit))
+	}
+
+	sort.SliceStable(visits, func(i, j int) bool {
+		if !visits[i].VisitedAt.Equal(*visits[j].VisitedAt) {
+			return visits[i].VisitedAt.After(*visits[j].VisitedAt)
+		}
+
+		return visits[i].ID > visits[j].ID
+	})
+
+	return page(visits, params.Pagination), len(visits), nil
+}
+
+func cloneVetVisit(visit *model.VetVisit) *model.VetVisit {
+	v := *visit
+	v.Diagnoses = make([]*model.Diagnosis, 0, len(visit.Diagnoses))
+	for _, diagnosis := range visit.Diagnoses {
+		d := *diagnosis
+		v.Diagnoses = append(v.Diagnoses, &d)
+	}
+
+	v.Treatments = make([]*model.Treatment, 0, len(visit.Treatments))
+	for _, treatment := range visit.Treatments {
+		t := *treatment
+		v.Treatments = append(v.Treatments, &t)
+	}
+
+	return &v
+}
+
+func (m *Memory) RecordBiometrics(ctx context.Context, biometrics []*model.Biometric) error {
+	const op errors.Op = "memory.RecordBiometrics"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.timestamp()
+	for _, biometric := range biometrics {
+		if err := park.CheckBiometric(biometric, *now); err != nil {
+			return errors.E(op, err)
+		}
+
+		if err := m.checkPatient(biometric.DinosaurID, op); err != nil {
+			return err
+		}
+	}
+
+	for _, biometric := range biometrics {
+		if biometric.MeasuredAt == nil {
+			biometric.MeasuredAt = now
+		} else {
+			measuredAt := biometric.MeasuredAt.UTC()
+			biometric.MeasuredAt = &measuredAt
+		}
+
+		biometric.ID = m.nextSeq()
+		biometric.CreatedAt = now
+
+		b := *biometric
+		m.biometrics = append(m.biometrics, &b)
+		m.audit(storage.NewAuditEntry(ctx, op, model.EntityBiometric, storage.SerialID(b.ID), nil, storage.Snapshot(&b)))
+	}
+
+	return nil
+}
+
+func (m *Memory) ListBiometrics(ctx context.Context, params storage.ListBiometricParams) ([]*model.Biometric, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var biometrics []*model.Biometric
+	for _, biometric := range m.biometrics {
+		if params.DinosaurID != "" && biometric.DinosaurID != params.DinosaurID {
+			continue
+		}
+
+		if params.Metric != "" && biometric.Metric != params.Metric {
+			continue
+		}
+
+		if !params.Since.IsZero() && biometric.MeasuredAt.Before(params.Since) {
+			continue
+		}
+
+		if !params.Until.IsZero() && !biometric.MeasuredAt.Before(params.Until) {
+			continue
+		}
+
+		b := *biometric
+		biometrics = append(biometrics, &b)
+	}
+
+	sort.SliceStable(biometrics, func(i, j int) bool {
+		if !biometrics[i].MeasuredAt.Equal(*biometrics[j].MeasuredAt) {
+			return biometrics[i].MeasuredAt.Before(*biometrics[j].MeasuredAt)
+		}
+
+		return biometrics[i].ID < biometrics[j].ID
+	})
+
+	return page(biometrics, params.Pagination), len(biometrics), nil
+}
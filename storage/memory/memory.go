@@ -0,0 +1,132 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memory implements storage.Storage in memory for local development
+// and tests. Every operation runs under a single lock, which gives updaters
+// the same all-or-nothing semantics as a database transaction.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/log"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/sirupsen/logrus"
+)
+
+type Memory struct {
+	mu     sync.RWMutex
+	logger logrus.FieldLogger
+
+	cages       map[model.ID]*model.Cage
+	zones       map[model.ID]*model.Zone
+	circuits    map[model.ID]*model.Circuit
+	dinosaurs   map[model.ID]*model.Dinosaur
+	species     map[model.Species]*model.SpeciesEntry
+	powerEvents []*model.PowerEvent
+	transfers   []*model.Transfer
+	auditLog    []*model.AuditEntry
+	outbox      []*model.Event
+
+	feedingSchedules map[model.ID]*model.FeedingSchedule
+	feedings         []*model.Feeding
+	vetVisits        []*model.VetVisit
+	biometrics       []*model.Biometric
+	maintenance      []*model.MaintenanceWindow
+	incidents        []*model.Incident
+	incidentNotes    []*model.IncidentNote
+	webhooks         []*model.Webhook
+	deliveries       []*model.WebhookDelivery
+	attempts         []*model.WebhookAttempt
+	outages          []*model.CircuitOutage
+	readings         []*model.Reading
+	rollups          map[rollupKey]*model.ReadingRollup
+	fenceAlerts      []*model.FenceAlert
+
+	// seq generates the IDs of append-only records.
+	seq int64
+
+	now func() time.Time
+}
+
+var _ storage.Storage = (*Memory)(nil)
+
+// New returns an in-memory storage holding the default species catalog.
+func New(now func() time.Time) *Memory {
+	if now == nil {
+		now = time.Now
+	}
+
+	m := &Memory{
+		logger:    log.WithField("component", "memory"),
+		cages:     make(map[model.ID]*model.Cage),
+		zones:     make(map[model.ID]*model.Zone),
+		circuits:  make(map[model.ID]*model.Circuit),
+		dinosaurs: make(map[model.ID]*model.Dinosaur),
+		species:   make(map[model.Species]*model.SpeciesEntry),
+
+		feedingSchedules: make(map[model.ID]*model.FeedingSchedule),
+		rollups:          make(map[rollupKey]*model.ReadingRollup),
+		now:              now,
+	}
+
+	for _, entry := range model.DefaultSpecies() {
+		entry.CreatedAt = m.timestamp()
+		entry.UpdatedAt = entry.CreatedAt
+		m.species[entry.Name] = entry
+	}
+
+	return m
+}
+
+func (m *Memory) Close() error {
+	m.logger.Info("Closing in-memory storage")
+	return nil
+}
+
+func (m *Memory) Check(ctx context.Context) error {
+	return nil
+}
+
+func (m *Memory) nextSeq() int64 {
+	m.seq++
+	return m.seq
+}
+
+// timestamp returns the current time as stored by the Postgres backend.
+func (m *Memory) timestamp() *time.Time {
+	now := m.now().UTC()
+	return &now
+}
+
+// page slices items according to pagination.
+func page[T any](items []T, pagination *storage.Pagination) []T {
+	if pagination == nil {
+		return items
+	}
+
+	if pagination.Offset >= len(items) {
+		return nil
+	}
+
+	items = items[pagination.Offset:]
+	if pagination.Limit < len(items) {
+		items = items[:pagination.Limit]
+	}
+
+	return items
+}
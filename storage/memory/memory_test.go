@@ -0,0 +1,78 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/app"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var seq int64
+
+func newID() string {
+	return fmt.Sprint(atomic.AddInt64(&seq, 1))
+}
+
+// newTestMemory returns an empty storage whose clock ticks one second on
+// every read, so that creation order is observable.
+func newTestMemory() *Memory {
+	var ticks int64
+	return New(func() time.Time {
+		return app.StartDate().Add(time.Duration(atomic.AddInt64(&ticks, 1)) * time.Second)
+	})
+}
+
+func newTestCage(t *testing.T, m *Memory) *model.Cage {
+	t.Helper()
+
+	cage := &model.Cage{
+		ID:       model.NewCageID(newID()),
+		Capacity: model.MaxCageCapacity,
+		Status:   model.PowerActive,
+	}
+
+	if err := m.CreateCage(context.Background(), cage); err != nil {
+		t.Fatal(err)
+	}
+
+	return cage
+}
+
+func newTestDinosaur(cageID model.ID, species model.Species) *model.Dinosaur {
+	id := newID()
+	return &model.Dinosaur{
+		ID:      model.NewDinosaurID(id),
+		Name:    "Dinosaur " + id,
+		Species: species,
+		CageID:  cageID,
+	}
+}
+
+func TestPage(t *testing.T) {
+	items := []int{1, 2, 3}
+
+	assert.Equal(t, items, page(items, nil))
+	assert.Equal(t, []int{2}, page(items, &storage.Pagination{Limit: 1, Offset: 1}))
+	assert.Equal(t, []int{3}, page(items, &storage.Pagination{Limit: 5, Offset: 2}))
+	assert.Empty(t, page(items, &storage.Pagination{Limit: 5, Offset: 3}))
+}
@@ -0,0 +1,166 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) ListSpecies(ctx context.Context) ([]*model.SpeciesEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make([]*model.SpeciesEntry, 0, len(m.species))
+	for _, entry := range m.species {
+		e := *entry
+		entries = append(entries, &e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+func (m *Memory) GetSpecies(ctx context.Context, name model.Species) (*model.SpeciesEntry, error) {
+	const op errors.Op = "memory.GetSpecies"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.getSpecies(name, op)
+}
+
+func (m *Memory) getSpecies(name model.Species, op errors.Op) (*model.SpeciesEntry, error) {
+	entry, ok := m.species[name]
+	if !ok {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("species %s does not exist", name))
+	}
+
+	e := *entry
+	return &e, nil
+}
+
+func (m *Memory) CreateSpecies(ctx context.Context, entry *model.SpeciesEntry) error {
+	const op errors.Op = "memory.CreateSpecies"
+
+	if err := park.CheckSpeciesEntry(entry); err != nil {
+		return errors.E(op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.species[entry.Name]; exists {
+		return errors.E(op, errors.KindAlreadyExists, fmt.Sprintf("species %s already exists", entry.Name))
+	}
+
+	now := m.timestamp()
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+
+	e := *entry
+	m.species[entry.Name] = &e
+	m.recordSpecies(ctx, op, entry.Name, nil, entry)
+
+	return nil
+}
+
+func (m *Memory) UpdateSpecies(ctx context.Context, name model.Species, updater storage.SpeciesUpdater) error {
+	const op errors.Op = "memory.UpdateSpecies"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, err := m.getSpecies(name, op)
+	if err != nil {
+		return err
+	}
+
+	before := *old
+	entry, err := updater(old)
+	if err != nil {
+		return err
+	}
+
+	entry.Name = name
+	if err := park.CheckSpeciesEntry(entry); err != nil {
+		return errors.E(op, err)
+	}
+
+	if entry.Kind != before.Kind {
+		if err := m.checkNoDinosaurs(name, "change the kind of", op); err != nil {
+			return err
+		}
+	}
+
+	entry.CreatedAt = before.CreatedAt
+	entry.UpdatedAt = m.timestamp()
+
+	e := *entry
+	m.species[name] = &e
+	m.recordSpecies(ctx, op, name, &before, entry)
+
+	return nil
+}
+
+func (m *Memory) DeleteSpecies(ctx context.Context, name model.Species) error {
+	const op errors.Op = "memory.DeleteSpecies"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.getSpecies(name, op)
+	if err != nil {
+		return err
+	}
+
+	if err := m.checkNoDinosaurs(name, "delete", op); err != nil {
+		return err
+	}
+
+	delete(m.species, name)
+	m.recordSpecies(ctx, op, name, entry, nil)
+
+	return nil
+}
+
+// checkNoDinosaurs fails unless no live dinosaur belongs to a species.
+func (m *Memory) checkNoDinosaurs(name model.Species, action string, op errors.Op) error {
+	n := 0
+	for _, d := range m.dinosaurs {
+		if d.Species == name && !d.Archived() {
+			n++
+		}
+	}
+
+	if n > 0 {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"cannot %s %s while %d dinosaurs belong to it", action, name, n))
+	}
+
+	return nil
+}
+
+func (m *Memory) recordSpecies(ctx context.Context, op errors.Op, name model.Species, before, after *model.SpeciesEntry) {
+	entry, event := storage.NewSpeciesChange(ctx, op, name, before, after)
+	m.audit(entry)
+	m.publish(event)
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_Species(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	cage := newTestCage(t, m)
+
+	entries, err := m.ListSpecies(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.GreaterOrEqual(t, len(entries), len(model.DefaultSpecies()))
+
+	name := model.Species("dilophosaurus" + newID())
+	entry := &model.SpeciesEntry{Name: name, Kind: model.KindCarnivore, MaxPerCage: 3, Habitat: "jungle"}
+	if err := m.CreateSpecies(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.GetSpecies(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "jungle", got.Habitat)
+	assert.Equal(t, 3, got.MaxPerCage)
+	assert.NotNil(t, got.CreatedAt)
+
+	err = m.CreateSpecies(ctx, entry)
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+
+	err = m.CreateSpecies(ctx, &model.SpeciesEntry{Name: "segnosaurus", Kind: model.KindUnknown})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	err = m.CreateDinosaur(ctx, newTestDinosaur(cage.ID, "segnosaurus"))
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	d := newTestDinosaur(cage.ID, name)
+	if err := m.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.UpdateSpecies(ctx, name, func(old *model.SpeciesEntry) (*model.SpeciesEntry, error) {
+		old.Kind = model.KindHerbivores
+		return old, nil
+	})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = m.UpdateSpecies(ctx, name, func(old *model.SpeciesEntry) (*model.SpeciesEntry, error) {
+		old.Habitat = "wetland"
+		return old, nil
+	})
+	assert.NoError(t, err)
+
+	err = m.DeleteSpecies(ctx, name)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	if err := m.ArchiveDinosaur(ctx, d.ID, "deceased"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, m.DeleteSpecies(ctx, name))
+
+	_, err = m.GetSpecies(ctx, name)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	events, err := m.ListEvents(ctx, storage.ListEventParams{Types: []string{model.EventSpeciesChanged}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes int
+	for _, event := range events {
+		if event.EntityID == model.ID(name) {
+			changes++
+		}
+	}
+
+	assert.Equal(t, 3, changes)
+}
@@ -0,0 +1,230 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+// rollupKey identifies the rollup of a sensor of a cage.
+type rollupKey struct {
+	cageID model.ID
+	sensor string
+	start  time.Time
+}
+
+func (m *Memory) RecordTelemetry(ctx context.Context, readings []*model.Reading) ([]*model.FenceAlert, error) {
+	const op errors.Op = "memory.RecordTelemetry"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.timestamp()
+	if err := park.CheckReadings(readings, *now); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	for _, reading := range readings {
+		if _, ok := m.cages[reading.CageID]; !ok {
+			return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("cage %s does not exist", reading.CageID))
+		}
+	}
+
+	for _, reading := range readings {
+		if reading.RecordedAt == nil {
+			reading.RecordedAt = now
+		} else {
+			recordedAt := reading.RecordedAt.UTC()
+			reading.RecordedAt = &recordedAt
+		}
+
+		reading.ID = m.nextSeq()
+		reading.CreatedAt = now
+
+		r := *reading
+		m.readings = append(m.readings, &r)
+	}
+
+	latest := park.LatestVoltages(readings)
+	ids := make([]model.ID, 0, len(latest))
+	for id := range latest {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	alerts := []*model.FenceAlert{}
+	for _, id := range ids {
+		if alert := m.checkFence(ctx, m.withOccupancy(m.cages[id], true), latest[id].Value, op); alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return alerts, nil
+}
+
+// checkFence raises or clears the fence alert of a cage, which must carry
+// its occupants, given its latest voltage. The alert of a cage whose fence
+// is no longer watched is cleared whatever the voltage. It returns a copy of
+// the alert raised or cleared, if any.
+func (m *Memory) checkFence(ctx context.Context, cage *model.Cage, voltage float64, op errors.Op) *model.FenceAlert {
+	kind, threshold, watched := park.FenceThreshold(cage)
+	open := m.openFenceAlert(cage.ID)
+	switch {
+	case !watched && open != nil:
+		return m.clearFenceAlert(ctx, open, park.FenceUnwatched(cage))
+	case !watched:
+		return nil
+	case voltage < threshold && open == nil:
+		alert := &model.FenceAlert{
+			ID:        m.nextSeq(),
+			CageID:    cage.ID,
+			Kind:      kind,
+			Threshold: threshold,
+			Voltage:   voltage,
+			RaisedAt:  m.timestamp(),
+		}
+
+		incident := park.FenceAlarm(cage, alert)
+		m.openIncident(ctx, incident, op)
+		alert.IncidentID = incident.ID
+		m.fenceAlerts = append(m.fenceAlerts, alert)
+
+		a := *alert
+		return &a
+	case voltage >= threshold && open != nil:
+		return m.clearFenceAlert(ctx, open, park.FenceRecovery(open, voltage))
+	default:
+		return nil
+	}
+}
+
+// clearFenceAlert clears an open alert and notes why on its incident. It
+// returns a copy of the alert.
+func (m *Memory) clearFenceAlert(ctx context.Context, alert *model.FenceAlert, note string) *model.FenceAlert {
+	alert.ClearedAt = m.timestamp()
+	m.addIncidentNote(ctx, alert.IncidentID, "", note)
+
+	a := *alert
+	return &a
+}
+
+// openFenceAlert returns the stored open alert of a cage, if any.
+func (m *Memory) openFenceAlert(cageID model.ID) *model.FenceAlert {
+	for _, alert := range m.fenceAlerts {
+		if alert.CageID == cageID && alert.Open() {
+			return alert
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) QueryTelemetry(ctx context.Context, query storage.TelemetryQuery) ([]*model.ReadingBucket, error) {
+	const op errors.Op = "memory.QueryTelemetry"
+
+	if err := park.CheckTelemetryQuery(query.Sensor, query.From, query.To, query.Bucket); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.cages[query.CageID]; !ok {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("cage %s does not exist", query.CageID))
+	}
+
+	within := func(t time.Time) bool {
+		return !t.Before(query.From) && t.Before(query.To)
+	}
+
+	var readings []*model.Reading
+	for _, reading := range m.readings {
+		if reading.CageID == query.CageID && reading.Sensor == query.Sensor && within(*reading.RecordedAt) {
+			readings = append(readings, reading)
+		}
+	}
+
+	var rollups []*model.ReadingRollup
+	for _, rollup := range m.rollups {
+		if rollup.CageID == query.CageID && rollup.Sensor == query.Sensor && within(rollup.BucketStart) {
+			rollups = append(rollups, rollup)
+		}
+	}
+
+	return park.Bucketize(readings, rollups, query.Bucket), nil
+}
+
+func (m *Memory) ListFenceAlerts(ctx context.Context, params storage.ListFenceAlertParams) ([]*model.FenceAlert, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var alerts []*model.FenceAlert
+	for i := len(m.fenceAlerts) - 1; i >= 0; i-- {
+		alert := m.fenceAlerts[i]
+		if params.CageID != "" && alert.CageID != params.CageID {
+			continue
+		}
+
+		if params.Open && !alert.Open() {
+			continue
+		}
+
+		a := *alert
+		alerts = append(alerts, &a)
+	}
+
+	return page(alerts, params.Pagination), len(alerts), nil
+}
+
+func (m *Memory) DownsampleTelemetry(ctx context.Context, rawBefore, rollupBefore time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expired, kept []*model.Reading
+	for _, reading := range m.readings {
+		if reading.RecordedAt.Before(rawBefore) {
+			expired = append(expired, reading)
+		} else {
+			kept = append(kept, reading)
+		}
+	}
+
+	for _, rollup := range park.Rollup(expired) {
+		key := rollupKey{rollup.CageID, rollup.Sensor, rollup.BucketStart}
+		if stored, ok := m.rollups[key]; ok {
+			park.MergeRollup(stored, rollup)
+		} else {
+			m.rollups[key] = rollup
+		}
+	}
+
+	for key := range m.rollups {
+		if key.start.Before(rollupBefore) {
+			delete(m.rollups, key)
+		}
+	}
+
+	m.readings = kept
+	return len(expired), nil
+}
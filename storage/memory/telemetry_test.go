@@ -0,0 +1,182 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/app"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_Telemetry(t *testing.T) {
+	m := newTestMemory()
+	ctx := storage.WithActor(context.Background(), "sensors")
+
+	carnivores, herbivores, empty := newTestCage(t, m), newTestCage(t, m), newTestCage(t, m)
+	cera := newTestDinosaur(herbivores.ID, model.Triceratops)
+	for _, d := range []*model.Dinosaur{
+		newTestDinosaur(carnivores.ID, model.Tyrannosaurus),
+		cera,
+	} {
+		if err := m.CreateDinosaur(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := park.BucketStart(app.StartDate().Add(-2*time.Hour), time.Hour)
+	reading := func(cageID model.ID, sensor string, value float64, d time.Duration) *model.Reading {
+		at := start.Add(d)
+		return &model.Reading{CageID: cageID, Sensor: sensor, Value: value, RecordedAt: &at}
+	}
+
+	// The latest voltage of a cage decides, whatever the order of the batch.
+	alerts, err := m.RecordTelemetry(ctx, []*model.Reading{
+		reading(carnivores.ID, model.SensorVoltage, 4000, time.Minute),
+		reading(carnivores.ID, model.SensorVoltage, 9500, 0),
+		reading(carnivores.ID, model.SensorDoor, 1, time.Minute),
+		reading(herbivores.ID, model.SensorVoltage, 5000, time.Minute),
+		reading(empty.ID, model.SensorVoltage, 0, time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(alerts))
+	}
+
+	alert := alerts[0]
+	assert.Equal(t, carnivores.ID, alert.CageID)
+	assert.Equal(t, model.KindCarnivore, alert.Kind)
+	assert.Equal(t, 4000.0, alert.Voltage)
+	assert.True(t, alert.Open())
+
+	incident, err := m.GetIncident(ctx, alert.IncidentID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.SeverityCritical, incident.Severity)
+		assert.Equal(t, []model.ID{carnivores.ID}, incident.CageIDs)
+	}
+
+	// An open alert is not raised again.
+	alerts, err = m.RecordTelemetry(ctx, []*model.Reading{reading(carnivores.ID, model.SensorVoltage, 3000, 2*time.Minute)})
+	if assert.NoError(t, err) {
+		assert.Empty(t, alerts)
+	}
+
+	// A batch with an unknown cage is refused as a whole.
+	_, err = m.RecordTelemetry(ctx, []*model.Reading{
+		reading(carnivores.ID, model.SensorVoltage, 9800, 3*time.Minute),
+		reading("cg_foo", model.SensorVoltage, 9800, 3*time.Minute),
+	})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	alerts, err = m.RecordTelemetry(ctx, []*model.Reading{reading(carnivores.ID, model.SensorVoltage, 9800, 3*time.Minute)})
+	if assert.NoError(t, err) && assert.Len(t, alerts, 1) {
+		assert.Equal(t, alert.ID, alerts[0].ID)
+		assert.False(t, alerts[0].Open())
+	}
+
+	incident, err = m.GetIncident(ctx, alert.IncidentID)
+	if assert.NoError(t, err) && assert.Len(t, incident.Notes, 2) {
+		assert.Equal(t, "fence voltage of cage "+string(carnivores.ID)+" is back to 9800 V", incident.Notes[1].Text)
+	}
+
+	listed, total, err := m.ListFenceAlerts(ctx, storage.ListFenceAlertParams{CageID: carnivores.ID})
+	if assert.NoError(t, err) && assert.Len(t, listed, 1) {
+		assert.Equal(t, 1, total)
+	}
+
+	listed, _, err = m.ListFenceAlerts(ctx, storage.ListFenceAlertParams{CageID: carnivores.ID, Open: true})
+	if assert.NoError(t, err) {
+		assert.Empty(t, listed)
+	}
+
+	// Alerts of fences no longer watched are cleared whatever the voltage.
+	alerts, err = m.RecordTelemetry(ctx, []*model.Reading{reading(herbivores.ID, model.SensorVoltage, 1000, 3*time.Minute)})
+	if assert.NoError(t, err) && assert.Len(t, alerts, 1) {
+		assert.True(t, alerts[0].Open())
+	}
+
+	if err := m.DeleteDinosaur(ctx, cera.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	alerts, err = m.RecordTelemetry(ctx, []*model.Reading{reading(herbivores.ID, model.SensorVoltage, 0, 4*time.Minute)})
+	if assert.NoError(t, err) && assert.Len(t, alerts, 1) {
+		assert.False(t, alerts[0].Open())
+
+		incident, err = m.GetIncident(ctx, alerts[0].IncidentID)
+		if assert.NoError(t, err) && assert.Len(t, incident.Notes, 2) {
+			assert.Equal(t, "cage "+string(herbivores.ID)+" is empty and its fence is no longer watched", incident.Notes[1].Text)
+		}
+	}
+
+	query := func(bucket time.Duration) []*model.ReadingBucket {
+		t.Helper()
+
+		buckets, err := m.QueryTelemetry(ctx, storage.TelemetryQuery{
+			CageID: carnivores.ID,
+			Sensor: model.SensorVoltage,
+			From:   start,
+			To:     start.Add(time.Hour),
+			Bucket: bucket,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return buckets
+	}
+
+	assert.Len(t, query(time.Minute), 4)
+	if buckets := query(time.Hour); assert.Len(t, buckets, 1) {
+		assert.Equal(t, start, buckets[0].Start)
+		assert.Equal(t, 4, buckets[0].Count)
+		assert.Equal(t, 3000.0, buckets[0].Min)
+		assert.Equal(t, 6575.0, buckets[0].Avg)
+		assert.Equal(t, 9800.0, buckets[0].Max)
+	}
+
+	_, err = m.QueryTelemetry(ctx, storage.TelemetryQuery{CageID: "cg_foo", Sensor: model.SensorVoltage, From: start, To: start.Add(time.Hour), Bucket: time.Minute})
+	assert.True(t, errors.Is(err, errors.KindNotFound))
+
+	// Rolled up readings still count, in the bucket of their rollup.
+	rolled, err := m.DownsampleTelemetry(ctx, start.Add(2*time.Minute), start.Add(-time.Hour))
+	if assert.NoError(t, err) {
+		assert.GreaterOrEqual(t, rolled, 2)
+	}
+
+	assert.Len(t, query(time.Minute), 3)
+	if buckets := query(time.Hour); assert.Len(t, buckets, 1) {
+		assert.Equal(t, 4, buckets[0].Count)
+		assert.Equal(t, 6575.0, buckets[0].Avg)
+	}
+
+	// Expired rollups are dropped.
+	if _, err := m.DownsampleTelemetry(ctx, start.Add(2*time.Minute), start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if buckets := query(time.Hour); assert.Len(t, buckets, 1) {
+		assert.Equal(t, 2, buckets[0].Count)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) TransferDinosaur(ctx context.Context, dinosaurID, toCageID model.ID, reason string) (*model.Transfer, error) {
+	const op errors.Op = "memory.TransferDinosaur"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dinosaur, err := m.getDinosaur(dinosaurID, op)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := m.transfer(ctx, dinosaur, toCageID, reason, op)
+	if err != nil {
+		return nil, err
+	}
+
+	t := *transfer
+	return &t, nil
+}
+
+func (m *Memory) TransferDinosaurs(ctx context.Context, moves []*model.PlannedTransfer, reason string) ([]*model.Transfer, error) {
+	const op errors.Op = "memory.TransferDinosaurs"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Moves are applied one at a time, so the state is restored if one fails.
+	var (
+		dinosaurs  = make(map[model.ID]*model.Dinosaur, len(moves))
+		nTransfers = len(m.transfers)
+		nAuditLog  = len(m.auditLog)
+		nOutbox    = len(m.outbox)
+		seq        = m.seq
+		transfers  []*model.Transfer
+		rollback   = func() {
+			for id, dinosaur := range dinosaurs {
+				m.dinosaurs[id] = dinosaur
+			}
+
+			m.transfers = m.transfers[:nTransfers]
+			m.auditLog = m.auditLog[:nAuditLog]
+			m.outbox = m.outbox[:nOutbox]
+			m.seq = seq
+		}
+	)
+
+	for _, move := range moves {
+		dinosaur, err := m.getDinosaur(move.DinosaurID, op)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+
+		if dinosaur.CageID != move.FromCageID {
+			rollback()
+			return nil, errors.E(op, errors.KindPreconditionFailed, fmt.Sprintf(
+				"dinosaur %s is no longer in cage %s", move.DinosaurID, move.FromCageID))
+		}
+
+		if _, ok := dinosaurs[dinosaur.ID]; !ok {
+			dinosaurs[dinosaur.ID] = m.dinosaurs[dinosaur.ID]
+		}
+
+		transfer, err := m.transfer(ctx, dinosaur, move.ToCageID, reason, op)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+
+		t := *transfer
+		transfers = append(transfers, &t)
+	}
+
+	return transfers, nil
+}
+
+// transfer moves dinosaur to another cage and records the transfer. The
+// caller must hold the write lock.
+func (m *Memory) transfer(ctx context.Context, dinosaur *model.Dinosaur, toCageID model.ID, reason string, op errors.Op) (*model.Transfer, error) {
+	if err := park.CheckTransfer(dinosaur); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if dinosaur.CageID == toCageID {
+		return nil, errors.E(op, errors.KindUnprocessable, fmt.Sprintf("%s is already in cage %s", dinosaur.ID, toCageID))
+	}
+
+	if err := park.CheckIncidentHolds([]model.ID{dinosaur.CageID, toCageID}, m.incidents); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	to, err := m.getCage(toCageID, op)
+	if errors.IsNotFoundErr(err) {
+		return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("cage %s does not exist", toCageID))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	before := storage.Snapshot(dinosaur)
+	from := dinosaur.CageID
+	dinosaur.CageID = toCageID
+	if err := park.CheckPlacement(to, to.Dinosaurs, dinosaur); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	now := m.timestamp()
+	dinosaur.UpdatedAt = now
+	dinosaur.Version++
+	m.dinosaurs[dinosaur.ID] = dinosaur
+
+	transfer := &model.Transfer{
+		ID:         m.nextSeq(),
+		DinosaurID: dinosaur.ID,
+		FromCageID: from,
+		ToCageID:   toCageID,
+		Reason:     reason,
+		Actor:      storage.Actor(ctx),
+		CreatedAt:  now,
+	}
+
+	m.transfers = append(m.transfers, transfer)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityDinosaur, dinosaur.ID, before, storage.Snapshot(dinosaur)))
+	m.publish(storage.NewEvent(model.EventDinosaurTransferred, dinosaur.ID, transfer))
+
+	return transfer, nil
+}
+
+func (m *Memory) ListTransfers(ctx context.Context, params storage.ListTransferParams) ([]*model.Transfer, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var transfers []*model.Transfer
+	for i := len(m.transfers) - 1; i >= 0; i-- {
+		transfer := m.transfers[i]
+		if params.DinosaurID != "" && transfer.DinosaurID != params.DinosaurID {
+			continue
+		}
+
+		if params.CageID != "" && transfer.FromCageID != params.CageID && transfer.ToCageID != params.CageID {
+			continue
+		}
+
+		t := *transfer
+		transfers = append(transfers, &t)
+	}
+
+	return page(transfers, params.Pagination), len(transfers), nil
+}
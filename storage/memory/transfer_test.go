@@ -0,0 +1,154 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_TransferDinosaur(t *testing.T) {
+	m := newTestMemory()
+	ctx := storage.WithActor(context.Background(), "muldoon")
+	c1, c2 := newTestCage(t, m), newTestCage(t, m)
+	d := newTestDinosaur(c1.ID, model.Tyrannosaurus)
+	if err := m.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	transfer, err := m.TransferDinosaur(ctx, d.ID, c2.ID, "paddock rotation")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotZero(t, transfer.ID)
+	assert.Equal(t, c1.ID, transfer.FromCageID)
+	assert.Equal(t, c2.ID, transfer.ToCageID)
+	assert.Equal(t, "muldoon", transfer.Actor)
+
+	got, err := m.GetDinosaur(ctx, d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, c2.ID, got.CageID)
+	assert.Equal(t, 2, got.Version)
+
+	for _, params := range []storage.ListTransferParams{{DinosaurID: d.ID}, {CageID: c1.ID}, {CageID: c2.ID}} {
+		transfers, total, err := m.ListTransfers(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 1, total)
+		assert.Equal(t, transfer.ID, transfers[0].ID)
+		assert.Equal(t, "paddock rotation", transfers[0].Reason)
+	}
+
+	_, err = m.TransferDinosaur(ctx, d.ID, c2.ID, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	_, err = m.TransferDinosaur(ctx, d.ID, "foo", "")
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	_, err = m.TransferDinosaur(ctx, "foo", c1.ID, "")
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	herbivores := newTestCage(t, m)
+	_, err = m.TransferDinosaur(ctx, d.ID, herbivores.ID, "")
+	assert.NoError(t, err)
+
+	if err := m.CreateDinosaur(ctx, newTestDinosaur(c1.ID, model.Ankylosaurus)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.TransferDinosaur(ctx, d.ID, c1.ID, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+}
+
+func TestMemory_TransferDinosaurs(t *testing.T) {
+	m := newTestMemory()
+	ctx := context.Background()
+	c1, c2, c3 := newTestCage(t, m), newTestCage(t, m), newTestCage(t, m)
+	d1 := newTestDinosaur(c1.ID, model.Triceratops)
+	d2 := newTestDinosaur(c2.ID, model.Triceratops)
+	rex := newTestDinosaur(c3.ID, model.Tyrannosaurus)
+	for _, d := range []*model.Dinosaur{d1, d2, rex} {
+		if err := m.CreateDinosaur(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := m.LastEventID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second move breaks the rules, so the first one is undone.
+	_, err = m.TransferDinosaurs(ctx, []*model.PlannedTransfer{
+		{DinosaurID: d1.ID, FromCageID: c1.ID, ToCageID: c2.ID},
+		{DinosaurID: d2.ID, FromCageID: c2.ID, ToCageID: c3.ID},
+	}, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	got, err := m.GetDinosaur(ctx, d1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, c1.ID, got.CageID)
+	assert.Equal(t, 1, got.Version)
+
+	last, err := m.LastEventID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, events, last)
+
+	_, err = m.TransferDinosaurs(ctx, []*model.PlannedTransfer{{DinosaurID: d1.ID, FromCageID: c2.ID, ToCageID: c3.ID}}, "")
+	assert.True(t, errors.Is(err, errors.KindPreconditionFailed))
+
+	transfers, err := m.TransferDinosaurs(ctx, []*model.PlannedTransfer{
+		{DinosaurID: d1.ID, FromCageID: c1.ID, ToCageID: c2.ID},
+		{DinosaurID: d1.ID, FromCageID: c2.ID, ToCageID: c1.ID},
+		{DinosaurID: d2.ID, FromCageID: c2.ID, ToCageID: c1.ID},
+	}, "consolidation")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, transfers, 3)
+	assert.Equal(t, "consolidation", transfers[2].Reason)
+
+	cage, err := m.GetCage(ctx, c1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, cage.Allocation)
+
+	_, total, err := m.ListTransfers(ctx, storage.ListTransferParams{CageID: c2.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, total)
+}
@@ -0,0 +1,410 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	const op errors.Op = "memory.CreateWebhook"
+
+	if err := park.CheckWebhook(webhook); err != nil {
+		return errors.E(op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.timestamp()
+	webhook.ID = m.nextSeq()
+	webhook.Actor = storage.Actor(ctx)
+	webhook.LastEventID = 0
+	if len(m.outbox) > 0 {
+		webhook.LastEventID = m.outbox[len(m.outbox)-1].ID
+	}
+
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	m.webhooks = append(m.webhooks, cloneWebhook(webhook))
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityWebhook, storage.SerialID(webhook.ID), nil, storage.Snapshot(webhook)))
+
+	return nil
+}
+
+func (m *Memory) GetWebhook(ctx context.Context, id int64) (*model.Webhook, error) {
+	const op errors.Op = "memory.GetWebhook"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	webhook, err := m.getWebhook(id, op)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneWebhook(webhook), nil
+}
+
+// getWebhook returns the stored webhook with an ID. The caller must hold the
+// lock.
+func (m *Memory) getWebhook(id int64, op errors.Op) (*model.Webhook, error) {
+	for _, webhook := range m.webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+
+	return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("webhook %d does not exist", id))
+}
+
+func (m *Memory) ListWebhooks(ctx context.Context, params storage.ListWebhookParams) ([]*model.Webhook, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	webhooks := make([]*model.Webhook, 0, len(m.webhooks))
+	for _, webhook := range m.webhooks {
+		webhooks = append(webhooks, cloneWebhook(webhook))
+	}
+
+	return page(webhooks, params.Pagination), len(webhooks), nil
+}
+
+func (m *Memory) UpdateWebhook(ctx context.Context, id int64, updater storage.WebhookUpdater) error {
+	const op errors.Op = "memory.UpdateWebhook"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.getWebhook(id, op)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := updater(cloneWebhook(stored))
+	if err != nil {
+		return err
+	}
+
+	if err := park.CheckWebhook(webhook); err != nil {
+		return errors.E(op, err)
+	}
+
+	before := storage.Snapshot(stored)
+	stored.URL = webhook.URL
+	stored.Secret = webhook.Secret
+	stored.Types = append([]string(nil), webhook.Types...)
+	stored.Active = webhook.Active
+	stored.UpdatedAt = m.timestamp()
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityWebhook, storage.SerialID(id), before, storage.Snapshot(stored)))
+
+	return nil
+}
+
+func (m *Memory) DeleteWebhook(ctx context.Context, id int64) error {
+	const op errors.Op = "memory.DeleteWebhook"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.getWebhook(id, op)
+	if err != nil {
+		return err
+	}
+
+	webhooks := m.webhooks[:0]
+	for _, webhook := range m.webhooks {
+		if webhook.ID != id {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	m.webhooks = webhooks
+
+	deliveries := m.deliveries[:0]
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID != id {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	m.deliveries = deliveries
+
+	attempts := m.attempts[:0]
+	for _, attempt := range m.attempts {
+		if attempt.WebhookID != id {
+			attempts = append(attempts, attempt)
+		}
+	}
+	m.attempts = attempts
+
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityWebhook, storage.SerialID(id), storage.Snapshot(stored), nil))
+
+	return nil
+}
+
+func (m *Memory) EnqueueWebhookDeliveries(ctx context.Context, limit int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var created int
+	for _, webhook := range m.webhooks {
+		var scanned int
+		for _, event := range m.outbox {
+			if event.ID <= webhook.LastEventID {
+				continue
+			}
+
+			if limit > 0 && scanned == limit {
+				break
+			}
+
+			scanned++
+			webhook.LastEventID = event.ID
+			if !webhook.Active || !webhook.Wants(event.Type) {
+				continue
+			}
+
+			now := m.timestamp()
+			m.deliveries = append(m.deliveries, &model.WebhookDelivery{
+				ID:            m.nextSeq(),
+				WebhookID:     webhook.ID,
+				EventID:       event.ID,
+				EventType:     event.Type,
+				Status:        model.DeliveryPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			})
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+func (m *Memory) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	const op errors.Op = "memory.ClaimWebhookDeliveries"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now = now.UTC()
+	var due []*model.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.Status == model.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(*due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+		}
+
+		return due[i].ID < due[j].ID
+	})
+
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*model.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		webhook, err := m.getWebhook(delivery.WebhookID, op)
+		if err != nil {
+			return nil, err
+		}
+
+		event := m.getEvent(delivery.EventID)
+		if event == nil {
+			return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("event %d does not exist", delivery.EventID))
+		}
+
+		next := now.Add(lease)
+		delivery.NextAttemptAt = &next
+		delivery.UpdatedAt = m.timestamp()
+
+		d := *delivery
+		d.Webhook = cloneWebhook(webhook)
+		e := *event
+		d.Event = &e
+		claimed = append(claimed, &d)
+	}
+
+	return claimed, nil
+}
+
+// getEvent returns the event of the outbox with an ID, or nil. The caller
+// must hold the lock.
+func (m *Memory) getEvent(id int64) *model.Event {
+	i := sort.Search(len(m.outbox), func(i int) bool { return m.outbox[i].ID >= id })
+	if i < len(m.outbox) && m.outbox[i].ID == id {
+		return m.outbox[i]
+	}
+
+	return nil
+}
+
+func (m *Memory) RecordWebhookAttempt(ctx context.Context, attempt *model.WebhookAttempt, claimed time.Time, status string, next time.Time) error {
+	const op errors.Op = "memory.RecordWebhookAttempt"
+
+	if !model.ValidDeliveryStatus(status) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid delivery status: %q", status))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery, err := m.getDelivery(attempt.DeliveryID, op)
+	if err != nil {
+		return err
+	}
+
+	if err := park.CheckDeliveryClaim(delivery, claimed); err != nil {
+		return errors.E(op, err)
+	}
+
+	now := m.timestamp()
+	attempt.ID = m.nextSeq()
+	attempt.WebhookID = delivery.WebhookID
+	attempt.EventID = delivery.EventID
+	attempt.CreatedAt = now
+
+	a := *attempt
+	m.attempts = append(m.attempts, &a)
+
+	delivery.Attempts = attempt.Attempt
+	delivery.Status = status
+	delivery.LastError = attempt.Error
+	delivery.UpdatedAt = now
+
+	switch status {
+	case model.DeliveryPending:
+		next = next.UTC()
+		delivery.NextAttemptAt = &next
+	case model.DeliveryDelivered:
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = now
+	case model.DeliveryDead:
+		delivery.NextAttemptAt = nil
+	}
+
+	return nil
+}
+
+// getDelivery returns the stored delivery with an ID. The caller must hold
+// the lock.
+func (m *Memory) getDelivery(id int64, op errors.Op) (*model.WebhookDelivery, error) {
+	for _, delivery := range m.deliveries {
+		if delivery.ID == id {
+			return delivery, nil
+		}
+	}
+
+	return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("delivery %d does not exist", id))
+}
+
+func (m *Memory) ListWebhookDeliveries(ctx context.Context, params storage.ListWebhookDeliveryParams) ([]*model.WebhookDelivery, int, error) {
+	const op errors.Op = "memory.ListWebhookDeliveries"
+
+	if params.Status != "" && !model.ValidDeliveryStatus(params.Status) {
+		return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid status: %q", params.Status))
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var deliveries []*model.WebhookDelivery
+	for i := len(m.deliveries) - 1; i >= 0; i-- {
+		delivery := m.deliveries[i]
+		if params.WebhookID != 0 && delivery.WebhookID != params.WebhookID {
+			continue
+		}
+
+		if params.Status != "" && delivery.Status != params.Status {
+			continue
+		}
+
+		d := *delivery
+		deliveries = append(deliveries, &d)
+	}
+
+	return page(deliveries, params.Pagination), len(deliveries), nil
+}
+
+func (m *Memory) ListWebhookAttempts(ctx context.Context, params storage.ListWebhookAttemptParams) ([]*model.WebhookAttempt, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var attempts []*model.WebhookAttempt
+	for i := len(m.attempts) - 1; i >= 0; i-- {
+		attempt := m.attempts[i]
+		if params.WebhookID != 0 && attempt.WebhookID != params.WebhookID {
+			continue
+		}
+
+		if params.DeliveryID != 0 && attempt.DeliveryID != params.DeliveryID {
+			continue
+		}
+
+		a := *attempt
+		attempts = append(attempts, &a)
+	}
+
+	return page(attempts, params.Pagination), len(attempts), nil
+}
+
+func (m *Memory) ReplayWebhookDelivery(ctx context.Context, webhookID, deliveryID int64) (*model.WebhookDelivery, error) {
+	const op errors.Op = "memory.ReplayWebhookDelivery"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery, err := m.getDelivery(deliveryID, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.WebhookID != webhookID {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("delivery %d does not exist", deliveryID))
+	}
+
+	if err := park.CheckDeliveryReplay(delivery); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	now := m.timestamp()
+	delivery.Status = model.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+
+	d := *delivery
+	return &d, nil
+}
+
+func cloneWebhook(webhook *model.Webhook) *model.Webhook {
+	w := *webhook
+	w.Types = append([]string(nil), webhook.Types...)
+	return &w
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/app"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_Webhooks(t *testing.T) {
+	m := newTestMemory()
+	ctx := storage.WithActor(context.Background(), "nedry")
+	before := newTestCage(t, m)
+
+	webhook := &model.Webhook{URL: "https://pager.example.com", Secret: "s3cr3t", Types: []string{model.EventCageCreated}, Active: true}
+	if err := m.CreateWebhook(ctx, webhook); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotZero(t, webhook.ID)
+	assert.Equal(t, "nedry", webhook.Actor)
+
+	err := m.CreateWebhook(ctx, &model.Webhook{URL: "pager", Secret: "s3cr3t"})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	got, err := m.GetWebhook(ctx, webhook.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, webhook.URL, got.URL)
+		assert.Equal(t, webhook.Types, got.Types)
+	}
+
+	_, err = m.GetWebhook(ctx, -1)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	webhooks, total, err := m.ListWebhooks(ctx, storage.ListWebhookParams{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, total)
+		assert.Equal(t, webhook.ID, webhooks[0].ID)
+	}
+
+	// Events published before the webhook was created are not delivered.
+	cage := newTestCage(t, m)
+	if err := m.CreateDinosaur(ctx, newTestDinosaur(cage.ID, model.Stegosaurus)); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := m.EnqueueWebhookDeliveries(ctx, 100)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, created)
+	}
+
+	created, err = m.EnqueueWebhookDeliveries(ctx, 100)
+	if assert.NoError(t, err) {
+		assert.Zero(t, created)
+	}
+
+	now := app.StartDate().UTC().Add(time.Hour)
+	claimed, err := m.ClaimWebhookDeliveries(ctx, now, 10, time.Minute)
+	if assert.NoError(t, err) && assert.Len(t, claimed, 1) {
+		assert.Equal(t, model.EventCageCreated, claimed[0].EventType)
+		assert.Equal(t, webhook.URL, claimed[0].Webhook.URL)
+		assert.Equal(t, "s3cr3t", claimed[0].Webhook.Secret)
+		assert.Equal(t, cage.ID, claimed[0].Event.EntityID)
+		assert.NotEqual(t, before.ID, claimed[0].Event.EntityID)
+	}
+
+	// Claimed deliveries are leased.
+	again, err := m.ClaimWebhookDeliveries(ctx, now, 10, time.Minute)
+	if assert.NoError(t, err) {
+		assert.Empty(t, again)
+	}
+
+	delivery := claimed[0]
+	err = m.RecordWebhookAttempt(ctx, &model.WebhookAttempt{DeliveryID: delivery.ID, Attempt: 1, StatusCode: 500, Error: "500 Internal Server Error"}, *delivery.NextAttemptAt, model.DeliveryPending, now.Add(10*time.Second))
+	assert.NoError(t, err)
+
+	// Attempts are only recorded under the claim they were made with.
+	err = m.RecordWebhookAttempt(ctx, &model.WebhookAttempt{DeliveryID: delivery.ID, Attempt: 2}, *delivery.NextAttemptAt, model.DeliveryDelivered, time.Time{})
+	assert.True(t, errors.IsPreconditionFailedErr(err))
+
+	claimed, err = m.ClaimWebhookDeliveries(ctx, now.Add(10*time.Second), 10, time.Minute)
+	if assert.NoError(t, err) && assert.Len(t, claimed, 1) {
+		assert.Equal(t, 1, claimed[0].Attempts)
+		assert.Equal(t, "500 Internal Server Error", claimed[0].LastError)
+	}
+
+	err = m.RecordWebhookAttempt(ctx, &model.WebhookAttempt{DeliveryID: delivery.ID, Attempt: 2, Error: "connection refused"}, *claimed[0].NextAttemptAt, model.DeliveryDead, time.Time{})
+	assert.NoError(t, err)
+
+	err = m.RecordWebhookAttempt(ctx, &model.WebhookAttempt{DeliveryID: delivery.ID, Attempt: 3}, *claimed[0].NextAttemptAt, "lost", time.Time{})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	// Settled deliveries are never moved back to pending.
+	err = m.RecordWebhookAttempt(ctx, &model.WebhookAttempt{DeliveryID: delivery.ID, Attempt: 3}, *claimed[0].NextAttemptAt, model.DeliveryPending, now.Add(time.Hour))
+	assert.True(t, errors.IsPreconditionFailedErr(err))
+
+	deliveries, total, err := m.ListWebhookDeliveries(ctx, storage.ListWebhookDeliveryParams{WebhookID: webhook.ID, Status: model.DeliveryDead})
+	if assert.NoError(t, err) && assert.Len(t, deliveries, 1) {
+		assert.Equal(t, 1, total)
+		assert.Equal(t, 2, deliveries[0].Attempts)
+		assert.Nil(t, deliveries[0].NextAttemptAt)
+	}
+
+	attempts, total, err := m.ListWebhookAttempts(ctx, storage.ListWebhookAttemptParams{DeliveryID: delivery.ID})
+	if assert.NoError(t, err) && assert.Len(t, attempts, 2) {
+		assert.Equal(t, 2, total)
+		assert.Equal(t, 2, attempts[0].Attempt)
+		assert.Equal(t, webhook.ID, attempts[0].WebhookID)
+		assert.Equal(t, 500, attempts[1].StatusCode)
+	}
+
+	_, err = m.ReplayWebhookDelivery(ctx, webhook.ID+1, delivery.ID)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	replayed, err := m.ReplayWebhookDelivery(ctx, webhook.ID, delivery.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.DeliveryPending, replayed.Status)
+		assert.Zero(t, replayed.Attempts)
+	}
+
+	_, err = m.ReplayWebhookDelivery(ctx, webhook.ID, delivery.ID)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	claimed, err = m.ClaimWebhookDeliveries(ctx, *replayed.NextAttemptAt, 10, time.Minute)
+	if assert.NoError(t, err) && assert.Len(t, claimed, 1) {
+		err = m.RecordWebhookAttempt(ctx, &model.WebhookAttempt{DeliveryID: delivery.ID, Attempt: 1, StatusCode: 204}, *claimed[0].NextAttemptAt, model.DeliveryDelivered, time.Time{})
+		assert.NoError(t, err)
+	}
+
+	deliveries, _, err = m.ListWebhookDeliveries(ctx, storage.ListWebhookDeliveryParams{WebhookID: webhook.ID})
+	if assert.NoError(t, err) && assert.Len(t, deliveries, 1) {
+		assert.Equal(t, model.DeliveryDelivered, deliveries[0].Status)
+		assert.NotNil(t, deliveries[0].DeliveredAt)
+	}
+
+	// Inactive webhooks skip the events published meanwhile.
+	err = m.UpdateWebhook(ctx, webhook.ID, func(old *model.Webhook) (*model.Webhook, error) {
+		old.Active = false
+		return old, nil
+	})
+	assert.NoError(t, err)
+
+	newTestCage(t, m)
+	created, err = m.EnqueueWebhookDeliveries(ctx, 100)
+	if assert.NoError(t, err) {
+		assert.Zero(t, created)
+	}
+
+	err = m.UpdateWebhook(ctx, webhook.ID, func(old *model.Webhook) (*model.Webhook, error) {
+		old.Types = []string{"cage.exploded"}
+		return old, nil
+	})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	assert.NoError(t, m.DeleteWebhook(ctx, webhook.ID))
+	assert.True(t, errors.IsNotFoundErr(m.DeleteWebhook(ctx, webhook.ID)))
+
+	_, total, err = m.ListWebhookAttempts(ctx, storage.ListWebhookAttemptParams{WebhookID: webhook.ID})
+	if assert.NoError(t, err) {
+		assert.Zero(t, total)
+	}
+
+	entries, _, err := m.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: storage.SerialID(webhook.ID)})
+	if assert.NoError(t, err) && assert.Len(t, entries, 3) {
+		for _, entry := range entries {
+			assert.NotContains(t, string(entry.Before)+string(entry.After), "s3cr3t")
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+)
+
+func (m *Memory) CreateZone(ctx context.Context, zone *model.Zone) error {
+	const op errors.Op = "memory.CreateZone"
+
+	if err := park.CheckZone(zone); err != nil {
+		return errors.E(op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.zones[zone.ID]; exists {
+		return errors.E(op, errors.KindAlreadyExists, fmt.Sprintf("zone %s already exists", zone.ID))
+	}
+
+	if err := m.checkZoneName(zone, op); err != nil {
+		return err
+	}
+
+	now := m.timestamp()
+	zone.CreatedAt = now
+	zone.UpdatedAt = now
+
+	m.zones[zone.ID] = cloneZone(zone)
+	park.SummarizeZone(zone, nil)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityZone, zone.ID, nil, storage.Snapshot(zone)))
+
+	return nil
+}
+
+// checkZoneName verifies that no other zone has the name of zone. The caller
+// must hold the lock.
+func (m *Memory) checkZoneName(zone *model.Zone, op errors.Op) error {
+	for _, z := range m.zones {
+		if z.ID != zone.ID && z.Name == zone.Name {
+			return errors.E(op, errors.KindAlreadyExists, fmt.Sprintf("zone %q already exists", zone.Name))
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) GetZone(ctx context.Context, id model.ID) (*model.Zone, error) {
+	const op errors.Op = "memory.GetZone"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	zone, ok := m.zones[id]
+	if !ok {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("zone %s does not exist", id))
+	}
+
+	return m.withCages(zone), nil
+}
+
+// withCages returns a copy of zone with the aggregates of its cages.
+func (m *Memory) withCages(zone *model.Zone) *model.Zone {
+	var cages []*model.Cage
+	for _, cage := range m.cages {
+		if cage.ZoneID == zone.ID {
+			cages = append(cages, m.withOccupancy(cage, false))
+		}
+	}
+
+	z := cloneZone(zone)
+	park.SummarizeZone(z, cages)
+	return z
+}
+
+func (m *Memory) ListZones(ctx context.Context, params storage.ListZoneParams) ([]*model.Zone, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	zones := make([]*model.Zone, 0, len(m.zones))
+	for _, zone := range m.zones {
+		zones = append(zones, m.withCages(zone))
+	}
+
+	sort.Slice(zones, func(i, j int) bool {
+		if zones[i].Name != zones[j].Name {
+			return zones[i].Name < zones[j].Name
+		}
+
+		return zones[i].ID < zones[j].ID
+	})
+
+	return page(zones, params.Pagination), len(zones), nil
+}
+
+func (m *Memory) UpdateZone(ctx context.Context, id model.ID, updater storage.ZoneUpdater) error {
+	const op errors.Op = "memory.UpdateZone"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.zones[id]
+	if !ok {
+		return errors.E(op, errors.KindNotFound, fmt.Sprintf("zone %s does not exist", id))
+	}
+
+	zone, err := updater(cloneZone(stored))
+	if err != nil {
+		return err
+	}
+
+	zone.ID = id
+	if err := park.CheckZone(zone); err != nil {
+		return errors.E(op, err)
+	}
+
+	if err := m.checkZoneName(zone, op); err != nil {
+		return err
+	}
+
+	var cages []*model.Cage
+	for _, cage := range m.cages {
+		if cage.ZoneID == id {
+			cages = append(cages, m.withOccupancy(cage, true))
+		}
+	}
+
+	sort.Slice(cages, func(i, j int) bool { return cages[i].ID < cages[j].ID })
+	if err := park.CheckZoneCages(zone, cages); err != nil {
+		return errors.E(op, err)
+	}
+
+	before := storage.Snapshot(stored)
+	stored.Name = zone.Name
+	stored.ForbiddenKinds = append([]string(nil), zone.ForbiddenKinds...)
+	stored.UpdatedAt = m.timestamp()
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityZone, id, before, storage.Snapshot(stored)))
+
+	return nil
+}
+
+func (m *Memory) DeleteZone(ctx context.Context, id model.ID) error {
+	const op errors.Op = "memory.DeleteZone"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	zone, ok := m.zones[id]
+	if !ok {
+		return errors.E(op, errors.KindNotFound, fmt.Sprintf("zone %s does not exist", id))
+	}
+
+	var cages int
+	for _, cage := range m.cages {
+		if cage.ZoneID == id {
+			cages++
+		}
+	}
+
+	if err := park.CheckZoneDelete(zone, cages); err != nil {
+		return errors.E(op, err)
+	}
+
+	delete(m.zones, id)
+	m.audit(storage.NewAuditEntry(ctx, op, model.EntityZone, id, storage.Snapshot(zone), nil))
+
+	return nil
+}
+
+// cloneZone copies the persisted fields of a zone.
+func cloneZone(zone *model.Zone) *model.Zone {
+	z := *zone
+	z.ForbiddenKinds = append([]string(nil), zone.ForbiddenKinds...)
+	z.Cages, z.Capacity, z.Allocation, z.Power = 0, 0, 0, nil
+	return &z
+}
@@ -15,6 +15,7 @@
 package postgres
 
 import (
+	"context"
 	"net/url"
 	"os"
 	"strings"
@@ -86,5 +87,9 @@ func newTestPostgres(t *testing.T) (*Postgres, error) {
 		return nil, err
 	}
 
+	if err := pg.Migrate(context.Background(), -1); err != nil {
+		return nil, err
+	}
+
 	return pg, nil
 }
@@ -0,0 +1,188 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+)
+
+func (p *Postgres) ArchiveCage(ctx context.Context, id model.ID, reason string) error {
+	const op errors.Op = "postgres.ArchiveCage"
+
+	archiveFn := func(tx *pg.Tx) error {
+		cage, err := lockCage(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if cage.Archived() {
+			return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is already archived", id))
+		}
+
+		if err := park.CheckArchive(cage, cage.Allocation); err != nil {
+			return errors.E(op, err)
+		}
+
+		before := storage.Snapshot(cage)
+		now := p.now().UTC()
+		cage.DeletedAt = &now
+		cage.ArchivedReason = reason
+		cage.UpdatedAt = &now
+		cage.Version++
+
+		if err := updateArchived(ctx, tx, cage, op); err != nil {
+			return err
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityCage, id, before, storage.Snapshot(cage))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		return p.publish(ctx, tx, storage.NewEvent(model.EventCageArchived, id, cage), op)
+	}
+
+	return p.ExecTx(ctx, archiveFn)
+}
+
+func (p *Postgres) RestoreCage(ctx context.Context, id model.ID) error {
+	const op errors.Op = "postgres.RestoreCage"
+
+	restoreFn := func(tx *pg.Tx) error {
+		cage, err := lockCage(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if !cage.Archived() {
+			return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is not archived", id))
+		}
+
+		before := storage.Snapshot(cage)
+		now := p.now().UTC()
+		cage.DeletedAt = nil
+		cage.ArchivedReason = ""
+		cage.UpdatedAt = &now
+		cage.Version++
+
+		if err := updateArchived(ctx, tx, cage, op); err != nil {
+			return err
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityCage, id, before, storage.Snapshot(cage))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		return p.publish(ctx, tx, storage.NewEvent(model.EventCageRestored, id, cage), op)
+	}
+
+	return p.ExecTx(ctx, restoreFn)
+}
+
+func (p *Postgres) ArchiveDinosaur(ctx context.Context, id model.ID, reason string) error {
+	const op errors.Op = "postgres.ArchiveDinosaur"
+
+	archiveFn := func(tx *pg.Tx) error {
+		dinosaur, err := lockDinosaur(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if dinosaur.Archived() {
+			return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is already archived", id))
+		}
+
+		before := storage.Snapshot(dinosaur)
+		now := p.now().UTC()
+		dinosaur.DeletedAt = &now
+		dinosaur.ArchivedReason = reason
+		dinosaur.UpdatedAt = &now
+		dinosaur.Version++
+
+		if err := updateArchived(ctx, tx, dinosaur, op); err != nil {
+			return err
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityDinosaur, id, before, storage.Snapshot(dinosaur))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		return p.publish(ctx, tx, storage.NewEvent(model.EventDinosaurRemoved, id, dinosaur), op)
+	}
+
+	return p.ExecTx(ctx, archiveFn)
+}
+
+func (p *Postgres) RestoreDinosaur(ctx context.Context, id model.ID) error {
+	const op errors.Op = "postgres.RestoreDinosaur"
+
+	restoreFn := func(tx *pg.Tx) error {
+		dinosaur, err := lockDinosaur(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if !dinosaur.Archived() {
+			return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is not archived", id))
+		}
+
+		before := storage.Snapshot(dinosaur)
+
+		// The cage may have changed while the dinosaur was away.
+		if err := place(ctx, tx, dinosaur, op); err != nil {
+			return err
+		}
+
+		now := p.now().UTC()
+		dinosaur.DeletedAt = nil
+		dinosaur.ArchivedReason = ""
+		dinosaur.UpdatedAt = &now
+		dinosaur.Version++
+
+		if err := updateArchived(ctx, tx, dinosaur, op); err != nil {
+			return err
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityDinosaur, id, before, storage.Snapshot(dinosaur))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		return p.publish(ctx, tx, storage.NewEvent(model.EventDinosaurPlaced, id, dinosaur), op)
+	}
+
+	return p.ExecTx(ctx, restoreFn)
+}
+
+// updateArchived writes the archive columns of a cage or dinosaur.
+func updateArchived(ctx context.Context, tx *pg.Tx, entity interface{}, op errors.Op) error {
+	if _, err := tx.ModelContext(ctx, entity).
+		Column("deleted_at", "archived_reason", "updated_at", "version").
+		WherePK().
+		Update(); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	return nil
+}
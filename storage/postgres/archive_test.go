@@ -0,0 +1,157 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_ArchiveDinosaur(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	cage := &model.Cage{ID: model.NewCageID(uuid.MustNextID()), Capacity: 1}
+	if err := postgres.CreateCage(ctx, cage); err != nil {
+		t.Fatal(err)
+	}
+
+	d1 := newTestDinosaur(cage.ID, model.Tyrannosaurus)
+	if err := postgres.CreateDinosaur(ctx, d1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := postgres.ArchiveDinosaur(ctx, d1.ID, "deceased"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := postgres.GetDinosaur(ctx, d1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, got.Archived())
+	assert.Equal(t, "deceased", got.ArchivedReason)
+
+	_, total, err := postgres.ListDinosaurs(ctx, storage.ListDinosaurParams{CageID: cage.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Zero(t, total)
+
+	_, total, err = postgres.ListDinosaurs(ctx, storage.ListDinosaurParams{CageID: cage.ID, IncludeArchived: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, total)
+
+	// The name and the slot of an archived dinosaur are free again.
+	d2 := newTestDinosaur(cage.ID, model.Tyrannosaurus)
+	d2.Name = d1.Name
+	if err := postgres.CreateDinosaur(ctx, d2); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = postgres.TransferDinosaur(ctx, d1.ID, newTestCage(t).ID, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = postgres.RestoreDinosaur(ctx, d1.ID)
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+
+	if err := postgres.ArchiveDinosaur(ctx, d2.ID, "relocated"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, postgres.RestoreDinosaur(ctx, d1.ID))
+
+	err = postgres.RestoreDinosaur(ctx, d1.ID)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = postgres.RestoreDinosaur(ctx, d2.ID)
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+}
+
+func TestPostgres_ArchiveCage(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	cage := newTestCage(t)
+
+	d := newTestDinosaur(cage.ID, model.Triceratops)
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	err := postgres.ArchiveCage(ctx, cage.ID, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	if err := postgres.ArchiveDinosaur(ctx, d.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := postgres.ArchiveCage(ctx, cage.ID, "decommissioned"); err != nil {
+		t.Fatal(err)
+	}
+
+	cages, _, err := postgres.ListCages(ctx, storage.ListCageParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, cages)
+
+	cages, _, err = postgres.ListCages(ctx, storage.ListCageParams{IncludeArchived: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, cages, 1)
+	assert.Equal(t, "decommissioned", cages[0].ArchivedReason)
+
+	err = postgres.CreateDinosaur(ctx, newTestDinosaur(cage.ID, model.Triceratops))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = postgres.RestoreDinosaur(ctx, d.ID)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	if err := postgres.RestoreCage(ctx, cage.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, postgres.RestoreDinosaur(ctx, d.ID))
+
+	got, err := postgres.GetCage(ctx, cage.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, got.Allocation)
+	assert.Equal(t, 3, got.Version)
+}
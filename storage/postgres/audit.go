@@ -0,0 +1,77 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+)
+
+// audit writes an entry to the audit log in the transaction of the change it
+// records.
+func (p *Postgres) audit(ctx context.Context, tx *pg.Tx, entry *model.AuditEntry, op errors.Op) error {
+	now := p.now().UTC()
+	entry.CreatedAt = &now
+
+	if _, err := tx.ModelContext(ctx, entry).Insert(); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) ListAuditEntries(ctx context.Context, params storage.ListAuditParams) ([]*model.AuditEntry, int, error) {
+	const op errors.Op = "postgres.ListAuditEntries"
+
+	var entries []*model.AuditEntry
+	q := p.db.WithContext(ctx).Model(&entries)
+
+	if params.EntityID != "" {
+		q = q.Where("entity_id = ?", params.EntityID)
+	}
+
+	if params.Actor != "" {
+		q = q.Where("actor = ?", params.Actor)
+	}
+
+	if !params.Since.IsZero() {
+		q = q.Where("created_at >= ?", params.Since)
+	}
+
+	if !params.Until.IsZero() {
+		q = q.Where("created_at < ?", params.Until)
+	}
+
+	if params.AfterID > 0 {
+		q = q.Where("id > ?", params.AfterID)
+	}
+
+	q = q.Order("created_at DESC", "id DESC")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_ListAuditEntries(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := storage.WithActor(context.Background(), "muldoon")
+	c1, c2 := newTestCage(t), newTestCage(t)
+	d := newTestDinosaur(c1.ID, model.Tyrannosaurus)
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := postgres.TransferDinosaur(ctx, d.ID, c2.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, total, err := postgres.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: d.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "postgres.TransferDinosaur", entries[0].Op)
+	assert.Equal(t, "muldoon", entries[0].Actor)
+	assert.Equal(t, model.EntityDinosaur, entries[0].EntityType)
+
+	var before, after model.Dinosaur
+	assert.NoError(t, json.Unmarshal(entries[0].Before, &before))
+	assert.NoError(t, json.Unmarshal(entries[0].After, &after))
+	assert.Equal(t, c1.ID, before.CageID)
+	assert.Equal(t, c2.ID, after.CageID)
+
+	assert.Equal(t, "postgres.CreateDinosaur", entries[1].Op)
+	assert.Nil(t, entries[1].Before)
+
+	_, total, err = postgres.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: c1.ID, Actor: "muldoon"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Zero(t, total)
+
+	// The test clock is frozen, so both entries share their time.
+	_, total, err = postgres.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: d.ID, Since: *entries[1].CreatedAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, total)
+
+	_, total, err = postgres.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: d.ID, Until: *entries[1].CreatedAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Zero(t, total)
+
+	tail, _, err := postgres.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: d.ID, AfterID: entries[1].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, tail, 1)
+	assert.Equal(t, entries[0].ID, tail[0].ID)
+}
+
+func TestPostgres_AuditRollback(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	cage := newTestCage(t)
+
+	err := postgres.UpdateCage(ctx, cage.ID, func(old *model.Cage) (*model.Cage, error) {
+		old.Version++
+		return old, nil
+	})
+	assert.Error(t, err)
+
+	_, total, err := postgres.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: cage.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, total)
+}
+
+func TestPostgres_AuditRecords(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := storage.WithActor(context.Background(), "muldoon")
+	cage := newTestCage(t)
+	d := newTestDinosaur(cage.ID, model.Stegosaurus)
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	audited := func(entityType string, id int64) {
+		t.Helper()
+
+		entries, _, err := postgres.ListAuditEntries(ctx, storage.ListAuditParams{EntityID: storage.SerialID(id), Actor: "muldoon"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, entry := range entries {
+			if entry.EntityType == entityType {
+				assert.Nil(t, entry.Before)
+				assert.NotNil(t, entry.After)
+				return
+			}
+		}
+
+		t.Errorf("%s %d was not audited", entityType, id)
+	}
+
+	feedings, err := postgres.RecordFeeding(ctx, &model.Feeding{CageID: cage.ID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, feeding := range feedings {
+		audited(model.EntityFeeding, feeding.ID)
+	}
+
+	visit := &model.VetVisit{DinosaurID: d.ID, Vet: "harding"}
+	if err := postgres.CreateVetVisit(ctx, visit); err != nil {
+		t.Fatal(err)
+	}
+
+	audited(model.EntityVetVisit, visit.ID)
+
+	biometrics := []*model.Biometric{
+		{DinosaurID: d.ID, Metric: model.MetricWeight, Value: 2500},
+		{DinosaurID: d.ID, Metric: model.MetricTemperature, Value: 37.5},
+	}
+	if err := postgres.RecordBiometrics(ctx, biometrics); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, biometric := range biometrics {
+		audited(model.EntityBiometric, biometric.ID)
+	}
+}
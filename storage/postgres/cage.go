@@ -16,61 +16,358 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
 	"github.com/danielnegri/jurassic-park-go/pkg/errors"
 	"github.com/danielnegri/jurassic-park-go/storage"
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 )
 
+// allocationExpr counts the live dinosaurs held by the cage aliased as "cage".
+const allocationExpr = "(SELECT count(*) FROM dinosaurs AS d WHERE d.cage_id = cage.id AND d.deleted_at IS NULL)"
+
+var cageOrders = map[string]string{
+	storage.CageOrderID:         "cage.id",
+	storage.CageOrderCapacity:   "cage.capacity",
+	storage.CageOrderAllocation: allocationExpr,
+	storage.CageOrderCreatedAt:  "cage.created_at",
+	storage.CageOrderUpdatedAt:  "cage.updated_at",
+}
+
+// occupancy aggregates the dinosaurs held by a cage.
+type occupancy struct {
+	CageID     model.ID
+	Allocation int
+	Species    model.Species
+}
+
 func (p *Postgres) CreateCage(ctx context.Context, cage *model.Cage) error {
-	const op errors.Op = "postgres.CreateProduct"
+	const op errors.Op = "postgres.CreateCage"
 
-	now := p.now().UTC()
-	cage.CreatedAt = &now
-	cage.UpdatedAt = &now
+	if cage.Status == "" {
+		cage.Status = model.PowerActive
+	}
 
-	_, err := p.db.WithContext(ctx).Model(cage).Insert()
-	if err != nil {
-		return errors.E(op, kind(err), err)
+	if !cage.Status.Valid() {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid power status: %q", cage.Status))
 	}
 
-	return nil
+	if err := park.CheckCapacity(cage, 0); err != nil {
+		return errors.E(op, err)
+	}
+
+	createFn := func(tx *pg.Tx) error {
+		if _, err := cageZone(ctx, tx, cage.ZoneID, op); err != nil {
+			return err
+		}
+
+		circuit, err := cageCircuit(ctx, tx, cage.CircuitID, op)
+		if err != nil {
+			return err
+		}
+
+		if err := park.CheckCircuitLive(circuit); err != nil {
+			return errors.E(op, err)
+		}
+
+		now := p.now().UTC()
+		cage.CreatedAt = &now
+		cage.UpdatedAt = &now
+		cage.Version = 1
+
+		if _, err := tx.ModelContext(ctx, cage).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityCage, cage.ID, nil, storage.Snapshot(cage))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		if err := p.publish(ctx, tx, storage.NewEvent(model.EventCageCreated, cage.ID, cage), op); err != nil {
+			return err
+		}
+
+		return p.recordPower(ctx, tx, cage.ID, "", cage.Status, op)
+	}
+
+	return p.ExecTx(ctx, createFn)
 }
 
 func (p *Postgres) UpdateCage(ctx context.Context, id model.ID, updater storage.CageUpdater) error {
 	const op errors.Op = "postgres.UpdateCage"
 
 	updateFn := func(tx *pg.Tx) error {
-		old, err := p.getCage(ctx, id, op)
+		old, err := lockCage(ctx, tx, id, op)
 		if err != nil {
 			return err
 		}
 
+		if old.Archived() {
+			return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is archived", id))
+		}
+
+		before := storage.Snapshot(old)
+		allocation, status, version, zoneID, circuitID := old.Allocation, old.Status, old.Version, old.ZoneID, old.CircuitID
 		cage, err := updater(old)
 		if err != nil {
 			return err
 		}
 
+		if err := storage.CheckVersion(id, version, cage.Version); err != nil {
+			return errors.E(op, err)
+		}
+
+		if err := park.CheckCapacity(cage, allocation); err != nil {
+			return errors.E(op, err)
+		}
+
+		if cage.Status != status {
+			if err := park.CheckPowerTransition(id, status, cage.Status, allocation); err != nil {
+				return errors.E(op, err)
+			}
+		}
+
+		if cage.ZoneID != zoneID {
+			zone, err := cageZone(ctx, tx, cage.ZoneID, op)
+			if err != nil {
+				return err
+			}
+
+			if err := park.CheckZoneCages(zone, []*model.Cage{cage}); err != nil {
+				return errors.E(op, err)
+			}
+		}
+
+		// The fence of a cage on a cut circuit stays DOWN until it is
+		// restored.
+		circuitIDs := []model.ID{circuitID, cage.CircuitID}
+		if cage.CircuitID == circuitID {
+			circuitIDs = circuitIDs[:1]
+		}
+
+		if cage.Status != status || cage.CircuitID != circuitID {
+			for _, cid := range circuitIDs {
+				circuit, err := cageCircuit(ctx, tx, cid, op)
+				if err != nil {
+					return err
+				}
+
+				if err := park.CheckCircuitLive(circuit); err != nil {
+					return errors.E(op, err)
+				}
+			}
+		}
+
 		now := p.now().UTC()
 		cage.UpdatedAt = &now
+		cage.Version++
 
-		if _, err := p.db.WithContext(ctx).
-			Model(cage).
+		if _, err := tx.ModelContext(ctx, cage).
 			WherePK().
 			Update(); err != nil {
 			return errors.E(op, kind(err), err)
 		}
 
-		return nil
+		entry := storage.NewAuditEntry(ctx, op, model.EntityCage, id, before, storage.Snapshot(cage))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		if cage.Status == status {
+			return nil
+		}
+
+		return p.recordPower(ctx, tx, id, status, cage.Status, op)
 	}
 
 	return p.ExecTx(ctx, updateFn)
 }
 
+// recordPower appends a power transition of a cage to its history and
+// publishes it unless the cage is new.
+func (p *Postgres) recordPower(ctx context.Context, tx *pg.Tx, id model.ID, from, to model.PowerStatus, op errors.Op) error {
+	now := p.now().UTC()
+	event := &model.PowerEvent{
+		CageID:    id,
+		From:      from,
+		To:        to,
+		Actor:     storage.Actor(ctx),
+		Reason:    storage.Reason(ctx),
+		CreatedAt: &now,
+	}
+
+	if _, err := tx.ModelContext(ctx, event).Insert(); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	if from == "" {
+		return nil
+	}
+
+	return p.publish(ctx, tx, storage.NewEvent(model.EventCagePowerChanged, id, event), op)
+}
+
+func (p *Postgres) ListPowerEvents(ctx context.Context, cageID model.ID, pagination *storage.Pagination) ([]*model.PowerEvent, int, error) {
+	const op errors.Op = "postgres.ListPowerEvents"
+
+	if _, err := p.getCage(ctx, cageID, op); err != nil {
+		return nil, 0, err
+	}
+
+	var events []*model.PowerEvent
+	q := p.db.WithContext(ctx).
+		Model(&events).
+		Where("cage_id = ?", string(cageID)).
+		Order("created_at DESC", "id DESC")
+
+	if pagination != nil {
+		q = q.Limit(pagination.Limit).Offset(pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return events, total, nil
+}
+
+// lockCage selects a cage with its dinosaurs and locks it for the rest of the
+// transaction, serializing every write that depends on its occupancy.
+func lockCage(ctx context.Context, tx *pg.Tx, id model.ID, op errors.Op) (*model.Cage, error) {
+	cages, err := lockCages(ctx, tx, []model.ID{id}, op)
+	if err != nil {
+		return nil, err
+	}
+
+	cage, ok := cages[id]
+	if !ok {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("cage %s does not exist", id))
+	}
+
+	return cage, nil
+}
+
+// lockCages is like lockCage for several cages. Rows are locked in order of
+// ID so that concurrent transactions cannot deadlock each other. Missing cages
+// are left out of the result.
+func lockCages(ctx context.Context, tx *pg.Tx, ids []model.ID, op errors.Op) (map[model.ID]*model.Cage, error) {
+	var cages []*model.Cage
+	err := tx.ModelContext(ctx, &cages).
+		Where("id IN (?)", pg.In(ids)).
+		Order("id").
+		For("UPDATE").
+		Select()
+	if err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	if err := fillOccupancy(ctx, tx, cages, true); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	byID := make(map[model.ID]*model.Cage, len(cages))
+	for _, cage := range cages {
+		byID[cage.ID] = cage
+	}
+
+	return byID, nil
+}
+
+// lockGroupCages locks the cages whose column holds id with their dinosaurs,
+// then calls lockGroup to lock the zone or circuit grouping them. Cages which
+// joined the group while the others were being locked are locked last: they
+// are no longer waiting for the group, and no other cage can join it until
+// the transaction ends. Cages which left it are left out.
+func lockGroupCages(ctx context.Context, tx *pg.Tx, column string, id model.ID, groupOf func(*model.Cage) model.ID, lockGroup func() error, op errors.Op) ([]*model.Cage, error) {
+	selectIDs := func() ([]model.ID, error) {
+		var ids []model.ID
+		if err := tx.ModelContext(ctx, (*model.Cage)(nil)).
+			Column("id").
+			Where("? = ?", pg.Ident(column), string(id)).
+			Select(&ids); err != nil {
+			return nil, errors.E(op, kind(err), err)
+		}
+
+		return ids, nil
+	}
+
+	locked := make(map[model.ID]*model.Cage)
+	lock := func(ids []model.ID) error {
+		var missing []model.ID
+		for _, cageID := range ids {
+			if _, ok := locked[cageID]; !ok {
+				missing = append(missing, cageID)
+			}
+		}
+
+		if len(missing) == 0 {
+			return nil
+		}
+
+		cages, err := lockCages(ctx, tx, missing, op)
+		if err != nil {
+			return err
+		}
+
+		for cageID, cage := range cages {
+			locked[cageID] = cage
+		}
+
+		return nil
+	}
+
+	ids, err := selectIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lock(ids); err != nil {
+		return nil, err
+	}
+
+	if err := lockGroup(); err != nil {
+		return nil, err
+	}
+
+	if ids, err = selectIDs(); err != nil {
+		return nil, err
+	}
+
+	if err := lock(ids); err != nil {
+		return nil, err
+	}
+
+	cages := make([]*model.Cage, 0, len(locked))
+	for _, cage := range locked {
+		if groupOf(cage) == id {
+			cages = append(cages, cage)
+		}
+	}
+
+	sort.Slice(cages, func(i, j int) bool { return cages[i].ID < cages[j].ID })
+
+	return cages, nil
+}
+
 func (p *Postgres) GetCage(ctx context.Context, id model.ID) (*model.Cage, error) {
 	const op errors.Op = "postgres.GetCage"
-	return p.getCage(ctx, id, op)
+
+	cage, err := p.getCage(ctx, id, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fillLastFed(ctx, p.db, cage.Dinosaurs); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return cage, nil
 }
 
 func (p *Postgres) getCage(ctx context.Context, id model.ID, op errors.Op) (*model.Cage, error) {
@@ -83,9 +380,151 @@ func (p *Postgres) getCage(ctx context.Context, id model.ID, op errors.Op) (*mod
 		return nil, errors.E(op, kind(err), err)
 	}
 
+	if err := fillOccupancy(ctx, p.db, []*model.Cage{&cage}, true); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
 	return &cage, nil
 }
 
-func (p *Postgres) ListCages(ctx context.Context, params storage.ListCageParams) ([]*model.Cage, error) {
-	return nil, nil
+func (p *Postgres) ListCages(ctx context.Context, params storage.ListCageParams) ([]*model.Cage, int, error) {
+	const op errors.Op = "postgres.ListCages"
+
+	var cages []*model.Cage
+	q := p.db.WithContext(ctx).Model(&cages)
+
+	if !params.IncludeArchived {
+		q = q.Where("cage.deleted_at IS NULL")
+	}
+
+	if params.Status != "" {
+		if !params.Status.Valid() {
+			return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid status: %q", params.Status))
+		}
+
+		q = q.Where("cage.status = ?", params.Status)
+	}
+
+	if params.Species != "" {
+		q = q.Where(`cage.id IN (
+			SELECT cage_id FROM dinosaurs
+			WHERE deleted_at IS NULL
+			GROUP BY cage_id
+			HAVING count(DISTINCT species) = 1 AND min(species) = ?)`, params.Species)
+	}
+
+	if params.Kind != "" {
+		if !model.ValidKind(params.Kind) {
+			return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid kind: %q", params.Kind))
+		}
+
+		q = q.Where(`cage.id IN (
+			SELECT d.cage_id FROM dinosaurs AS d
+			JOIN species AS s ON s.name = d.species
+			WHERE d.deleted_at IS NULL AND s.kind = ?)`, params.Kind)
+	}
+
+	if params.Available {
+		q = q.Where(allocationExpr+" < LEAST(cage.capacity, ?)", model.MaxCageCapacity)
+	}
+
+	if params.ZoneID != "" {
+		q = q.Where("cage.zone_id = ?", params.ZoneID)
+	}
+
+	if params.CircuitID != "" {
+		q = q.Where("cage.circuit_id = ?", params.CircuitID)
+	}
+
+	field, desc := storage.SplitOrder(params.OrderBy)
+	if field == "" {
+		field = storage.CageOrderCreatedAt
+	}
+
+	order, ok := cageOrders[field]
+	if !ok {
+		return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid order: %q", params.OrderBy))
+	}
+
+	// Ties are broken by ID in the same direction.
+	tieBreak := "cage.id"
+	if desc {
+		order += " DESC"
+		tieBreak += " DESC"
+	}
+
+	q = q.OrderExpr(order).OrderExpr(tieBreak)
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	if err := fillOccupancy(ctx, p.db, cages, params.WithDinosaurs); err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return cages, total, nil
+}
+
+// fillOccupancy computes Allocation and Species for cages with a single
+// aggregate query, sets the kinds forbidden by their zones and, if
+// withDinosaurs is set, loads their dinosaurs. Archived dinosaurs do not
+// occupy cages.
+func fillOccupancy(ctx context.Context, db orm.DB, cages []*model.Cage, withDinosaurs bool) error {
+	if len(cages) == 0 {
+		return nil
+	}
+
+	byID := make(map[model.ID]*model.Cage, len(cages))
+	ids := make([]model.ID, 0, len(cages))
+	for _, cage := range cages {
+		byID[cage.ID] = cage
+		ids = append(ids, cage.ID)
+	}
+
+	var rows []occupancy
+	if _, err := db.QueryContext(ctx, &rows, `
+		SELECT cage_id,
+		       count(*) AS allocation,
+		       CASE WHEN count(DISTINCT species) = 1 THEN min(species) END AS species
+		FROM dinosaurs
+		WHERE cage_id IN (?) AND deleted_at IS NULL
+		GROUP BY cage_id`, pg.In(ids)); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		cage := byID[row.CageID]
+		cage.Allocation = row.Allocation
+		cage.Species = row.Species
+	}
+
+	if err := fillForbiddenKinds(ctx, db, cages); err != nil {
+		return err
+	}
+
+	if !withDinosaurs {
+		return nil
+	}
+
+	var dinosaurs []*model.Dinosaur
+	if err := db.ModelContext(ctx, &dinosaurs).
+		Where("cage_id IN (?)", pg.In(ids)).
+		Where("deleted_at IS NULL").
+		Order("created_at", "id").
+		Select(); err != nil {
+		return err
+	}
+
+	for _, dinosaur := range dinosaurs {
+		cage := byID[dinosaur.CageID]
+		cage.Dinosaurs = append(cage.Dinosaurs, dinosaur)
+	}
+
+	return nil
 }
@@ -17,11 +17,15 @@ package postgres
 import (
 	"context"
 	"encoding/json"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/danielnegri/jurassic-park-go/model"
 	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,7 +40,7 @@ func TestPostgres_CreateCage(t *testing.T) {
 	p1 := &model.Cage{
 		ID:       model.NewCageID(uid),
 		Capacity: gofakeit.IntRange(0, 10000),
-		Active:   gofakeit.Bool(),
+		Status:   model.PowerDown,
 	}
 
 	ctx := context.Background()
@@ -76,7 +80,7 @@ func TestPostgres_UpdateCage(t *testing.T) {
 	p0 := &model.Cage{
 		ID:       model.NewCageID(uid),
 		Capacity: gofakeit.IntRange(0, 10000),
-		Active:   false,
+		Status:   model.PowerActive,
 	}
 
 	ctx := context.Background()
@@ -91,7 +95,7 @@ func TestPostgres_UpdateCage(t *testing.T) {
 
 	updater := func(old *model.Cage) (*model.Cage, error) {
 		old.Capacity = gofakeit.IntRange(0, 10000)
-		old.Active = true
+		old.Status = model.PowerMaintenance
 		return old, nil
 	}
 
@@ -106,8 +110,8 @@ func TestPostgres_UpdateCage(t *testing.T) {
 
 	assert.Equal(t, p1.ID, p2.ID)
 	assert.NotEqual(t, p1.Capacity, p2.Capacity)
-	assert.False(t, p1.Active)
-	assert.True(t, p2.Active)
+	assert.Equal(t, model.PowerActive, p1.Status)
+	assert.Equal(t, model.PowerMaintenance, p2.Status)
 	assert.Equal(t, p1.CreatedAt.UTC(), p2.CreatedAt.UTC())
 	assert.GreaterOrEqual(t, p2.UpdatedAt.UTC(), p1.UpdatedAt.UTC())
 }
@@ -123,3 +127,301 @@ func TestPostgres_GetCageByHandleNotFound(t *testing.T) {
 	assert.True(t, errors.Is(err, errors.KindNotFound))
 	assert.Nil(t, p)
 }
+
+func TestPostgres_ListCages(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	// The cages of this test are created a second apart, after those of the
+	// other tests, so that ordering by creation time is observable.
+	start := time.Now().UTC()
+	ticks := 0
+	clocked := &Postgres{db: postgres.db, logger: postgres.logger, now: func() time.Time {
+		ticks++
+		return start.Add(time.Duration(ticks) * time.Second)
+	}}
+
+	ctx := context.Background()
+	newCage := func(capacity int, status model.PowerStatus) *model.Cage {
+		cage := &model.Cage{
+			ID:       model.NewCageID(uuid.MustNextID()),
+			Capacity: capacity,
+			Status:   status,
+		}
+
+		if err := clocked.CreateCage(ctx, cage); err != nil {
+			t.Fatal(err)
+		}
+
+		return cage
+	}
+
+	addDinosaur := func(cage *model.Cage, species model.Species) {
+		id := uuid.MustNextID()
+		if _, err := postgres.db.Model(&model.Dinosaur{
+			ID:      model.NewDinosaurID(id),
+			Name:    gofakeit.Name() + " " + id,
+			Species: species,
+			CageID:  cage.ID,
+		}).Insert(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	full := newCage(1, model.PowerActive)
+	addDinosaur(full, model.Tyrannosaurus)
+
+	mixed := newCage(5, model.PowerActive)
+	addDinosaur(mixed, model.Triceratops)
+	addDinosaur(mixed, model.Stegosaurus)
+
+	empty := newCage(3, model.PowerDown)
+
+	ids := func(cages []*model.Cage) []model.ID {
+		var ids []model.ID
+		for _, cage := range cages {
+			ids = append(ids, cage.ID)
+		}
+		return ids
+	}
+
+	cages, total, err := postgres.ListCages(ctx, storage.ListCageParams{Species: model.Tyrannosaurus, WithDinosaurs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, len(cages), total)
+	assert.Contains(t, ids(cages), full.ID)
+	assert.NotContains(t, ids(cages), mixed.ID)
+	for _, cage := range cages {
+		if cage.ID == full.ID {
+			assert.Equal(t, 1, cage.Allocation)
+			assert.Equal(t, model.Tyrannosaurus, cage.Species)
+			assert.Len(t, cage.Dinosaurs, 1)
+		}
+	}
+
+	cages, _, err = postgres.ListCages(ctx, storage.ListCageParams{Kind: model.KindHerbivores, Available: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, ids(cages), mixed.ID)
+	assert.NotContains(t, ids(cages), full.ID)
+	for _, cage := range cages {
+		if cage.ID == mixed.ID {
+			assert.Equal(t, 2, cage.Allocation)
+			assert.Empty(t, cage.Species)
+			assert.Empty(t, cage.Dinosaurs)
+		}
+	}
+
+	cages, _, err = postgres.ListCages(ctx, storage.ListCageParams{Status: model.PowerDown, Available: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, ids(cages), empty.ID)
+	assert.NotContains(t, ids(cages), mixed.ID)
+
+	cages, total, err = postgres.ListCages(ctx, storage.ListCageParams{
+		OrderBy:    "-" + storage.CageOrderCreatedAt,
+		Pagination: storage.NewPagination(1, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, cages, 1)
+	assert.GreaterOrEqual(t, total, 3)
+	assert.Equal(t, empty.ID, cages[0].ID)
+
+	_, _, err = postgres.ListCages(ctx, storage.ListCageParams{OrderBy: "name"})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+}
+
+func TestPostgres_UpdateCageCapacityBelowOccupancy(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	cage := newTestCage(t)
+	for i := 0; i < 2; i++ {
+		if err := postgres.CreateDinosaur(ctx, newTestDinosaur(cage.ID, model.Brachiosaurus)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := postgres.UpdateCage(ctx, cage.ID, func(old *model.Cage) (*model.Cage, error) {
+		old.Capacity = 1
+		return old, nil
+	})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = postgres.UpdateCage(ctx, cage.ID, func(old *model.Cage) (*model.Cage, error) {
+		old.Capacity = 2
+		return old, nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestPostgres_ConcurrentPlacements(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	const (
+		capacity   = 3
+		placements = 20
+	)
+
+	ctx := context.Background()
+	cage := &model.Cage{
+		ID:       model.NewCageID(uuid.MustNextID()),
+		Capacity: capacity,
+		Status:   model.PowerActive,
+	}
+
+	if err := postgres.CreateCage(ctx, cage); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		placed int32
+		full   int32
+	)
+
+	for i := 0; i < placements; i++ {
+		d := newTestDinosaur(cage.ID, model.Triceratops)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := postgres.CreateDinosaur(ctx, d)
+			switch {
+			case err == nil:
+				atomic.AddInt32(&placed, 1)
+			case errors.IsUnprocessableErr(err):
+				atomic.AddInt32(&full, 1)
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.EqualValues(t, capacity, placed)
+	assert.EqualValues(t, placements-capacity, full)
+
+	got, err := postgres.GetCage(ctx, cage.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, capacity, got.Allocation)
+}
+
+func TestPostgres_CagePowerTransitions(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := storage.WithActor(context.Background(), "muldoon")
+	cage := newTestCage(t)
+	d := newTestDinosaur(cage.ID, model.Velociraptor)
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	power := func(status model.PowerStatus) storage.CageUpdater {
+		return func(old *model.Cage) (*model.Cage, error) {
+			old.Status = status
+			return old, nil
+		}
+	}
+
+	err := postgres.UpdateCage(ctx, cage.ID, power(model.PowerDown))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	if err := postgres.DeleteDinosaur(ctx, d.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := postgres.UpdateCage(storage.WithReason(ctx, "fence repair"), cage.ID, power(model.PowerDown)); err != nil {
+		t.Fatal(err)
+	}
+
+	err = postgres.CreateDinosaur(ctx, newTestDinosaur(cage.ID, model.Velociraptor))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = postgres.UpdateCage(ctx, cage.ID, power(model.PowerActive))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	for _, status := range []model.PowerStatus{model.PowerMaintenance, model.PowerActive} {
+		if err := postgres.UpdateCage(ctx, cage.ID, power(status)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, total, err := postgres.ListPowerEvents(ctx, cage.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 4, total)
+	assert.Equal(t, model.PowerMaintenance, events[0].From)
+	assert.Equal(t, model.PowerActive, events[0].To)
+	assert.Equal(t, "muldoon", events[0].Actor)
+	assert.Equal(t, model.PowerDown, events[2].To)
+	assert.Equal(t, "fence repair", events[2].Reason)
+	assert.Empty(t, events[3].From)
+
+	_, _, err = postgres.ListPowerEvents(ctx, "foo", nil)
+	assert.True(t, errors.IsNotFoundErr(err))
+}
+
+func TestPostgres_UpdateCageVersion(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	cage := newTestCage(t)
+	assert.Equal(t, 1, cage.Version)
+
+	capacity := func(version, capacity int) storage.CageUpdater {
+		return func(old *model.Cage) (*model.Cage, error) {
+			old.Version = version
+			old.Capacity = capacity
+			return old, nil
+		}
+	}
+
+	assert.NoError(t, postgres.UpdateCage(ctx, cage.ID, capacity(1, 5)))
+
+	err := postgres.UpdateCage(ctx, cage.ID, capacity(1, 3))
+	assert.True(t, errors.IsPreconditionFailedErr(err))
+
+	got, err := postgres.GetCage(ctx, cage.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, got.Version)
+	assert.Equal(t, 5, got.Capacity)
+}
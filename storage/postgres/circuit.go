@@ -0,0 +1,492 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Circuits are locked after their cages, as zones are: a cage joining a
+// circuit or changing power state on it locks the cage, then the circuit.
+
+func (p *Postgres) CreateCircuit(ctx context.Context, circuit *model.Circuit) error {
+	const op errors.Op = "postgres.CreateCircuit"
+
+	if err := park.CheckCircuit(circuit); err != nil {
+		return errors.E(op, err)
+	}
+
+	createFn := func(tx *pg.Tx) error {
+		now := p.now().UTC()
+		circuit.Status = model.CircuitLive
+		circuit.Cages = 0
+		circuit.CreatedAt = &now
+		circuit.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, circuit).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityCircuit, circuit.ID, nil, storage.Snapshot(circuit))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, createFn)
+}
+
+func (p *Postgres) GetCircuit(ctx context.Context, id model.ID) (*model.Circuit, error) {
+	const op errors.Op = "postgres.GetCircuit"
+
+	circuit, err := getCircuit(ctx, p.db, id, false, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := summarizeCircuits(ctx, p.db, []*model.Circuit{circuit}); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return circuit, nil
+}
+
+// getCircuit selects a circuit, locking it if lock is set.
+func getCircuit(ctx context.Context, db orm.DB, id model.ID, lock bool, op errors.Op) (*model.Circuit, error) {
+	circuit := &model.Circuit{ID: id}
+	q := db.ModelContext(ctx, circuit).WherePK()
+	if lock {
+		q = q.For("UPDATE")
+	}
+
+	if err := q.Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("circuit %s does not exist", id))
+		}
+
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return circuit, nil
+}
+
+// cageCircuit locks the circuit a cage joins, or returns an empty circuit
+// for cages off the grid. Unknown circuits are reported as
+// errors.KindBadRequest.
+func cageCircuit(ctx context.Context, tx *pg.Tx, id model.ID, op errors.Op) (*model.Circuit, error) {
+	if id == "" {
+		return &model.Circuit{}, nil
+	}
+
+	circuit, err := getCircuit(ctx, tx, id, true, op)
+	if errors.IsNotFoundErr(err) {
+		return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("circuit %s does not exist", id))
+	}
+
+	return circuit, err
+}
+
+func (p *Postgres) ListCircuits(ctx context.Context, params storage.ListCircuitParams) ([]*model.Circuit, int, error) {
+	const op errors.Op = "postgres.ListCircuits"
+
+	var circuits []*model.Circuit
+	q := p.db.WithContext(ctx).Model(&circuits).Order("circuit.name", "circuit.id")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	if err := summarizeCircuits(ctx, p.db, circuits); err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return circuits, total, nil
+}
+
+// summarizeCircuits counts the live cages of circuits.
+func summarizeCircuits(ctx context.Context, db orm.DB, circuits []*model.Circuit) error {
+	if len(circuits) == 0 {
+		return nil
+	}
+
+	ids := make([]model.ID, 0, len(circuits))
+	for _, circuit := range circuits {
+		ids = append(ids, circuit.ID)
+	}
+
+	var cages []*model.Cage
+	if err := db.ModelContext(ctx, &cages).
+		Column("id", "circuit_id", "deleted_at").
+		Where("circuit_id IN (?)", pg.In(ids)).
+		Where("deleted_at IS NULL").
+		Select(); err != nil {
+		return err
+	}
+
+	for _, circuit := range circuits {
+		park.SummarizeCircuit(circuit, cages)
+	}
+
+	return nil
+}
+
+// lockCircuit locks the cages of a circuit with their dinosaurs, then the
+// circuit, as lockGroupCages does.
+func lockCircuit(ctx context.Context, tx *pg.Tx, id model.ID, op errors.Op) (*model.Circuit, []*model.Cage, error) {
+	var circuit *model.Circuit
+	cages, err := lockGroupCages(ctx, tx, "circuit_id", id,
+		func(cage *model.Cage) model.ID { return cage.CircuitID },
+		func() (err error) {
+			circuit, err = getCircuit(ctx, tx, id, true, op)
+			return err
+		}, op)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return circuit, cages, nil
+}
+
+func (p *Postgres) DeleteCircuit(ctx context.Context, id model.ID) error {
+	const op errors.Op = "postgres.DeleteCircuit"
+
+	deleteFn := func(tx *pg.Tx) error {
+		circuit, cages, err := lockCircuit(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if err := park.CheckCircuitDelete(circuit, len(cages)); err != nil {
+			return errors.E(op, err)
+		}
+
+		if _, err := tx.ModelContext(ctx, circuit).WherePK().Delete(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityCircuit, id, storage.Snapshot(circuit), nil)
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, deleteFn)
+}
+
+func (p *Postgres) CutCircuit(ctx context.Context, id model.ID) (*model.CircuitOutage, error) {
+	const op errors.Op = "postgres.CutCircuit"
+
+	var outage *model.CircuitOutage
+	cutFn := func(tx *pg.Tx) error {
+		circuit, cages, err := lockCircuit(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if err := park.CheckCircuitCut(circuit); err != nil {
+			return errors.E(op, err)
+		}
+
+		impact := park.CircuitImpact(circuit, cages)
+
+		now := p.now().UTC()
+		before := storage.Snapshot(circuit)
+		if err := p.setCircuitStatus(ctx, tx, circuit, model.CircuitCut, op); err != nil {
+			return err
+		}
+
+		reason := park.CircuitReason(circuit, storage.Reason(ctx))
+		ctx := storage.WithReason(ctx, reason)
+		outage = &model.CircuitOutage{
+			CircuitID: id,
+			Reason:    reason,
+			Actor:     storage.Actor(ctx),
+			StartedAt: &now,
+		}
+
+		if _, err := tx.ModelContext(ctx, outage).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		byID := make(map[model.ID]*model.Cage, len(cages))
+		for _, cage := range cages {
+			byID[cage.ID] = cage
+		}
+
+		for _, c := range impact.Cages {
+			cage := byID[c.ID]
+			affected := &model.OutageCage{
+				OutageID:   outage.ID,
+				CageID:     cage.ID,
+				Status:     cage.Status,
+				Allocation: cage.Allocation,
+			}
+
+			if err := p.forcePower(ctx, tx, cage, model.PowerDown, op); err != nil {
+				return err
+			}
+
+			if cage.Allocation > 0 {
+				incident := park.OutageAlarm(circuit, cage, reason)
+				if err := p.openIncident(ctx, tx, incident, op); err != nil {
+					return err
+				}
+
+				affected.IncidentID = incident.ID
+			}
+
+			outage.Cages = append(outage.Cages, affected)
+		}
+
+		if len(outage.Cages) > 0 {
+			if _, err := tx.ModelContext(ctx, &outage.Cages).Insert(); err != nil {
+				return errors.E(op, kind(err), err)
+			}
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityCircuit, id, before, storage.Snapshot(circuit))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		return p.publish(ctx, tx, storage.NewEvent(model.EventCircuitCut, id, outage), op)
+	}
+
+	if err := p.ExecTx(ctx, cutFn); err != nil {
+		return nil, err
+	}
+
+	return outage, nil
+}
+
+func (p *Postgres) RestoreCircuit(ctx context.Context, id model.ID) (*model.CircuitOutage, error) {
+	const op errors.Op = "postgres.RestoreCircuit"
+
+	var outage *model.CircuitOutage
+	restoreFn := func(tx *pg.Tx) error {
+		circuit, cages, err := lockCircuit(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if err := park.CheckCircuitRestore(circuit); err != nil {
+			return errors.E(op, err)
+		}
+
+		outage = new(model.CircuitOutage)
+		if err := tx.ModelContext(ctx, outage).
+			Where("circuit_id = ?", string(id)).
+			Where("restored_at IS NULL").
+			OrderExpr("id DESC").
+			Limit(1).
+			For("UPDATE").
+			Select(); err != nil {
+			if err == pg.ErrNoRows {
+				return errors.E(op, errors.KindUnexpected, fmt.Sprintf("circuit %s is cut without an outage", id))
+			}
+
+			return errors.E(op, kind(err), err)
+		}
+
+		if err := fillOutageCages(ctx, tx, []*model.CircuitOutage{outage}); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		now := p.now().UTC()
+		before := storage.Snapshot(circuit)
+		if err := p.setCircuitStatus(ctx, tx, circuit, model.CircuitLive, op); err != nil {
+			return err
+		}
+
+		outage.RestoredAt = &now
+		if _, err := tx.ModelContext(ctx, outage).
+			Column("restored_at").
+			WherePK().
+			Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		byID := make(map[model.ID]*model.Cage, len(cages))
+		for _, cage := range cages {
+			byID[cage.ID] = cage
+		}
+
+		reason := park.CircuitReason(circuit, storage.Reason(ctx))
+		ctx := storage.WithReason(ctx, reason)
+		for _, affected := range outage.Cages {
+			if cage, ok := byID[affected.CageID]; ok && !cage.Archived() && cage.Status == model.PowerDown {
+				if err := p.forcePower(ctx, tx, cage, affected.Status, op); err != nil {
+					return err
+				}
+			}
+
+			if affected.IncidentID == 0 {
+				continue
+			}
+
+			if err := p.addIncidentNote(ctx, tx, &model.IncidentNote{
+				IncidentID: affected.IncidentID,
+				Text:       reason,
+			}, op); err != nil {
+				return err
+			}
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityCircuit, id, before, storage.Snapshot(circuit))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		return p.publish(ctx, tx, storage.NewEvent(model.EventCircuitRestored, id, outage), op)
+	}
+
+	if err := p.ExecTx(ctx, restoreFn); err != nil {
+		return nil, err
+	}
+
+	return outage, nil
+}
+
+// setCircuitStatus updates the status of a locked circuit.
+func (p *Postgres) setCircuitStatus(ctx context.Context, tx *pg.Tx, circuit *model.Circuit, status string, op errors.Op) error {
+	now := p.now().UTC()
+	circuit.Status = status
+	circuit.UpdatedAt = &now
+
+	if _, err := tx.ModelContext(ctx, circuit).
+		Column("status", "updated_at").
+		WherePK().
+		Update(); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	return nil
+}
+
+// forcePower moves a locked cage to a power state regardless of its
+// occupancy and of the transitions allowed to operators, as a circuit does
+// to its fences.
+func (p *Postgres) forcePower(ctx context.Context, tx *pg.Tx, cage *model.Cage, to model.PowerStatus, op errors.Op) error {
+	now := p.now().UTC()
+	before := storage.Snapshot(cage)
+	from := cage.Status
+
+	cage.Status = to
+	cage.UpdatedAt = &now
+	cage.Version++
+
+	if _, err := tx.ModelContext(ctx, cage).
+		Column("status", "updated_at", "version").
+		WherePK().
+		Update(); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	entry := storage.NewAuditEntry(ctx, op, model.EntityCage, cage.ID, before, storage.Snapshot(cage))
+	if err := p.audit(ctx, tx, entry, op); err != nil {
+		return err
+	}
+
+	return p.recordPower(ctx, tx, cage.ID, from, to, op)
+}
+
+func (p *Postgres) GetCircuitImpact(ctx context.Context, id model.ID) (*model.CircuitImpact, error) {
+	const op errors.Op = "postgres.GetCircuitImpact"
+
+	circuit, err := getCircuit(ctx, p.db, id, false, op)
+	if err != nil {
+		return nil, err
+	}
+
+	var cages []*model.Cage
+	if err := p.db.ModelContext(ctx, &cages).
+		Where("circuit_id = ?", string(id)).
+		Order("id").
+		Select(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	if err := fillOccupancy(ctx, p.db, cages, true); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return park.CircuitImpact(circuit, cages), nil
+}
+
+func (p *Postgres) ListCircuitOutages(ctx context.Context, circuitID model.ID, pagination *storage.Pagination) ([]*model.CircuitOutage, int, error) {
+	const op errors.Op = "postgres.ListCircuitOutages"
+
+	if _, err := getCircuit(ctx, p.db, circuitID, false, op); err != nil {
+		return nil, 0, err
+	}
+
+	var outages []*model.CircuitOutage
+	q := p.db.WithContext(ctx).Model(&outages).
+		Where("circuit_id = ?", string(circuitID)).
+		OrderExpr("id DESC")
+
+	if pagination != nil {
+		q = q.Limit(pagination.Limit).Offset(pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	if err := fillOutageCages(ctx, p.db, outages); err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return outages, total, nil
+}
+
+// fillOutageCages loads the cages put DOWN by outages.
+func fillOutageCages(ctx context.Context, db orm.DB, outages []*model.CircuitOutage) error {
+	if len(outages) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.CircuitOutage, len(outages))
+	ids := make([]int64, 0, len(outages))
+	for _, outage := range outages {
+		byID[outage.ID] = outage
+		ids = append(ids, outage.ID)
+	}
+
+	var cages []*model.OutageCage
+	if err := db.ModelContext(ctx, &cages).
+		Where("outage_id IN (?)", pg.In(ids)).
+		Order("outage_id", "cage_id").
+		Select(); err != nil {
+		return err
+	}
+
+	for _, cage := range cages {
+		outage := byID[cage.OutageID]
+		outage.Cages = append(outage.Cages, cage)
+	}
+
+	return nil
+}
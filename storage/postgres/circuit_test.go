@@ -0,0 +1,204 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_Circuits(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := storage.WithActor(context.Background(), "arnold")
+
+	id := uuid.MustNextID()
+	circuit := &model.Circuit{ID: model.NewCircuitID(id), Name: "Grid " + id}
+	if err := postgres.CreateCircuit(ctx, circuit); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, model.CircuitLive, circuit.Status)
+
+	err := postgres.CreateCircuit(ctx, &model.Circuit{ID: model.NewCircuitID(uuid.MustNextID()), Name: circuit.Name})
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+
+	err = postgres.CreateCircuit(ctx, &model.Circuit{ID: model.NewCircuitID(uuid.MustNextID())})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	join := func(cageID, circuitID model.ID) error {
+		return postgres.UpdateCage(ctx, cageID, func(old *model.Cage) (*model.Cage, error) {
+			old.CircuitID = circuitID
+			return old, nil
+		})
+	}
+
+	power := func(cageID model.ID, status model.PowerStatus) error {
+		return postgres.UpdateCage(ctx, cageID, func(old *model.Cage) (*model.Cage, error) {
+			old.Status = status
+			return old, nil
+		})
+	}
+
+	carnivores, herbivores, empty := newTestCage(t), newTestCage(t), newTestCage(t)
+	rex := newTestDinosaur(carnivores.ID, model.Tyrannosaurus)
+	cera := newTestDinosaur(herbivores.ID, model.Triceratops)
+	for _, d := range []*model.Dinosaur{rex, cera} {
+		if err := postgres.CreateDinosaur(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := power(empty.ID, model.PowerMaintenance); err != nil {
+		t.Fatal(err)
+	}
+	for _, cage := range []*model.Cage{carnivores, herbivores, empty} {
+		if err := join(cage.ID, circuit.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.True(t, errors.Is(join(carnivores.ID, "ct_foo"), errors.KindBadRequest))
+
+	got, err := postgres.GetCircuit(ctx, circuit.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, got.Cages)
+	}
+
+	cages, total, err := postgres.ListCages(ctx, storage.ListCageParams{CircuitID: circuit.ID})
+	if assert.NoError(t, err) && assert.Len(t, cages, 3) {
+		assert.Equal(t, 3, total)
+	}
+
+	// A dry run changes nothing.
+	impact, err := postgres.GetCircuitImpact(ctx, circuit.ID)
+	if assert.NoError(t, err) {
+		assert.Len(t, impact.Cages, 3)
+		assert.Len(t, impact.Dinosaurs, 2)
+		assert.Equal(t, 2, impact.Alarms)
+	}
+
+	_, err = postgres.GetCircuitImpact(ctx, "ct_foo")
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	outage, err := postgres.CutCircuit(storage.WithReason(ctx, "storm"), circuit.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "storm", outage.Reason)
+	assert.Equal(t, "arnold", outage.Actor)
+	assert.Nil(t, outage.RestoredAt)
+	if len(outage.Cages) != 3 {
+		t.Fatalf("outage cages = %d, want 3", len(outage.Cages))
+	}
+
+	statuses := make(map[model.ID]model.PowerStatus)
+	incidents := make(map[model.ID]int64)
+	for _, affected := range outage.Cages {
+		statuses[affected.CageID] = affected.Status
+		incidents[affected.CageID] = affected.IncidentID
+	}
+
+	assert.Equal(t, model.PowerActive, statuses[carnivores.ID])
+	assert.Equal(t, model.PowerMaintenance, statuses[empty.ID])
+	assert.Zero(t, incidents[empty.ID])
+
+	for _, cage := range []*model.Cage{carnivores, herbivores, empty} {
+		c, err := postgres.GetCage(ctx, cage.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, model.PowerDown, c.Status)
+		}
+	}
+
+	alarm, err := postgres.GetIncident(ctx, incidents[carnivores.ID])
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.IncidentPowerFailure, alarm.Kind)
+		assert.Equal(t, model.SeverityCritical, alarm.Severity)
+		assert.Equal(t, []model.ID{rex.ID}, alarm.DinosaurIDs)
+	}
+
+	alarm, err = postgres.GetIncident(ctx, incidents[herbivores.ID])
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.SeverityHigh, alarm.Severity)
+	}
+
+	events, _, err := postgres.ListPowerEvents(ctx, carnivores.ID, nil)
+	if assert.NoError(t, err) && assert.NotEmpty(t, events) {
+		assert.Equal(t, model.PowerDown, events[0].To)
+		assert.Equal(t, "storm", events[0].Reason)
+	}
+
+	// Fences stay DOWN until the circuit is restored.
+	_, err = postgres.CutCircuit(ctx, circuit.ID)
+	assert.True(t, errors.IsUnprocessableErr(err))
+	assert.True(t, errors.IsUnprocessableErr(power(empty.ID, model.PowerMaintenance)))
+	assert.True(t, errors.IsUnprocessableErr(join(empty.ID, "")))
+	assert.True(t, errors.IsUnprocessableErr(join(newTestCage(t).ID, circuit.ID)))
+	assert.True(t, errors.IsUnprocessableErr(postgres.CreateCage(ctx, &model.Cage{ID: model.NewCageID(uuid.MustNextID()), Capacity: 2, CircuitID: circuit.ID})))
+	assert.True(t, errors.IsUnprocessableErr(postgres.DeleteCircuit(ctx, circuit.ID)))
+
+	restored, err := postgres.RestoreCircuit(ctx, circuit.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, outage.ID, restored.ID)
+	assert.NotNil(t, restored.RestoredAt)
+
+	for cageID, status := range statuses {
+		c, err := postgres.GetCage(ctx, cageID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, status, c.Status)
+		}
+	}
+
+	alarm, err = postgres.GetIncident(ctx, incidents[carnivores.ID])
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.IncidentOpen, alarm.Status)
+		if assert.Len(t, alarm.Notes, 2) {
+			assert.Equal(t, "circuit "+string(circuit.ID)+" was restored", alarm.Notes[1].Text)
+		}
+	}
+
+	_, err = postgres.RestoreCircuit(ctx, circuit.ID)
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	outages, total, err := postgres.ListCircuitOutages(ctx, circuit.ID, nil)
+	if assert.NoError(t, err) && assert.Len(t, outages, 1) {
+		assert.Equal(t, 1, total)
+		assert.Len(t, outages[0].Cages, 3)
+		assert.NotNil(t, outages[0].RestoredAt)
+	}
+
+	_, _, err = postgres.ListCircuitOutages(ctx, "ct_foo", nil)
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	assert.True(t, errors.IsUnprocessableErr(postgres.DeleteCircuit(ctx, circuit.ID)))
+	for _, cage := range []*model.Cage{carnivores, herbivores, empty} {
+		if err := join(cage.ID, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.NoError(t, postgres.DeleteCircuit(ctx, circuit.ID))
+	assert.True(t, errors.IsNotFoundErr(postgres.DeleteCircuit(ctx, circuit.ID)))
+}
@@ -0,0 +1,233 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (p *Postgres) CreateDinosaur(ctx context.Context, dinosaur *model.Dinosaur) error {
+	const op errors.Op = "postgres.CreateDinosaur"
+
+	createFn := func(tx *pg.Tx) error {
+		if err := place(ctx, tx, dinosaur, op); err != nil {
+			return err
+		}
+
+		now := p.now().UTC()
+		dinosaur.CreatedAt = &now
+		dinosaur.UpdatedAt = &now
+		dinosaur.Version = 1
+
+		if _, err := tx.ModelContext(ctx, dinosaur).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityDinosaur, dinosaur.ID, nil, storage.Snapshot(dinosaur))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		return p.publish(ctx, tx, storage.NewEvent(model.EventDinosaurPlaced, dinosaur.ID, dinosaur), op)
+	}
+
+	return p.ExecTx(ctx, createFn)
+}
+
+func (p *Postgres) UpdateDinosaur(ctx context.Context, id model.ID, updater storage.DinosaurUpdater) error {
+	const op errors.Op = "postgres.UpdateDinosaur"
+
+	updateFn := func(tx *pg.Tx) error {
+		old, err := lockDinosaur(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if old.Archived() {
+			return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is archived", id))
+		}
+
+		before := storage.Snapshot(old)
+		cageID, species, version := old.CageID, old.Species, old.Version
+		dinosaur, err := updater(old)
+		if err != nil {
+			return err
+		}
+
+		if err := storage.CheckVersion(id, version, dinosaur.Version); err != nil {
+			return errors.E(op, err)
+		}
+
+		if dinosaur.CageID != cageID {
+			return errors.E(op, errors.KindBadRequest, "dinosaurs change cages through transfers")
+		}
+
+		if dinosaur.Species != species {
+			if err := place(ctx, tx, dinosaur, op); err != nil {
+				return err
+			}
+		}
+
+		now := p.now().UTC()
+		dinosaur.UpdatedAt = &now
+		dinosaur.Version++
+
+		if _, err := tx.ModelContext(ctx, dinosaur).
+			WherePK().
+			Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityDinosaur, id, before, storage.Snapshot(dinosaur))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, updateFn)
+}
+
+func (p *Postgres) GetDinosaur(ctx context.Context, id model.ID) (*model.Dinosaur, error) {
+	const op errors.Op = "postgres.GetDinosaur"
+	return getDinosaur(ctx, p.db, id, op)
+}
+
+func getDinosaur(ctx context.Context, db orm.DB, id model.ID, op errors.Op) (*model.Dinosaur, error) {
+	var dinosaur model.Dinosaur
+	err := db.ModelContext(ctx, &dinosaur).
+		Where("id = ?", string(id)).
+		Select()
+	if err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return &dinosaur, nil
+}
+
+// lockDinosaur selects a dinosaur and locks it for the rest of the
+// transaction. Dinosaurs are always locked before their cages.
+func lockDinosaur(ctx context.Context, tx *pg.Tx, id model.ID, op errors.Op) (*model.Dinosaur, error) {
+	var dinosaur model.Dinosaur
+	err := tx.ModelContext(ctx, &dinosaur).
+		Where("id = ?", string(id)).
+		For("UPDATE").
+		Select()
+	if err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return &dinosaur, nil
+}
+
+// place locks the destination cage of dinosaur for the rest of the
+// transaction and verifies that the dinosaur may join its occupants. The
+// catalog entry of its species is locked in share mode so that the species
+// cannot be deleted or change kind meanwhile.
+func place(ctx context.Context, tx *pg.Tx, dinosaur *model.Dinosaur, op errors.Op) error {
+	n, err := tx.ModelContext(ctx, (*model.SpeciesEntry)(nil)).
+		Where("name = ?", string(dinosaur.Species)).
+		For("SHARE").
+		Count()
+	if err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	if n == 0 {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("unknown species: %q", dinosaur.Species))
+	}
+
+	cage, err := lockCage(ctx, tx, dinosaur.CageID, op)
+	if errors.IsNotFoundErr(err) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("cage %s does not exist", dinosaur.CageID))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := park.CheckPlacement(cage, cage.Dinosaurs, dinosaur); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) DeleteDinosaur(ctx context.Context, id model.ID) error {
+	const op errors.Op = "postgres.DeleteDinosaur"
+
+	deleteFn := func(tx *pg.Tx) error {
+		dinosaur, err := lockDinosaur(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ModelContext(ctx, dinosaur).WherePK().Delete(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityDinosaur, id, storage.Snapshot(dinosaur), nil)
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		return p.publish(ctx, tx, storage.NewEvent(model.EventDinosaurRemoved, id, dinosaur), op)
+	}
+
+	return p.ExecTx(ctx, deleteFn)
+}
+
+func (p *Postgres) ListDinosaurs(ctx context.Context, params storage.ListDinosaurParams) ([]*model.Dinosaur, int, error) {
+	const op errors.Op = "postgres.ListDinosaurs"
+
+	var dinosaurs []*model.Dinosaur
+	q := p.db.WithContext(ctx).Model(&dinosaurs)
+
+	if !params.IncludeArchived {
+		q = q.Where("dinosaur.deleted_at IS NULL")
+	}
+
+	if params.Species != "" {
+		q = q.Where("dinosaur.species = ?", params.Species)
+	}
+
+	if params.CageID != "" {
+		q = q.Where("dinosaur.cage_id = ?", params.CageID)
+	}
+
+	if params.Name != "" {
+		q = q.Where("dinosaur.name ILIKE ?", "%"+likeEscaper.Replace(params.Name)+"%")
+	}
+
+	q = q.Order("dinosaur.created_at", "dinosaur.id")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return dinosaurs, total, nil
+}
@@ -0,0 +1,288 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCage(t *testing.T) *model.Cage {
+	t.Helper()
+
+	cage := &model.Cage{
+		ID:       model.NewCageID(uuid.MustNextID()),
+		Capacity: model.MaxCageCapacity,
+		Status:   model.PowerActive,
+	}
+
+	if err := postgres.CreateCage(context.Background(), cage); err != nil {
+		t.Fatal(err)
+	}
+
+	return cage
+}
+
+func newTestDinosaur(cageID model.ID, species model.Species) *model.Dinosaur {
+	uid := uuid.MustNextID()
+	return &model.Dinosaur{
+		ID:      model.NewDinosaurID(uid),
+		Name:    gofakeit.FirstName() + " " + uid,
+		Species: species,
+		CageID:  cageID,
+	}
+}
+
+func TestPostgres_CreateDinosaur(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	cage := newTestCage(t)
+	d1 := newTestDinosaur(cage.ID, model.Velociraptor)
+
+	ctx := context.Background()
+	if err := postgres.CreateDinosaur(ctx, d1); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotNil(t, d1.CreatedAt)
+	assert.NotNil(t, d1.UpdatedAt)
+
+	d2, err := postgres.GetDinosaur(ctx, d1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j1, err := json.Marshal(d1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j2, err := json.Marshal(d2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, string(j1), string(j2))
+}
+
+func TestPostgres_CreateDinosaurDuplicateName(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	cage := newTestCage(t)
+	d1 := newTestDinosaur(cage.ID, model.Triceratops)
+
+	ctx := context.Background()
+	if err := postgres.CreateDinosaur(ctx, d1); err != nil {
+		t.Fatal(err)
+	}
+
+	d2 := newTestDinosaur(cage.ID, model.Triceratops)
+	d2.Name = d1.Name
+
+	err := postgres.CreateDinosaur(ctx, d2)
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+}
+
+func TestPostgres_CreateDinosaurInvalidCage(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	d := newTestDinosaur("foo", model.Triceratops)
+	err := postgres.CreateDinosaur(context.Background(), d)
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+}
+
+func TestPostgres_UpdateDinosaur(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	cage := newTestCage(t)
+	d0 := newTestDinosaur(cage.ID, model.Stegosaurus)
+
+	ctx := context.Background()
+	if err := postgres.CreateDinosaur(ctx, d0); err != nil {
+		t.Fatal(err)
+	}
+
+	name := gofakeit.FirstName() + " " + uuid.MustNextID()
+	updater := func(old *model.Dinosaur) (*model.Dinosaur, error) {
+		old.Name = name
+		return old, nil
+	}
+
+	if err := postgres.UpdateDinosaur(ctx, d0.ID, updater); err != nil {
+		t.Fatal(err)
+	}
+
+	d1, err := postgres.GetDinosaur(ctx, d0.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, name, d1.Name)
+	assert.Equal(t, d0.CreatedAt.UTC(), d1.CreatedAt.UTC())
+	assert.GreaterOrEqual(t, d1.UpdatedAt.UTC(), d0.UpdatedAt.UTC())
+
+	err = postgres.UpdateDinosaur(ctx, "foo", updater)
+	assert.True(t, errors.Is(err, errors.KindNotFound))
+}
+
+func TestPostgres_DeleteDinosaur(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	cage := newTestCage(t)
+	d := newTestDinosaur(cage.ID, model.Ankylosaurus)
+
+	ctx := context.Background()
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := postgres.DeleteDinosaur(ctx, d.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := postgres.GetDinosaur(ctx, d.ID)
+	assert.True(t, errors.Is(err, errors.KindNotFound))
+
+	err = postgres.DeleteDinosaur(ctx, d.ID)
+	assert.True(t, errors.Is(err, errors.KindNotFound))
+}
+
+func TestPostgres_ListDinosaurs(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	cage := newTestCage(t)
+	d1 := newTestDinosaur(cage.ID, model.Brachiosaurus)
+	d2 := newTestDinosaur(cage.ID, model.Triceratops)
+
+	ctx := context.Background()
+	for _, d := range []*model.Dinosaur{d1, d2} {
+		if err := postgres.CreateDinosaur(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dinosaurs, total, err := postgres.ListDinosaurs(ctx, storage.ListDinosaurParams{CageID: cage.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, total)
+	assert.Equal(t, d1.ID, dinosaurs[0].ID)
+	assert.Equal(t, d2.ID, dinosaurs[1].ID)
+
+	dinosaurs, total, err = postgres.ListDinosaurs(ctx, storage.ListDinosaurParams{
+		CageID:  cage.ID,
+		Species: model.Triceratops,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, total)
+	assert.Equal(t, d2.ID, dinosaurs[0].ID)
+
+	dinosaurs, total, err = postgres.ListDinosaurs(ctx, storage.ListDinosaurParams{Name: strings.ToUpper(d1.Name)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, total)
+	assert.Equal(t, d1.ID, dinosaurs[0].ID)
+
+	dinosaurs, total, err = postgres.ListDinosaurs(ctx, storage.ListDinosaurParams{
+		CageID:     cage.ID,
+		Pagination: storage.NewPagination(1, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, total)
+	assert.Len(t, dinosaurs, 1)
+	assert.Equal(t, d2.ID, dinosaurs[0].ID)
+}
+
+func TestPostgres_CreateDinosaurIncompatible(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	herbivores := newTestCage(t)
+	for _, species := range []model.Species{model.Triceratops, model.Stegosaurus} {
+		if err := postgres.CreateDinosaur(ctx, newTestDinosaur(herbivores.ID, species)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := postgres.CreateDinosaur(ctx, newTestDinosaur(herbivores.ID, model.Velociraptor))
+	assert.True(t, errors.Is(err, errors.KindUnprocessable))
+
+	carnivores := newTestCage(t)
+	if err := postgres.CreateDinosaur(ctx, newTestDinosaur(carnivores.ID, model.Velociraptor)); err != nil {
+		t.Fatal(err)
+	}
+
+	err = postgres.CreateDinosaur(ctx, newTestDinosaur(carnivores.ID, model.Tyrannosaurus))
+	assert.True(t, errors.Is(err, errors.KindUnprocessable))
+
+	d := newTestDinosaur(carnivores.ID, model.Velociraptor)
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = postgres.TransferDinosaur(ctx, d.ID, herbivores.ID, "")
+	assert.True(t, errors.Is(err, errors.KindUnprocessable))
+
+	err = postgres.UpdateDinosaur(ctx, d.ID, func(old *model.Dinosaur) (*model.Dinosaur, error) {
+		old.CageID = herbivores.ID
+		return old, nil
+	})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+)
+
+// outboxLock is the key of the advisory lock held by transactions writing to
+// the outbox. It makes events commit in order of ID, so that readers
+// following the IDs never skip an event committed late.
+const outboxLock = 7_270_002
+
+// publish writes an event to the outbox in the transaction of the change it
+// announces.
+func (p *Postgres) publish(ctx context.Context, tx *pg.Tx, event *model.Event, op errors.Op) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?)", outboxLock); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	now := p.now().UTC()
+	event.CreatedAt = &now
+
+	if _, err := tx.ModelContext(ctx, event).Insert(); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) ListEvents(ctx context.Context, params storage.ListEventParams) ([]*model.Event, error) {
+	const op errors.Op = "postgres.ListEvents"
+
+	var events []*model.Event
+	q := p.db.WithContext(ctx).
+		Model(&events).
+		Where("id > ?", params.AfterID)
+
+	if len(params.Types) > 0 {
+		q = q.Where("type IN (?)", pg.In(params.Types))
+	}
+
+	q = q.Order("id")
+
+	if params.Limit > 0 {
+		q = q.Limit(params.Limit)
+	}
+
+	if err := q.Select(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return events, nil
+}
+
+func (p *Postgres) LastEventID(ctx context.Context) (int64, error) {
+	const op errors.Op = "postgres.LastEventID"
+
+	var id int64
+	if _, err := p.db.QueryOneContext(ctx, pg.Scan(&id), "SELECT coalesce(max(id), 0) FROM outbox"); err != nil {
+		return 0, errors.E(op, kind(err), err)
+	}
+
+	return id, nil
+}
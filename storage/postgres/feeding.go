@@ -0,0 +1,308 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+type lastFeeding struct {
+	DinosaurID model.ID
+	FedAt      time.Time
+}
+
+func (p *Postgres) GetFeedingSchedule(ctx context.Context, cageID model.ID) (*model.FeedingSchedule, error) {
+	const op errors.Op = "postgres.GetFeedingSchedule"
+
+	cage, err := p.getCage(ctx, cageID, op)
+	if err != nil {
+		return nil, err
+	}
+
+	return feedingSchedule(ctx, p.db, cage, op)
+}
+
+// feedingSchedule returns the stored or derived feeding schedule of a cage
+// with its dinosaurs.
+func feedingSchedule(ctx context.Context, db orm.DB, cage *model.Cage, op errors.Op) (*model.FeedingSchedule, error) {
+	schedule, err := storedFeedingSchedule(ctx, db, cage.ID, op)
+	if err != nil || schedule != nil {
+		return schedule, err
+	}
+
+	schedule = park.DefaultFeedingSchedule(cage, cage.Dinosaurs)
+	if schedule == nil {
+		return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("cage %s has no feeding schedule", cage.ID))
+	}
+
+	return schedule, nil
+}
+
+// storedFeedingSchedule returns the schedule set for a cage, or nil if none
+// was.
+func storedFeedingSchedule(ctx context.Context, db orm.DB, cageID model.ID, op errors.Op) (*model.FeedingSchedule, error) {
+	var schedule model.FeedingSchedule
+	err := db.ModelContext(ctx, &schedule).
+		Where("cage_id = ?", string(cageID)).
+		Select()
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return &schedule, nil
+}
+
+func (p *Postgres) SetFeedingSchedule(ctx context.Context, schedule *model.FeedingSchedule) error {
+	const op errors.Op = "postgres.SetFeedingSchedule"
+
+	if err := park.CheckFeedingSchedule(schedule); err != nil {
+		return errors.E(op, err)
+	}
+
+	setFn := func(tx *pg.Tx) error {
+		cage, err := lockCage(ctx, tx, schedule.CageID, op)
+		if err != nil {
+			return err
+		}
+
+		if cage.Archived() {
+			return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("cage %s is archived", cage.ID))
+		}
+
+		before, err := storedFeedingSchedule(ctx, tx, cage.ID, op)
+		if err != nil {
+			return err
+		}
+
+		now := p.now().UTC()
+		schedule.Default = false
+		schedule.CreatedAt = &now
+		schedule.UpdatedAt = &now
+		if before != nil {
+			schedule.CreatedAt = before.CreatedAt
+		}
+
+		if _, err := tx.ModelContext(ctx, schedule).
+			OnConflict("(cage_id) DO UPDATE").
+			Set("food = EXCLUDED.food").
+			Set("interval_hours = EXCLUDED.interval_hours").
+			Set("ration_kg = EXCLUDED.ration_kg").
+			Set("updated_at = EXCLUDED.updated_at").
+			Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityFeedingSchedule, cage.ID, storage.Snapshot(before), storage.Snapshot(schedule))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, setFn)
+}
+
+func (p *Postgres) RecordFeeding(ctx context.Context, feeding *model.Feeding, dinosaurIDs []model.ID) ([]*model.Feeding, error) {
+	const op errors.Op = "postgres.RecordFeeding"
+
+	now := p.now().UTC()
+	if err := park.CheckFeeding(feeding, now); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	var feedings []*model.Feeding
+	recordFn := func(tx *pg.Tx) error {
+		// The cage is locked so that its occupants cannot move meanwhile.
+		cage, err := lockCage(ctx, tx, feeding.CageID, op)
+		if err != nil {
+			return err
+		}
+
+		fed, err := park.FeedingTargets(cage, cage.Dinosaurs, dinosaurIDs)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		schedule, err := feedingSchedule(ctx, tx, cage, op)
+		if err != nil {
+			return err
+		}
+
+		park.FillFeeding(feeding, schedule, now)
+
+		feedings = make([]*model.Feeding, 0, len(fed))
+		for _, d := range fed {
+			f := *feeding
+			f.DinosaurID = d.ID
+			f.Actor = storage.Actor(ctx)
+			f.CreatedAt = &now
+			feedings = append(feedings, &f)
+		}
+
+		if _, err := tx.ModelContext(ctx, &feedings).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		for _, f := range feedings {
+			entry := storage.NewAuditEntry(ctx, op, model.EntityFeeding, storage.SerialID(f.ID), nil, storage.Snapshot(f))
+			if err := p.audit(ctx, tx, entry, op); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := p.ExecTx(ctx, recordFn); err != nil {
+		return nil, err
+	}
+
+	return feedings, nil
+}
+
+func (p *Postgres) ListFeedings(ctx context.Context, params storage.ListFeedingParams) ([]*model.Feeding, int, error) {
+	const op errors.Op = "postgres.ListFeedings"
+
+	var feedings []*model.Feeding
+	q := p.db.WithContext(ctx).Model(&feedings)
+
+	if params.CageID != "" {
+		q = q.Where("feeding.cage_id = ?", params.CageID)
+	}
+
+	if params.DinosaurID != "" {
+		q = q.Where("feeding.dinosaur_id = ?", params.DinosaurID)
+	}
+
+	q = q.Order("feeding.fed_at DESC", "feeding.id DESC")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return feedings, total, nil
+}
+
+func (p *Postgres) ListOverdueFeedings(ctx context.Context, cageID model.ID) ([]*model.OverdueFeeding, error) {
+	const op errors.Op = "postgres.ListOverdueFeedings"
+
+	var cages []*model.Cage
+	q := p.db.WithContext(ctx).
+		Model(&cages).
+		Where("cage.deleted_at IS NULL").
+		Where("cage.id IN (SELECT cage_id FROM dinosaurs WHERE deleted_at IS NULL)").
+		Order("cage.id")
+
+	if cageID != "" {
+		if _, err := p.getCage(ctx, cageID, op); err != nil {
+			return nil, err
+		}
+
+		q = q.Where("cage.id = ?", cageID)
+	}
+
+	if err := q.Select(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	if len(cages) == 0 {
+		return []*model.OverdueFeeding{}, nil
+	}
+
+	if err := fillOccupancy(ctx, p.db, cages, true); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	ids := make([]model.ID, 0, len(cages))
+	var dinosaurs []*model.Dinosaur
+	for _, cage := range cages {
+		ids = append(ids, cage.ID)
+		dinosaurs = append(dinosaurs, cage.Dinosaurs...)
+	}
+
+	if err := fillLastFed(ctx, p.db, dinosaurs); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	var stored []*model.FeedingSchedule
+	if err := p.db.ModelContext(ctx, &stored).Where("cage_id IN (?)", pg.In(ids)).Select(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	schedules := make(map[model.ID]*model.FeedingSchedule, len(stored))
+	for _, schedule := range stored {
+		schedules[schedule.CageID] = schedule
+	}
+
+	now := p.now().UTC()
+	overdue := []*model.OverdueFeeding{}
+	for _, cage := range cages {
+		schedule, ok := schedules[cage.ID]
+		if !ok {
+			schedule = park.DefaultFeedingSchedule(cage, cage.Dinosaurs)
+		}
+
+		if o := park.CheckOverdue(cage, cage.Dinosaurs, schedule, now); o != nil {
+			overdue = append(overdue, o)
+		}
+	}
+
+	return overdue, nil
+}
+
+// fillLastFed sets the last feeding time of dinosaurs with a single aggregate
+// query.
+func fillLastFed(ctx context.Context, db orm.DB, dinosaurs []*model.Dinosaur) error {
+	if len(dinosaurs) == 0 {
+		return nil
+	}
+
+	byID := make(map[model.ID]*model.Dinosaur, len(dinosaurs))
+	ids := make([]model.ID, 0, len(dinosaurs))
+	for _, d := range dinosaurs {
+		byID[d.ID] = d
+		ids = append(ids, d.ID)
+	}
+
+	var rows []lastFeeding
+	if _, err := db.QueryContext(ctx, &rows, `
+		SELECT dinosaur_id, max(fed_at) AS fed_at
+		FROM feedings
+		WHERE dinosaur_id IN (?)
+		GROUP BY dinosaur_id`, pg.In(ids)); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		fedAt := row.FedAt.UTC()
+		byID[row.DinosaurID].LastFedAt = &fedAt
+	}
+
+	return nil
+}
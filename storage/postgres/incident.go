@@ -0,0 +1,379 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func (p *Postgres) CreateIncident(ctx context.Context, incident *model.Incident) error {
+	const op errors.Op = "postgres.CreateIncident"
+
+	if err := park.CheckIncident(incident); err != nil {
+		return errors.E(op, err)
+	}
+
+	createFn := func(tx *pg.Tx) error {
+		// The cages are locked so that a transfer cannot slip past the hold
+		// of a critical incident.
+		if len(incident.CageIDs) > 0 {
+			cages, err := lockCages(ctx, tx, incident.CageIDs, op)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range incident.CageIDs {
+				if _, ok := cages[id]; !ok {
+					return errors.E(op, errors.KindNotFound, fmt.Sprintf("cage %s does not exist", id))
+				}
+			}
+		}
+
+		for _, id := range incident.DinosaurIDs {
+			if _, err := getDinosaur(ctx, tx, id, op); err != nil {
+				return err
+			}
+		}
+
+		return p.openIncident(ctx, tx, incident, op)
+	}
+
+	return p.ExecTx(ctx, createFn)
+}
+
+// openIncident inserts a new incident on behalf of the actor in ctx, with the
+// reason in ctx as the first note of its timeline. Its cages must be locked
+// and the incident checked.
+func (p *Postgres) openIncident(ctx context.Context, tx *pg.Tx, incident *model.Incident, op errors.Op) error {
+	now := p.now().UTC()
+	incident.Status = model.IncidentOpen
+	incident.Actor = storage.Actor(ctx)
+	incident.Notes = nil
+	incident.AcknowledgedAt = nil
+	incident.MitigatedAt = nil
+	incident.ClosedAt = nil
+	incident.CreatedAt = &now
+	incident.UpdatedAt = &now
+
+	if _, err := tx.ModelContext(ctx, incident).Insert(); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	if err := insertIncidentLinks(ctx, tx, incident); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	if err := p.addIncidentNote(ctx, tx, &model.IncidentNote{
+		IncidentID: incident.ID,
+		Status:     model.IncidentOpen,
+		Text:       storage.Reason(ctx),
+	}, op); err != nil {
+		return err
+	}
+
+	id := storage.SerialID(incident.ID)
+	entry := storage.NewAuditEntry(ctx, op, model.EntityIncident, id, nil, storage.Snapshot(incident))
+	if err := p.audit(ctx, tx, entry, op); err != nil {
+		return err
+	}
+
+	return p.publish(ctx, tx, storage.NewEvent(model.EventIncidentOpened, id, incident), op)
+}
+
+// insertIncidentLinks links an incident to its cages and dinosaurs.
+func insertIncidentLinks(ctx context.Context, tx *pg.Tx, incident *model.Incident) error {
+	if len(incident.CageIDs) > 0 {
+		links := make([]*model.IncidentCage, 0, len(incident.CageIDs))
+		for _, id := range incident.CageIDs {
+			links = append(links, &model.IncidentCage{IncidentID: incident.ID, CageID: id})
+		}
+
+		if _, err := tx.ModelContext(ctx, &links).Insert(); err != nil {
+			return err
+		}
+	}
+
+	if len(incident.DinosaurIDs) > 0 {
+		links := make([]*model.IncidentDinosaur, 0, len(incident.DinosaurIDs))
+		for _, id := range incident.DinosaurIDs {
+			links = append(links, &model.IncidentDinosaur{IncidentID: incident.ID, DinosaurID: id})
+		}
+
+		if _, err := tx.ModelContext(ctx, &links).Insert(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Postgres) GetIncident(ctx context.Context, id int64) (*model.Incident, error) {
+	const op errors.Op = "postgres.GetIncident"
+
+	incident, err := getIncident(ctx, p.db, id, false, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.db.ModelContext(ctx, &incident.Notes).
+		Where("incident_id = ?", id).
+		Order("id").
+		Select(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return incident, nil
+}
+
+// getIncident returns an incident with its links, locked for the rest of the
+// transaction if lock is set.
+func getIncident(ctx context.Context, db orm.DB, id int64, lock bool, op errors.Op) (*model.Incident, error) {
+	incident := &model.Incident{ID: id}
+	q := db.ModelContext(ctx, incident).WherePK()
+	if lock {
+		q = q.For("UPDATE")
+	}
+
+	if err := q.Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("incident %d does not exist", id))
+		}
+
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	if err := fillIncidentLinks(ctx, db, []*model.Incident{incident}); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return incident, nil
+}
+
+func (p *Postgres) ListIncidents(ctx context.Context, params storage.ListIncidentParams) ([]*model.Incident, int, error) {
+	const op errors.Op = "postgres.ListIncidents"
+
+	var incidents []*model.Incident
+	q := p.db.WithContext(ctx).Model(&incidents)
+
+	if params.Status != "" {
+		q = q.Where("incident.status = ?", params.Status)
+	}
+
+	if params.Severity != "" {
+		q = q.Where("incident.severity = ?", params.Severity)
+	}
+
+	if params.Kind != "" {
+		q = q.Where("incident.kind = ?", params.Kind)
+	}
+
+	if params.Open {
+		q = q.Where("incident.status <> ?", model.IncidentClosed)
+	}
+
+	if params.CageID != "" {
+		q = q.Where("EXISTS (SELECT 1 FROM incident_cages AS ic WHERE ic.incident_id = incident.id AND ic.cage_id = ?)", params.CageID)
+	}
+
+	if params.DinosaurID != "" {
+		q = q.Where("EXISTS (SELECT 1 FROM incident_dinosaurs AS idn WHERE idn.incident_id = incident.id AND idn.dinosaur_id = ?)", params.DinosaurID)
+	}
+
+	q = q.Order("incident.id DESC")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	if err := fillIncidentLinks(ctx, p.db, incidents); err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return incidents, total, nil
+}
+
+// fillIncidentLinks loads the cages and dinosaurs linked to incidents.
+func fillIncidentLinks(ctx context.Context, db orm.DB, incidents []*model.Incident) error {
+	if len(incidents) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Incident, len(incidents))
+	ids := make([]int64, 0, len(incidents))
+	for _, incident := range incidents {
+		incident.CageIDs, incident.DinosaurIDs = nil, nil
+		byID[incident.ID] = incident
+		ids = append(ids, incident.ID)
+	}
+
+	var cages []*model.IncidentCage
+	if err := db.ModelContext(ctx, &cages).Where("incident_id IN (?)", pg.In(ids)).Order("cage_id").Select(); err != nil {
+		return err
+	}
+
+	for _, link := range cages {
+		incident := byID[link.IncidentID]
+		incident.CageIDs = append(incident.CageIDs, link.CageID)
+	}
+
+	var dinosaurs []*model.IncidentDinosaur
+	if err := db.ModelContext(ctx, &dinosaurs).Where("incident_id IN (?)", pg.In(ids)).Order("dinosaur_id").Select(); err != nil {
+		return err
+	}
+
+	for _, link := range dinosaurs {
+		incident := byID[link.IncidentID]
+		incident.DinosaurIDs = append(incident.DinosaurIDs, link.DinosaurID)
+	}
+
+	return nil
+}
+
+func (p *Postgres) UpdateIncident(ctx context.Context, id int64, updater storage.IncidentUpdater) error {
+	const op errors.Op = "postgres.UpdateIncident"
+
+	updateFn := func(tx *pg.Tx) error {
+		stored, err := getIncident(ctx, tx, id, true, op)
+		if err != nil {
+			return err
+		}
+
+		before := storage.Snapshot(stored)
+		old := *stored
+		incident, err := updater(&old)
+		if err != nil {
+			return err
+		}
+
+		if !model.ValidSeverity(incident.Severity) {
+			return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid severity: %q", incident.Severity))
+		}
+
+		statusChanged := incident.Status != stored.Status
+		if statusChanged {
+			if err := park.CheckIncidentTransition(stored, incident.Status); err != nil {
+				return errors.E(op, err)
+			}
+		}
+
+		held := stored.Holds()
+		now := p.now().UTC()
+		stored.Severity = incident.Severity
+		stored.UpdatedAt = &now
+		if statusChanged {
+			stored.Status = incident.Status
+			park.StampIncident(stored, now)
+		}
+
+		// Cages coming on hold are locked like on creation.
+		if !held && stored.Holds() && len(stored.CageIDs) > 0 {
+			if _, err := lockCages(ctx, tx, stored.CageIDs, op); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ModelContext(ctx, stored).
+			Column("severity", "status", "acknowledged_at", "mitigated_at", "closed_at", "updated_at").
+			WherePK().
+			Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		if statusChanged {
+			if err := p.addIncidentNote(ctx, tx, &model.IncidentNote{
+				IncidentID: id,
+				Status:     stored.Status,
+				Text:       storage.Reason(ctx),
+			}, op); err != nil {
+				return err
+			}
+		}
+
+		entityID := storage.SerialID(id)
+		entry := storage.NewAuditEntry(ctx, op, model.EntityIncident, entityID, before, storage.Snapshot(stored))
+		if err := p.audit(ctx, tx, entry, op); err != nil {
+			return err
+		}
+
+		return p.publish(ctx, tx, storage.NewEvent(model.EventIncidentChanged, entityID, stored), op)
+	}
+
+	return p.ExecTx(ctx, updateFn)
+}
+
+func (p *Postgres) AddIncidentNote(ctx context.Context, note *model.IncidentNote) error {
+	const op errors.Op = "postgres.AddIncidentNote"
+
+	if err := park.CheckIncidentNote(note); err != nil {
+		return errors.E(op, err)
+	}
+
+	addFn := func(tx *pg.Tx) error {
+		if _, err := getIncident(ctx, tx, note.IncidentID, false, op); err != nil {
+			return err
+		}
+
+		note.Status = ""
+		return p.addIncidentNote(ctx, tx, note, op)
+	}
+
+	return p.ExecTx(ctx, addFn)
+}
+
+// addIncidentNote appends a note to the timeline of an incident.
+func (p *Postgres) addIncidentNote(ctx context.Context, tx *pg.Tx, note *model.IncidentNote, op errors.Op) error {
+	now := p.now().UTC()
+	note.Actor = storage.Actor(ctx)
+	note.CreatedAt = &now
+
+	if _, err := tx.ModelContext(ctx, note).Insert(); err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	return nil
+}
+
+// incidentHolds returns the incidents putting a transfer hold on any of
+// the given cages.
+func incidentHolds(ctx context.Context, db orm.DB, cageIDs []model.ID, op errors.Op) ([]*model.Incident, error) {
+	var incidents []*model.Incident
+	if err := db.ModelContext(ctx, &incidents).
+		Where("incident.severity = ?", model.SeverityCritical).
+		Where("incident.status <> ?", model.IncidentClosed).
+		Where("EXISTS (SELECT 1 FROM incident_cages AS ic WHERE ic.incident_id = incident.id AND ic.cage_id IN (?))", pg.In(cageIDs)).
+		Order("incident.id").
+		Select(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	if err := fillIncidentLinks(ctx, db, incidents); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return incidents, nil
+}
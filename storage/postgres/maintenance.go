@@ -0,0 +1,154 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+)
+
+func (p *Postgres) CreateMaintenanceWindow(ctx context.Context, window *model.MaintenanceWindow) error {
+	const op errors.Op = "postgres.CreateMaintenanceWindow"
+
+	createFn := func(tx *pg.Tx) error {
+		// The cage is locked so that it cannot be filled, nor given another
+		// window, meanwhile.
+		cage, err := lockCage(ctx, tx, window.CageID, op)
+		if err != nil {
+			return err
+		}
+
+		var windows []*model.MaintenanceWindow
+		if err := tx.ModelContext(ctx, &windows).
+			Where("cage_id = ?", string(cage.ID)).
+			Where("status IN (?)", pg.In([]string{model.MaintenanceScheduled, model.MaintenanceInProgress})).
+			Select(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		now := p.now().UTC()
+		if err := park.CheckMaintenanceWindow(window, cage, windows, now); err != nil {
+			return errors.E(op, err)
+		}
+
+		window.StartsAt = window.StartsAt.UTC()
+		window.EndsAt = window.EndsAt.UTC()
+		window.Actor = storage.Actor(ctx)
+		window.Status = model.MaintenanceScheduled
+		window.Error = ""
+		window.CreatedAt = &now
+		window.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, window).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityMaintenanceWindow, storage.SerialID(window.ID), nil, storage.Snapshot(window))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, createFn)
+}
+
+func (p *Postgres) ListMaintenanceWindows(ctx context.Context, params storage.ListMaintenanceParams) ([]*model.MaintenanceWindow, int, error) {
+	const op errors.Op = "postgres.ListMaintenanceWindows"
+
+	if params.Status != "" && !model.ValidMaintenanceStatus(params.Status) {
+		return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid status: %q", params.Status))
+	}
+
+	var windows []*model.MaintenanceWindow
+	q := p.db.WithContext(ctx).Model(&windows)
+
+	if params.CageID != "" {
+		q = q.Where("maintenance_window.cage_id = ?", params.CageID)
+	}
+
+	if params.Status != "" {
+		q = q.Where("maintenance_window.status = ?", params.Status)
+	}
+
+	if !params.StartsBefore.IsZero() {
+		q = q.Where("maintenance_window.starts_at < ?", params.StartsBefore)
+	}
+
+	if !params.EndsBefore.IsZero() {
+		q = q.Where("maintenance_window.ends_at < ?", params.EndsBefore)
+	}
+
+	q = q.Order("maintenance_window.starts_at", "maintenance_window.id")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return windows, total, nil
+}
+
+func (p *Postgres) UpdateMaintenanceWindow(ctx context.Context, id int64, updater storage.MaintenanceUpdater) error {
+	const op errors.Op = "postgres.UpdateMaintenanceWindow"
+
+	updateFn := func(tx *pg.Tx) error {
+		stored := &model.MaintenanceWindow{ID: id}
+		if err := tx.ModelContext(ctx, stored).WherePK().For("UPDATE").Select(); err != nil {
+			if err == pg.ErrNoRows {
+				return errors.E(op, errors.KindNotFound, fmt.Sprintf("maintenance window %d does not exist", id))
+			}
+
+			return errors.E(op, kind(err), err)
+		}
+
+		before := storage.Snapshot(stored)
+		old := *stored
+		window, err := updater(&old)
+		if err != nil {
+			return err
+		}
+
+		if window.Status != stored.Status {
+			if err := park.CheckMaintenanceTransition(stored, window.Status); err != nil {
+				return errors.E(op, err)
+			}
+		}
+
+		now := p.now().UTC()
+		stored.Status = window.Status
+		stored.Error = window.Error
+		stored.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, stored).
+			Column("status", "error", "updated_at").
+			WherePK().
+			Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityMaintenanceWindow, storage.SerialID(id), before, storage.Snapshot(stored))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, updateFn)
+}
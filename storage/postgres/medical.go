@@ -0,0 +1,249 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func (p *Postgres) CreateVetVisit(ctx context.Context, visit *model.VetVisit) error {
+	const op errors.Op = "postgres.CreateVetVisit"
+
+	now := p.now().UTC()
+	if err := park.CheckVetVisit(visit, now); err != nil {
+		return errors.E(op, err)
+	}
+
+	createFn := func(tx *pg.Tx) error {
+		if err := checkPatient(ctx, tx, visit.DinosaurID, op); err != nil {
+			return err
+		}
+
+		if visit.VisitedAt == nil {
+			visit.VisitedAt = &now
+		} else {
+			visitedAt := visit.VisitedAt.UTC()
+			visit.VisitedAt = &visitedAt
+		}
+
+		visit.Actor = storage.Actor(ctx)
+		visit.CreatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, visit).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		for _, diagnosis := range visit.Diagnoses {
+			diagnosis.VisitID = visit.ID
+			diagnosis.DinosaurID = visit.DinosaurID
+		}
+
+		for _, treatment := range visit.Treatments {
+			treatment.VisitID = visit.ID
+			treatment.DinosaurID = visit.DinosaurID
+		}
+
+		if len(visit.Diagnoses) > 0 {
+			if _, err := tx.ModelContext(ctx, &visit.Diagnoses).Insert(); err != nil {
+				return errors.E(op, kind(err), err)
+			}
+		}
+
+		if len(visit.Treatments) > 0 {
+			if _, err := tx.ModelContext(ctx, &visit.Treatments).Insert(); err != nil {
+				return errors.E(op, kind(err), err)
+			}
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityVetVisit, storage.SerialID(visit.ID), nil, storage.Snapshot(visit))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, createFn)
+}
+
+// checkPatient verifies that medical records can be added for a dinosaur.
+func checkPatient(ctx context.Context, db orm.DB, id model.ID, op errors.Op) error {
+	dinosaur, err := getDinosaur(ctx, db, id, op)
+	if err != nil {
+		return err
+	}
+
+	if dinosaur.Archived() {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf("dinosaur %s is archived", id))
+	}
+
+	return nil
+}
+
+func (p *Postgres) ListVetVisits(ctx context.Context, params storage.ListVetVisitParams) ([]*model.VetVisit, int, error) {
+	const op errors.Op = "postgres.ListVetVisits"
+
+	var visits []*model.VetVisit
+	q := p.db.WithContext(ctx).Model(&visits)
+
+	if params.DinosaurID != "" {
+		q = q.Where("vet_visit.dinosaur_id = ?", params.DinosaurID)
+	}
+
+	q = q.Order("vet_visit.visited_at DESC", "vet_visit.id DESC")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	if err := fillVetRecords(ctx, p.db, visits); err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return visits, total, nil
+}
+
+// fillVetRecords loads the diagnoses and treatments of visits.
+func fillVetRecords(ctx context.Context, db orm.DB, visits []*model.VetVisit) error {
+	if len(visits) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.VetVisit, len(visits))
+	ids := make([]int64, 0, len(visits))
+	for _, visit := range visits {
+		byID[visit.ID] = visit
+		ids = append(ids, visit.ID)
+	}
+
+	var diagnoses []*model.Diagnosis
+	if err := db.ModelContext(ctx, &diagnoses).Where("visit_id IN (?)", pg.In(ids)).Order("id").Select(); err != nil {
+		return err
+	}
+
+	for _, diagnosis := range diagnoses {
+		visit := byID[diagnosis.VisitID]
+		visit.Diagnoses = append(visit.Diagnoses, diagnosis)
+	}
+
+	var treatments []*model.Treatment
+	if err := db.ModelContext(ctx, &treatments).Where("visit_id IN (?)", pg.In(ids)).Order("id").Select(); err != nil {
+		return err
+	}
+
+	for _, treatment := range treatments {
+		visit := byID[treatment.VisitID]
+		visit.Treatments = append(visit.Treatments, treatment)
+	}
+
+	return nil
+}
+
+func (p *Postgres) RecordBiometrics(ctx context.Context, biometrics []*model.Biometric) error {
+	const op errors.Op = "postgres.RecordBiometrics"
+
+	if len(biometrics) == 0 {
+		return nil
+	}
+
+	now := p.now().UTC()
+	for _, biometric := range biometrics {
+		if err := park.CheckBiometric(biometric, now); err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	recordFn := func(tx *pg.Tx) error {
+		checked := make(map[model.ID]bool)
+		for _, biometric := range biometrics {
+			if !checked[biometric.DinosaurID] {
+				if err := checkPatient(ctx, tx, biometric.DinosaurID, op); err != nil {
+					return err
+				}
+
+				checked[biometric.DinosaurID] = true
+			}
+
+			if biometric.MeasuredAt == nil {
+				biometric.MeasuredAt = &now
+			} else {
+				measuredAt := biometric.MeasuredAt.UTC()
+				biometric.MeasuredAt = &measuredAt
+			}
+
+			biometric.CreatedAt = &now
+		}
+
+		if _, err := tx.ModelContext(ctx, &biometrics).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		for _, biometric := range biometrics {
+			entry := storage.NewAuditEntry(ctx, op, model.EntityBiometric, storage.SerialID(biometric.ID), nil, storage.Snapshot(biometric))
+			if err := p.audit(ctx, tx, entry, op); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return p.ExecTx(ctx, recordFn)
+}
+
+func (p *Postgres) ListBiometrics(ctx context.Context, params storage.ListBiometricParams) ([]*model.Biometric, int, error) {
+	const op errors.Op = "postgres.ListBiometrics"
+
+	var biometrics []*model.Biometric
+	q := p.db.WithContext(ctx).Model(&biometrics)
+
+	if params.DinosaurID != "" {
+		q = q.Where("biometric.dinosaur_id = ?", params.DinosaurID)
+	}
+
+	if params.Metric != "" {
+		q = q.Where("biometric.metric = ?", params.Metric)
+	}
+
+	if !params.Since.IsZero() {
+		q = q.Where("biometric.measured_at >= ?", params.Since)
+	}
+
+	if !params.Until.IsZero() {
+		q = q.Where("biometric.measured_at < ?", params.Until)
+	}
+
+	q = q.Order("biometric.measured_at", "biometric.id")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return biometrics, total, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/app"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_MedicalHold(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	c1, c2 := newTestCage(t), newTestCage(t)
+	d := newTestDinosaur(c1.ID, model.Stegosaurus)
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	err := postgres.UpdateDinosaur(ctx, d.ID, func(old *model.Dinosaur) (*model.Dinosaur, error) {
+		old.MedicalHold = true
+		old.MedicalHoldReason = "fever"
+		return old, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = postgres.TransferDinosaur(ctx, d.ID, c2.ID, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	_, err = postgres.TransferDinosaurs(ctx, []*model.PlannedTransfer{{DinosaurID: d.ID, FromCageID: c1.ID, ToCageID: c2.ID}}, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = postgres.UpdateDinosaur(ctx, d.ID, func(old *model.Dinosaur) (*model.Dinosaur, error) {
+		old.MedicalHold = false
+		return old, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = postgres.TransferDinosaur(ctx, d.ID, c2.ID, "")
+	assert.NoError(t, err)
+}
+
+func TestPostgres_VetVisits(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := storage.WithActor(context.Background(), "harding")
+	c := newTestCage(t)
+	d := newTestDinosaur(c.ID, model.Stegosaurus)
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	earlier := app.StartDate().UTC().Add(-time.Hour)
+	visits := []*model.VetVisit{
+		{DinosaurID: d.ID, Vet: "harding", Reason: "checkup", VisitedAt: &earlier},
+		{
+			DinosaurID: d.ID,
+			Vet:        "harding",
+			Reason:     "lethargy",
+			Diagnoses:  []*model.Diagnosis{{Condition: "west indian lilac poisoning"}},
+			Treatments: []*model.Treatment{{Name: "activated charcoal", Dosage: "5 kg"}},
+		},
+	}
+
+	for _, visit := range visits {
+		if err := postgres.CreateVetVisit(ctx, visit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.NotZero(t, visits[1].ID)
+	assert.Equal(t, visits[1].ID, visits[1].Diagnoses[0].VisitID)
+	assert.Equal(t, d.ID, visits[1].Treatments[0].DinosaurID)
+
+	got, total, err := postgres.ListVetVisits(ctx, storage.ListVetVisitParams{DinosaurID: d.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "lethargy", got[0].Reason)
+	assert.Equal(t, "harding", got[0].Actor)
+	assert.Equal(t, "west indian lilac poisoning", got[0].Diagnoses[0].Condition)
+	assert.Equal(t, "5 kg", got[0].Treatments[0].Dosage)
+	assert.Equal(t, "checkup", got[1].Reason)
+
+	err = postgres.CreateVetVisit(ctx, &model.VetVisit{DinosaurID: "foo", Vet: "harding"})
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	err = postgres.CreateVetVisit(ctx, &model.VetVisit{DinosaurID: d.ID})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+}
+
+func TestPostgres_Biometrics(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	c := newTestCage(t)
+	d := newTestDinosaur(c.ID, model.Stegosaurus)
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	start := app.StartDate().UTC().Add(-72 * time.Hour)
+	var samples []*model.Biometric
+	for i, weight := range []float64{3000, 3100, 3400} {
+		measuredAt := start.Add(time.Duration(i) * 24 * time.Hour)
+		samples = append(samples, &model.Biometric{DinosaurID: d.ID, Metric: model.MetricWeight, Value: weight, MeasuredAt: &measuredAt})
+	}
+
+	samples = append(samples, &model.Biometric{DinosaurID: d.ID, Metric: model.MetricTemperature, Value: 37.5})
+	if err := postgres.RecordBiometrics(ctx, samples); err != nil {
+		t.Fatal(err)
+	}
+
+	got, total, err := postgres.ListBiometrics(ctx, storage.ListBiometricParams{DinosaurID: d.ID, Metric: model.MetricWeight})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, total)
+	assert.Equal(t, 3000.0, got[0].Value)
+	assert.Equal(t, 3400.0, got[2].Value)
+
+	_, total, err = postgres.ListBiometrics(ctx, storage.ListBiometricParams{DinosaurID: d.ID, Since: start.Add(time.Hour), Until: start.Add(48 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, total)
+
+	// A bad sample rejects the whole batch.
+	err = postgres.RecordBiometrics(ctx, []*model.Biometric{
+		{DinosaurID: d.ID, Metric: model.MetricLength, Value: 9},
+		{DinosaurID: d.ID, Metric: model.MetricWeight, Value: -1},
+	})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	_, total, err = postgres.ListBiometrics(ctx, storage.ListBiometricParams{DinosaurID: d.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 4, total)
+
+	err = postgres.RecordBiometrics(ctx, []*model.Biometric{{DinosaurID: "foo", Metric: model.MetricLength, Value: 9}})
+	assert.True(t, errors.IsNotFoundErr(err))
+}
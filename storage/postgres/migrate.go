@@ -0,0 +1,266 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/migrations"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// migrationLock is the key of the advisory lock held while migrating, so
+// that concurrent instances apply migrations one at a time.
+const migrationLock = 7_270_001
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version    INTEGER                   NOT NULL PRIMARY KEY,
+    checksum   TEXT                      NOT NULL,
+    applied_at TIMESTAMPTZ DEFAULT now() NOT NULL
+)`
+
+// SchemaMigration records an applied schema version.
+type SchemaMigration struct {
+	tableName struct{} `pg:"schema_migrations,alias:schema_migration"`
+
+	Version   int `pg:",pk"`
+	Checksum  string
+	AppliedAt *time.Time
+}
+
+// MigrationStatus describes an embedded migration and whether the database
+// has applied it.
+type MigrationStatus struct {
+	Version   int
+	Applied   bool
+	AppliedAt *time.Time
+	// Modified is set when the embedded migration changed after it was
+	// applied.
+	Modified bool
+}
+
+// Migrate applies or rolls back migrations until the schema is at version
+// target, or at the latest version if target is negative. The whole run
+// happens in one transaction under an advisory lock.
+func (p *Postgres) Migrate(ctx context.Context, target int) error {
+	const op errors.Op = "postgres.Migrate"
+
+	all, err := migrations.All()
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	if target < 0 {
+		target = all[len(all)-1].Version
+	}
+
+	if target > all[len(all)-1].Version {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("unknown schema version: %d", target))
+	}
+
+	return p.ExecTx(ctx, func(tx *pg.Tx) error {
+		applied, err := lockSchemaMigrations(ctx, tx, op)
+		if err != nil {
+			return err
+		}
+
+		if err := verifyChecksums(all, applied, op); err != nil {
+			return err
+		}
+
+		for _, m := range all {
+			if _, ok := applied[m.Version]; ok || m.Version > target {
+				continue
+			}
+
+			p.logger.Infof("Applying migration %d", m.Version)
+			if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+				return errors.E(op, kind(err), fmt.Errorf("migration %d: %w", m.Version, err))
+			}
+
+			if _, err := tx.ModelContext(ctx, &SchemaMigration{
+				Version:  m.Version,
+				Checksum: m.Checksum,
+			}).Insert(); err != nil {
+				return errors.E(op, kind(err), err)
+			}
+		}
+
+		for i := len(all) - 1; i >= 0; i-- {
+			m := all[i]
+			if _, ok := applied[m.Version]; !ok || m.Version <= target {
+				continue
+			}
+
+			p.logger.Infof("Rolling back migration %d", m.Version)
+			if _, err := tx.ExecContext(ctx, m.Down); err != nil {
+				return errors.E(op, kind(err), fmt.Errorf("migration %d: %w", m.Version, err))
+			}
+
+			if _, err := tx.ModelContext(ctx, &SchemaMigration{Version: m.Version}).WherePK().Delete(); err != nil {
+				return errors.E(op, kind(err), err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// MigrateDown rolls back the most recently applied migration.
+func (p *Postgres) MigrateDown(ctx context.Context) error {
+	const op errors.Op = "postgres.MigrateDown"
+
+	version, err := p.SchemaVersion(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if version == 0 {
+		return errors.E(op, errors.KindBadRequest, "no migration to roll back")
+	}
+
+	return p.Migrate(ctx, version-1)
+}
+
+// SchemaVersion returns the highest applied schema version, or 0 on an empty
+// database.
+func (p *Postgres) SchemaVersion(ctx context.Context) (int, error) {
+	const op errors.Op = "postgres.SchemaVersion"
+
+	statuses, err := p.MigrationStatus(ctx)
+	if err != nil {
+		return 0, errors.E(op, err)
+	}
+
+	version := 0
+	for _, status := range statuses {
+		if status.Applied && status.Version > version {
+			version = status.Version
+		}
+	}
+
+	return version, nil
+}
+
+// MigrationStatus reports which embedded migrations the database applied.
+func (p *Postgres) MigrationStatus(ctx context.Context) ([]*MigrationStatus, error) {
+	const op errors.Op = "postgres.MigrationStatus"
+
+	all, err := migrations.All()
+	if err != nil {
+		return nil, errors.E(op, errors.KindUnexpected, err)
+	}
+
+	var exists bool
+	if _, err := p.db.QueryOneContext(ctx, pg.Scan(&exists), "SELECT to_regclass('schema_migrations') IS NOT NULL"); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	applied := make(map[int]*SchemaMigration)
+	if exists {
+		if applied, err = selectSchemaMigrations(ctx, p.db, op); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(all))
+	for _, m := range all {
+		status := &MigrationStatus{Version: m.Version}
+		if a, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = a.AppliedAt
+			status.Modified = a.Checksum != m.Checksum
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// CheckSchema fails unless the database is at the latest schema version.
+func (p *Postgres) CheckSchema(ctx context.Context) error {
+	const op errors.Op = "postgres.CheckSchema"
+
+	latest, err := migrations.Latest()
+	if err != nil {
+		return errors.E(op, errors.KindUnexpected, err)
+	}
+
+	version, err := p.SchemaVersion(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if version != latest {
+		return errors.E(op, errors.KindUnexpected, fmt.Sprintf(
+			"database schema is at version %d, want %d", version, latest))
+	}
+
+	return nil
+}
+
+// lockSchemaMigrations takes the migration lock and returns the applied
+// versions.
+func lockSchemaMigrations(ctx context.Context, tx *pg.Tx, op errors.Op) (map[int]*SchemaMigration, error) {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?)", migrationLock); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	if _, err := tx.ExecContext(ctx, createSchemaMigrations); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return selectSchemaMigrations(ctx, tx, op)
+}
+
+func selectSchemaMigrations(ctx context.Context, db orm.DB, op errors.Op) (map[int]*SchemaMigration, error) {
+	var applied []*SchemaMigration
+	if err := db.ModelContext(ctx, &applied).Select(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	byVersion := make(map[int]*SchemaMigration, len(applied))
+	for _, m := range applied {
+		byVersion[m.Version] = m
+	}
+
+	return byVersion, nil
+}
+
+// verifyChecksums refuses to migrate a database whose applied migrations
+// differ from the embedded ones.
+func verifyChecksums(all []*migrations.Migration, applied map[int]*SchemaMigration, op errors.Op) error {
+	for _, m := range all {
+		if a, ok := applied[m.Version]; ok && a.Checksum != m.Checksum {
+			return errors.E(op, errors.KindUnexpected, fmt.Sprintf(
+				"migration %d was modified after it was applied", m.Version))
+		}
+	}
+
+	for version := range applied {
+		if version > len(all) {
+			return errors.E(op, errors.KindUnexpected, fmt.Sprintf(
+				"database has unknown migration %d", version))
+		}
+	}
+
+	return nil
+}
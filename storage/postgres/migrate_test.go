@@ -0,0 +1,74 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/migrations"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_Migrate(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	latest, err := migrations.Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, postgres.CheckSchema(ctx))
+
+	if err := postgres.MigrateDown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := postgres.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, latest-1, version)
+	assert.Error(t, postgres.CheckSchema(ctx))
+
+	statuses, err := postgres.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, statuses, latest)
+	assert.False(t, statuses[latest-1].Applied)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[0].Modified)
+
+	// Migrating is idempotent.
+	for i := 0; i < 2; i++ {
+		if err := postgres.Migrate(ctx, -1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.NoError(t, postgres.CheckSchema(ctx))
+
+	err = postgres.Migrate(ctx, latest+1)
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+}
@@ -17,7 +17,6 @@ package postgres
 import (
 	"context"
 	"runtime"
-	"strings"
 	"time"
 
 	"github.com/danielnegri/jurassic-park-go/pkg/errors"
@@ -95,9 +94,21 @@ func (p *Postgres) ExecTx(ctx context.Context, fn func(tx *pg.Tx) error) error {
 	return p.db.RunInTransaction(ctx, fn)
 }
 
+// PostgreSQL error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	codeForeignKeyViolation = "23503"
+	codeUniqueViolation     = "23505"
+)
+
 func kind(err error) int {
-	if strings.Contains(err.Error(), "duplicate key value") {
-		return errors.KindAlreadyExists
+	var pgErr pg.Error
+	if errors.AsErr(err, &pgErr) {
+		switch pgErr.Field('C') {
+		case codeUniqueViolation:
+			return errors.KindAlreadyExists
+		case codeForeignKeyViolation:
+			return errors.KindBadRequest
+		}
 	}
 
 	if err == pg.ErrNoRows {
@@ -0,0 +1,173 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func (p *Postgres) ListSpecies(ctx context.Context) ([]*model.SpeciesEntry, error) {
+	const op errors.Op = "postgres.ListSpecies"
+
+	var entries []*model.SpeciesEntry
+	if err := p.db.ModelContext(ctx, &entries).Order("name").Select(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return entries, nil
+}
+
+func (p *Postgres) GetSpecies(ctx context.Context, name model.Species) (*model.SpeciesEntry, error) {
+	const op errors.Op = "postgres.GetSpecies"
+	return getSpecies(ctx, p.db, name, false, op)
+}
+
+func getSpecies(ctx context.Context, db orm.DB, name model.Species, lock bool, op errors.Op) (*model.SpeciesEntry, error) {
+	var entry model.SpeciesEntry
+	q := db.ModelContext(ctx, &entry).Where("name = ?", string(name))
+	if lock {
+		q = q.For("UPDATE")
+	}
+
+	if err := q.Select(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return &entry, nil
+}
+
+func (p *Postgres) CreateSpecies(ctx context.Context, entry *model.SpeciesEntry) error {
+	const op errors.Op = "postgres.CreateSpecies"
+
+	if err := park.CheckSpeciesEntry(entry); err != nil {
+		return errors.E(op, err)
+	}
+
+	createFn := func(tx *pg.Tx) error {
+		now := p.now().UTC()
+		entry.CreatedAt = &now
+		entry.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, entry).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		return p.recordSpecies(ctx, tx, entry.Name, nil, entry, op)
+	}
+
+	return p.ExecTx(ctx, createFn)
+}
+
+func (p *Postgres) UpdateSpecies(ctx context.Context, name model.Species, updater storage.SpeciesUpdater) error {
+	const op errors.Op = "postgres.UpdateSpecies"
+
+	updateFn := func(tx *pg.Tx) error {
+		old, err := getSpecies(ctx, tx, name, true, op)
+		if err != nil {
+			return err
+		}
+
+		before := *old
+		entry, err := updater(old)
+		if err != nil {
+			return err
+		}
+
+		entry.Name = name
+		if err := park.CheckSpeciesEntry(entry); err != nil {
+			return errors.E(op, err)
+		}
+
+		if entry.Kind != before.Kind {
+			if err := checkNoDinosaurs(ctx, tx, name, "change the kind of", op); err != nil {
+				return err
+			}
+		}
+
+		now := p.now().UTC()
+		entry.CreatedAt = before.CreatedAt
+		entry.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, entry).WherePK().Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		return p.recordSpecies(ctx, tx, name, &before, entry, op)
+	}
+
+	return p.ExecTx(ctx, updateFn)
+}
+
+func (p *Postgres) DeleteSpecies(ctx context.Context, name model.Species) error {
+	const op errors.Op = "postgres.DeleteSpecies"
+
+	deleteFn := func(tx *pg.Tx) error {
+		entry, err := getSpecies(ctx, tx, name, true, op)
+		if err != nil {
+			return err
+		}
+
+		if err := checkNoDinosaurs(ctx, tx, name, "delete", op); err != nil {
+			return err
+		}
+
+		if _, err := tx.ModelContext(ctx, entry).WherePK().Delete(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		return p.recordSpecies(ctx, tx, name, entry, nil, op)
+	}
+
+	return p.ExecTx(ctx, deleteFn)
+}
+
+// checkNoDinosaurs fails unless no live dinosaur belongs to a species. The
+// caller must hold the lock on its catalog entry, which placements share.
+func checkNoDinosaurs(ctx context.Context, tx *pg.Tx, name model.Species, action string, op errors.Op) error {
+	n, err := tx.ModelContext(ctx, (*model.Dinosaur)(nil)).
+		Where("species = ?", string(name)).
+		Where("deleted_at IS NULL").
+		Count()
+	if err != nil {
+		return errors.E(op, kind(err), err)
+	}
+
+	if n > 0 {
+		return errors.E(op, errors.KindUnprocessable, fmt.Sprintf(
+			"cannot %s %s while %d dinosaurs belong to it", action, name, n))
+	}
+
+	return nil
+}
+
+// recordSpecies audits a change of the catalog and publishes it, so that
+// every instance reloads its registry. Either before or after is nil when the
+// species is created or deleted.
+func (p *Postgres) recordSpecies(ctx context.Context, tx *pg.Tx, name model.Species, before, after *model.SpeciesEntry, op errors.Op) error {
+	entry, event := storage.NewSpeciesChange(ctx, op, name, before, after)
+	if err := p.audit(ctx, tx, entry, op); err != nil {
+		return err
+	}
+
+	return p.publish(ctx, tx, event, op)
+}
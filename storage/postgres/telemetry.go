@@ -0,0 +1,281 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+)
+
+func (p *Postgres) RecordTelemetry(ctx context.Context, readings []*model.Reading) ([]*model.FenceAlert, error) {
+	const op errors.Op = "postgres.RecordTelemetry"
+
+	now := p.now().UTC()
+	if err := park.CheckReadings(readings, now); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	var alerts []*model.FenceAlert
+	recordFn := func(tx *pg.Tx) error {
+		alerts = []*model.FenceAlert{}
+
+		seen := make(map[model.ID]bool)
+		var ids []model.ID
+		for _, reading := range readings {
+			if !seen[reading.CageID] {
+				seen[reading.CageID] = true
+				ids = append(ids, reading.CageID)
+			}
+		}
+
+		// Locking the cages keeps their occupants and alerts steady while
+		// the fences are checked.
+		cages, err := lockCages(ctx, tx, ids, op)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if _, ok := cages[id]; !ok {
+				return errors.E(op, errors.KindBadRequest, fmt.Sprintf("cage %s does not exist", id))
+			}
+		}
+
+		for _, reading := range readings {
+			if reading.RecordedAt == nil {
+				reading.RecordedAt = &now
+			} else {
+				recordedAt := reading.RecordedAt.UTC()
+				reading.RecordedAt = &recordedAt
+			}
+
+			reading.CreatedAt = &now
+		}
+
+		if _, err := tx.ModelContext(ctx, &readings).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		latest := park.LatestVoltages(readings)
+		ids = ids[:0]
+		for id := range latest {
+			ids = append(ids, id)
+		}
+
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			alert, err := p.checkFence(ctx, tx, cages[id], latest[id].Value, op)
+			if err != nil {
+				return err
+			}
+
+			if alert != nil {
+				alerts = append(alerts, alert)
+			}
+		}
+
+		return nil
+	}
+
+	if err := p.ExecTx(ctx, recordFn); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// checkFence raises or clears the fence alert of a locked cage, which must
+// carry its occupants, given its latest voltage. The alert of a cage whose
+// fence is no longer watched is cleared whatever the voltage. It returns the
+// alert raised or cleared, if any.
+func (p *Postgres) checkFence(ctx context.Context, tx *pg.Tx, cage *model.Cage, voltage float64, op errors.Op) (*model.FenceAlert, error) {
+	dietKind, threshold, watched := park.FenceThreshold(cage)
+
+	var open []*model.FenceAlert
+	err := tx.ModelContext(ctx, &open).
+		Where("cage_id = ?", cage.ID).
+		Where("cleared_at IS NULL").
+		Select()
+	if err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	now := p.now().UTC()
+	switch {
+	case !watched && len(open) > 0:
+		return p.clearFenceAlert(ctx, tx, open[0], park.FenceUnwatched(cage), op)
+	case !watched:
+		return nil, nil
+	case voltage < threshold && len(open) == 0:
+		alert := &model.FenceAlert{
+			CageID:    cage.ID,
+			Kind:      dietKind,
+			Threshold: threshold,
+			Voltage:   voltage,
+			RaisedAt:  &now,
+		}
+
+		incident := park.FenceAlarm(cage, alert)
+		if err := p.openIncident(ctx, tx, incident, op); err != nil {
+			return nil, err
+		}
+
+		alert.IncidentID = incident.ID
+		if _, err := tx.ModelContext(ctx, alert).Insert(); err != nil {
+			return nil, errors.E(op, kind(err), err)
+		}
+
+		return alert, nil
+	case voltage >= threshold && len(open) > 0:
+		return p.clearFenceAlert(ctx, tx, open[0], park.FenceRecovery(open[0], voltage), op)
+	default:
+		return nil, nil
+	}
+}
+
+// clearFenceAlert clears an open alert and notes why on its incident.
+func (p *Postgres) clearFenceAlert(ctx context.Context, tx *pg.Tx, alert *model.FenceAlert, note string, op errors.Op) (*model.FenceAlert, error) {
+	now := p.now().UTC()
+	alert.ClearedAt = &now
+	if _, err := tx.ModelContext(ctx, alert).Column("cleared_at").WherePK().Update(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	if err := p.addIncidentNote(ctx, tx, &model.IncidentNote{
+		IncidentID: alert.IncidentID,
+		Text:       note,
+	}, op); err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+func (p *Postgres) QueryTelemetry(ctx context.Context, query storage.TelemetryQuery) ([]*model.ReadingBucket, error) {
+	const op errors.Op = "postgres.QueryTelemetry"
+
+	if err := park.CheckTelemetryQuery(query.Sensor, query.From, query.To, query.Bucket); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if _, err := p.getCage(ctx, query.CageID, op); err != nil {
+		return nil, err
+	}
+
+	// Buckets are aligned on the Unix epoch, as park.BucketStart does.
+	var buckets []*model.ReadingBucket
+	if _, err := p.db.QueryContext(ctx, &buckets, `
+		SELECT to_timestamp(floor(extract(epoch FROM at) / ?0) * ?0) AS start,
+			sum(count) AS count, min(min) AS min, max(max) AS max, sum(sum) AS sum
+		FROM (
+			SELECT recorded_at AS at, 1 AS count, value AS min, value AS max, value AS sum
+			FROM telemetry_readings
+			WHERE cage_id = ?1 AND sensor = ?2 AND recorded_at >= ?3 AND recorded_at < ?4
+			UNION ALL
+			SELECT bucket_start, count, min, max, sum
+			FROM telemetry_rollups
+			WHERE cage_id = ?1 AND sensor = ?2 AND bucket_start >= ?3 AND bucket_start < ?4
+		) AS samples
+		GROUP BY 1
+		ORDER BY 1`, int64(query.Bucket/time.Second), query.CageID, query.Sensor, query.From, query.To); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	for _, bucket := range buckets {
+		bucket.Start = bucket.Start.UTC()
+		bucket.Avg = bucket.Sum / float64(bucket.Count)
+	}
+
+	return buckets, nil
+}
+
+func (p *Postgres) ListFenceAlerts(ctx context.Context, params storage.ListFenceAlertParams) ([]*model.FenceAlert, int, error) {
+	const op errors.Op = "postgres.ListFenceAlerts"
+
+	var alerts []*model.FenceAlert
+	q := p.db.WithContext(ctx).Model(&alerts)
+
+	if params.CageID != "" {
+		q = q.Where("fence_alert.cage_id = ?", params.CageID)
+	}
+
+	if params.Open {
+		q = q.Where("fence_alert.cleared_at IS NULL")
+	}
+
+	q = q.Order("fence_alert.id DESC")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return alerts, total, nil
+}
+
+func (p *Postgres) DownsampleTelemetry(ctx context.Context, rawBefore, rollupBefore time.Time) (int, error) {
+	const op errors.Op = "postgres.DownsampleTelemetry"
+
+	var rolled int
+	downsampleFn := func(tx *pg.Tx) error {
+		// Readings are deleted and rolled up in one statement, so that none
+		// recorded late is dropped without being counted.
+		if _, err := tx.QueryOneContext(ctx, pg.Scan(&rolled), `
+			WITH expired AS (
+				DELETE FROM telemetry_readings
+				WHERE recorded_at < ?0
+				RETURNING cage_id, sensor, value, recorded_at
+			), rolled AS (
+				INSERT INTO telemetry_rollups (cage_id, sensor, bucket_start, count, min, max, sum)
+				SELECT cage_id, sensor, to_timestamp(floor(extract(epoch FROM recorded_at) / ?1) * ?1),
+					count(*), min(value), max(value), sum(value)
+				FROM expired
+				GROUP BY 1, 2, 3
+				ON CONFLICT (cage_id, sensor, bucket_start) DO UPDATE SET
+					count = telemetry_rollups.count + excluded.count,
+					min = LEAST(telemetry_rollups.min, excluded.min),
+					max = GREATEST(telemetry_rollups.max, excluded.max),
+					sum = telemetry_rollups.sum + excluded.sum
+			)
+			SELECT count(*) FROM expired`, rawBefore, int64(park.RollupWidth/time.Second)); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM telemetry_rollups WHERE bucket_start < ?", rollupBefore); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		return nil
+	}
+
+	if err := p.ExecTx(ctx, downsampleFn); err != nil {
+		return 0, err
+	}
+
+	return rolled, nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+)
+
+func (p *Postgres) TransferDinosaur(ctx context.Context, dinosaurID, toCageID model.ID, reason string) (*model.Transfer, error) {
+	const op errors.Op = "postgres.TransferDinosaur"
+
+	var transfer *model.Transfer
+	transferFn := func(tx *pg.Tx) error {
+		dinosaur, err := lockDinosaur(ctx, tx, dinosaurID, op)
+		if err != nil {
+			return err
+		}
+
+		cages, err := lockCages(ctx, tx, []model.ID{dinosaur.CageID, toCageID}, op)
+		if err != nil {
+			return err
+		}
+
+		transfer, err = p.transfer(ctx, tx, dinosaur, cages, toCageID, reason, op)
+		return err
+	}
+
+	if err := p.ExecTx(ctx, transferFn); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (p *Postgres) TransferDinosaurs(ctx context.Context, moves []*model.PlannedTransfer, reason string) ([]*model.Transfer, error) {
+	const op errors.Op = "postgres.TransferDinosaurs"
+
+	if len(moves) == 0 {
+		return nil, nil
+	}
+
+	var transfers []*model.Transfer
+	transferFn := func(tx *pg.Tx) error {
+		transfers = nil
+
+		// Dinosaurs are locked before cages, each in order of ID.
+		ids := make([]model.ID, 0, len(moves))
+		for _, move := range moves {
+			ids = append(ids, move.DinosaurID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		dinosaurs := make(map[model.ID]*model.Dinosaur, len(ids))
+		for _, id := range ids {
+			if _, ok := dinosaurs[id]; ok {
+				continue
+			}
+
+			dinosaur, err := lockDinosaur(ctx, tx, id, op)
+			if err != nil {
+				return err
+			}
+
+			dinosaurs[id] = dinosaur
+		}
+
+		cageIDs := make([]model.ID, 0, 2*len(moves))
+		for _, move := range moves {
+			cageIDs = append(cageIDs, move.FromCageID, move.ToCageID)
+		}
+
+		cages, err := lockCages(ctx, tx, cageIDs, op)
+		if err != nil {
+			return err
+		}
+
+		for _, move := range moves {
+			dinosaur := dinosaurs[move.DinosaurID]
+			if dinosaur.CageID != move.FromCageID {
+				return errors.E(op, errors.KindPreconditionFailed, fmt.Sprintf(
+					"dinosaur %s is no longer in cage %s", move.DinosaurID, move.FromCageID))
+			}
+
+			transfer, err := p.transfer(ctx, tx, dinosaur, cages, move.ToCageID, reason, op)
+			if err != nil {
+				return err
+			}
+
+			transfers = append(transfers, transfer)
+		}
+
+		return nil
+	}
+
+	if err := p.ExecTx(ctx, transferFn); err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+// transfer moves a locked dinosaur to another cage and records the transfer.
+// cages holds the locked cages involved, whose occupants are kept up to date
+// so that several transfers can be made in the same transaction.
+func (p *Postgres) transfer(ctx context.Context, tx *pg.Tx, dinosaur *model.Dinosaur, cages map[model.ID]*model.Cage, toCageID model.ID, reason string, op errors.Op) (*model.Transfer, error) {
+	if err := park.CheckTransfer(dinosaur); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	if dinosaur.CageID == toCageID {
+		return nil, errors.E(op, errors.KindUnprocessable, fmt.Sprintf("%s is already in cage %s", dinosaur.ID, toCageID))
+	}
+
+	cageIDs := []model.ID{dinosaur.CageID, toCageID}
+	holds, err := incidentHolds(ctx, tx, cageIDs, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := park.CheckIncidentHolds(cageIDs, holds); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	to, ok := cages[toCageID]
+	if !ok {
+		return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("cage %s does not exist", toCageID))
+	}
+
+	before := storage.Snapshot(dinosaur)
+	from := dinosaur.CageID
+	dinosaur.CageID = toCageID
+	if err := park.CheckPlacement(to, to.Dinosaurs, dinosaur); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	now := p.now().UTC()
+	dinosaur.UpdatedAt = &now
+	dinosaur.Version++
+
+	if _, err := tx.ModelContext(ctx, dinosaur).
+		Column("cage_id", "updated_at", "version").
+		WherePK().
+		Update(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	if cage, ok := cages[from]; ok {
+		for i, d := range cage.Dinosaurs {
+			if d.ID == dinosaur.ID {
+				cage.Dinosaurs = append(cage.Dinosaurs[:i:i], cage.Dinosaurs[i+1:]...)
+				break
+			}
+		}
+	}
+	to.Dinosaurs = append(to.Dinosaurs, dinosaur)
+
+	transfer := &model.Transfer{
+		DinosaurID: dinosaur.ID,
+		FromCageID: from,
+		ToCageID:   toCageID,
+		Reason:     reason,
+		Actor:      storage.Actor(ctx),
+		CreatedAt:  &now,
+	}
+
+	if _, err := tx.ModelContext(ctx, transfer).Insert(); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	entry := storage.NewAuditEntry(ctx, op, model.EntityDinosaur, dinosaur.ID, before, storage.Snapshot(dinosaur))
+	if err := p.audit(ctx, tx, entry, op); err != nil {
+		return nil, err
+	}
+
+	if err := p.publish(ctx, tx, storage.NewEvent(model.EventDinosaurTransferred, dinosaur.ID, transfer), op); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (p *Postgres) ListTransfers(ctx context.Context, params storage.ListTransferParams) ([]*model.Transfer, int, error) {
+	const op errors.Op = "postgres.ListTransfers"
+
+	var transfers []*model.Transfer
+	q := p.db.WithContext(ctx).Model(&transfers)
+
+	if params.DinosaurID != "" {
+		q = q.Where("transfer.dinosaur_id = ?", params.DinosaurID)
+	}
+
+	if params.CageID != "" {
+		q = q.WhereGroup(func(q *pg.Query) (*pg.Query, error) {
+			return q.Where("transfer.from_cage_id = ?", params.CageID).
+				WhereOr("transfer.to_cage_id = ?", params.CageID), nil
+		})
+	}
+
+	q = q.Order("transfer.created_at DESC", "transfer.id DESC")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return transfers, total, nil
+}
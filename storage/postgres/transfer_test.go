@@ -0,0 +1,207 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_TransferDinosaur(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := storage.WithActor(context.Background(), "muldoon")
+	c1, c2 := newTestCage(t), newTestCage(t)
+	d := newTestDinosaur(c1.ID, model.Tyrannosaurus)
+	if err := postgres.CreateDinosaur(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	transfer, err := postgres.TransferDinosaur(ctx, d.ID, c2.ID, "paddock rotation")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotZero(t, transfer.ID)
+	assert.Equal(t, c1.ID, transfer.FromCageID)
+	assert.Equal(t, c2.ID, transfer.ToCageID)
+	assert.Equal(t, "muldoon", transfer.Actor)
+
+	got, err := postgres.GetDinosaur(ctx, d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, c2.ID, got.CageID)
+	assert.Equal(t, 2, got.Version)
+
+	for _, params := range []storage.ListTransferParams{{DinosaurID: d.ID}, {CageID: c1.ID}, {CageID: c2.ID}} {
+		transfers, total, err := postgres.ListTransfers(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 1, total)
+		assert.Equal(t, transfer.ID, transfers[0].ID)
+		assert.Equal(t, "paddock rotation", transfers[0].Reason)
+	}
+
+	_, err = postgres.TransferDinosaur(ctx, d.ID, c2.ID, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	_, err = postgres.TransferDinosaur(ctx, d.ID, "foo", "")
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	_, err = postgres.TransferDinosaur(ctx, "foo", c1.ID, "")
+	assert.True(t, errors.IsNotFoundErr(err))
+}
+
+func TestPostgres_ConcurrentTransfers(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	c1, c2 := newTestCage(t), newTestCage(t)
+
+	var dinosaurs []*model.Dinosaur
+	for _, cage := range []*model.Cage{c1, c2} {
+		for i := 0; i < 3; i++ {
+			d := newTestDinosaur(cage.ID, model.Stegosaurus)
+			if err := postgres.CreateDinosaur(ctx, d); err != nil {
+				t.Fatal(err)
+			}
+
+			dinosaurs = append(dinosaurs, d)
+		}
+	}
+
+	// Swap every dinosaur concurrently; locking both cages in a stable order
+	// must not deadlock.
+	var wg sync.WaitGroup
+	for i, d := range dinosaurs {
+		to := c2.ID
+		if i >= 3 {
+			to = c1.ID
+		}
+
+		wg.Add(1)
+		go func(d *model.Dinosaur, to model.ID) {
+			defer wg.Done()
+
+			if _, err := postgres.TransferDinosaur(ctx, d.ID, to, ""); err != nil {
+				t.Error(err)
+			}
+		}(d, to)
+	}
+
+	wg.Wait()
+
+	for _, cage := range []*model.Cage{c1, c2} {
+		got, err := postgres.GetCage(ctx, cage.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 3, got.Allocation)
+	}
+}
+
+func TestPostgres_TransferDinosaurs(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+	c1, c2, c3 := newTestCage(t), newTestCage(t), newTestCage(t)
+	d1 := newTestDinosaur(c1.ID, model.Triceratops)
+	d2 := newTestDinosaur(c2.ID, model.Triceratops)
+	rex := newTestDinosaur(c3.ID, model.Tyrannosaurus)
+	for _, d := range []*model.Dinosaur{d1, d2, rex} {
+		if err := postgres.CreateDinosaur(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := postgres.LastEventID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second move breaks the rules, so the first one is undone.
+	_, err = postgres.TransferDinosaurs(ctx, []*model.PlannedTransfer{
+		{DinosaurID: d1.ID, FromCageID: c1.ID, ToCageID: c2.ID},
+		{DinosaurID: d2.ID, FromCageID: c2.ID, ToCageID: c3.ID},
+	}, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	got, err := postgres.GetDinosaur(ctx, d1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, c1.ID, got.CageID)
+	assert.Equal(t, 1, got.Version)
+
+	last, err := postgres.LastEventID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, events, last)
+
+	_, err = postgres.TransferDinosaurs(ctx, []*model.PlannedTransfer{{DinosaurID: d1.ID, FromCageID: c2.ID, ToCageID: c3.ID}}, "")
+	assert.True(t, errors.Is(err, errors.KindPreconditionFailed))
+
+	transfers, err := postgres.TransferDinosaurs(ctx, []*model.PlannedTransfer{
+		{DinosaurID: d1.ID, FromCageID: c1.ID, ToCageID: c2.ID},
+		{DinosaurID: d1.ID, FromCageID: c2.ID, ToCageID: c1.ID},
+		{DinosaurID: d2.ID, FromCageID: c2.ID, ToCageID: c1.ID},
+	}, "consolidation")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, transfers, 3)
+	assert.Equal(t, "consolidation", transfers[2].Reason)
+
+	cage, err := postgres.GetCage(ctx, c1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, cage.Allocation)
+
+	_, total, err := postgres.ListTransfers(ctx, storage.ListTransferParams{CageID: c2.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, total)
+}
@@ -0,0 +1,499 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func (p *Postgres) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	const op errors.Op = "postgres.CreateWebhook"
+
+	if err := park.CheckWebhook(webhook); err != nil {
+		return errors.E(op, err)
+	}
+
+	createFn := func(tx *pg.Tx) error {
+		// The outbox lock keeps events from committing between the read of
+		// the cursor and the insert, so the webhook gets every later event.
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?)", outboxLock); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		if _, err := tx.QueryOneContext(ctx, pg.Scan(&webhook.LastEventID), "SELECT coalesce(max(id), 0) FROM outbox"); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		now := p.now().UTC()
+		webhook.Actor = storage.Actor(ctx)
+		webhook.CreatedAt = &now
+		webhook.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, webhook).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityWebhook, storage.SerialID(webhook.ID), nil, storage.Snapshot(webhook))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, createFn)
+}
+
+func (p *Postgres) GetWebhook(ctx context.Context, id int64) (*model.Webhook, error) {
+	const op errors.Op = "postgres.GetWebhook"
+
+	return getWebhook(ctx, p.db, id, false, op)
+}
+
+// getWebhook selects a webhook, locking it if lock is set.
+func getWebhook(ctx context.Context, db orm.DB, id int64, lock bool, op errors.Op) (*model.Webhook, error) {
+	webhook := &model.Webhook{ID: id}
+	q := db.ModelContext(ctx, webhook).WherePK()
+	if lock {
+		q = q.For("UPDATE")
+	}
+
+	if err := q.Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("webhook %d does not exist", id))
+		}
+
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return webhook, nil
+}
+
+func (p *Postgres) ListWebhooks(ctx context.Context, params storage.ListWebhookParams) ([]*model.Webhook, int, error) {
+	const op errors.Op = "postgres.ListWebhooks"
+
+	var webhooks []*model.Webhook
+	q := p.db.WithContext(ctx).Model(&webhooks).Order("webhook.id")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return webhooks, total, nil
+}
+
+func (p *Postgres) UpdateWebhook(ctx context.Context, id int64, updater storage.WebhookUpdater) error {
+	const op errors.Op = "postgres.UpdateWebhook"
+
+	updateFn := func(tx *pg.Tx) error {
+		stored, err := getWebhook(ctx, tx, id, true, op)
+		if err != nil {
+			return err
+		}
+
+		before := storage.Snapshot(stored)
+		old := *stored
+		old.Types = append([]string(nil), stored.Types...)
+		webhook, err := updater(&old)
+		if err != nil {
+			return err
+		}
+
+		if err := park.CheckWebhook(webhook); err != nil {
+			return errors.E(op, err)
+		}
+
+		now := p.now().UTC()
+		stored.URL = webhook.URL
+		stored.Secret = webhook.Secret
+		stored.Types = webhook.Types
+		stored.Active = webhook.Active
+		stored.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, stored).
+			Column("url", "secret", "types", "active", "updated_at").
+			WherePK().
+			Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityWebhook, storage.SerialID(id), before, storage.Snapshot(stored))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, updateFn)
+}
+
+func (p *Postgres) DeleteWebhook(ctx context.Context, id int64) error {
+	const op errors.Op = "postgres.DeleteWebhook"
+
+	deleteFn := func(tx *pg.Tx) error {
+		stored, err := getWebhook(ctx, tx, id, true, op)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ModelContext(ctx, stored).WherePK().Delete(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityWebhook, storage.SerialID(id), storage.Snapshot(stored), nil)
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, deleteFn)
+}
+
+func (p *Postgres) EnqueueWebhookDeliveries(ctx context.Context, limit int) (int, error) {
+	const op errors.Op = "postgres.EnqueueWebhookDeliveries"
+
+	var created int
+	enqueueFn := func(tx *pg.Tx) error {
+		created = 0
+
+		// Webhooks being enqueued by someone else are skipped until the
+		// next round.
+		var webhooks []*model.Webhook
+		if err := tx.ModelContext(ctx, &webhooks).
+			Order("webhook.id").
+			For("UPDATE SKIP LOCKED").
+			Select(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		now := p.now().UTC()
+		for _, webhook := range webhooks {
+			var events []*model.Event
+			q := tx.ModelContext(ctx, &events).
+				Where("id > ?", webhook.LastEventID).
+				Order("id")
+			if limit > 0 {
+				q = q.Limit(limit)
+			}
+
+			if err := q.Select(); err != nil {
+				return errors.E(op, kind(err), err)
+			}
+
+			if len(events) == 0 {
+				continue
+			}
+
+			var deliveries []*model.WebhookDelivery
+			for _, event := range events {
+				if !webhook.Active || !webhook.Wants(event.Type) {
+					continue
+				}
+
+				deliveries = append(deliveries, &model.WebhookDelivery{
+					WebhookID:     webhook.ID,
+					EventID:       event.ID,
+					EventType:     event.Type,
+					Status:        model.DeliveryPending,
+					NextAttemptAt: &now,
+					CreatedAt:     &now,
+					UpdatedAt:     &now,
+				})
+			}
+
+			if len(deliveries) > 0 {
+				if _, err := tx.ModelContext(ctx, &deliveries).Insert(); err != nil {
+					return errors.E(op, kind(err), err)
+				}
+			}
+
+			webhook.LastEventID = events[len(events)-1].ID
+			if _, err := tx.ModelContext(ctx, webhook).
+				Column("last_event_id").
+				WherePK().
+				Update(); err != nil {
+				return errors.E(op, kind(err), err)
+			}
+
+			created += len(deliveries)
+		}
+
+		return nil
+	}
+
+	if err := p.ExecTx(ctx, enqueueFn); err != nil {
+		return 0, err
+	}
+
+	return created, nil
+}
+
+func (p *Postgres) ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	const op errors.Op = "postgres.ClaimWebhookDeliveries"
+
+	var deliveries []*model.WebhookDelivery
+	claimFn := func(tx *pg.Tx) error {
+		deliveries = nil
+
+		q := tx.ModelContext(ctx, &deliveries).
+			Where("webhook_delivery.status = ?", model.DeliveryPending).
+			Where("webhook_delivery.next_attempt_at <= ?", now.UTC()).
+			Order("webhook_delivery.next_attempt_at", "webhook_delivery.id").
+			For("UPDATE SKIP LOCKED")
+		if limit > 0 {
+			q = q.Limit(limit)
+		}
+
+		if err := q.Select(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, 0, len(deliveries))
+		webhookIDs := make([]int64, 0, len(deliveries))
+		eventIDs := make([]int64, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+			webhookIDs = append(webhookIDs, delivery.WebhookID)
+			eventIDs = append(eventIDs, delivery.EventID)
+		}
+
+		// The lease doubles as the claim that RecordWebhookAttempt checks,
+		// so it must survive the round trip at the precision of Postgres.
+		next := now.UTC().Add(lease).Truncate(time.Microsecond)
+		updated := p.now().UTC()
+		if _, err := tx.ModelContext(ctx, (*model.WebhookDelivery)(nil)).
+			Set("next_attempt_at = ?", next).
+			Set("updated_at = ?", updated).
+			Where("id IN (?)", pg.In(ids)).
+			Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		var webhooks []*model.Webhook
+		if err := tx.ModelContext(ctx, &webhooks).Where("id IN (?)", pg.In(webhookIDs)).Select(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		var events []*model.Event
+		if err := tx.ModelContext(ctx, &events).Where("id IN (?)", pg.In(eventIDs)).Select(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		byWebhook := make(map[int64]*model.Webhook, len(webhooks))
+		for _, webhook := range webhooks {
+			byWebhook[webhook.ID] = webhook
+		}
+
+		byEvent := make(map[int64]*model.Event, len(events))
+		for _, event := range events {
+			byEvent[event.ID] = event
+		}
+
+		for _, delivery := range deliveries {
+			delivery.NextAttemptAt = &next
+			delivery.UpdatedAt = &updated
+			delivery.Webhook = byWebhook[delivery.WebhookID]
+			delivery.Event = byEvent[delivery.EventID]
+		}
+
+		return nil
+	}
+
+	if err := p.ExecTx(ctx, claimFn); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (p *Postgres) RecordWebhookAttempt(ctx context.Context, attempt *model.WebhookAttempt, claimed time.Time, status string, next time.Time) error {
+	const op errors.Op = "postgres.RecordWebhookAttempt"
+
+	if !model.ValidDeliveryStatus(status) {
+		return errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid delivery status: %q", status))
+	}
+
+	recordFn := func(tx *pg.Tx) error {
+		delivery, err := getDelivery(ctx, tx, attempt.DeliveryID, op)
+		if err != nil {
+			return err
+		}
+
+		if err := park.CheckDeliveryClaim(delivery, claimed); err != nil {
+			return errors.E(op, err)
+		}
+
+		now := p.now().UTC()
+		attempt.WebhookID = delivery.WebhookID
+		attempt.EventID = delivery.EventID
+		attempt.CreatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, attempt).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		delivery.Attempts = attempt.Attempt
+		delivery.Status = status
+		delivery.LastError = attempt.Error
+		delivery.UpdatedAt = &now
+
+		switch status {
+		case model.DeliveryPending:
+			next = next.UTC()
+			delivery.NextAttemptAt = &next
+		case model.DeliveryDelivered:
+			delivery.NextAttemptAt = nil
+			delivery.DeliveredAt = &now
+		case model.DeliveryDead:
+			delivery.NextAttemptAt = nil
+		}
+
+		if _, err := tx.ModelContext(ctx, delivery).
+			Column("attempts", "status", "last_error", "next_attempt_at", "delivered_at", "updated_at").
+			WherePK().
+			Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		return nil
+	}
+
+	return p.ExecTx(ctx, recordFn)
+}
+
+// getDelivery selects and locks a delivery.
+func getDelivery(ctx context.Context, tx *pg.Tx, id int64, op errors.Op) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{ID: id}
+	if err := tx.ModelContext(ctx, delivery).WherePK().For("UPDATE").Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("delivery %d does not exist", id))
+		}
+
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return delivery, nil
+}
+
+func (p *Postgres) ListWebhookDeliveries(ctx context.Context, params storage.ListWebhookDeliveryParams) ([]*model.WebhookDelivery, int, error) {
+	const op errors.Op = "postgres.ListWebhookDeliveries"
+
+	if params.Status != "" && !model.ValidDeliveryStatus(params.Status) {
+		return nil, 0, errors.E(op, errors.KindBadRequest, fmt.Sprintf("invalid status: %q", params.Status))
+	}
+
+	var deliveries []*model.WebhookDelivery
+	q := p.db.WithContext(ctx).Model(&deliveries)
+
+	if params.WebhookID != 0 {
+		q = q.Where("webhook_delivery.webhook_id = ?", params.WebhookID)
+	}
+
+	if params.Status != "" {
+		q = q.Where("webhook_delivery.status = ?", params.Status)
+	}
+
+	q = q.Order("webhook_delivery.id DESC")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return deliveries, total, nil
+}
+
+func (p *Postgres) ListWebhookAttempts(ctx context.Context, params storage.ListWebhookAttemptParams) ([]*model.WebhookAttempt, int, error) {
+	const op errors.Op = "postgres.ListWebhookAttempts"
+
+	var attempts []*model.WebhookAttempt
+	q := p.db.WithContext(ctx).Model(&attempts)
+
+	if params.WebhookID != 0 {
+		q = q.Where("webhook_attempt.webhook_id = ?", params.WebhookID)
+	}
+
+	if params.DeliveryID != 0 {
+		q = q.Where("webhook_attempt.delivery_id = ?", params.DeliveryID)
+	}
+
+	q = q.Order("webhook_attempt.id DESC")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return attempts, total, nil
+}
+
+func (p *Postgres) ReplayWebhookDelivery(ctx context.Context, webhookID, deliveryID int64) (*model.WebhookDelivery, error) {
+	const op errors.Op = "postgres.ReplayWebhookDelivery"
+
+	var delivery *model.WebhookDelivery
+	replayFn := func(tx *pg.Tx) error {
+		var err error
+		delivery, err = getDelivery(ctx, tx, deliveryID, op)
+		if err != nil {
+			return err
+		}
+
+		if delivery.WebhookID != webhookID {
+			return errors.E(op, errors.KindNotFound, fmt.Sprintf("delivery %d does not exist", deliveryID))
+		}
+
+		if err := park.CheckDeliveryReplay(delivery); err != nil {
+			return errors.E(op, err)
+		}
+
+		now := p.now().UTC()
+		delivery.Status = model.DeliveryPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = &now
+		delivery.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, delivery).
+			Column("status", "attempts", "next_attempt_at", "updated_at").
+			WherePK().
+			Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		return nil
+	}
+
+	if err := p.ExecTx(ctx, replayFn); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,273 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Zones are locked after their cages: a cage joining a zone locks the cage,
+// then the zone, and so do changes to a zone after locking its cages.
+
+func (p *Postgres) CreateZone(ctx context.Context, zone *model.Zone) error {
+	const op errors.Op = "postgres.CreateZone"
+
+	if err := park.CheckZone(zone); err != nil {
+		return errors.E(op, err)
+	}
+
+	createFn := func(tx *pg.Tx) error {
+		now := p.now().UTC()
+		zone.CreatedAt = &now
+		zone.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, zone).Insert(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		park.SummarizeZone(zone, nil)
+		entry := storage.NewAuditEntry(ctx, op, model.EntityZone, zone.ID, nil, storage.Snapshot(zone))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, createFn)
+}
+
+func (p *Postgres) GetZone(ctx context.Context, id model.ID) (*model.Zone, error) {
+	const op errors.Op = "postgres.GetZone"
+
+	zone, err := getZone(ctx, p.db, id, false, op)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := summarizeZones(ctx, p.db, []*model.Zone{zone}); err != nil {
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return zone, nil
+}
+
+// getZone selects a zone, locking it if lock is set.
+func getZone(ctx context.Context, db orm.DB, id model.ID, lock bool, op errors.Op) (*model.Zone, error) {
+	zone := &model.Zone{ID: id}
+	q := db.ModelContext(ctx, zone).WherePK()
+	if lock {
+		q = q.For("UPDATE")
+	}
+
+	if err := q.Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, errors.E(op, errors.KindNotFound, fmt.Sprintf("zone %s does not exist", id))
+		}
+
+		return nil, errors.E(op, kind(err), err)
+	}
+
+	return zone, nil
+}
+
+// cageZone locks the zone a cage joins, or returns an empty zone for cages
+// out of any zone. Unknown zones are reported as errors.KindBadRequest.
+func cageZone(ctx context.Context, tx *pg.Tx, id model.ID, op errors.Op) (*model.Zone, error) {
+	if id == "" {
+		return &model.Zone{}, nil
+	}
+
+	zone, err := getZone(ctx, tx, id, true, op)
+	if errors.IsNotFoundErr(err) {
+		return nil, errors.E(op, errors.KindBadRequest, fmt.Sprintf("zone %s does not exist", id))
+	}
+
+	return zone, err
+}
+
+func (p *Postgres) ListZones(ctx context.Context, params storage.ListZoneParams) ([]*model.Zone, int, error) {
+	const op errors.Op = "postgres.ListZones"
+
+	var zones []*model.Zone
+	q := p.db.WithContext(ctx).Model(&zones).Order("zone.name", "zone.id")
+
+	if params.Pagination != nil {
+		q = q.Limit(params.Pagination.Limit).Offset(params.Pagination.Offset)
+	}
+
+	total, err := q.SelectAndCount()
+	if err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	if err := summarizeZones(ctx, p.db, zones); err != nil {
+		return nil, 0, errors.E(op, kind(err), err)
+	}
+
+	return zones, total, nil
+}
+
+// summarizeZones sets the aggregates of zones from their live cages.
+func summarizeZones(ctx context.Context, db orm.DB, zones []*model.Zone) error {
+	if len(zones) == 0 {
+		return nil
+	}
+
+	ids := make([]model.ID, 0, len(zones))
+	for _, zone := range zones {
+		ids = append(ids, zone.ID)
+	}
+
+	var cages []*model.Cage
+	if err := db.ModelContext(ctx, &cages).
+		Where("zone_id IN (?)", pg.In(ids)).
+		Where("deleted_at IS NULL").
+		Select(); err != nil {
+		return err
+	}
+
+	if err := fillOccupancy(ctx, db, cages, false); err != nil {
+		return err
+	}
+
+	for _, zone := range zones {
+		park.SummarizeZone(zone, cages)
+	}
+
+	return nil
+}
+
+// fillForbiddenKinds sets the kinds forbidden by the zones of cages.
+func fillForbiddenKinds(ctx context.Context, db orm.DB, cages []*model.Cage) error {
+	var ids []model.ID
+	for _, cage := range cages {
+		if cage.ZoneID != "" {
+			ids = append(ids, cage.ZoneID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var zones []*model.Zone
+	if err := db.ModelContext(ctx, &zones).Where("id IN (?)", pg.In(ids)).Select(); err != nil {
+		return err
+	}
+
+	byID := make(map[model.ID]*model.Zone, len(zones))
+	for _, zone := range zones {
+		byID[zone.ID] = zone
+	}
+
+	for _, cage := range cages {
+		if zone, ok := byID[cage.ZoneID]; ok {
+			cage.ForbiddenKinds = zone.ForbiddenKinds
+		}
+	}
+
+	return nil
+}
+
+func (p *Postgres) UpdateZone(ctx context.Context, id model.ID, updater storage.ZoneUpdater) error {
+	const op errors.Op = "postgres.UpdateZone"
+
+	updateFn := func(tx *pg.Tx) error {
+		stored, cages, err := lockZone(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		before := storage.Snapshot(stored)
+		old := *stored
+		old.ForbiddenKinds = append([]string(nil), stored.ForbiddenKinds...)
+		zone, err := updater(&old)
+		if err != nil {
+			return err
+		}
+
+		zone.ID = id
+		if err := park.CheckZone(zone); err != nil {
+			return errors.E(op, err)
+		}
+
+		if err := park.CheckZoneCages(zone, cages); err != nil {
+			return errors.E(op, err)
+		}
+
+		now := p.now().UTC()
+		stored.Name = zone.Name
+		stored.ForbiddenKinds = zone.ForbiddenKinds
+		stored.UpdatedAt = &now
+
+		if _, err := tx.ModelContext(ctx, stored).
+			Column("name", "forbidden_kinds", "updated_at").
+			WherePK().
+			Update(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityZone, id, before, storage.Snapshot(stored))
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, updateFn)
+}
+
+// lockZone locks the cages of a zone with their dinosaurs, then the zone,
+// as lockGroupCages does.
+func lockZone(ctx context.Context, tx *pg.Tx, id model.ID, op errors.Op) (*model.Zone, []*model.Cage, error) {
+	var zone *model.Zone
+	cages, err := lockGroupCages(ctx, tx, "zone_id", id,
+		func(cage *model.Cage) model.ID { return cage.ZoneID },
+		func() (err error) {
+			zone, err = getZone(ctx, tx, id, true, op)
+			return err
+		}, op)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return zone, cages, nil
+}
+
+func (p *Postgres) DeleteZone(ctx context.Context, id model.ID) error {
+	const op errors.Op = "postgres.DeleteZone"
+
+	deleteFn := func(tx *pg.Tx) error {
+		zone, cages, err := lockZone(ctx, tx, id, op)
+		if err != nil {
+			return err
+		}
+
+		if err := park.CheckZoneDelete(zone, len(cages)); err != nil {
+			return errors.E(op, err)
+		}
+
+		if _, err := tx.ModelContext(ctx, zone).WherePK().Delete(); err != nil {
+			return errors.E(op, kind(err), err)
+		}
+
+		entry := storage.NewAuditEntry(ctx, op, model.EntityZone, id, storage.Snapshot(zone), nil)
+		return p.audit(ctx, tx, entry, op)
+	}
+
+	return p.ExecTx(ctx, deleteFn)
+}
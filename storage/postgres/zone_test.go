@@ -0,0 +1,141 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_Zones(t *testing.T) {
+	if shouldSkip() {
+		t.SkipNow()
+	}
+
+	setup(t)
+
+	ctx := context.Background()
+
+	id := uuid.MustNextID()
+	zoo := &model.Zone{ID: model.NewZoneID(id), Name: "Petting Zoo " + id, ForbiddenKinds: []string{model.KindCarnivore}}
+	if err := postgres.CreateZone(ctx, zoo); err != nil {
+		t.Fatal(err)
+	}
+
+	err := postgres.CreateZone(ctx, &model.Zone{ID: model.NewZoneID(uuid.MustNextID()), Name: zoo.Name})
+	assert.True(t, errors.Is(err, errors.KindAlreadyExists))
+
+	err = postgres.CreateZone(ctx, &model.Zone{ID: model.NewZoneID(uuid.MustNextID()), Name: "Carnivore Row", ForbiddenKinds: []string{"omnivore"}})
+	assert.True(t, errors.Is(err, errors.KindBadRequest))
+
+	join := func(cageID, zoneID model.ID) error {
+		return postgres.UpdateCage(ctx, cageID, func(old *model.Cage) (*model.Cage, error) {
+			old.ZoneID = zoneID
+			return old, nil
+		})
+	}
+
+	empty, herbivores, carnivores := newTestCage(t), newTestCage(t), newTestCage(t)
+	for _, d := range []*model.Dinosaur{
+		newTestDinosaur(herbivores.ID, model.Triceratops),
+		newTestDinosaur(herbivores.ID, model.Stegosaurus),
+		newTestDinosaur(carnivores.ID, model.Velociraptor),
+	} {
+		if err := postgres.CreateDinosaur(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.NoError(t, join(empty.ID, zoo.ID))
+	assert.NoError(t, join(herbivores.ID, zoo.ID))
+	assert.True(t, errors.IsUnprocessableErr(join(carnivores.ID, zoo.ID)))
+	assert.True(t, errors.Is(join(carnivores.ID, "zn_foo"), errors.KindBadRequest))
+
+	// The zone rule applies to the first occupant of a cage.
+	err = postgres.CreateDinosaur(ctx, newTestDinosaur(empty.ID, model.Tyrannosaurus))
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	rex := newTestDinosaur(carnivores.ID, model.Velociraptor)
+	if err := postgres.CreateDinosaur(ctx, rex); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = postgres.TransferDinosaur(ctx, rex.ID, empty.ID, "")
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	got, err := postgres.GetCage(ctx, empty.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, zoo.ID, got.ZoneID)
+		assert.Equal(t, []string{model.KindCarnivore}, got.ForbiddenKinds)
+	}
+
+	cages, total, err := postgres.ListCages(ctx, storage.ListCageParams{ZoneID: zoo.ID})
+	if assert.NoError(t, err) && assert.Len(t, cages, 2) {
+		assert.Equal(t, 2, total)
+	}
+
+	zone, err := postgres.GetZone(ctx, zoo.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, zone.Cages)
+		assert.Equal(t, 2*model.MaxCageCapacity, zone.Capacity)
+		assert.Equal(t, 2, zone.Allocation)
+		assert.Equal(t, map[model.PowerStatus]int{model.PowerActive: 2}, zone.Power)
+	}
+
+	_, err = postgres.GetZone(ctx, "zn_foo")
+	assert.True(t, errors.IsNotFoundErr(err))
+
+	zones, _, err := postgres.ListZones(ctx, storage.ListZoneParams{})
+	if assert.NoError(t, err) && assert.NotEmpty(t, zones) {
+		var found bool
+		for _, z := range zones {
+			if z.ID == zoo.ID {
+				found = true
+				assert.Equal(t, 2, z.Allocation)
+			}
+		}
+
+		assert.True(t, found)
+	}
+
+	// Kinds held by the cages of a zone cannot be forbidden.
+	row := &model.Zone{ID: model.NewZoneID(uuid.MustNextID()), Name: "Carnivore Row " + id}
+	if err := postgres.CreateZone(ctx, row); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, join(carnivores.ID, row.ID))
+	err = postgres.UpdateZone(ctx, row.ID, func(old *model.Zone) (*model.Zone, error) {
+		old.ForbiddenKinds = []string{model.KindCarnivore}
+		return old, nil
+	})
+	assert.True(t, errors.IsUnprocessableErr(err))
+
+	err = postgres.UpdateZone(ctx, row.ID, func(old *model.Zone) (*model.Zone, error) {
+		old.ForbiddenKinds = []string{model.KindHerbivores}
+		return old, nil
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, errors.IsUnprocessableErr(postgres.DeleteZone(ctx, row.ID)))
+	assert.NoError(t, join(carnivores.ID, ""))
+	assert.NoError(t, postgres.DeleteZone(ctx, row.ID))
+	assert.True(t, errors.IsNotFoundErr(postgres.DeleteZone(ctx, row.ID)))
+}
@@ -16,8 +16,12 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
 )
 
 type Storage interface {
@@ -30,22 +34,483 @@ type Storage interface {
 
 	GetCage(ctx context.Context, id model.ID) (*model.Cage, error)
 
-	ListCages(ctx context.Context, params ListCageParams) ([]*model.Cage, error)
+	// ListCages returns the cages matching params and the total number of
+	// matches regardless of pagination.
+	ListCages(ctx context.Context, params ListCageParams) ([]*model.Cage, int, error)
+
+	// ListPowerEvents returns the power history of a cage, most recent first.
+	ListPowerEvents(ctx context.Context, cageID model.ID, pagination *Pagination) ([]*model.PowerEvent, int, error)
+
+	// ArchiveCage decommissions an empty cage. Archived cages keep their
+	// history but are hidden from lists and take no dinosaurs.
+	ArchiveCage(ctx context.Context, id model.ID, reason string) error
+
+	// RestoreCage puts an archived cage back into service.
+	RestoreCage(ctx context.Context, id model.ID) error
+
+	CreateDinosaur(ctx context.Context, dinosaur *model.Dinosaur) error
+
+	UpdateDinosaur(ctx context.Context, id model.ID, updater DinosaurUpdater) error
+
+	GetDinosaur(ctx context.Context, id model.ID) (*model.Dinosaur, error)
+
+	// DeleteDinosaur removes a dinosaur and its history. Use ArchiveDinosaur
+	// to keep them.
+	DeleteDinosaur(ctx context.Context, id model.ID) error
+
+	// ArchiveDinosaur records that a dinosaur is deceased or left the park.
+	// Archived dinosaurs no longer occupy their cage nor reserve their name.
+	ArchiveDinosaur(ctx context.Context, id model.ID, reason string) error
+
+	// RestoreDinosaur brings back an archived dinosaur into its cage, which
+	// must still accept it under the containment rules.
+	RestoreDinosaur(ctx context.Context, id model.ID) error
+
+	// ListDinosaurs returns the dinosaurs matching params and the total number
+	// of matches regardless of pagination.
+	ListDinosaurs(ctx context.Context, params ListDinosaurParams) ([]*model.Dinosaur, int, error)
+
+	// TransferDinosaur moves a dinosaur to another cage and records the
+	// transfer. The destination is checked against the containment rules.
+	TransferDinosaur(ctx context.Context, dinosaurID, toCageID model.ID, reason string) (*model.Transfer, error)
+
+	// TransferDinosaurs applies moves in order within a single transaction:
+	// either every dinosaur is transferred or none is. A move fails with
+	// errors.KindPreconditionFailed if its dinosaur left FromCageID.
+	TransferDinosaurs(ctx context.Context, moves []*model.PlannedTransfer, reason string) ([]*model.Transfer, error)
+
+	// ListTransfers returns the transfers matching params, most recent first,
+	// and the total number of matches regardless of pagination.
+	ListTransfers(ctx context.Context, params ListTransferParams) ([]*model.Transfer, int, error)
+
+	// ListAuditEntries returns the audit entries matching params, most recent
+	// first, and the total number of matches regardless of pagination.
+	ListAuditEntries(ctx context.Context, params ListAuditParams) ([]*model.AuditEntry, int, error)
+
+	// ListSpecies returns the species catalog sorted by name.
+	ListSpecies(ctx context.Context) ([]*model.SpeciesEntry, error)
+
+	GetSpecies(ctx context.Context, name model.Species) (*model.SpeciesEntry, error)
+
+	CreateSpecies(ctx context.Context, entry *model.SpeciesEntry) error
+
+	// UpdateSpecies changes a catalog entry. The diet kind of a species
+	// cannot change while live dinosaurs belong to it, and a lower MaxPerCage
+	// only applies to later placements.
+	UpdateSpecies(ctx context.Context, name model.Species, updater SpeciesUpdater) error
+
+	// DeleteSpecies removes a species no live dinosaur belongs to.
+	DeleteSpecies(ctx context.Context, name model.Species) error
+
+	// ListEvents returns the events of the outbox matching params in the
+	// order they were committed.
+	ListEvents(ctx context.Context, params ListEventParams) ([]*model.Event, error)
+
+	// LastEventID returns the ID of the last event of the outbox, or zero if
+	// it is empty.
+	LastEventID(ctx context.Context) (int64, error)
+
+	// GetFeedingSchedule returns the feeding schedule of a cage. Unless one
+	// was set, it is derived from the diet kind of the occupants.
+	GetFeedingSchedule(ctx context.Context, cageID model.ID) (*model.FeedingSchedule, error)
+
+	// SetFeedingSchedule stores the feeding schedule of a cage.
+	SetFeedingSchedule(ctx context.Context, schedule *model.FeedingSchedule) error
+
+	// RecordFeeding records that the given occupants of a cage, or all of them
+	// if dinosaurIDs is empty, were fed. The food and amount default to those
+	// of the schedule of the cage. It returns one feeding per dinosaur.
+	RecordFeeding(ctx context.Context, feeding *model.Feeding, dinosaurIDs []model.ID) ([]*model.Feeding, error)
+
+	// ListFeedings returns the feedings matching params, most recent first,
+	// and the total number of matches regardless of pagination.
+	ListFeedings(ctx context.Context, params ListFeedingParams) ([]*model.Feeding, int, error)
+
+	// ListOverdueFeedings returns the cages whose occupants were not fed on
+	// schedule, or only cageID if set.
+	ListOverdueFeedings(ctx context.Context, cageID model.ID) ([]*model.OverdueFeeding, error)
+
+	// CreateVetVisit records a visit of a live dinosaur with its diagnoses
+	// and treatments.
+	CreateVetVisit(ctx context.Context, visit *model.VetVisit) error
+
+	// ListVetVisits returns the visits matching params, most recent first,
+	// and the total number of matches regardless of pagination.
+	ListVetVisits(ctx context.Context, params ListVetVisitParams) ([]*model.VetVisit, int, error)
+
+	// RecordBiometrics stores measurements of live dinosaurs, all or none.
+	RecordBiometrics(ctx context.Context, biometrics []*model.Biometric) error
+
+	// ListBiometrics returns the measurements matching params, oldest first,
+	// and the total number of matches regardless of pagination.
+	ListBiometrics(ctx context.Context, params ListBiometricParams) ([]*model.Biometric, int, error)
+
+	// CreateMaintenanceWindow schedules maintenance of a live cage. Windows
+	// are rejected if the cage would still be occupied when they start.
+	CreateMaintenanceWindow(ctx context.Context, window *model.MaintenanceWindow) error
+
+	// ListMaintenanceWindows returns the windows matching params, earliest
+	// first, and the total number of matches regardless of pagination.
+	ListMaintenanceWindows(ctx context.Context, params ListMaintenanceParams) ([]*model.MaintenanceWindow, int, error)
+
+	// UpdateMaintenanceWindow changes the status and error of a window.
+	UpdateMaintenanceWindow(ctx context.Context, id int64, updater MaintenanceUpdater) error
+
+	// CreateIncident opens an incident on existing cages and dinosaurs.
+	// Critical incidents put a transfer hold on their cages until closed.
+	CreateIncident(ctx context.Context, incident *model.Incident) error
+
+	// GetIncident returns an incident with its links and timeline.
+	GetIncident(ctx context.Context, id int64) (*model.Incident, error)
+
+	// ListIncidents returns the incidents matching params with their links,
+	// most recent first, and the total number of matches regardless of
+	// pagination.
+	ListIncidents(ctx context.Context, params ListIncidentParams) ([]*model.Incident, int, error)
+
+	// UpdateIncident changes the status or severity of an incident. Status
+	// changes are added to its timeline with the reason carried by ctx.
+	UpdateIncident(ctx context.Context, id int64, updater IncidentUpdater) error
+
+	// AddIncidentNote adds a note to the timeline of an incident.
+	AddIncidentNote(ctx context.Context, note *model.IncidentNote) error
+
+	// CreateWebhook subscribes a webhook to the events published from now
+	// on.
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+
+	// GetWebhook returns a webhook by ID.
+	GetWebhook(ctx context.Context, id int64) (*model.Webhook, error)
+
+	// ListWebhooks returns the webhooks in order of ID and the total number
+	// of webhooks regardless of pagination.
+	ListWebhooks(ctx context.Context, params ListWebhookParams) ([]*model.Webhook, int, error)
+
+	// UpdateWebhook changes the URL, secret, event types or activity of a
+	// webhook.
+	UpdateWebhook(ctx context.Context, id int64, updater WebhookUpdater) error
+
+	// DeleteWebhook removes a webhook with its deliveries and attempts.
+	DeleteWebhook(ctx context.Context, id int64) error
+
+	// EnqueueWebhookDeliveries creates pending deliveries for up to limit
+	// events of the outbox not considered yet by each webhook, and returns
+	// the number of deliveries created. Inactive webhooks skip the events.
+	EnqueueWebhookDeliveries(ctx context.Context, limit int) (int, error)
+
+	// ClaimWebhookDeliveries returns up to limit pending deliveries due at
+	// now, with their webhook and event, and postpones them by lease so that
+	// no one else claims them while they are sent. The NextAttemptAt of a
+	// claimed delivery is its claim, to pass to RecordWebhookAttempt.
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*model.WebhookDelivery, error)
+
+	// RecordWebhookAttempt stores an attempt to send a delivery claimed
+	// until claimed and moves the delivery to status. Pending deliveries are
+	// retried at next. It fails with errors.KindPreconditionFailed if the
+	// delivery was claimed again or settled since.
+	RecordWebhookAttempt(ctx context.Context, attempt *model.WebhookAttempt, claimed time.Time, status string, next time.Time) error
+
+	// ListWebhookDeliveries returns the deliveries matching params, most
+	// recent first, and the total number of matches regardless of
+	// pagination.
+	ListWebhookDeliveries(ctx context.Context, params ListWebhookDeliveryParams) ([]*model.WebhookDelivery, int, error)
+
+	// ListWebhookAttempts is ListWebhookDeliveries for attempts.
+	ListWebhookAttempts(ctx context.Context, params ListWebhookAttemptParams) ([]*model.WebhookAttempt, int, error)
+
+	// ReplayWebhookDelivery sends a dead delivery of a webhook again, with a
+	// fresh set of attempts.
+	ReplayWebhookDelivery(ctx context.Context, webhookID, deliveryID int64) (*model.WebhookDelivery, error)
+
+	// CreateZone creates an empty zone.
+	CreateZone(ctx context.Context, zone *model.Zone) error
+
+	// GetZone returns a zone with the aggregates of its cages.
+	GetZone(ctx context.Context, id model.ID) (*model.Zone, error)
+
+	// ListZones returns the zones with their aggregates in order of name, and
+	// the total number of zones regardless of pagination.
+	ListZones(ctx context.Context, params ListZoneParams) ([]*model.Zone, int, error)
+
+	// UpdateZone changes the name or forbidden kinds of a zone. Kinds held by
+	// its cages cannot be forbidden.
+	UpdateZone(ctx context.Context, id model.ID, updater ZoneUpdater) error
+
+	// DeleteZone removes a zone without cages.
+	DeleteZone(ctx context.Context, id model.ID) error
+
+	// CreateCircuit creates a live circuit without cages.
+	CreateCircuit(ctx context.Context, circuit *model.Circuit) error
+
+	// GetCircuit returns a circuit with the number of its cages.
+	GetCircuit(ctx context.Context, id model.ID) (*model.Circuit, error)
+
+	// ListCircuits returns the circuits in order of name, and the total
+	// number of circuits regardless of pagination.
+	ListCircuits(ctx context.Context, params ListCircuitParams) ([]*model.Circuit, int, error)
+
+	// DeleteCircuit removes a live circuit without cages.
+	DeleteCircuit(ctx context.Context, id model.ID) error
+
+	// CutCircuit cuts a live circuit. Its cages go DOWN in the same
+	// transaction, whether occupied or not, and an incident is opened for
+	// each occupied one. The reason in ctx is recorded on the outage.
+	CutCircuit(ctx context.Context, id model.ID) (*model.CircuitOutage, error)
+
+	// RestoreCircuit restores a cut circuit and brings the cages put DOWN by
+	// its outage back to their former power states. Incidents stay open.
+	RestoreCircuit(ctx context.Context, id model.ID) (*model.CircuitOutage, error)
+
+	// GetCircuitImpact tells what cutting a circuit would do without
+	// cutting it.
+	GetCircuitImpact(ctx context.Context, id model.ID) (*model.CircuitImpact, error)
+
+	// ListCircuitOutages returns the outages of a circuit with their cages,
+	// latest first, and the total number of outages regardless of
+	// pagination.
+	ListCircuitOutages(ctx context.Context, circuitID model.ID, pagination *Pagination) ([]*model.CircuitOutage, int, error)
+
+	// RecordTelemetry stores readings of the fence sensors of existing cages,
+	// all or none. The latest voltage reading of each cage raises an alert,
+	// with an incident, when it is below the threshold of the occupants of
+	// the cage, and clears its open alert once back above. The alerts raised
+	// or cleared are returned.
+	RecordTelemetry(ctx context.Context, readings []*model.Reading) ([]*model.FenceAlert, error)
+
+	// QueryTelemetry summarizes the readings and rollups of a sensor of a
+	// cage in buckets, earliest first. Empty buckets are left out, and a
+	// rollup counts in the bucket holding its start.
+	QueryTelemetry(ctx context.Context, query TelemetryQuery) ([]*model.ReadingBucket, error)
+
+	// ListFenceAlerts returns the fence alerts matching params, latest first,
+	// and the total number of matching alerts regardless of pagination.
+	ListFenceAlerts(ctx context.Context, params ListFenceAlertParams) ([]*model.FenceAlert, int, error)
+
+	// DownsampleTelemetry rolls the readings recorded before rawBefore up
+	// into park.RollupWidth rollups and drops the rollups starting before
+	// rollupBefore. It returns the number of readings rolled up.
+	DownsampleTelemetry(ctx context.Context, rawBefore, rollupBefore time.Time) (int, error)
 }
 
 type (
+	// CageUpdater returns the new state of a cage given its current one.
+	// Updates fail with errors.KindPreconditionFailed unless the returned
+	// cage keeps the version of old, so an updater can demand a version by
+	// setting it.
 	CageUpdater func(old *model.Cage) (*model.Cage, error)
 
 	ListCageParams struct {
 		Pagination *Pagination
+
+		// Status filters cages by power status.
+		Status model.PowerStatus
+
+		// Species filters cages designated for a species, that is, cages whose
+		// occupants all belong to it.
+		Species model.Species
+
+		// Kind filters cages holding dinosaurs of a diet kind.
+		Kind string
+
+		// Available restricts the result to cages with free slots.
+		Available bool
+
+		// ZoneID filters cages of a zone.
+		ZoneID model.ID
+
+		// CircuitID filters cages on a circuit.
+		CircuitID model.ID
+
+		// OrderBy sorts the result by one of the CageOrder fields. A leading
+		// "-" sorts in descending order.
+		OrderBy string
+
+		// WithDinosaurs populates Cage.Dinosaurs.
+		WithDinosaurs bool
+
+		// IncludeArchived lists archived cages too.
+		IncludeArchived bool
+	}
+
+	// DinosaurUpdater is the CageUpdater of dinosaurs.
+	DinosaurUpdater func(old *model.Dinosaur) (*model.Dinosaur, error)
+
+	ListDinosaurParams struct {
+		Pagination *Pagination
+		Species    model.Species
+		CageID     model.ID
+
+		// Name matches dinosaurs whose name contains it, ignoring case.
+		Name string
+
+		// IncludeArchived lists archived dinosaurs too.
+		IncludeArchived bool
+	}
+
+	// SpeciesUpdater is the CageUpdater of species, without versions.
+	SpeciesUpdater func(old *model.SpeciesEntry) (*model.SpeciesEntry, error)
+
+	ListTransferParams struct {
+		Pagination *Pagination
+		DinosaurID model.ID
+
+		// CageID matches transfers from or to a cage.
+		CageID model.ID
+	}
+
+	ListFeedingParams struct {
+		Pagination *Pagination
+		CageID     model.ID
+		DinosaurID model.ID
+	}
+
+	ListVetVisitParams struct {
+		Pagination *Pagination
+		DinosaurID model.ID
+	}
+
+	ListBiometricParams struct {
+		Pagination *Pagination
+		DinosaurID model.ID
+		Metric     string
+
+		// Since and Until bound the time of the measurements when set. Since
+		// is inclusive and Until exclusive.
+		Since time.Time
+		Until time.Time
+	}
+
+	// MaintenanceUpdater is the CageUpdater of maintenance windows, without
+	// versions. Only the status and error of a window can change.
+	MaintenanceUpdater func(old *model.MaintenanceWindow) (*model.MaintenanceWindow, error)
+
+	ListMaintenanceParams struct {
+		Pagination *Pagination
+		CageID     model.ID
+		Status     string
+
+		// StartsBefore and EndsBefore match windows starting or ending before
+		// a time when set.
+		StartsBefore time.Time
+		EndsBefore   time.Time
+	}
+
+	// IncidentUpdater is the CageUpdater of incidents, without versions.
+	// Only the status and severity of an incident can change.
+	IncidentUpdater func(old *model.Incident) (*model.Incident, error)
+
+	ListIncidentParams struct {
+		Pagination *Pagination
+		Status     string
+		Severity   string
+		Kind       string
+		CageID     model.ID
+		DinosaurID model.ID
+
+		// Open restricts the result to incidents not closed yet.
+		Open bool
+	}
+
+	// WebhookUpdater is the CageUpdater of webhooks, without versions.
+	WebhookUpdater func(old *model.Webhook) (*model.Webhook, error)
+
+	ListWebhookParams struct {
+		Pagination *Pagination
+	}
+
+	ListWebhookDeliveryParams struct {
+		Pagination *Pagination
+		WebhookID  int64
 		Status     string
 	}
+
+	ListWebhookAttemptParams struct {
+		Pagination *Pagination
+		WebhookID  int64
+		DeliveryID int64
+	}
+
+	// ZoneUpdater is the CageUpdater of zones, without versions.
+	ZoneUpdater func(old *model.Zone) (*model.Zone, error)
+
+	ListZoneParams struct {
+		Pagination *Pagination
+	}
+
+	ListCircuitParams struct {
+		Pagination *Pagination
+	}
+
+	// TelemetryQuery selects the readings of a sensor of a cage recorded
+	// from From, inclusive, to To, exclusive, summarized in buckets of
+	// Bucket.
+	TelemetryQuery struct {
+		CageID model.ID
+		Sensor string
+		From   time.Time
+		To     time.Time
+		Bucket time.Duration
+	}
+
+	ListFenceAlertParams struct {
+		Pagination *Pagination
+		CageID     model.ID
+
+		// Open restricts the result to alerts not cleared yet.
+		Open bool
+	}
+
+	ListAuditParams struct {
+		Pagination *Pagination
+		EntityID   model.ID
+		Actor      string
+
+		// Since and Until bound the time of the entries when set. Since is
+		// inclusive and Until exclusive.
+		Since time.Time
+		Until time.Time
+
+		// AfterID restricts the result to entries recorded after the one with
+		// this ID, for following the log.
+		AfterID int64
+	}
+
+	ListEventParams struct {
+		// AfterID restricts the result to events committed after the one with
+		// this ID.
+		AfterID int64
+
+		// Types filters events by type.
+		Types []string
+
+		// Limit caps the number of events returned when positive.
+		Limit int
+	}
 )
 
 const (
 	PaginationLimit = 100
 )
 
+// Cage list orderings.
+const (
+	CageOrderID         = "id"
+	CageOrderCapacity   = "capacity"
+	CageOrderAllocation = "allocation"
+	CageOrderCreatedAt  = "created_at"
+	CageOrderUpdatedAt  = "updated_at"
+)
+
+// SplitOrder splits an OrderBy value into the field name and whether the
+// order is descending.
+func SplitOrder(orderBy string) (string, bool) {
+	if strings.HasPrefix(orderBy, "-") {
+		return orderBy[1:], true
+	}
+
+	return orderBy, false
+}
+
 // Pagination is passed as a parameter to limit the total of rows.
 type Pagination struct {
 	Limit  int
@@ -62,3 +527,16 @@ func NewPagination(perPage, page int) *Pagination {
 		Offset: page * perPage,
 	}
 }
+
+// CheckVersion verifies that an updater kept the current version of the
+// entity it was given.
+func CheckVersion(id model.ID, current, expected int) error {
+	const op errors.Op = "storage.CheckVersion"
+
+	if current != expected {
+		return errors.E(op, errors.KindPreconditionFailed, fmt.Sprintf(
+			"%s is at version %d, not %d", id, current, expected))
+	}
+
+	return nil
+}
@@ -15,8 +15,12 @@
 package storage
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
 
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,3 +35,47 @@ func TestNewPaginationWithDefault(t *testing.T) {
 	assert.Equal(t, PaginationLimit, p.Limit)
 	assert.Equal(t, 0, p.Offset)
 }
+
+func TestSplitOrder(t *testing.T) {
+	field, desc := SplitOrder(CageOrderCapacity)
+	assert.Equal(t, CageOrderCapacity, field)
+	assert.False(t, desc)
+
+	field, desc = SplitOrder("-" + CageOrderCreatedAt)
+	assert.Equal(t, CageOrderCreatedAt, field)
+	assert.True(t, desc)
+}
+
+func TestActor(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, DefaultActor, Actor(ctx))
+	assert.Equal(t, "muldoon", Actor(WithActor(ctx, "muldoon")))
+}
+
+func TestReason(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, Reason(ctx))
+	assert.Equal(t, "fence repair", Reason(WithReason(ctx, "fence repair")))
+}
+
+func TestCheckVersion(t *testing.T) {
+	assert.NoError(t, CheckVersion("cg_1", 2, 2))
+	assert.True(t, errors.IsPreconditionFailedErr(CheckVersion("cg_1", 2, 1)))
+}
+
+func TestSnapshot(t *testing.T) {
+	assert.Nil(t, Snapshot(nil))
+	assert.Nil(t, Snapshot((*model.FeedingSchedule)(nil)))
+
+	cage := &model.Cage{ID: "cg_1", Capacity: 2, Allocation: 1, Species: model.Tyrannosaurus}
+	var got map[string]interface{}
+	assert.NoError(t, json.Unmarshal(Snapshot(cage), &got))
+	assert.Equal(t, "cg_1", got["id"])
+	assert.NotContains(t, got, "allocation")
+	assert.Equal(t, 1, cage.Allocation)
+
+	entry := NewAuditEntry(WithActor(context.Background(), "muldoon"), "memory.CreateCage", model.EntityCage, cage.ID, nil, Snapshot(cage))
+	assert.Equal(t, "muldoon", entry.Actor)
+	assert.Equal(t, "memory.CreateCage", entry.Op)
+	assert.Equal(t, cage.ID, entry.EntityID)
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry keeps the fence readings of cages within their
+// retention. Readings are kept as pushed for RawRetention, then rolled up
+// into park.RollupWidth buckets kept for RollupRetention.
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/pkg/log"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultInterval = 10 * time.Minute
+
+	// RawRetention is how long readings are kept as pushed.
+	RawRetention = 24 * time.Hour
+
+	// RollupRetention is how long the rollups of readings are kept.
+	RollupRetention = 90 * 24 * time.Hour
+)
+
+// Downsampler periodically rolls up the readings past their retention and
+// drops the rollups past theirs. Readings are deleted as they are rolled
+// up, so several downsamplers can run against the same storage.
+type Downsampler struct {
+	storage  storage.Storage
+	interval time.Duration
+	logger   logrus.FieldLogger
+	now      func() time.Time
+}
+
+// NewDownsampler returns a downsampler of st running every interval, or
+// DefaultInterval if zero, and telling time with now, or time.Now if nil.
+func NewDownsampler(st storage.Storage, interval time.Duration, now func() time.Time) *Downsampler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &Downsampler{
+		storage:  st,
+		interval: interval,
+		logger:   log.WithField("component", "telemetry"),
+		now:      now,
+	}
+}
+
+// Run downsamples the readings until ctx is done.
+func (d *Downsampler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := d.tick(ctx); err != nil && ctx.Err() == nil {
+				d.logger.Errorf("error while downsampling telemetry: %v", err)
+			}
+		}
+	}
+}
+
+func (d *Downsampler) tick(ctx context.Context) error {
+	now := d.now().UTC()
+
+	rolled, err := d.storage.DownsampleTelemetry(ctx, now.Add(-RawRetention), now.Add(-RollupRetention))
+	if err != nil {
+		return err
+	}
+
+	if rolled > 0 {
+		d.logger.Infof("Rolled up %d readings", rolled)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/park"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownsampler(t *testing.T) {
+	ctx := context.Background()
+	start := park.BucketStart(time.Now(), time.Hour)
+	now := start
+	clock := func() time.Time { return now }
+	st := memory.New(clock)
+	d := NewDownsampler(st, 0, clock)
+
+	require.NoError(t, st.CreateCage(ctx, &model.Cage{ID: "cg_1", Capacity: 1}))
+
+	var readings []*model.Reading
+	for i := 0; i < 10; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		readings = append(readings, &model.Reading{CageID: "cg_1", Sensor: model.SensorVoltage, Value: float64(9000 + i), RecordedAt: &at})
+	}
+
+	now = start.Add(10 * time.Minute)
+	_, err := st.RecordTelemetry(ctx, readings)
+	require.NoError(t, err)
+
+	query := func() []*model.ReadingBucket {
+		buckets, err := st.QueryTelemetry(ctx, storage.TelemetryQuery{
+			CageID: "cg_1",
+			Sensor: model.SensorVoltage,
+			From:   start,
+			To:     start.Add(time.Hour),
+			Bucket: time.Minute,
+		})
+		require.NoError(t, err)
+		return buckets
+	}
+
+	// Readings within their retention are left as pushed.
+	require.NoError(t, d.tick(ctx))
+	assert.Len(t, query(), 10)
+
+	now = start.Add(RawRetention + 7*time.Minute)
+	require.NoError(t, d.tick(ctx))
+	buckets := query()
+	if assert.Len(t, buckets, 5) {
+		assert.Equal(t, start, buckets[0].Start)
+		assert.Equal(t, 5, buckets[0].Count)
+		assert.Equal(t, 9000.0, buckets[0].Min)
+		assert.Equal(t, 9004.0, buckets[0].Max)
+		assert.Equal(t, 2, buckets[1].Count)
+		assert.Equal(t, 1, buckets[2].Count)
+	}
+
+	now = start.Add(RawRetention + RollupRetention)
+	require.NoError(t, d.tick(ctx))
+	assert.Empty(t, query())
+}
@@ -0,0 +1,263 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhooks delivers the events of the outbox to the webhooks
+// subscribed to them. Every delivery is signed with the secret of its webhook
+// and retried with exponential backoff until it is acknowledged with a 2xx
+// response or runs out of attempts, at which point it is dead and can only be
+// replayed through the API. Deliveries are claimed with a lease, so several
+// dispatchers can run against the same storage.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/pkg/errors"
+	"github.com/danielnegri/jurassic-park-go/pkg/log"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultInterval = 5 * time.Second
+
+	// MaxAttempts is the number of attempts after which a delivery is dead.
+	MaxAttempts = 8
+
+	// BaseBackoff and MaxBackoff bound the delay before a retry, which
+	// doubles with every failed attempt.
+	BaseBackoff = 10 * time.Second
+	MaxBackoff  = time.Hour
+
+	// Timeout bounds a single attempt.
+	Timeout = 10 * time.Second
+
+	// lease is how long a claimed delivery is hidden from other dispatchers.
+	// Deliveries are claimed one at a time, right before they are sent, so
+	// the lease only has to outlast Timeout for a delivery not to be sent
+	// twice at once.
+	lease = time.Minute
+
+	// batchSize caps the number of events enqueued at once and of
+	// deliveries sent in a tick.
+	batchSize = 100
+)
+
+// Headers of a delivery. SignatureHeader carries "sha256=" followed by the
+// hex HMAC-SHA256 of the timestamp, a dot and the body, keyed with the
+// secret of the webhook. Receivers should reject stale timestamps to prevent
+// replays.
+const (
+	EventHeader     = "X-Park-Event"
+	DeliveryHeader  = "X-Park-Delivery"
+	TimestampHeader = "X-Park-Timestamp"
+	SignatureHeader = "X-Park-Signature"
+)
+
+// Sign returns the signature of a delivery body sent at timestamp, in unix
+// seconds.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before retrying a delivery whose attempt failed.
+// The delay doubles with every attempt up to MaxBackoff, and jitter, in
+// [0, 1), spreads it over its upper half so that retries of the events of a
+// burst do not all land at once.
+func Backoff(attempt int, jitter float64) time.Duration {
+	d := MaxBackoff
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	if shift := attempt - 1; shift < 32 && BaseBackoff<<shift < MaxBackoff {
+		d = BaseBackoff << shift
+	}
+
+	return d/2 + time.Duration(jitter*float64(d/2))
+}
+
+// Dispatcher enqueues the events of the outbox for the webhooks subscribed
+// to them and sends the deliveries that are due.
+type Dispatcher struct {
+	storage  storage.Storage
+	interval time.Duration
+	logger   logrus.FieldLogger
+	client   *http.Client
+	now      func() time.Time
+	jitter   func() float64
+}
+
+// NewDispatcher returns a dispatcher polling st at interval, or
+// DefaultInterval if zero, and telling time with now, or time.Now if nil.
+func NewDispatcher(st storage.Storage, interval time.Duration, now func() time.Time) *Dispatcher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	return &Dispatcher{
+		storage:  st,
+		interval: interval,
+		logger:   log.WithField("component", "webhooks"),
+		client:   &http.Client{Timeout: Timeout},
+		now:      now,
+		jitter:   rand.Float64,
+	}
+}
+
+// Run delivers events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := d.tick(ctx); err != nil && ctx.Err() == nil {
+				d.logger.Errorf("error while delivering webhooks: %v", err)
+			}
+		}
+	}
+}
+
+// tick enqueues the new events, then sends the deliveries that are due.
+func (d *Dispatcher) tick(ctx context.Context) error {
+	for {
+		created, err := d.storage.EnqueueWebhookDeliveries(ctx, batchSize)
+		if err != nil {
+			return err
+		}
+
+		if created == 0 {
+			break
+		}
+	}
+
+	for i := 0; i < batchSize; i++ {
+		deliveries, err := d.storage.ClaimWebhookDeliveries(ctx, d.now().UTC(), 1, lease)
+		if err != nil {
+			return err
+		}
+
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		if err := d.deliver(ctx, deliveries[0]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliver makes an attempt to send a delivery and records its outcome.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if delivery.Webhook == nil || delivery.Event == nil || delivery.NextAttemptAt == nil {
+		return nil
+	}
+
+	attempt := &model.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts + 1,
+	}
+
+	start := d.now()
+	attempt.StatusCode, attempt.Error = d.send(ctx, delivery)
+	attempt.DurationMs = d.now().Sub(start).Milliseconds()
+
+	status, next := model.DeliveryDelivered, time.Time{}
+	switch {
+	case attempt.Error == "":
+		d.logger.Debugf("Delivered event %d to webhook %d", delivery.EventID, delivery.WebhookID)
+	case attempt.Attempt >= MaxAttempts:
+		status = model.DeliveryDead
+		d.logger.Warnf("Delivery %d of event %d to webhook %d is dead after %d attempts: %s",
+			delivery.ID, delivery.EventID, delivery.WebhookID, attempt.Attempt, attempt.Error)
+	default:
+		status = model.DeliveryPending
+		next = d.now().UTC().Add(Backoff(attempt.Attempt, d.jitter()))
+	}
+
+	// An attempt that outlived its lease is dropped, since the delivery may
+	// have been claimed and settled by another dispatcher meanwhile.
+	err := d.storage.RecordWebhookAttempt(ctx, attempt, *delivery.NextAttemptAt, status, next)
+	if errors.Is(err, errors.KindPreconditionFailed) {
+		d.logger.Warnf("Dropped attempt %d of delivery %d whose lease ran out: %v", attempt.Attempt, delivery.ID, err)
+		return nil
+	}
+
+	return err
+}
+
+// send posts the event of a delivery to its webhook. It returns the status
+// code of the response, if any, and why the attempt failed, if it did.
+func (d *Dispatcher) send(ctx context.Context, delivery *model.WebhookDelivery) (int, string) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+
+	// Draining a bounded part of the body lets the connection be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Sprintf("unexpected response %s", resp.Status)
+	}
+
+	return resp.StatusCode, ""
+}
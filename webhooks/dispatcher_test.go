@@ -0,0 +1,300 @@
+// Copyright 2023 The Jurassic Park Authors
+//
+// Licensed under the AGPL, Version 3.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danielnegri/jurassic-park-go/model"
+	"github.com/danielnegri/jurassic-park-go/storage"
+	"github.com/danielnegri/jurassic-park-go/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver is a webhook endpoint failing a number of requests before
+// acknowledging them.
+type receiver struct {
+	mu       sync.Mutex
+	secret   string
+	failures int
+	events   []*model.Event
+	invalid  int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	timestamp, _ := strconv.ParseInt(req.Header.Get(TimestampHeader), 10, 64)
+	if req.Header.Get(SignatureHeader) != Sign(r.secret, timestamp, body) {
+		r.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var event model.Event
+	if err := json.Unmarshal(body, &event); err != nil || event.Type != req.Header.Get(EventHeader) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.events = append(r.events, &event)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *receiver) received() []*model.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*model.Event(nil), r.events...)
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	clock := func() time.Time { return now }
+	st := memory.New(clock)
+	d := NewDispatcher(st, 0, clock)
+	d.jitter = func() float64 { return 0 }
+
+	recv := &receiver{secret: "s3cr3t", failures: 2}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	webhook := &model.Webhook{URL: server.URL, Secret: recv.secret, Types: []string{model.EventCageCreated}, Active: true}
+	require.NoError(t, st.CreateWebhook(ctx, webhook))
+
+	cage := &model.Cage{ID: "cg_1", Capacity: 1}
+	require.NoError(t, st.CreateCage(ctx, cage))
+	require.NoError(t, st.CreateDinosaur(ctx, &model.Dinosaur{ID: "dn_1", Name: "Rexy", Species: model.Tyrannosaurus, CageID: cage.ID}))
+
+	delivery := func() *model.WebhookDelivery {
+		deliveries, _, err := st.ListWebhookDeliveries(ctx, storage.ListWebhookDeliveryParams{WebhookID: webhook.ID})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		return deliveries[0]
+	}
+
+	require.NoError(t, d.tick(ctx))
+	assert.Equal(t, model.DeliveryPending, delivery().Status)
+	assert.Equal(t, 1, delivery().Attempts)
+	assert.Equal(t, now.Add(BaseBackoff/2), *delivery().NextAttemptAt)
+	assert.Empty(t, recv.received())
+
+	// Retries wait for their backoff.
+	require.NoError(t, d.tick(ctx))
+	assert.Equal(t, 1, delivery().Attempts)
+
+	now = now.Add(BaseBackoff / 2)
+	require.NoError(t, d.tick(ctx))
+	assert.Equal(t, 2, delivery().Attempts)
+	assert.Equal(t, now.Add(BaseBackoff), *delivery().NextAttemptAt)
+
+	now = now.Add(BaseBackoff)
+	require.NoError(t, d.tick(ctx))
+	assert.Equal(t, model.DeliveryDelivered, delivery().Status)
+	assert.Equal(t, 3, delivery().Attempts)
+	assert.Empty(t, delivery().LastError)
+
+	events := recv.received()
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, model.EventCageCreated, events[0].Type)
+		assert.Equal(t, cage.ID, events[0].EntityID)
+	}
+
+	attempts, _, err := st.ListWebhookAttempts(ctx, storage.ListWebhookAttemptParams{WebhookID: webhook.ID})
+	require.NoError(t, err)
+	if assert.Len(t, attempts, 3) {
+		assert.Equal(t, http.StatusNoContent, attempts[0].StatusCode)
+		assert.Equal(t, http.StatusServiceUnavailable, attempts[2].StatusCode)
+		assert.Contains(t, attempts[2].Error, "503")
+	}
+
+	assert.Zero(t, recv.invalid)
+}
+
+func TestDispatcher_DeadLetter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	clock := func() time.Time { return now }
+	st := memory.New(clock)
+	d := NewDispatcher(st, 0, clock)
+	d.jitter = func() float64 { return 0 }
+
+	recv := &receiver{secret: "s3cr3t", failures: MaxAttempts}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	webhook := &model.Webhook{URL: server.URL, Secret: recv.secret, Active: true}
+	require.NoError(t, st.CreateWebhook(ctx, webhook))
+	require.NoError(t, st.CreateCage(ctx, &model.Cage{ID: "cg_1", Capacity: 1}))
+
+	for i := 0; i < MaxAttempts; i++ {
+		require.NoError(t, d.tick(ctx))
+		now = now.Add(MaxBackoff)
+	}
+
+	deliveries, _, err := st.ListWebhookDeliveries(ctx, storage.ListWebhookDeliveryParams{WebhookID: webhook.ID, Status: model.DeliveryDead})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, MaxAttempts, deliveries[0].Attempts)
+
+	// Dead deliveries are left alone until replayed.
+	require.NoError(t, d.tick(ctx))
+	assert.Empty(t, recv.received())
+
+	_, err = st.ReplayWebhookDelivery(ctx, webhook.ID, deliveries[0].ID)
+	require.NoError(t, err)
+
+	require.NoError(t, d.tick(ctx))
+	assert.Len(t, recv.received(), 1)
+
+	_, total, err := st.ListWebhookAttempts(ctx, storage.ListWebhookAttemptParams{DeliveryID: deliveries[0].ID})
+	require.NoError(t, err)
+	assert.Equal(t, MaxAttempts+1, total)
+}
+
+// stalling is a webhook endpoint holding its first request until released,
+// then failing it, and acknowledging every later request.
+type stalling struct {
+	receiver
+	stalled chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *stalling) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	first := false
+	s.once.Do(func() { first = true })
+	if first {
+		close(s.stalled)
+		<-s.release
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	s.receiver.ServeHTTP(w, req)
+}
+
+func TestDispatcher_LeaseRanOut(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	now := time.Now().UTC()
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	st := memory.New(clock)
+	slow := NewDispatcher(st, 0, clock)
+	fast := NewDispatcher(st, 0, clock)
+
+	recv := &stalling{receiver: receiver{secret: "s3cr3t"}, stalled: make(chan struct{}), release: make(chan struct{})}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	webhook := &model.Webhook{URL: server.URL, Secret: recv.secret, Active: true}
+	require.NoError(t, st.CreateWebhook(ctx, webhook))
+	require.NoError(t, st.CreateCage(ctx, &model.Cage{ID: "cg_1", Capacity: 1}))
+
+	done := make(chan error, 1)
+	go func() { done <- slow.tick(ctx) }()
+	<-recv.stalled
+
+	// The lease of the slow dispatcher runs out, so the delivery is claimed
+	// and delivered by the other one.
+	mu.Lock()
+	now = now.Add(lease)
+	mu.Unlock()
+
+	require.NoError(t, fast.tick(ctx))
+	assert.Len(t, recv.received(), 1)
+
+	// The late failure of the slow dispatcher does not undo the delivery.
+	close(recv.release)
+	require.NoError(t, <-done)
+
+	deliveries, _, err := st.ListWebhookDeliveries(ctx, storage.ListWebhookDeliveryParams{WebhookID: webhook.ID})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.DeliveryDelivered, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+
+	attempts, _, err := st.ListWebhookAttempts(ctx, storage.ListWebhookAttemptParams{DeliveryID: deliveries[0].ID})
+	require.NoError(t, err)
+	if assert.Len(t, attempts, 1) {
+		assert.Equal(t, http.StatusNoContent, attempts[0].StatusCode)
+	}
+
+	mu.Lock()
+	now = now.Add(MaxBackoff)
+	mu.Unlock()
+
+	require.NoError(t, slow.tick(ctx))
+	require.NoError(t, fast.tick(ctx))
+	assert.Len(t, recv.received(), 1)
+}
+
+func TestDispatcher_Signature(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New(nil)
+	d := NewDispatcher(st, 0, nil)
+
+	recv := &receiver{secret: "other"}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	webhook := &model.Webhook{URL: server.URL, Secret: "s3cr3t", Active: true}
+	require.NoError(t, st.CreateWebhook(ctx, webhook))
+	require.NoError(t, st.CreateCage(ctx, &model.Cage{ID: "cg_1", Capacity: 1}))
+
+	require.NoError(t, d.tick(ctx))
+	assert.Equal(t, 1, recv.invalid)
+	assert.Empty(t, recv.received())
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	assert.Equal(t, Sign("s3cr3t", 1685577600, body), Sign("s3cr3t", 1685577600, body))
+	assert.NotEqual(t, Sign("s3cr3t", 1685577600, body), Sign("s3cr3t", 1685577601, body))
+	assert.NotEqual(t, Sign("s3cr3t", 1685577600, body), Sign("other", 1685577600, body))
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", Sign("s3cr3t", 1685577600, body))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, BaseBackoff/2, Backoff(1, 0))
+	assert.Equal(t, BaseBackoff, Backoff(2, 0))
+	assert.Equal(t, 2*BaseBackoff-time.Nanosecond, Backoff(2, 0.9999999999))
+	assert.Equal(t, MaxBackoff/2, Backoff(20, 0))
+	assert.Equal(t, MaxBackoff/2, Backoff(100, 0))
+	assert.True(t, Backoff(3, 0.5) > Backoff(3, 0) && Backoff(3, 0.5) < 4*BaseBackoff)
+}